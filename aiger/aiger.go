@@ -0,0 +1,69 @@
+package aiger
+
+import (
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A Circuit is an and-inverter graph. All its nodes are described by AIGER literals.
+type Circuit struct {
+	MaxVar  int     // Biggest node index, M in the AIGER header
+	Inputs  []int   // Literals of the inputs
+	Latches []Latch // Latches, i.e memory elements of sequential circuits
+	Outputs []int   // Literals of the outputs
+	Ands    []And   // AND gates, in the order they were defined
+}
+
+// A Latch is a memory element, whose value in the next state of the circuit is the current value of Next.
+type Latch struct {
+	Lit  int // Literal of the latch
+	Next int // Literal of its next value
+	Init int // Initial value: 0, 1, or Lit itself if it is uninitialized
+}
+
+// An And is an AND gate, stating Lhs is true iff both Rhs0 and Rhs1 are true.
+type And struct {
+	Lhs, Rhs0, Rhs1 int
+}
+
+// Tseitin returns the CNF encoding of c, stating that all outputs of c are true, along with the solver var
+// representing each node of c: node v is represented by vars[v] and vars[0] is an extra var,
+// always false, that represents the constants.
+// Latches are considered as free inputs, representing the current state of the circuit:
+// the transition relation is not unrolled.
+func (c *Circuit) Tseitin() (pb *solver.Problem, vars []solver.Var) {
+	nbVars := c.MaxVar + 1
+	vars = make([]solver.Var, nbVars)
+	for v := 1; v < nbVars; v++ {
+		vars[v] = solver.IntToVar(int32(v))
+	}
+	vars[0] = solver.IntToVar(int32(nbVars))
+	clauses := [][]int{{-nbVars}}
+	for _, and := range c.Ands {
+		lhs, rhs0, rhs1 := c.cnfLit(and.Lhs), c.cnfLit(and.Rhs0), c.cnfLit(and.Rhs1)
+		switch {
+		case rhs0 == rhs1:
+			clauses = append(clauses, []int{-lhs, rhs0}, []int{lhs, -rhs0})
+		case rhs0 == -rhs1: // Always false
+			clauses = append(clauses, []int{-lhs})
+		default:
+			clauses = append(clauses, []int{-lhs, rhs0}, []int{-lhs, rhs1}, []int{lhs, -rhs0, -rhs1})
+		}
+	}
+	for _, out := range c.Outputs {
+		clauses = append(clauses, []int{c.cnfLit(out)})
+	}
+	return solver.ParseSliceNb(clauses, nbVars), vars
+}
+
+// cnfLit returns the DIMACS literal corresponding to the AIGER literal lit.
+// Constants are represented by var MaxVar+1, which is always false.
+func (c *Circuit) cnfLit(lit int) int {
+	v := lit / 2
+	if v == 0 {
+		v = c.MaxVar + 1
+	}
+	if lit&1 == 1 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,52 @@
+package aiger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestTseitin(t *testing.T) {
+	// Output is x1 & !x2 & !(x1 & x2)
+	const aag = "aag 5 2 0 1 3\n2\n4\n10\n6 2 5\n8 2 4\n10 6 9\n"
+	c, err := Parse(strings.NewReader(aag))
+	if err != nil {
+		t.Fatalf("could not parse circuit: %v", err)
+	}
+	pb, vars := c.Tseitin()
+	s := solver.New(pb)
+	if status := s.Solve(); status != solver.Sat {
+		t.Fatalf("expected sat, got %v", status)
+	}
+	model := s.Model()
+	if !model[vars[1]] || model[vars[2]] || !model[vars[5]] || model[vars[0]] {
+		t.Errorf("invalid model %v", model)
+	}
+}
+
+func TestTseitinUnsat(t *testing.T) {
+	for _, aag := range []string{
+		"aag 2 1 0 1 1\n2\n4\n4 2 3\n", // x & !x
+		"aag 2 1 0 1 1\n2\n4\n4 2 0\n", // x & false
+		"aag 0 0 0 1 0\n0\n",           // false
+	} {
+		c, err := Parse(strings.NewReader(aag))
+		if err != nil {
+			t.Fatalf("could not parse circuit %q: %v", aag, err)
+		}
+		pb, _ := c.Tseitin()
+		if status := solver.New(pb).Solve(); status != solver.Unsat {
+			t.Errorf("expected unsat for %q, got %v", aag, status)
+		}
+	}
+	c, err := Parse(strings.NewReader("aag 2 1 0 1 1\n2\n4\n4 2 2\n")) // x & x
+	if err != nil {
+		t.Fatalf("could not parse circuit: %v", err)
+	}
+	pb, vars := c.Tseitin()
+	s := solver.New(pb)
+	if status := s.Solve(); status != solver.Sat || !s.Model()[vars[1]] {
+		t.Errorf("expected sat with x true, got %v", status)
+	}
+}
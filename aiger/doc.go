@@ -0,0 +1,24 @@
+// Package aiger reads and-inverter graphs (AIG) in the AIGER format, and converts them to CNF problems.
+//
+// An AIG is a circuit made only of AND gates and inverters. Its nodes are numbered from 1 to M,
+// and literals are written 2v for node v and 2v+1 for its negation; 0 and 1 are the constants false and true.
+// The ASCII variant of the format starts with a "aag M I L O A" header, followed by the inputs,
+// the latches, the outputs and the AND gates of the circuit, one per line:
+//
+//	aag 3 2 0 1 1
+//	2
+//	4
+//	6
+//	6 2 4
+//
+// describes a circuit whose only output is the conjunction of its two inputs.
+// The binary variant, starting with a "aig M I L O A" header, is more compact and is the one used in
+// hardware-verification benchmarks. Both are read by Parse:
+//
+//	c, err := aiger.Parse(f)
+//	pb, vars := c.Tseitin()
+//	s := solver.New(pb)
+//
+// The resulting problem is satisfiable iff some binding of the inputs makes all outputs true, and vars
+// indicates which solver var represents each node. See http://fmv.jku.at/aiger/ for more details about the format.
+package aiger
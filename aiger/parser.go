@@ -0,0 +1,240 @@
+package aiger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// maxNodes is the maximum number of nodes, M in the AIGER header, of a parsed circuit.
+// Since the parser allocates memory for all announced nodes, bigger headers are rejected
+// before anything is allocated, so that untrusted files cannot exhaust memory.
+const maxNodes = 1 << 26
+
+// aigerParser holds the data used while parsing an AIGER file.
+type aigerParser struct {
+	r       *bufio.Reader
+	c       Circuit
+	binary  bool   // True iff the file is in the binary format
+	lineNb  int    // Number of the last line read
+	defined []bool // For each node, whether it was already defined as an input, a latch or an AND gate
+}
+
+// Parse parses an AIGER file, either in the ASCII ("aag" header) or in the binary ("aig" header) format,
+// and returns the corresponding circuit. The symbol table and the comments following the circuit are ignored.
+// Only the original format is supported: headers from AIGER 1.9 announcing bad states, invariant constraints,
+// justice properties or fairness constraints are rejected.
+func Parse(r io.Reader) (*Circuit, error) {
+	p := aigerParser{r: bufio.NewReader(r)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return &p.c, nil
+}
+
+// parse parses the whole circuit.
+func (p *aigerParser) parse() error {
+	fields, err := p.readFields()
+	if err != nil {
+		return err
+	}
+	nbInputs, nbLatches, nbOutputs, nbAnds, err := p.parseHeader(fields)
+	if err != nil {
+		return p.errorf(solver.ErrBadHeader, "%v", err)
+	}
+	for i := 0; i < nbInputs; i++ {
+		lit := 2 * (i + 1)
+		if !p.binary {
+			vals, err := p.readInts(1, 1)
+			if err != nil {
+				return err
+			}
+			lit = vals[0]
+		}
+		if err := p.define(lit); err != nil {
+			return err
+		}
+		p.c.Inputs = append(p.c.Inputs, lit)
+	}
+	for i := 0; i < nbLatches; i++ {
+		latch, err := p.parseLatch(2 * (nbInputs + i + 1))
+		if err != nil {
+			return err
+		}
+		p.c.Latches = append(p.c.Latches, latch)
+	}
+	for i := 0; i < nbOutputs; i++ {
+		vals, err := p.readInts(1, 1)
+		if err != nil {
+			return err
+		}
+		p.c.Outputs = append(p.c.Outputs, vals[0])
+	}
+	for i := 0; i < nbAnds; i++ {
+		var and And
+		if p.binary {
+			and.Lhs = 2 * (nbInputs + nbLatches + i + 1)
+			if and.Rhs0, and.Rhs1, err = p.readDeltas(and.Lhs); err != nil {
+				cause := solver.ErrBadLiteral
+				if errors.Is(err, io.EOF) {
+					cause = solver.ErrTruncated
+				}
+				return p.errorf(cause, "invalid AND gate #%d: %v", i+1, err)
+			}
+		} else {
+			vals, err := p.readInts(3, 3)
+			if err != nil {
+				return err
+			}
+			and = And{Lhs: vals[0], Rhs0: vals[1], Rhs1: vals[2]}
+		}
+		if err := p.define(and.Lhs); err != nil {
+			return err
+		}
+		p.c.Ands = append(p.c.Ands, and)
+	}
+	return nil
+}
+
+// parseHeader parses the fields of the header, and returns the number of inputs, latches, outputs and AND gates.
+func (p *aigerParser) parseHeader(fields []string) (nbInputs, nbLatches, nbOutputs, nbAnds int, err error) {
+	if len(fields) < 6 || (fields[0] != "aag" && fields[0] != "aig") {
+		return 0, 0, 0, 0, fmt.Errorf("invalid AIGER header %q", strings.Join(fields, " "))
+	}
+	p.binary = fields[0] == "aig"
+	vals := make([]int, len(fields)-1)
+	for i, field := range fields[1:] {
+		if vals[i], err = strconv.Atoi(field); err != nil || vals[i] < 0 {
+			return 0, 0, 0, 0, fmt.Errorf("%q is not a positive int in AIGER header", field)
+		}
+		if i >= 5 && vals[i] != 0 {
+			return 0, 0, 0, 0, fmt.Errorf("AIGER 1.9 extensions are not supported")
+		}
+		if vals[i] > maxNodes {
+			return 0, 0, 0, 0, fmt.Errorf("%d is too big in AIGER header: at most %d nodes are supported", vals[i], maxNodes)
+		}
+	}
+	p.c.MaxVar = vals[0]
+	nbInputs, nbLatches, nbOutputs, nbAnds = vals[1], vals[2], vals[3], vals[4]
+	if p.c.MaxVar < nbInputs+nbLatches+nbAnds {
+		return 0, 0, 0, 0, fmt.Errorf("%d nodes announced, but %d are defined", p.c.MaxVar, nbInputs+nbLatches+nbAnds)
+	}
+	if p.binary && p.c.MaxVar != nbInputs+nbLatches+nbAnds {
+		return 0, 0, 0, 0, fmt.Errorf("%d nodes announced, but %d are defined in binary file", p.c.MaxVar, nbInputs+nbLatches+nbAnds)
+	}
+	p.defined = make([]bool, p.c.MaxVar+1)
+	return nbInputs, nbLatches, nbOutputs, nbAnds, nil
+}
+
+// parseLatch parses the line describing a latch. In binary files, the latch's literal is implicit and is lit.
+func (p *aigerParser) parseLatch(lit int) (Latch, error) {
+	minVals := 2
+	if p.binary {
+		minVals = 1
+	}
+	vals, err := p.readInts(minVals, minVals+1)
+	if err != nil {
+		return Latch{}, err
+	}
+	if !p.binary {
+		lit, vals = vals[0], vals[1:]
+	}
+	if err := p.define(lit); err != nil {
+		return Latch{}, err
+	}
+	latch := Latch{Lit: lit, Next: vals[0]}
+	if len(vals) == 2 {
+		latch.Init = vals[1]
+		if latch.Init != 0 && latch.Init != 1 && latch.Init != lit {
+			return Latch{}, p.errorf(solver.ErrBadLiteral, "invalid initial value %d for latch %d", latch.Init, lit)
+		}
+	}
+	return latch, nil
+}
+
+// define indicates the node whose literal is lit is defined.
+func (p *aigerParser) define(lit int) error {
+	if lit < 2 || lit&1 == 1 {
+		return p.errorf(solver.ErrBadLiteral, "invalid definition of literal %d", lit)
+	}
+	if p.defined[lit/2] {
+		return p.errorf(solver.ErrBadLiteral, "literal %d defined several times", lit)
+	}
+	p.defined[lit/2] = true
+	return nil
+}
+
+// readDeltas reads the two deltas encoding the inputs of the binary AND gate lhs, and returns these inputs.
+func (p *aigerParser) readDeltas(lhs int) (rhs0, rhs1 int, err error) {
+	delta0, err := p.readDelta()
+	if err != nil {
+		return 0, 0, err
+	}
+	delta1, err := p.readDelta()
+	if err != nil {
+		return 0, 0, err
+	}
+	if delta0 == 0 || delta0 > lhs || delta1 > lhs-delta0 {
+		return 0, 0, fmt.Errorf("invalid deltas %d and %d", delta0, delta1)
+	}
+	rhs0 = lhs - delta0
+	return rhs0, rhs0 - delta1, nil
+}
+
+// readDelta reads a binary encoded unsigned int: 7 bits per byte, least significant first,
+// the most significant bit of each byte being set iff more bytes follow.
+func (p *aigerParser) readDelta() (int, error) {
+	res := 0
+	for shift := 0; shift < 31; shift += 7 {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("could not read delta: %w", err)
+		}
+		res |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return res, nil
+		}
+	}
+	return 0, fmt.Errorf("delta is too big")
+}
+
+// readInts reads a line made of between min and max ints, each of them being a valid literal.
+func (p *aigerParser) readInts(min, max int) ([]int, error) {
+	fields, err := p.readFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < min || len(fields) > max {
+		return nil, p.errorf(solver.ErrBadConstraint, "invalid line %q", strings.Join(fields, " "))
+	}
+	vals := make([]int, len(fields))
+	for i, field := range fields {
+		if vals[i], err = strconv.Atoi(field); err != nil || vals[i] < 0 || vals[i] > 2*p.c.MaxVar+1 {
+			return nil, p.errorf(solver.ErrBadLiteral, "invalid literal %q", field)
+		}
+	}
+	return vals, nil
+}
+
+// readFields reads the next line and returns its fields.
+func (p *aigerParser) readFields() ([]string, error) {
+	line, err := p.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return nil, &solver.ParseError{Line: p.lineNb + 1, Msg: "unexpected end of AIGER file", Err: solver.ErrTruncated}
+		}
+		return nil, fmt.Errorf("could not read AIGER file: %w", err)
+	}
+	p.lineNb++
+	return strings.Fields(line), nil
+}
+
+// errorf returns an error about the given faulty part of the input on the last line read.
+func (p *aigerParser) errorf(cause error, format string, args ...interface{}) error {
+	return &solver.ParseError{Line: p.lineNb, Msg: fmt.Sprintf(format, args...), Err: cause}
+}
@@ -0,0 +1,88 @@
+package aiger
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestParseASCII(t *testing.T) {
+	const aag = "aag 5 2 1 1 2\n2\n4\n6 8 0\n9\n8 2 6\n10 8 5\ni0 x\nc\nsome comment\n"
+	c, err := Parse(strings.NewReader(aag))
+	if err != nil {
+		t.Fatalf("could not parse circuit: %v", err)
+	}
+	expected := Circuit{
+		MaxVar:  5,
+		Inputs:  []int{2, 4},
+		Latches: []Latch{{Lit: 6, Next: 8}},
+		Outputs: []int{9},
+		Ands:    []And{{Lhs: 8, Rhs0: 2, Rhs1: 6}, {Lhs: 10, Rhs0: 8, Rhs1: 5}},
+	}
+	if !reflect.DeepEqual(*c, expected) {
+		t.Errorf("invalid circuit: expected %+v, got %+v", expected, *c)
+	}
+}
+
+func TestParseBinary(t *testing.T) {
+	const aig = "aig 4 2 1 1 1\n8 1\n9\n\x02\x04"
+	c, err := Parse(strings.NewReader(aig))
+	if err != nil {
+		t.Fatalf("could not parse circuit: %v", err)
+	}
+	expected := Circuit{
+		MaxVar:  4,
+		Inputs:  []int{2, 4},
+		Latches: []Latch{{Lit: 6, Next: 8, Init: 1}},
+		Outputs: []int{9},
+		Ands:    []And{{Lhs: 8, Rhs0: 6, Rhs1: 2}},
+	}
+	if !reflect.DeepEqual(*c, expected) {
+		t.Errorf("invalid circuit: expected %+v, got %+v", expected, *c)
+	}
+}
+
+func TestReadDelta(t *testing.T) {
+	p := aigerParser{r: bufio.NewReader(strings.NewReader("\x81\x01\x7f"))}
+	for _, expected := range []int{129, 127} {
+		if delta, err := p.readDelta(); err != nil || delta != expected {
+			t.Errorf("expected delta %d, got %d, error %v", expected, delta, err)
+		}
+	}
+	if _, err := p.readDelta(); err == nil {
+		t.Errorf("expected an error at EOF")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, aag := range []string{
+		"",
+		"aag 1 1 0 0\n2\n",
+		"aig 1 x 0 0 0\n",
+		"aag 1 2 0 0 0\n2\n4\n",
+		"aag 1 1 0 0 0 1\n2\n",
+		"aag 2 2 0 0 0\n2\n2\n",
+		"aag 1 1 0 0 0\n3\n",
+		"aag 1 0 0 1 0\n4\n",
+		"aag 2 1 1 0 0\n2\n4 2 3\n",
+		"aag 2 1 0 0 1\n2\n4 2\n",
+		"aig 2 1 0 0 1\n\x05\x00",
+		"aig 2 1 0 0 1\n\x02",
+		"aag 2 1 0 0 1\n2\n",
+		"aig 4611686018427387903 0 0 0 0\n",
+		"aag 1 9223372036854775807 0 9223372036854775807 1\n",
+	} {
+		if _, err := Parse(strings.NewReader(aag)); err == nil {
+			t.Errorf("expected an error for %q", aag)
+		}
+	}
+	for _, aag := range []string{"aag 2 1 0 0 1\n2\n", "aig 2 1 0 0 1\n\x02"} {
+		if _, err := Parse(strings.NewReader(aag)); !errors.Is(err, solver.ErrTruncated) {
+			t.Errorf("expected %q to be reported as truncated, got %v", aag, err)
+		}
+	}
+}
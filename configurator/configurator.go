@@ -0,0 +1,145 @@
+// Package configurator provides a facade over the solver dedicated to interactive configuration,
+// i.e to problems where a user selects options one after the other, and must be told,
+// after each selection, which options are still available, which ones are forced, and why.
+//
+// The problem describes the rules of the configuration: variables are options, and clauses are
+// constraints between options. User selections are lits that are assumed to be true.
+package configurator
+
+import (
+	"fmt"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A Configurator keeps track of the selections made by the user on a given problem.
+type Configurator struct {
+	s          *solver.Solver
+	selections []solver.Lit
+	forced     []solver.Lit // Backbone under the current selections, or nil if it must be computed again
+}
+
+// New returns a configurator for the given problem, with no selection.
+// It will panic if the problem itself has no solution.
+func New(pb *solver.Problem) *Configurator {
+	s := solver.New(pb)
+	s.TrackAntecedents = true
+	if s.Solve() != solver.Sat {
+		panic("configuration problem has no solution")
+	}
+	return &Configurator{s: s}
+}
+
+// Selections returns the current selections.
+func (c *Configurator) Selections() []solver.Lit {
+	res := make([]solver.Lit, len(c.selections))
+	copy(res, c.selections)
+	return res
+}
+
+// Select adds lit to the current selections.
+// If lit is inconsistent with the current selections, it is not added and an error is returned,
+// indicating which previous selections are incompatible with it.
+func (c *Configurator) Select(lit solver.Lit) error {
+	sels := append(c.Selections(), lit)
+	if c.s.Assume(sels) == solver.Unsat || c.s.Solve() == solver.Unsat {
+		core := c.s.Core()
+		c.s.Assume(c.selections)
+		var conflicting []int32
+		for _, l := range core {
+			if l != lit {
+				conflicting = append(conflicting, l.Int())
+			}
+		}
+		return fmt.Errorf("selection %d is incompatible with selections %v", lit.Int(), conflicting)
+	}
+	c.selections = sels
+	if c.forced != nil {
+		c.forced = c.s.UpdateBackbone(c.forced, c.selections)
+	}
+	return nil
+}
+
+// AddRule adds the clause made of the given lits to the rules of the configuration.
+// If the rule is inconsistent with the current selections, an error is returned,
+// and the selections must be changed before the configuration can go on.
+func (c *Configurator) AddRule(lits ...solver.Lit) error {
+	c.s.Assume(nil) // Otherwise, the clause would be simplified by the current selections
+	c.s.AppendClause(solver.NewClause(lits))
+	if c.forced != nil {
+		c.forced = c.s.UpdateBackbone(c.forced, c.selections)
+	} else {
+		c.s.Assume(c.selections)
+	}
+	if c.s.Solve() != solver.Sat {
+		c.forced = nil
+		return fmt.Errorf("rule is incompatible with selections %v", c.Selections())
+	}
+	return nil
+}
+
+// Unselect removes lit from the current selections, if it was selected.
+func (c *Configurator) Unselect(lit solver.Lit) {
+	for i, l := range c.selections {
+		if l == lit {
+			c.selections = append(c.selections[:i], c.selections[i+1:]...)
+			c.forced = nil
+			break
+		}
+	}
+	c.s.Assume(c.selections)
+}
+
+// Propagate returns the assignment implied by unit propagation of the current selections.
+// It is cheap to compute, but some forced options might be missing; see Forced.
+func (c *Configurator) Propagate() solver.Assignment {
+	return c.s.Propagate(c.selections)
+}
+
+// Forced returns all the lits that are true in all valid configurations, given the current selections.
+// Selections are part of the result.
+// The result is kept and updated incrementally as selections and rules are added.
+func (c *Configurator) Forced() []solver.Lit {
+	if c.forced == nil {
+		c.forced = c.s.Backbone(c.selections)
+	}
+	res := make([]solver.Lit, len(c.forced))
+	copy(res, c.forced)
+	return res
+}
+
+// ValidValues indicates which values of v are still possible given the current selections.
+func (c *Configurator) ValidValues(v solver.Var) (canBeTrue, canBeFalse bool) {
+	canBeTrue = c.consistent(v.Lit())
+	canBeFalse = c.consistent(v.Lit().Negation())
+	c.s.Assume(c.selections)
+	return canBeTrue, canBeFalse
+}
+
+// consistent returns true iff lit is consistent with the current selections.
+func (c *Configurator) consistent(lit solver.Lit) bool {
+	sels := append(c.Selections(), lit)
+	return c.s.Assume(sels) != solver.Unsat && c.s.Solve() == solver.Sat
+}
+
+// Why explains why lit is forced by the current selections.
+// If lit is implied by unit propagation, rules contains the problem clauses that imply it, and selections
+// the selections they need.
+// Otherwise, rules is nil and selections only indicates a set of selections that force lit.
+// An error is returned if lit is not forced.
+func (c *Configurator) Why(lit solver.Lit) (rules []*solver.Clause, selections []solver.Lit, err error) {
+	if a := c.s.Propagate(c.selections); a != nil && a.LitValue(lit) == solver.True {
+		return c.s.ExplainLit(lit)
+	}
+	if c.consistent(lit.Negation()) {
+		c.s.Assume(c.selections)
+		return nil, nil, fmt.Errorf("literal %d is not forced", lit.Int())
+	}
+	for _, l := range c.s.Core() {
+		if l != lit.Negation() {
+			selections = append(selections, l)
+		}
+	}
+	c.s.Assume(c.selections)
+	return nil, selections, nil
+}
@@ -0,0 +1,90 @@
+package configurator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// Options of a car: 1 = diesel, 2 = electric, 3 = towbar, 4 = big battery, 5 = sunroof.
+// Exactly one engine, towbar requires diesel, electric requires big battery.
+var carRules = [][]int{{1, 2}, {-1, -2}, {-3, 1}, {-2, 4}, {5, -5}}
+
+func lits(vals ...int32) []solver.Lit {
+	res := make([]solver.Lit, len(vals))
+	for i, val := range vals {
+		res[i] = solver.IntToLit(val)
+	}
+	return res
+}
+
+func TestConfigurator(t *testing.T) {
+	c := New(solver.ParseSlice(carRules))
+	if forced := c.Forced(); len(forced) != 0 {
+		t.Errorf("expected no forced option, got %v", forced)
+	}
+	if err := c.Select(solver.IntToLit(3)); err != nil {
+		t.Fatalf("could not select towbar: %v", err)
+	}
+	if forced, expected := c.Forced(), lits(1, -2, 3); !reflect.DeepEqual(forced, expected) {
+		t.Errorf("invalid forced options: expected %v, got %v", expected, forced)
+	}
+	if a := c.Propagate(); a.LitValue(solver.IntToLit(2)) != solver.False {
+		t.Errorf("electric engine should be propagated to false, got %v", a)
+	}
+	if canBeTrue, canBeFalse := c.ValidValues(solver.Var(1)); canBeTrue || !canBeFalse {
+		t.Errorf("invalid valid values for electric engine: %t, %t", canBeTrue, canBeFalse)
+	}
+	if canBeTrue, canBeFalse := c.ValidValues(solver.Var(3)); !canBeTrue || !canBeFalse {
+		t.Errorf("invalid valid values for big battery: %t, %t", canBeTrue, canBeFalse)
+	}
+	rules, sels, err := c.Why(solver.IntToLit(-2))
+	if err != nil {
+		t.Fatalf("could not explain electric engine: %v", err)
+	}
+	if len(rules) != 2 || !reflect.DeepEqual(sels, lits(3)) {
+		t.Errorf("invalid explanation: rules %v, selections %v", rules, sels)
+	}
+	if _, _, err := c.Why(solver.IntToLit(4)); err == nil {
+		t.Errorf("big battery is not forced, but an explanation was found")
+	}
+	if err := c.Select(solver.IntToLit(2)); err == nil {
+		t.Errorf("electric engine should be incompatible with towbar")
+	}
+	if sels := c.Selections(); !reflect.DeepEqual(sels, lits(3)) {
+		t.Errorf("invalid selections after failed selection: %v", sels)
+	}
+	c.Unselect(solver.IntToLit(3))
+	if err := c.Select(solver.IntToLit(2)); err != nil {
+		t.Errorf("could not select electric engine after unselecting towbar: %v", err)
+	}
+	if forced, expected := c.Forced(), lits(-1, 2, -3, 4); !reflect.DeepEqual(forced, expected) {
+		t.Errorf("invalid forced options: expected %v, got %v", expected, forced)
+	}
+}
+
+func TestAddRule(t *testing.T) {
+	c := New(solver.ParseSlice(carRules))
+	if err := c.Select(solver.IntToLit(5)); err != nil {
+		t.Fatalf("could not select sunroof: %v", err)
+	}
+	if forced, expected := c.Forced(), lits(5); !reflect.DeepEqual(forced, expected) {
+		t.Errorf("invalid forced options: expected %v, got %v", expected, forced)
+	}
+	// Sunroof is only available on electric cars
+	if err := c.AddRule(solver.IntToLit(-5), solver.IntToLit(2)); err != nil {
+		t.Fatalf("could not add rule: %v", err)
+	}
+	if forced, expected := c.Forced(), lits(-1, 2, -3, 4, 5); !reflect.DeepEqual(forced, expected) {
+		t.Errorf("invalid forced options after new rule: expected %v, got %v", expected, forced)
+	}
+	// Sunroof is not available with a big battery
+	if err := c.AddRule(solver.IntToLit(-5), solver.IntToLit(-4)); err == nil {
+		t.Errorf("rule should be incompatible with sunroof")
+	}
+	c.Unselect(solver.IntToLit(5))
+	if forced, expected := c.Forced(), lits(-5); !reflect.DeepEqual(forced, expected) {
+		t.Errorf("invalid forced options after unselection: expected %v, got %v", expected, forced)
+	}
+}
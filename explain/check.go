@@ -2,7 +2,6 @@
 package explain
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strconv"
@@ -79,7 +78,7 @@ func (pb *Problem) UnsatChan(ch chan string) (valid bool, err error) {
 func (pb *Problem) Unsat(cert io.Reader) (valid bool, err error) {
 	defer pb.restore()
 	pb.initTagged()
-	sc := bufio.NewScanner(cert)
+	sc := solver.NewScanner(cert, 0)
 	for sc.Scan() {
 		line := sc.Text()
 		fields := strings.Fields(line)
@@ -10,7 +10,7 @@ import (
 	"github.com/j-blue-arz/tiny-gophersat/solver"
 )
 
-func ExampleInstanceIsAMUS() {
+func Example_instanceIsAMUS() {
 	const cnf = `p cnf 1 2
 	c This is a simple problem
 	1 0
@@ -1,11 +1,12 @@
 package explain
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
 )
 
 // parseClause parses a line representing a clause in the DIMACS CNF syntax.
@@ -24,10 +25,17 @@ func parseClause(fields []string) ([]int, error) {
 }
 
 // ParseCNF parses a CNF and returns the associated problem.
+// As allowed by the DIMACS syntax, a clause can span several lines, and a line can hold several clauses:
+// a clause ends with the literal 0 only.
 func ParseCNF(r io.Reader) (*Problem, error) {
-	sc := bufio.NewScanner(r)
-	var pb Problem
+	sc := solver.NewScanner(r, 0)
+	var (
+		pb     Problem
+		clause []int // Clause being parsed, that might span several lines
+		lineNb int
+	)
 	for sc.Scan() {
+		lineNb++
 		line := sc.Text()
 		fields := strings.Fields(line)
 		if len(fields) == 0 {
@@ -38,16 +46,30 @@ func ParseCNF(r io.Reader) (*Problem, error) {
 			continue
 		case "p":
 			if err := pb.parseHeader(fields); err != nil {
-				return nil, fmt.Errorf("could not parse header %q: %v", line, err)
+				return nil, &solver.ParseError{Line: lineNb, Msg: fmt.Sprintf("could not parse header %q: %v", line, err), Err: solver.ErrBadHeader}
 			}
 		default:
-			if err := pb.parseClause(fields); err != nil {
-				return nil, fmt.Errorf("could not parse clause %q: %v", line, err)
+			for _, rawLit := range fields {
+				lit, err := strconv.Atoi(rawLit)
+				if err != nil {
+					return nil, &solver.ParseError{Line: lineNb, Msg: fmt.Sprintf("could not parse clause %q: %v", line, err), Err: solver.ErrBadLiteral}
+				}
+				if lit != 0 {
+					clause = append(clause, lit)
+					continue
+				}
+				if err := pb.addClause(clause); err != nil {
+					return nil, &solver.ParseError{Line: lineNb, Msg: fmt.Sprintf("could not parse clause %q: %v", line, err), Err: solver.ErrBadLiteral}
+				}
+				clause = nil
 			}
 		}
 	}
 	if err := sc.Err(); err != nil {
-		return nil, fmt.Errorf("could not parse problem: %v", err)
+		return nil, fmt.Errorf("could not parse problem: %w", err)
+	}
+	if len(clause) != 0 {
+		return nil, &solver.ParseError{Line: lineNb, Msg: fmt.Sprintf("last clause %v is not terminated by 0", clause), Err: solver.ErrTruncated}
 	}
 	return &pb, nil
 }
@@ -78,10 +100,10 @@ func (pb *Problem) parseHeader(fields []string) error {
 	return nil
 }
 
-func (pb *Problem) parseClause(fields []string) error {
-	clause, err := parseClause(fields)
-	if err != nil {
-		return err
+// addClause adds the given clause, whose terminating 0 was removed, to pb.
+func (pb *Problem) addClause(clause []int) error {
+	if clause == nil {
+		clause = []int{}
 	}
 	pb.Clauses = append(pb.Clauses, clause)
 	if len(clause) == 1 {
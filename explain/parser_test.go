@@ -0,0 +1,44 @@
+package explain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestParseCNFMultiLine(t *testing.T) {
+	const cnf = `p cnf 4 4
+	c A clause can span several lines
+	1 2
+	-3 0
+	c and a line can hold several clauses
+	-1 0 -2 4 0
+	3
+	0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	expected := [][]int{{1, 2, -3}, {-1}, {-2, 4}, {3}}
+	if !reflect.DeepEqual(pb.Clauses, expected) {
+		t.Errorf("invalid clauses: expected %v, got %v", expected, pb.Clauses)
+	}
+	if pb.units[0] != -1 || pb.units[2] != 1 {
+		t.Errorf("invalid units: %v", pb.units)
+	}
+}
+
+func TestParseCNFUnterminated(t *testing.T) {
+	if _, err := ParseCNF(strings.NewReader("p cnf 2 1\n1 -2\n")); err == nil {
+		t.Errorf("expected an error for a clause not terminated by 0")
+	}
+}
+
+func TestParseCNFErrorLine(t *testing.T) {
+	_, err := ParseCNF(strings.NewReader("p cnf 2 2\n1 -2 0\n\n2 x 0\n"))
+	if perr, ok := err.(*solver.ParseError); !ok || perr.Line != 4 {
+		t.Errorf("expected an error on line 4, got %v", err)
+	}
+}
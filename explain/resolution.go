@@ -0,0 +1,68 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+)
+
+// ErrGraphTooBig is the error returned when a resolution graph has more nodes than allowed.
+var ErrGraphTooBig = fmt.Errorf("resolution graph is too big")
+
+// A ResolutionNode is a clause of a resolution graph.
+type ResolutionNode struct {
+	Clause      []int // The clause itself. It is empty for the final conflict.
+	Lemma       bool  // True iff the clause was derived, false if it is a clause from the problem
+	Antecedents []int // Indices of the nodes the clause was derived from by resolution. Empty for problem clauses.
+}
+
+// A ResolutionGraph is the DAG of the derivation of the empty clause: each lemma is linked to the clauses
+// it was derived from. Nodes are sorted so that each node comes after its antecedents;
+// the last node is the empty clause.
+type ResolutionGraph struct {
+	Nodes []ResolutionNode
+}
+
+// ResolutionGraph reads a RUP certificate proving pb is UNSAT from cert and returns the resolution graph
+// of the final conflict, restricted to the clauses that are actually needed in it.
+// If maxNodes is strictly positive and the graph has more than maxNodes nodes, ErrGraphTooBig is returned
+// as soon as this is detected, so that huge proofs do not exhaust memory.
+// An error is returned if the certificate is invalid.
+func (pb *Problem) ResolutionGraph(cert io.Reader, maxNodes int) (*ResolutionGraph, error) {
+	lemmas, err := parseCert(cert)
+	if err != nil {
+		return nil, err
+	}
+	ants := make([][]int, pb.NbClauses+len(lemmas)+1)
+	maxUsed := 0
+	if maxNodes > 0 {
+		maxUsed = maxNodes - 1 // The empty clause is not counted as a used clause
+		if maxUsed == 0 {
+			return nil, ErrGraphTooBig
+		}
+	}
+	used, err := pb.trim(lemmas, ants, maxUsed)
+	if err != nil {
+		return nil, err
+	}
+	used = append(used, true)     // The empty clause is always part of the graph
+	idx := make([]int, len(used)) // Index of each used clause in the graph
+	var graph ResolutionGraph
+	for i, u := range used {
+		if !u {
+			continue
+		}
+		idx[i] = len(graph.Nodes)
+		node := ResolutionNode{Lemma: i >= pb.NbClauses}
+		switch {
+		case i < pb.NbClauses:
+			node.Clause = pb.Clauses[i]
+		case i < len(used)-1:
+			node.Clause = lemmas[i-pb.NbClauses]
+		}
+		for _, j := range ants[i] {
+			node.Antecedents = append(node.Antecedents, idx[j])
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	return &graph, nil
+}
@@ -0,0 +1,53 @@
+package explain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolutionGraph(t *testing.T) {
+	const cnf = `p cnf 4 8
+	 1  2 -3 0
+	-1 -2  3 0
+	 2  3 -4 0
+	-2 -3  4 0
+	 1  3  4 0
+	-1 -3 -4 0
+	-1  2  4 0
+	 1 -2 -4 0`
+	const cert = "-1 2 3 0\n1 2 0\n1 0\n2 0\n0\n"
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	graph, err := pb.ResolutionGraph(strings.NewReader(cert), 0)
+	if err != nil {
+		t.Fatalf("could not build resolution graph: %v", err)
+	}
+	nodes := graph.Nodes
+	last := nodes[len(nodes)-1]
+	if len(last.Clause) != 0 || !last.Lemma || len(last.Antecedents) == 0 {
+		t.Errorf("last node should be the empty clause, got %+v", last)
+	}
+	var lemmas [][]int
+	for i, node := range nodes {
+		if node.Lemma && len(node.Clause) != 0 {
+			lemmas = append(lemmas, node.Clause)
+		}
+		if !node.Lemma && len(node.Antecedents) != 0 {
+			t.Errorf("problem clause %v has antecedents", node.Clause)
+		}
+		for _, j := range node.Antecedents {
+			if j >= i {
+				t.Errorf("node %d has antecedent %d, which comes after it", i, j)
+			}
+		}
+	}
+	if expected := [][]int{{1, 2}, {1}, {2}}; !reflect.DeepEqual(lemmas, expected) {
+		t.Errorf("invalid lemmas in graph: expected %v, got %v", expected, lemmas)
+	}
+	if _, err := pb.ResolutionGraph(strings.NewReader(cert), 3); err != ErrGraphTooBig {
+		t.Errorf("expected ErrGraphTooBig, got %v", err)
+	}
+}
@@ -0,0 +1,409 @@
+package explain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// This file deals with the backward trimming of RUP certificates.
+// Starting from the final conflict, lemmas are checked from the last to the first one, but only if they were used
+// to derive the empty clause or a lemma that is itself needed: lemmas that are not needed are never checked.
+// They are dropped, and problem clauses that were never needed are not part of the unsat core.
+
+// parseCert reads the lemmas of a RUP certificate, up to the empty clause, if any.
+// Lines that are not clauses, such as comments, are ignored.
+func parseCert(cert io.Reader) ([][]int, error) {
+	var lemmas [][]int
+	sc := solver.NewScanner(cert, 0)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			continue
+		}
+		clause, err := parseClause(fields)
+		if err != nil {
+			return nil, err
+		}
+		if len(clause) == 0 {
+			break
+		}
+		lemmas = append(lemmas, clause)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not parse certificate: %v", err)
+	}
+	return lemmas, nil
+}
+
+// A propagator runs unit propagation on a list of clauses, using two watched lits per clause of at least two lits.
+// Watches are kept from one call to conflict to the next: since all vars are unbound again after each call,
+// any two lits of a clause can be watched at the beginning of a call.
+type propagator struct {
+	clauses   [][]int
+	preferred []bool   // Clauses that are propagated first
+	watched   [][2]int // For each clause of at least two lits, the positions of its two watched lits
+	watches   [][]int  // For each lit, the indices of the clauses watching it
+	units     []int    // Indices of the clauses that have less than two lits
+	binding   []int    // For each var, 0 if the var is unbound, 1 if true, -1 if false
+	reasons   []int    // For each bound var, index of the clause that propagated it, or -1 if it was assumed
+	trail     []int    // Bound lits, in the order they were bound
+}
+
+// newPropagator returns a propagator for the given clauses, whose vars are between 1 and nbVars.
+// preferred is read by each call to conflict, so that clauses can be preferred between two calls.
+func newPropagator(nbVars int, clauses [][]int, preferred []bool) *propagator {
+	p := &propagator{
+		clauses:   clauses,
+		preferred: preferred,
+		watched:   make([][2]int, len(clauses)),
+		watches:   make([][]int, 2*nbVars),
+		binding:   make([]int, nbVars),
+		reasons:   make([]int, nbVars),
+	}
+	for i, c := range clauses {
+		if len(c) < 2 {
+			p.units = append(p.units, i)
+			continue
+		}
+		second := 1
+		for second < len(c)-1 && c[second] == c[0] { // Try not to watch the same lit twice
+			second++
+		}
+		p.watched[i] = [2]int{0, second}
+		p.watches[litIndex(c[0])] = append(p.watches[litIndex(c[0])], i)
+		p.watches[litIndex(c[second])] = append(p.watches[litIndex(c[second])], i)
+	}
+	return p
+}
+
+// litIndex returns the index of lit in lists indexed by lits.
+func litIndex(lit int) int {
+	if lit > 0 {
+		return 2 * (lit - 1)
+	}
+	return 2*(-lit-1) + 1
+}
+
+// value returns 1 if lit is true, -1 if it is false, 0 if it is unbound.
+func (p *propagator) value(lit int) int {
+	return p.binding[abs(lit)-1] * sign(lit)
+}
+
+// bind makes lit true, because of the clause whose index is reason, or -1 if it is assumed.
+func (p *propagator) bind(lit, reason int) {
+	p.binding[abs(lit)-1] = sign(lit)
+	p.reasons[abs(lit)-1] = reason
+	p.trail = append(p.trail, lit)
+}
+
+// conflict propagates the negation of the lits in clause on the clauses whose index is lower than limit.
+// If propagation leads to a conflict, it returns the indices of the clauses involved in it.
+// Otherwise, it returns false.
+// Preferred clauses are propagated first, so that other clauses are only used when needed:
+// after each propagation of another clause, preferred clauses are propagated again.
+func (p *propagator) conflict(limit int, clause []int) (used []int, ok bool) {
+	defer p.reset()
+	for _, lit := range clause {
+		switch p.value(lit) {
+		case 1: // Tautology: trivially implied
+			return nil, true
+		case 0:
+			p.bind(-lit, -1)
+		}
+	}
+	var pending []int // Clauses that are not preferred and became unit, in that order
+	for _, i := range p.units {
+		if i >= limit {
+			break
+		}
+		if len(p.clauses[i]) == 0 {
+			return []int{i}, true
+		}
+		if lit := p.clauses[i][0]; !p.preferred[i] {
+			pending = append(pending, i)
+		} else if val := p.value(lit); val == -1 {
+			return p.reasonsOf(i), true
+		} else if val == 0 {
+			p.bind(lit, i)
+		}
+	}
+	for head := 0; ; {
+		for ; head < len(p.trail); head++ {
+			if confl := p.propagate(-p.trail[head], limit, &pending); confl != -1 {
+				return p.reasonsOf(confl), true
+			}
+		}
+		// Preferred clauses were propagated: use a pending clause that is still unit, if any.
+		for len(pending) > 0 && head == len(p.trail) {
+			i := pending[0]
+			pending = pending[1:]
+			c := p.clauses[i]
+			lits := c
+			if len(c) >= 2 {
+				lits = []int{c[p.watched[i][0]], c[p.watched[i][1]]}
+			}
+			unit, sat := 0, false
+			for _, lit := range lits {
+				switch p.value(lit) {
+				case 1:
+					sat = true
+				case 0:
+					unit = lit
+				}
+			}
+			if sat {
+				continue
+			}
+			if unit == 0 { // Unit clause whose lit is false
+				return p.reasonsOf(i), true
+			}
+			p.bind(unit, i)
+		}
+		if head == len(p.trail) {
+			return nil, false
+		}
+	}
+}
+
+// propagate visits the clauses whose index is lower than limit and that watch lit, once lit became false.
+// Watches are moved to lits that are not false, if any. Preferred clauses that became unit are propagated,
+// and other ones are appended to pending. It returns the index of a clause whose lits are all false, or -1.
+func (p *propagator) propagate(lit, limit int, pending *[]int) (confl int) {
+	ws := p.watches[litIndex(lit)]
+	j := 0
+	defer func() { p.watches[litIndex(lit)] = ws[:j] }()
+	for k, i := range ws {
+		if i >= limit {
+			ws[j] = i
+			j++
+			continue
+		}
+		c, w := p.clauses[i], &p.watched[i]
+		if c[w[0]] != lit {
+			w[0], w[1] = w[1], w[0]
+		}
+		other := c[w[1]]
+		if p.value(other) == 1 {
+			ws[j] = i
+			j++
+			continue
+		}
+		moved := false
+		for pos, l := range c {
+			if pos != w[0] && pos != w[1] && l != other && p.value(l) != -1 { // Watching other twice would hide units
+				w[0] = pos
+				p.watches[litIndex(l)] = append(p.watches[litIndex(l)], i)
+				moved = true
+				break
+			}
+		}
+		if moved {
+			continue
+		}
+		ws[j] = i
+		j++
+		switch {
+		case p.value(other) == -1:
+			j += copy(ws[j:], ws[k+1:])
+			return i
+		case p.preferred[i]:
+			p.bind(other, i)
+		default:
+			*pending = append(*pending, i)
+		}
+	}
+	return -1
+}
+
+// reset unbinds all vars.
+func (p *propagator) reset() {
+	for _, lit := range p.trail {
+		p.binding[abs(lit)-1] = 0
+	}
+	p.trail = p.trail[:0]
+}
+
+// reasonsOf returns the index of the conflict clause confl, and of all clauses that propagated its lits,
+// directly or not.
+func (p *propagator) reasonsOf(confl int) []int {
+	return reasonsOf(p.clauses, p.reasons, confl)
+}
+
+// reasonsOf returns the index of the conflict clause confl, and of all clauses that propagated its lits,
+// directly or not.
+func reasonsOf(clauses [][]int, reasons []int, confl int) []int {
+	seen := map[int]bool{confl: true}
+	res := []int{confl}
+	for i := 0; i < len(res); i++ {
+		for _, lit := range clauses[res[i]] {
+			if r := reasons[abs(lit)-1]; r != -1 && !seen[r] {
+				seen[r] = true
+				res = append(res, r)
+			}
+		}
+	}
+	return res
+}
+
+func abs(val int) int {
+	if val < 0 {
+		return -val
+	}
+	return val
+}
+
+func sign(val int) int {
+	if val < 0 {
+		return -1
+	}
+	return 1
+}
+
+// trim checks the lemmas backward and returns, for each problem clause and then each lemma,
+// whether it is used to derive the empty clause.
+// If ants is not nil, it must contain one slice per problem clause and lemma, and one more for the empty clause:
+// for each used lemma, and for the empty clause, the indices of the clauses it was derived from are appended to it.
+// If maxUsed is strictly positive and more than maxUsed clauses are used, trimming stops and ErrGraphTooBig is returned.
+func (pb *Problem) trim(lemmas [][]int, ants [][]int, maxUsed int) (used []bool, err error) {
+	clauses := make([][]int, 0, pb.NbClauses+len(lemmas))
+	clauses = append(clauses, pb.Clauses[:pb.NbClauses]...)
+	clauses = append(clauses, lemmas...)
+	nbVars := pb.NbVars
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			if abs(lit) > nbVars {
+				nbVars = abs(lit)
+			}
+		}
+	}
+	used = make([]bool, len(clauses))
+	preferred := make([]bool, len(clauses)) // Problem clauses and used lemmas
+	for i := 0; i < pb.NbClauses; i++ {
+		preferred[i] = true
+	}
+	nbUsed := 0
+	// markUsed indicates the clause #i was derived from the clauses in confl.
+	markUsed := func(i int, confl []int) error {
+		if ants != nil {
+			ants[i] = append(ants[i], confl...)
+		}
+		for _, j := range confl {
+			if !used[j] {
+				used[j] = true
+				preferred[j] = true
+				nbUsed++
+			}
+		}
+		if maxUsed > 0 && nbUsed > maxUsed {
+			return ErrGraphTooBig
+		}
+		return nil
+	}
+	p := newPropagator(nbVars, clauses, preferred)
+	confl, ok := p.conflict(len(clauses), nil)
+	if !ok {
+		return nil, fmt.Errorf("certificate does not lead to a conflict")
+	}
+	if err := markUsed(len(clauses), confl); err != nil {
+		return nil, err
+	}
+	for i := len(clauses) - 1; i >= pb.NbClauses; i-- {
+		if !used[i] {
+			continue
+		}
+		confl, ok := p.conflict(i, clauses[i])
+		if !ok {
+			return nil, fmt.Errorf("lemma #%d is not implied by the problem and the previous lemmas", i-pb.NbClauses+1)
+		}
+		if err := markUsed(i, confl); err != nil {
+			return nil, err
+		}
+	}
+	return used, nil
+}
+
+// Trim reads a RUP certificate proving pb is UNSAT from cert and writes on w an equivalent certificate,
+// containing only the lemmas that are actually needed to derive the empty clause, followed by the empty clause.
+// It returns the number of lemmas in both certificates.
+// An error is returned if the certificate is invalid.
+func (pb *Problem) Trim(cert io.Reader, w io.Writer) (nbKept, nbLemmas int, err error) {
+	lemmas, err := parseCert(cert)
+	if err != nil {
+		return 0, 0, err
+	}
+	used, err := pb.trim(lemmas, nil, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	bw := bufio.NewWriter(w)
+	for i, lemma := range lemmas {
+		if !used[pb.NbClauses+i] {
+			continue
+		}
+		nbKept++
+		for _, lit := range lemma {
+			fmt.Fprintf(bw, "%d ", lit)
+		}
+		fmt.Fprintln(bw, "0")
+	}
+	fmt.Fprintln(bw, "0")
+	return nbKept, len(lemmas), bw.Flush()
+}
+
+// CertCore reads a RUP certificate proving pb is UNSAT from cert and returns an unsatisfiable subset of the problem,
+// made of the clauses that are actually used to derive the empty clause once the certificate was trimmed.
+// Contrary to cores based on assumptions, it does not need any selector, and is usually smaller than UnsatSubset.
+// An error is returned if the certificate is invalid.
+func (pb *Problem) CertCore(cert io.Reader) (core *Problem, err error) {
+	lemmas, err := parseCert(cert)
+	if err != nil {
+		return nil, err
+	}
+	used, err := pb.trim(lemmas, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	core = &Problem{NbVars: pb.NbVars}
+	for i, clause := range pb.Clauses[:pb.NbClauses] {
+		if used[i] {
+			core.Clauses = append(core.Clauses, clause)
+			core.NbClauses++
+		}
+	}
+	return core, nil
+}
+
+// ProofCore solves the problem once, generating a certificate, and returns the unsat core extracted from it.
+// See CertCore. If the problem is satisfiable, ErrNotUnsat is returned.
+func (pb *Problem) ProofCore() (core *Problem, err error) {
+	if solver.ParseSlice(pb.Clauses).Status == solver.Unsat { // Trivially UNSAT: unit propagation is enough
+		return pb.CertCore(strings.NewReader(""))
+	}
+	s := solver.New(solver.ParseSlice(pb.Clauses))
+	s.Certified = true
+	s.CertChan = make(chan string)
+	var cert strings.Builder
+	done := make(chan struct{})
+	go func() {
+		for line := range s.CertChan {
+			cert.WriteString(line)
+			cert.WriteByte('\n')
+		}
+		close(done)
+	}()
+	status := s.Solve()
+	close(s.CertChan)
+	<-done
+	if status != solver.Unsat {
+		return nil, ErrNotUnsat
+	}
+	return pb.CertCore(strings.NewReader(cert.String()))
+}
@@ -0,0 +1,134 @@
+package explain
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestTrim(t *testing.T) {
+	const cnf = `p cnf 4 8
+	 1  2 -3 0
+	-1 -2  3 0
+	 2  3 -4 0
+	-2 -3  4 0
+	 1  3  4 0
+	-1 -3 -4 0
+	-1  2  4 0
+	 1 -2 -4 0`
+	const cert = `c The first and third lemmas are useless
+	-1 2 3 0
+	1 2 0
+	2 3 4 0
+	1 0
+	2 0
+	0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	var trimmed bytes.Buffer
+	nbKept, nbLemmas, err := pb.Trim(strings.NewReader(cert), &trimmed)
+	if err != nil {
+		t.Fatalf("could not trim certificate: %v", err)
+	}
+	if nbKept != 3 || nbLemmas != 5 {
+		t.Errorf("expected 3 lemmas out of 5, got %d out of %d", nbKept, nbLemmas)
+	}
+	if expected := "1 2 0\n1 0\n2 0\n0\n"; trimmed.String() != expected {
+		t.Errorf("invalid trimmed certificate: expected %q, got %q", expected, trimmed.String())
+	}
+	if _, _, err := pb.Trim(strings.NewReader("-1 -2 0\n0"), &trimmed); err == nil {
+		t.Errorf("invalid certificate was trimmed")
+	}
+	// The lemma is checked by propagating the first clause, whose duplicate lit must be watched only once.
+	pb, err = ParseCNF(strings.NewReader("p cnf 3 5\n1 1 2 0\n-1 3 0\n-1 -3 0\n-2 3 0\n-2 -3 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	trimmed.Reset()
+	if nbKept, _, err := pb.Trim(strings.NewReader("2 0\n0\n"), &trimmed); err != nil || nbKept != 1 {
+		t.Errorf("expected the lemma to be kept, got %d lemmas, error %v", nbKept, err)
+	}
+}
+
+func TestTrimFile(t *testing.T) {
+	f, err := os.Open("testcnf/125.cnf")
+	if err != nil {
+		t.Fatalf("could not read CNF file: %v", err)
+	}
+	defer f.Close()
+	pb, err := ParseCNF(f)
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	cert, err := os.Open("testcnf/125_cert.out")
+	if err != nil {
+		t.Fatalf("could not read certificate: %v", err)
+	}
+	defer cert.Close()
+	var trimmed bytes.Buffer
+	nbKept, nbLemmas, err := pb.Trim(cert, &trimmed)
+	if err != nil {
+		t.Fatalf("could not trim certificate: %v", err)
+	}
+	if nbKept == 0 || nbKept > nbLemmas {
+		t.Errorf("invalid number of kept lemmas: %d out of %d", nbKept, nbLemmas)
+	}
+	if ok, err := pb.Unsat(&trimmed); err != nil || !ok {
+		t.Errorf("trimmed certificate is invalid: %v", err)
+	}
+}
+
+func TestCertCore(t *testing.T) {
+	const cnf = `p cnf 6 11
+	 1  2 -3 0
+	-1 -2  3 0
+	 2  3 -4 0
+	-2 -3  4 0
+	 1  3  4 0
+	-1 -3 -4 0
+	-1  2  4 0
+	 1 -2 -4 0
+	 5  6 0
+	-5  6 0
+	 1  5 0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	core, err := pb.CertCore(strings.NewReader("1 2 0\n1 0\n2 0\n0\n"))
+	if err != nil {
+		t.Fatalf("could not compute core: %v", err)
+	}
+	if core.NbClauses != 8 {
+		t.Errorf("expected a core of 8 clauses, got %d: %s", core.NbClauses, core.CNF())
+	}
+	core, err = pb.ProofCore()
+	if err != nil {
+		t.Fatalf("could not compute core from proof: %v", err)
+	}
+	for _, clause := range core.Clauses {
+		for _, lit := range clause {
+			if lit == 5 || lit == -5 || lit == 6 {
+				t.Errorf("core contains useless clause %v", clause)
+			}
+		}
+	}
+	if s := solver.New(solver.ParseSlice(core.Clauses)); s.Solve() != solver.Unsat {
+		t.Errorf("core is satisfiable: %s", core.CNF())
+	}
+	if _, err := pb.CertCore(strings.NewReader("-1 -2 0\n0")); err == nil {
+		t.Errorf("core was computed from an invalid certificate")
+	}
+	sat, err := ParseCNF(strings.NewReader("p cnf 2 2\n1 2 0\n-1 2 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	if _, err := sat.ProofCore(); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat for sat problem, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+// Package fd provides a finite-domain modeling layer on top of the gophersat solver.
+//
+// Problems are described as integer variables, each taking its value in a finite range,
+// and as constraints linking those variables. They are then compiled to SAT/PB constraints
+// and solved by gophersat.
+//
+// Each integer variable is encoded with boolean variables, as needed by the constraints it appears in.
+// The direct encoding associates each possible value v of a variable x with a literal meaning "x = v";
+// exactly one of those literals is true in any model.
+// The order encoding associates each value v of x with a literal meaning "x >= v".
+// When both encodings of a variable are used, channeling clauses are generated automatically,
+// so that whatever is deduced with one encoding is propagated to the other one.
+//
+// For instance, the following code declares two variables x and y between 1 and 3,
+// such that (x, y) is either (1, 2), (2, 3) or (3, 1):
+//
+//	m := fd.NewModel()
+//	x := m.IntVar("x", 1, 3)
+//	y := m.IntVar("y", 1, 3)
+//	m.Table([]*fd.IntVar{x, y}, [][]int{{1, 2}, {2, 3}, {3, 1}})
+//	if sol, ok := m.Solve(); ok {
+//	    fmt.Println(sol.Int(x), sol.Int(y))
+//	}
+//
+// A linear objective over integer variables can be declared with m.Minimize or m.Maximize.
+// It is lowered to a PB cost function, using the order encoding of the variables, and m.Optimize
+// then returns an optimal solution along with the value of the objective.
+//
+// Boolean variables can be mixed with integer variables: m.Bool() returns a new boolean variable,
+// as a DIMACS-style literal, that can be used in clauses with m.Clause and in any constraint expecting literals.
+package fd
@@ -0,0 +1,65 @@
+package fd
+
+// LexLeq adds a constraint to m stating that the vector xs is lexicographically smaller than
+// or equal to the vector ys, false being smaller than true.
+// It will panic if xs and ys do not have the same length.
+func (m *Model) LexLeq(xs, ys []int) {
+	m.lex(xs, ys, false)
+}
+
+// LexLess adds a constraint to m stating that the vector xs is strictly lexicographically smaller than the vector ys,
+// false being smaller than true.
+// It will panic if xs and ys do not have the same length.
+func (m *Model) LexLess(xs, ys []int) {
+	m.lex(xs, ys, true)
+}
+
+// LexChain adds constraints to m stating that each row is lexicographically smaller than or equal to the next one.
+// It is typically used to break row symmetries in matrices of decision variables.
+func (m *Model) LexChain(rows [][]int) {
+	for i := 1; i < len(rows); i++ {
+		m.LexLeq(rows[i-1], rows[i])
+	}
+}
+
+// lex encodes the lexicographic ordering of xs and ys.
+// A new literal e[i+1] means that xs and ys are equal on their first i+1 values.
+// As long as both vectors are equal, xs[i] must not be greater than ys[i].
+// If strict is true, both vectors cannot be equal.
+func (m *Model) lex(xs, ys []int, strict bool) {
+	if len(xs) != len(ys) {
+		panic("vectors must have the same length")
+	}
+	eq := 0 // Literal meaning the vectors are equal so far, 0 meaning true
+	for i := range xs {
+		x, y := xs[i], ys[i]
+		m.Clause(prefix(eq, -x, y)...) // eq -> x <= y
+		if i == len(xs)-1 && !strict {
+			break
+		}
+		next := m.Bool()
+		m.Clause(prefix(eq, -x, -y, next)...) // eq ∧ x = y -> next
+		m.Clause(prefix(eq, x, y, next)...)
+		m.Clause(-next, -x, y) // next -> eq ∧ x = y
+		m.Clause(-next, x, -y)
+		if eq != 0 {
+			m.Clause(-next, eq)
+		}
+		eq = next
+	}
+	if strict {
+		if eq == 0 { // Empty vectors are always equal
+			m.Clause()
+		} else {
+			m.Clause(-eq)
+		}
+	}
+}
+
+// prefix returns the clause lits, prefixed with the negation of eq if eq is not 0.
+func prefix(eq int, lits ...int) []int {
+	if eq == 0 {
+		return lits
+	}
+	return append([]int{-eq}, lits...)
+}
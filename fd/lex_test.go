@@ -0,0 +1,56 @@
+package fd
+
+import (
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestLex(t *testing.T) {
+	if !solver.Capabilities().Counting {
+		t.Skip("model counting with tree decompositions is not part of this build")
+	}
+	for _, test := range []struct {
+		strict   bool
+		expected int
+	}{{false, 10}, {true, 6}} {
+		m := NewModel()
+		xs := []int{m.Bool(), m.Bool()}
+		ys := []int{m.Bool(), m.Bool()}
+		if test.strict {
+			m.LexLess(xs, ys)
+		} else {
+			m.LexLeq(xs, ys)
+		}
+		if nb, err := m.Problem().CountModelsTD(nil); err != nil || nb.Int64() != int64(test.expected) {
+			t.Errorf("strict=%t: expected %d models, got %d (error: %v)", test.strict, test.expected, nb, err)
+		}
+	}
+}
+
+func TestLexEmpty(t *testing.T) {
+	m := NewModel()
+	m.LexLeq(nil, nil)
+	if _, ok := m.Solve(); !ok {
+		t.Errorf("empty vectors should be lexicographically smaller than or equal to each other")
+	}
+	m = NewModel()
+	m.LexLess(nil, nil)
+	if _, ok := m.Solve(); ok {
+		t.Errorf("empty vectors should not be strictly lexicographically smaller than each other")
+	}
+}
+
+func TestLexChain(t *testing.T) {
+	m := NewModel()
+	rows := make([][]int, 3)
+	for i := range rows {
+		rows[i] = []int{m.Bool(), m.Bool(), m.Bool()}
+	}
+	m.LexChain(rows)
+	m.Clause(rows[0][0])
+	m.Clause(-rows[2][0])
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
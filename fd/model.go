@@ -0,0 +1,155 @@
+package fd
+
+import (
+	"fmt"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A Model is a set of integer and boolean variables, and of constraints over those variables.
+type Model struct {
+	nbVars  int               // Number of boolean variables allocated so far
+	constrs []solver.PBConstr // Constraints generated so far
+	ints    []*IntVar         // All integer variables, in order of declaration
+	trueLit int               // A literal that is always true, or 0 if it was not needed yet
+	obj     objective         // Function to optimize, if any
+}
+
+// An IntVar is an integer variable, whose value is between Min and Max, both included.
+type IntVar struct {
+	Name   string
+	Min    int
+	Max    int
+	m      *Model
+	direct []int // direct[i] is the lit meaning "x = Min+i"
+	order  []int // order[i] is the lit meaning "x >= Min+i+1"
+}
+
+// A Solution associates each variable from a model with its value.
+type Solution struct {
+	bools []bool
+}
+
+// NewModel returns a new, empty model.
+func NewModel() *Model {
+	return &Model{}
+}
+
+// Bool returns a new boolean variable, as a positive DIMACS literal.
+func (m *Model) Bool() int {
+	m.nbVars++
+	return m.nbVars
+}
+
+// IntVar declares a new integer variable whose value is between min and max, both included.
+// It will panic if max < min.
+func (m *Model) IntVar(name string, min, max int) *IntVar {
+	if max < min {
+		panic(fmt.Sprintf("invalid domain [%d, %d] for var %q", min, max, name))
+	}
+	x := &IntVar{Name: name, Min: min, Max: max, m: m}
+	m.ints = append(m.ints, x)
+	return x
+}
+
+// True returns a literal that is true in all solutions.
+func (m *Model) True() int {
+	if m.trueLit == 0 {
+		m.trueLit = m.Bool()
+		m.Clause(m.trueLit)
+	}
+	return m.trueLit
+}
+
+// Clause adds a clause to the model: at least one of the given lits must be true.
+func (m *Model) Clause(lits ...int) {
+	m.constrs = append(m.constrs, solver.PropClause(lits...))
+}
+
+// Add adds the given PB constraints to the model.
+func (m *Model) Add(constrs ...solver.PBConstr) {
+	m.constrs = append(m.constrs, constrs...)
+}
+
+// exactly1 adds a constraint stating exactly one of the lits must be true.
+func (m *Model) exactly1(lits []int) {
+	m.Clause(append([]int{}, lits...)...)
+	m.Add(solver.AtMost(append([]int{}, lits...), 1))
+}
+
+// Size returns the number of values x can take.
+func (x *IntVar) Size() int {
+	return x.Max - x.Min + 1
+}
+
+// String returns the name of x.
+func (x *IntVar) String() string {
+	return x.Name
+}
+
+// Eq returns the literal meaning "x = val".
+// If val is not in the domain of x, it will panic.
+// The direct encoding of x is generated the first time Eq is called.
+func (x *IntVar) Eq(val int) int {
+	if val < x.Min || val > x.Max {
+		panic(fmt.Sprintf("value %d out of domain [%d, %d] for var %q", val, x.Min, x.Max, x.Name))
+	}
+	if x.direct == nil {
+		x.direct = make([]int, x.Size())
+		for i := range x.direct {
+			x.direct[i] = x.m.Bool()
+		}
+		x.m.exactly1(x.direct)
+		if x.order != nil {
+			x.channel()
+		}
+	}
+	return x.direct[val-x.Min]
+}
+
+// Problem returns the SAT/PB problem equivalent to m.
+// If an objective was declared, it is lowered to the cost function of the problem.
+func (m *Model) Problem() *solver.Problem {
+	lits, weights := m.obj.lower()
+	for _, x := range m.ints { // Make sure each var is encoded, even if no constraint is applied on it.
+		x.Eq(x.Min)
+	}
+	pb := solver.ParsePBConstrs(m.constrs)
+	if lits != nil && pb.Status != solver.Unsat {
+		pb.SetCostFunc(lits, weights)
+	}
+	return pb
+}
+
+// Solve solves m and returns a solution, if any.
+// If the problem has no solution, ok is false.
+func (m *Model) Solve() (sol Solution, ok bool) {
+	s := solver.New(m.Problem())
+	if s.Solve() != solver.Sat {
+		return Solution{}, false
+	}
+	return Solution{bools: s.Model()}, true
+}
+
+// Bool returns the binding of the given literal in sol.
+func (sol Solution) Bool(lit int) bool {
+	v := lit
+	if v < 0 {
+		v = -v
+	}
+	val := v <= len(sol.bools) && sol.bools[v-1]
+	if lit < 0 {
+		return !val
+	}
+	return val
+}
+
+// Int returns the value of x in sol.
+func (sol Solution) Int(x *IntVar) int {
+	for i, lit := range x.direct {
+		if sol.Bool(lit) {
+			return x.Min + i
+		}
+	}
+	return x.Min
+}
@@ -0,0 +1,26 @@
+package fd
+
+import "testing"
+
+func TestIntVar(t *testing.T) {
+	m := NewModel()
+	x := m.IntVar("x", -2, 2)
+	b := m.Bool()
+	m.Clause(-b, x.Eq(-1), x.Eq(1))
+	m.Clause(b)
+	sol, ok := m.Solve()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if val := sol.Int(x); val != -1 && val != 1 {
+		t.Errorf("invalid value for x: expected -1 or 1, got %d", val)
+	}
+	if !sol.Bool(b) || sol.Bool(-b) {
+		t.Errorf("invalid value for b")
+	}
+	m.Clause(-x.Eq(-1))
+	m.Clause(-x.Eq(1))
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
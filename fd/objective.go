@@ -0,0 +1,92 @@
+package fd
+
+import "github.com/j-blue-arz/tiny-gophersat/solver"
+
+// A Term is an integer variable multiplied by a coefficient, part of a linear expression.
+type Term struct {
+	Coeff int
+	X     *IntVar
+}
+
+// An objective is a linear function of integer variables to optimize.
+type objective struct {
+	terms    []Term
+	maximize bool
+	offset   int // Constant part of the cost function, once lowered
+}
+
+// Minimize declares the linear function to minimize when calling Optimize.
+// It replaces any previously declared objective.
+func (m *Model) Minimize(terms ...Term) {
+	m.obj = objective{terms: terms}
+}
+
+// Maximize declares the linear function to maximize when calling Optimize.
+// It replaces any previously declared objective.
+func (m *Model) Maximize(terms ...Term) {
+	m.obj = objective{terms: terms, maximize: true}
+}
+
+// lower returns the PB cost function equivalent to obj, as lits and weights, or nil if there is no objective.
+// Each variable is expressed with its order encoding, i.e x = x.Min + sum(x >= v) for v from x.Min+1 to x.Max.
+// Since the solver only minimizes positive weights, a term c.l with c < 0 is rewritten c + (-c).¬l,
+// and the constant parts of the function are kept in obj.offset.
+func (obj *objective) lower() ([]solver.Lit, []int) {
+	if obj.terms == nil {
+		return nil, nil
+	}
+	var (
+		lits    []solver.Lit
+		weights []int
+	)
+	obj.offset = 0
+	for _, term := range obj.terms {
+		coeff := term.Coeff
+		if obj.maximize {
+			coeff = -coeff
+		}
+		if coeff == 0 {
+			continue
+		}
+		x := term.X
+		obj.offset += coeff * x.Min
+		for v := x.Min + 1; v <= x.Max; v++ {
+			lit := x.Geq(v)
+			if coeff < 0 {
+				obj.offset += coeff
+				lits = append(lits, solver.IntToLit(int32(-lit)))
+				weights = append(weights, -coeff)
+			} else {
+				lits = append(lits, solver.IntToLit(int32(lit)))
+				weights = append(weights, coeff)
+			}
+		}
+	}
+	return lits, weights
+}
+
+// value returns the value of obj, as declared, given the cost of a model of the lowered problem.
+func (obj *objective) value(cost int) int {
+	if obj.maximize {
+		return -(cost + obj.offset)
+	}
+	return cost + obj.offset
+}
+
+// Optimize solves m and returns an optimal solution according to its objective, along with the value of the objective.
+// If no objective was declared, any solution is returned, with a value of 0.
+// The objective is lowered to the cost function of the problem, see Problem.
+// If the problem has no solution, ok is false.
+func (m *Model) Optimize() (sol Solution, value int, ok bool) {
+	pb := m.Problem()
+	if !pb.Optim() {
+		sol, ok = m.Solve()
+		return sol, m.obj.value(0), ok
+	}
+	s := solver.New(pb)
+	res := s.Optimal(nil, nil)
+	if res.Status != solver.Sat {
+		return Solution{}, 0, false
+	}
+	return Solution{bools: res.Model}, m.obj.value(res.Weight), true
+}
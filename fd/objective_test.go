@@ -0,0 +1,44 @@
+package fd
+
+import "testing"
+
+func TestMinimize(t *testing.T) {
+	m := NewModel()
+	x := m.IntVar("x", 0, 5)
+	y := m.IntVar("y", -2, 5)
+	m.Clause(x.Geq(3))
+	m.Clause(y.Geq(2))
+	m.Clause(-x.Eq(3))
+	m.Minimize(Term{2, x}, Term{3, y})
+	sol, value, ok := m.Optimize()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if value != 14 || sol.Int(x) != 4 || sol.Int(y) != 2 {
+		t.Errorf("invalid optimum: expected 14 with (4, 2), got %d with (%d, %d)", value, sol.Int(x), sol.Int(y))
+	}
+	m.Minimize(Term{-1, x}, Term{1, y})
+	if _, value, ok := m.Optimize(); !ok || value != -3 {
+		t.Errorf("invalid optimum: expected -3, got %d", value)
+	}
+}
+
+func TestMaximize(t *testing.T) {
+	m := NewModel()
+	x := m.IntVar("x", 0, 5)
+	y := m.IntVar("y", 1, 5)
+	m.Clause(x.Leq(4))
+	m.Clause(y.Geq(2))
+	m.Maximize(Term{1, x}, Term{-1, y})
+	sol, value, ok := m.Optimize()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if value != 2 || sol.Int(x) != 4 || sol.Int(y) != 2 {
+		t.Errorf("invalid optimum: expected 2 with (4, 2), got %d with (%d, %d)", value, sol.Int(x), sol.Int(y))
+	}
+	m.Clause(x.Geq(5))
+	if _, _, ok := m.Optimize(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
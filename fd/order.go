@@ -0,0 +1,56 @@
+package fd
+
+// This file deals with the order encoding of integer variables, and with its channeling with the direct encoding.
+// In the order encoding, each value v of x but the smallest one is associated with a literal meaning "x >= v".
+// It propagates bounds much better than the direct encoding, which is better at propagating holes in domains.
+// When both encodings of a variable are generated, they are linked by channeling clauses.
+
+// Geq returns the literal meaning "x >= val".
+// If val is not greater than x.Min, the literal is always true; if it is greater than x.Max, it is always false.
+// The order encoding of x is generated the first time Geq or Leq is called.
+func (x *IntVar) Geq(val int) int {
+	if val <= x.Min {
+		return x.m.True()
+	}
+	if val > x.Max {
+		return -x.m.True()
+	}
+	if x.order == nil {
+		x.order = make([]int, x.Size()-1)
+		for i := range x.order {
+			x.order[i] = x.m.Bool()
+			if i > 0 {
+				x.m.Clause(-x.order[i], x.order[i-1]) // x >= Min+i+1 -> x >= Min+i
+			}
+		}
+		if x.direct != nil {
+			x.channel()
+		}
+	}
+	return x.order[val-x.Min-1]
+}
+
+// Leq returns the literal meaning "x <= val".
+func (x *IntVar) Leq(val int) int {
+	return -x.Geq(val + 1)
+}
+
+// channel generates the clauses linking the direct and the order encodings of x:
+// x = v iff x >= v and not x >= v+1.
+func (x *IntVar) channel() {
+	for i, eq := range x.direct {
+		var lits []int // Lits that are true iff x = Min+i
+		if i > 0 {
+			lits = append(lits, x.order[i-1])
+		}
+		if i < len(x.order) {
+			lits = append(lits, -x.order[i])
+		}
+		clause := []int{eq}
+		for _, lit := range lits {
+			x.m.Clause(-eq, lit)
+			clause = append(clause, -lit)
+		}
+		x.m.Clause(clause...)
+	}
+}
@@ -0,0 +1,53 @@
+package fd
+
+import (
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestOrder(t *testing.T) {
+	m := NewModel()
+	x := m.IntVar("x", 1, 4)
+	m.Clause(x.Geq(3))
+	m.Clause(-x.Eq(3))
+	top, bottom := x.Geq(0), x.Geq(5)
+	sol, ok := m.Solve()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if val := sol.Int(x); val != 4 {
+		t.Errorf("invalid value for x: expected 4, got %d", val)
+	}
+	if !sol.Bool(top) || sol.Bool(bottom) {
+		t.Errorf("out of domain bounds should be constant")
+	}
+	m.Clause(x.Leq(3))
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
+
+func TestChannel(t *testing.T) {
+	if !solver.Capabilities().Counting {
+		t.Skip("model counting with tree decompositions is not part of this build")
+	}
+	// Each value must be associated with exactly one assignment of both encodings.
+	m := NewModel()
+	x := m.IntVar("x", 0, 4)
+	x.Geq(1)
+	x.Eq(0)
+	if nb, err := m.Problem().CountModelsTD(nil); err != nil || nb.Int64() != 5 {
+		t.Errorf("expected 5 models, got %d (error: %v)", nb, err)
+	}
+	// Order encoding first
+	m = NewModel()
+	y := m.IntVar("y", 0, 4)
+	m.Clause(y.Leq(2))
+	m.Clause(-y.Eq(2))
+	m.Clause(-y.Eq(1))
+	m.Clause(y.Geq(1))
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
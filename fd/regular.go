@@ -0,0 +1,129 @@
+package fd
+
+import (
+	"fmt"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A DFA is a deterministic finite automaton reading sequences of boolean values.
+// States are numbered from 0 to len(Next)-1.
+// Next[q][0] is the state reached from q when reading false, and Next[q][1] the one reached when reading true.
+// A negative value means there is no such transition, i.e the sequence is rejected.
+type DFA struct {
+	Start     int      // Initial state
+	Next      [][2]int // Transitions from each state
+	Accepting []bool   // Whether each state is accepting
+}
+
+// MaxConsecutive returns a DFA accepting all sequences where no more than k consecutive values are true,
+// e.g "no more than 3 consecutive night shifts".
+func MaxConsecutive(k int) DFA {
+	dfa := DFA{Next: make([][2]int, k+1), Accepting: make([]bool, k+1)}
+	for q := 0; q <= k; q++ { // q is the number of consecutive true values read so far
+		dfa.Next[q] = [2]int{0, q + 1}
+		dfa.Accepting[q] = true
+	}
+	dfa.Next[k][1] = -1
+	return dfa
+}
+
+// NbStates returns the number of states of dfa.
+func (dfa DFA) NbStates() int {
+	return len(dfa.Next)
+}
+
+// Regular adds a regular constraint to m: the sequence of values of lits must be accepted by dfa.
+//
+// The constraint is encoded by unrolling the automaton: for each position in the sequence and each state
+// that can be reached at that position, and from which an accepting state can still be reached at the end
+// of the sequence, a new literal means the automaton is in that state at that position.
+// Exactly one state is active at each position, and each transition is encoded as a clause.
+func (m *Model) Regular(lits []int, dfa DFA) {
+	if dfa.Start < 0 || dfa.Start >= dfa.NbStates() {
+		panic(fmt.Sprintf("invalid start state %d for automaton with %d states", dfa.Start, dfa.NbStates()))
+	}
+	useful := dfa.unroll(len(lits))
+	if !useful[0][dfa.Start] { // No accepting state can be reached
+		m.Clause()
+		return
+	}
+	states := make([][]int, len(useful)) // states[t][q] is the lit meaning the automaton is in state q at position t, if useful
+	for t := range useful {
+		states[t] = make([]int, dfa.NbStates())
+		for q, ok := range useful[t] {
+			if ok {
+				states[t][q] = m.Bool()
+			}
+		}
+	}
+	for t := range states {
+		var layer []int
+		for _, s := range states[t] {
+			if s != 0 {
+				layer = append(layer, s)
+			}
+		}
+		if t == 0 {
+			m.Clause(layer[0])
+		}
+		m.Add(solver.AtMost(layer, 1))
+	}
+	for t, lit := range lits {
+		for q, s := range states[t] {
+			if s == 0 {
+				continue
+			}
+			for b, l := range [2]int{-lit, lit} { // l is true iff the value read is b
+				if next := dfa.Next[q][b]; next >= 0 && states[t+1][next] != 0 {
+					m.Clause(-s, -l, states[t+1][next])
+				} else {
+					m.Clause(-s, -l)
+				}
+			}
+		}
+	}
+}
+
+// unroll returns, for each position from 0 to n and for each state, whether that state can be part of
+// an accepting run at that position, i.e whether it can be reached at that position and
+// an accepting state can be reached from it at position n.
+func (dfa DFA) unroll(n int) [][]bool {
+	reachable := make([][]bool, n+1)
+	for t := range reachable {
+		reachable[t] = make([]bool, dfa.NbStates())
+	}
+	reachable[0][dfa.Start] = true
+	for t := 0; t < n; t++ {
+		for q, ok := range reachable[t] {
+			if !ok {
+				continue
+			}
+			for _, next := range dfa.Next[q] {
+				if next >= 0 {
+					reachable[t+1][next] = true
+				}
+			}
+		}
+	}
+	useful := make([][]bool, n+1)
+	for t := range useful {
+		useful[t] = make([]bool, dfa.NbStates())
+	}
+	for q := range useful[n] {
+		useful[n][q] = reachable[n][q] && dfa.Accepting[q]
+	}
+	for t := n - 1; t >= 0; t-- {
+		for q := range useful[t] {
+			if !reachable[t][q] {
+				continue
+			}
+			for _, next := range dfa.Next[q] {
+				if next >= 0 && useful[t+1][next] {
+					useful[t][q] = true
+				}
+			}
+		}
+	}
+	return useful
+}
@@ -0,0 +1,46 @@
+package fd
+
+import (
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestRegular(t *testing.T) {
+	if !solver.Capabilities().Counting {
+		t.Skip("model counting with tree decompositions is not part of this build")
+	}
+	m := NewModel()
+	lits := make([]int, 5)
+	for i := range lits {
+		lits[i] = m.Bool()
+	}
+	m.Regular(lits, MaxConsecutive(2))
+	if nb, err := m.Problem().CountModelsTD(nil); err != nil || nb.Int64() != 24 {
+		t.Errorf("expected 24 sequences with no more than 2 consecutive true values, got %d (error: %v)", nb, err)
+	}
+	m.Clause(lits[0])
+	m.Clause(lits[1])
+	sol, ok := m.Solve()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if sol.Bool(lits[2]) {
+		t.Errorf("third value should be false")
+	}
+	m.Clause(lits[2])
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
+
+func TestRegularNoRun(t *testing.T) {
+	m := NewModel()
+	lits := []int{m.Bool(), m.Bool()}
+	// Accepts only sequences of length 3
+	dfa := DFA{Next: [][2]int{{1, 1}, {2, 2}, {3, 3}, {-1, -1}}, Accepting: []bool{false, false, false, true}}
+	m.Regular(lits, dfa)
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
@@ -0,0 +1,48 @@
+package fd
+
+// Table adds a table constraint to m: the values of vars must be one of the given tuples.
+// Each tuple must have as many values as there are vars.
+// Tuples containing values that are out of the domain of the corresponding var are ignored.
+//
+// The constraint is encoded with support literals: a new literal is associated with each tuple,
+// meaning the tuple is the one that is selected. Each value of each var must then be supported
+// by at least one selected tuple, so that unit propagation removes any value that has no support left.
+// The returned slice contains, for each valid tuple, its support literal.
+func (m *Model) Table(vars []*IntVar, tuples [][]int) []int {
+	supports := make([][][]int, len(vars)) // supports[i][j] are the tuples where vars[i] = vars[i].Min+j
+	for i, x := range vars {
+		supports[i] = make([][]int, x.Size())
+	}
+	var selectors []int
+	for _, tuple := range tuples {
+		if len(tuple) != len(vars) {
+			panic("tuple size does not match number of vars")
+		}
+		if !inDomains(vars, tuple) {
+			continue
+		}
+		sel := m.Bool()
+		selectors = append(selectors, sel)
+		for i, x := range vars {
+			m.Clause(-sel, x.Eq(tuple[i])) // sel -> vars[i] = tuple[i]
+			supports[i][tuple[i]-x.Min] = append(supports[i][tuple[i]-x.Min], sel)
+		}
+	}
+	m.Clause(append([]int{}, selectors...)...)
+	for i, x := range vars {
+		for j, sels := range supports[i] {
+			m.Clause(append([]int{-x.Eq(x.Min + j)}, sels...)...) // vars[i] = val -> one of its supports
+		}
+	}
+	return selectors
+}
+
+// inDomains returns true iff each value in tuple is in the domain of the corresponding var.
+func inDomains(vars []*IntVar, tuple []int) bool {
+	for i, x := range vars {
+		if tuple[i] < x.Min || tuple[i] > x.Max {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package fd
+
+import (
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestTable(t *testing.T) {
+	m := NewModel()
+	x := m.IntVar("x", 1, 3)
+	y := m.IntVar("y", 1, 3)
+	m.Table([]*IntVar{x, y}, [][]int{{1, 2}, {2, 3}, {3, 1}, {4, 4}})
+	m.Clause(-x.Eq(1)) // Only (2, 3) remains
+	m.Clause(-x.Eq(3))
+	sol, ok := m.Solve()
+	if !ok {
+		t.Fatalf("model should be satisfiable")
+	}
+	if sol.Int(x) != 2 || sol.Int(y) != 3 {
+		t.Errorf("invalid solution: expected (2, 3), got (%d, %d)", sol.Int(x), sol.Int(y))
+	}
+	m.Clause(-y.Eq(3))
+	if _, ok := m.Solve(); ok {
+		t.Errorf("model should not be satisfiable")
+	}
+}
+
+func TestTableCount(t *testing.T) {
+	m := NewModel()
+	vars := []*IntVar{m.IntVar("a", 0, 1), m.IntVar("b", 0, 2), m.IntVar("c", 0, 1)}
+	tuples := [][]int{{0, 0, 0}, {0, 2, 1}, {1, 1, 0}, {1, 2, 1}, {0, 3, 0}}
+	m.Table(vars, tuples)
+	s := solver.New(m.Problem())
+	if nb := s.CountModels(); nb != 4 {
+		t.Errorf("expected 4 solutions, got %d", nb)
+	}
+}
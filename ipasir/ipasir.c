@@ -0,0 +1,62 @@
+#include <stdlib.h>
+#include "ipasir.h"
+#include "_cgo_export.h"
+
+/* What IPASIR users see as a solver: the handle of the Go solver, along with its terminate callback. */
+typedef struct {
+	uintptr_t handle;
+	void *terminate_data;
+	int (*terminate)(void *data);
+} wrapper;
+
+const char *ipasir_signature(void) {
+	return "gophersat";
+}
+
+void *ipasir_init(void) {
+	wrapper *w = calloc(1, sizeof(wrapper));
+	w->handle = goNew();
+	return w;
+}
+
+void ipasir_release(void *solver) {
+	wrapper *w = solver;
+	goRelease(w->handle);
+	free(w);
+}
+
+void ipasir_add(void *solver, int32_t lit_or_zero) {
+	goAdd(((wrapper *)solver)->handle, lit_or_zero);
+}
+
+void ipasir_assume(void *solver, int32_t lit) {
+	goAssume(((wrapper *)solver)->handle, lit);
+}
+
+int ipasir_solve(void *solver) {
+	return goSolve(((wrapper *)solver)->handle);
+}
+
+int32_t ipasir_val(void *solver, int32_t lit) {
+	return goVal(((wrapper *)solver)->handle, lit);
+}
+
+int ipasir_failed(void *solver, int32_t lit) {
+	return goFailed(((wrapper *)solver)->handle, lit);
+}
+
+void ipasir_set_terminate(void *solver, void *data, int (*terminate)(void *data)) {
+	wrapper *w = solver;
+	w->terminate_data = data;
+	w->terminate = terminate;
+	goSetTerminate(w->handle, terminate != NULL ? w : NULL);
+}
+
+/* Learned clauses are not exported. */
+void ipasir_set_learn(void *solver, void *data, int max_length, void (*learn)(void *data, int32_t *clause)) {
+}
+
+int call_terminate(void *solver) {
+	wrapper *w = solver;
+	return w->terminate(w->terminate_data);
+}
@@ -0,0 +1,60 @@
+package main
+
+// #include <stdint.h>
+// int call_terminate(void *solver);
+import "C"
+
+import (
+	"unsafe"
+)
+
+// This file is the cgo layer exporting the solvers of the registry to C. The IPASIR functions themselves,
+// which take and return the opaque pointers IPASIR users expect, are defined in ipasir.c.
+
+//export goNew
+func goNew() C.uintptr_t {
+	return C.uintptr_t(newSolver())
+}
+
+//export goRelease
+func goRelease(h C.uintptr_t) {
+	releaseSolver(uintptr(h))
+}
+
+//export goAdd
+func goAdd(h C.uintptr_t, lit C.int32_t) {
+	getSolver(uintptr(h)).add(int(lit))
+}
+
+//export goAssume
+func goAssume(h C.uintptr_t, lit C.int32_t) {
+	getSolver(uintptr(h)).assume(int(lit))
+}
+
+//export goSolve
+func goSolve(h C.uintptr_t) C.int {
+	return C.int(getSolver(uintptr(h)).solve())
+}
+
+//export goVal
+func goVal(h C.uintptr_t, lit C.int32_t) C.int32_t {
+	return C.int32_t(getSolver(uintptr(h)).val(int(lit)))
+}
+
+//export goFailed
+func goFailed(h C.uintptr_t, lit C.int32_t) C.int {
+	if getSolver(uintptr(h)).failed(int(lit)) {
+		return 1
+	}
+	return 0
+}
+
+//export goSetTerminate
+func goSetTerminate(h C.uintptr_t, w unsafe.Pointer) {
+	s := getSolver(uintptr(h))
+	if w == nil {
+		s.setTerminate(nil)
+		return
+	}
+	s.setTerminate(func() bool { return C.call_terminate(w) != 0 })
+}
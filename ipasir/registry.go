@@ -0,0 +1,146 @@
+// Command ipasir builds gophersat as a C shared library implementing the IPASIR interface,
+// the standard API of incremental SAT solvers, so that it can be loaded from C, Python or Rust programs:
+//
+//	go build -buildmode=c-shared -o libgophersat.so ./ipasir
+//
+// The functions of the library are declared in ipasir.h.
+package main
+
+import (
+	"sync"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// Go pointers cannot be kept by C code, so C code only sees handles, that are associated with Go solvers here.
+var (
+	mu         sync.Mutex
+	solvers    = make(map[uintptr]*ipasirSolver)
+	lastHandle uintptr
+)
+
+// IPASIR solve results.
+const (
+	interrupted   = 0
+	satisfiable   = 10
+	unsatisfiable = 20
+)
+
+// An ipasirSolver is an incremental solver, along with the state IPASIR needs between two calls.
+type ipasirSolver struct {
+	is          *solver.IncrementalSolver
+	clause      []int        // Clause being added, lit by lit
+	assumptions []int        // Assumptions of the next call to solve
+	model       []int        // Model found by the last call to solve, if it was Sat
+	failedLits  map[int]bool // Failed assumptions of the last call to solve, if it was Unsat
+	// First error met by add or assume: IPASIR cannot report it, so every later call to solve is interrupted.
+	err error
+}
+
+// newSolver creates a new solver, and returns its handle.
+func newSolver() uintptr {
+	mu.Lock()
+	defer mu.Unlock()
+	lastHandle++
+	solvers[lastHandle] = &ipasirSolver{is: solver.NewIncrementalSolver()}
+	return lastHandle
+}
+
+// getSolver returns the solver whose handle is h.
+func getSolver(h uintptr) *ipasirSolver {
+	mu.Lock()
+	defer mu.Unlock()
+	return solvers[h]
+}
+
+// releaseSolver forgets the solver whose handle is h.
+func releaseSolver(h uintptr) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(solvers, h)
+}
+
+// add adds lit to the clause being added, or adds that clause to the problem if lit is 0.
+// Lits about vars bigger than solver.DefaultMaxVar are rejected, since they could exhaust memory.
+func (s *ipasirSolver) add(lit int) {
+	if lit != 0 {
+		if s.check(lit) {
+			s.clause = append(s.clause, lit)
+		}
+		return
+	}
+	s.is.AddClause(s.clause)
+	s.clause = nil
+}
+
+// assume adds lit to the assumptions of the next call to solve.
+// As with add, lits about vars bigger than solver.DefaultMaxVar are rejected.
+func (s *ipasirSolver) assume(lit int) {
+	if s.check(lit) {
+		s.assumptions = append(s.assumptions, lit)
+	}
+}
+
+// check returns true iff lit is a valid lit. Otherwise, the error is recorded, so that solve is interrupted.
+func (s *ipasirSolver) check(lit int) bool {
+	err := s.is.CheckLits([]int{lit})
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return err == nil
+}
+
+// solve solves the problem under the current assumptions, and forgets them.
+// It returns 10 if the problem is Sat, 20 if it is Unsat, and 0 if the search was interrupted
+// or if an invalid lit was given to add or assume.
+func (s *ipasirSolver) solve() int {
+	s.is.Assume(s.assumptions)
+	s.assumptions = nil
+	s.model = nil
+	s.failedLits = nil
+	if s.err != nil {
+		return interrupted
+	}
+	switch s.is.Solve() {
+	case solver.Sat:
+		s.model = s.is.Model()
+		return satisfiable
+	case solver.Unsat:
+		s.failedLits = make(map[int]bool)
+		for _, lit := range s.is.Core() {
+			s.failedLits[lit] = true
+		}
+		return unsatisfiable
+	default:
+		return interrupted
+	}
+}
+
+// val returns the binding of lit's var in the last model: lit if lit is true, -lit if lit is false.
+// It returns 0 if the var does not appear in the problem.
+func (s *ipasirSolver) val(lit int) int {
+	v := lit
+	if v < 0 {
+		v = -v
+	}
+	if v > len(s.model) {
+		return 0
+	}
+	if (s.model[v-1] > 0) == (lit > 0) {
+		return lit
+	}
+	return -lit
+}
+
+// failed returns true iff lit is one of the assumptions responsible for the last Unsat result.
+func (s *ipasirSolver) failed(lit int) bool {
+	return s.failedLits[lit]
+}
+
+// setTerminate sets the function that is called regularly while solving, and interrupts the search
+// once it returns true. If fn is nil, the search is never interrupted.
+func (s *ipasirSolver) setTerminate(fn func() bool) {
+	s.is.Solver().Terminate = fn
+}
+
+func main() {}
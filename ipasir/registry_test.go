@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestIpasirSolver(t *testing.T) {
+	h := newSolver()
+	s := getSolver(h)
+	for _, lit := range []int{1, 2, 0, -1, 3, 0} {
+		s.add(lit)
+	}
+	s.assume(-2)
+	s.assume(-3)
+	if res := s.solve(); res != unsatisfiable {
+		t.Fatalf("expected unsat under assumptions, got %d", res)
+	}
+	if !s.failed(-2) && !s.failed(-3) || s.failed(2) {
+		t.Errorf("invalid failed assumptions %v", s.failedLits)
+	}
+	s.assume(-2)
+	if res := s.solve(); res != satisfiable {
+		t.Fatalf("expected sat under assumptions, got %d", res)
+	}
+	if s.val(1) != 1 || s.val(-1) != 1 || s.val(2) != -2 || s.val(3) != 3 || s.val(4) != 0 {
+		t.Errorf("invalid model %v", s.model)
+	}
+	s.setTerminate(func() bool { return true })
+	if res := s.solve(); res != satisfiable && res != interrupted {
+		t.Errorf("expected sat or interrupted, got %d", res)
+	}
+	releaseSolver(h)
+	if getSolver(h) != nil {
+		t.Errorf("solver was not released")
+	}
+}
+
+func TestIpasirMaxVar(t *testing.T) {
+	h := newSolver()
+	defer releaseSolver(h)
+	s := getSolver(h)
+	for _, lit := range []int{1, 2, 0} {
+		s.add(lit)
+	}
+	if res := s.solve(); res != satisfiable {
+		t.Fatalf("expected sat, got %d", res)
+	}
+	s.add(1 << 30)
+	s.add(0)
+	if res := s.solve(); res != interrupted || s.err == nil {
+		t.Errorf("expected an interruption after an invalid lit, got %d", res)
+	}
+}
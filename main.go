@@ -8,9 +8,12 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/j-blue-arz/tiny-gophersat/aiger"
 	"github.com/j-blue-arz/tiny-gophersat/bf"
 	"github.com/j-blue-arz/tiny-gophersat/explain"
 	"github.com/j-blue-arz/tiny-gophersat/maxsat"
+	"github.com/j-blue-arz/tiny-gophersat/qbf"
+	"github.com/j-blue-arz/tiny-gophersat/smtlib"
 	"github.com/j-blue-arz/tiny-gophersat/solver"
 )
 
@@ -24,29 +27,60 @@ func main() {
 		cert    bool
 		mus     bool
 		count   bool
+		stream  bool
+		server  bool
+		jsonRPC bool
+		maxVar  int
 		help    bool
 	)
 	flag.BoolVar(&verbose, "verbose", false, "sets verbose mode on")
 	flag.BoolVar(&cert, "certified", false, "displays RUP certificate on stdout")
 	flag.BoolVar(&mus, "mus", false, "extracts a MUS from an unsat problem")
 	flag.BoolVar(&count, "count", false, "rather than solving the problem, counts the number of models it accepts")
+	flag.BoolVar(&stream, "stream", false, "solves, in order, each of the CNF problems concatenated in the file (- for stdin)")
+	flag.BoolVar(&server, "server", false, "reads incremental solving commands on stdin and answers them on stdout, rather than solving a file")
+	flag.BoolVar(&jsonRPC, "json", false, "with -server, commands and answers are JSON-RPC 2.0 messages")
+	flag.IntVar(&maxVar, "maxvar", solver.DefaultMaxVar, "with -server, biggest var clients can use")
 	flag.BoolVar(&help, "help", false, "displays help")
 	flag.Parse()
+	if err := checkFlags(cert, mus, count, stream, server, jsonRPC); err != nil && !help {
+		fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+		os.Exit(1)
+	}
+	if server && !help {
+		is := solver.NewIncrementalSolver()
+		is.MaxVar = maxVar
+		serve := is.Serve
+		if jsonRPC {
+			serve = is.ServeJSON
+		}
+		if err := serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "could not serve: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	if !help && len(flag.Args()) != 1 {
 		fmt.Printf(helpString)
-		fmt.Fprintf(os.Stderr, "Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb)\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Syntax : %s [options] (file.cnf|file.wcnf|file.qdimacs|file.bf|file.opb|file.aag|file.aig|file.smt2)\n", os.Args[0])
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 	if help {
 		fmt.Printf(helpString)
-		fmt.Printf("Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb)\n", os.Args[0])
+		fmt.Printf("Syntax : %s [options] (file.cnf|file.wcnf|file.qdimacs|file.bf|file.opb|file.aag|file.aig|file.smt2)\n", os.Args[0])
+		fmt.Printf("Features : %v\n", solver.Capabilities())
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
 	path := flag.Args()[0]
 	if mus {
 		extractMUS(path)
+	} else if stream {
+		if err := solveStream(path, verbose); err != nil {
+			fmt.Fprintf(os.Stderr, "could not parse stream: %v\n", err)
+			os.Exit(1)
+		}
 	} else {
 		fmt.Printf("c solving %s\n", path)
 		if strings.HasSuffix(path, ".bf") {
@@ -59,6 +93,11 @@ func main() {
 				fmt.Fprintf(os.Stderr, "could not parse MAXSAT file %q: %v", path, err)
 				os.Exit(1)
 			}
+		} else if strings.HasSuffix(path, ".qdimacs") {
+			if err := parseAndSolveQDIMACS(path); err != nil {
+				fmt.Fprintf(os.Stderr, "could not parse QBF file %q: %v", path, err)
+				os.Exit(1)
+			}
 		} else {
 			if pb, printFn, err := parse(flag.Args()[0]); err != nil {
 				fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
@@ -72,6 +111,32 @@ func main() {
 	}
 }
 
+// checkFlags returns an error if the given flags cannot be used together.
+func checkFlags(cert, mus, count, stream, server, jsonRPC bool) error {
+	if jsonRPC && !server {
+		return fmt.Errorf("-json can only be used with -server")
+	}
+	nbModes := 0
+	for _, mode := range []bool{mus, count, stream, server} {
+		if mode {
+			nbModes++
+		}
+	}
+	if nbModes > 1 {
+		return fmt.Errorf("-mus, -count, -stream and -server are mutually exclusive")
+	}
+	if cert && nbModes != 0 {
+		return fmt.Errorf("-certified can only be used when solving a single problem")
+	}
+	if server && !solver.Capabilities().Server {
+		return fmt.Errorf("-server is not supported by this build")
+	}
+	if cert && !solver.Capabilities().Proofs {
+		return fmt.Errorf("-certified is not supported by this build")
+	}
+	return nil
+}
+
 func extractMUS(path string) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -132,6 +197,23 @@ func solve(pb *solver.Problem, verbose, cert bool, printFn func(chan solver.Resu
 	}
 }
 
+// solveStream solves each CNF problem of the stream read from path, or from stdin if path is "-".
+func solveStream(path string, verbose bool) error {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return fmt.Errorf("could not open %q: %v", path, err)
+		}
+		defer f.Close()
+	}
+	return solver.ParseCNFStream(f, solver.ParseOptions{}, func(i int, pb *solver.Problem) error {
+		fmt.Printf("c solving problem #%d\n", i+1)
+		solve(pb, verbose, false, printDecisionResults)
+		return nil
+	})
+}
+
 func parseAndSolveWCNF(path string, verbose bool) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -148,6 +230,20 @@ func parseAndSolveWCNF(path string, verbose bool) error {
 	return nil
 }
 
+func parseAndSolveQDIMACS(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open %q: %v", path, err)
+	}
+	defer f.Close()
+	pb, err := qbf.ParseQDIMACS(f)
+	if err != nil {
+		return fmt.Errorf("could not parse qdimacs content: %v", err)
+	}
+	printQBFResult(pb, pb.Solve())
+	return nil
+}
+
 func parseAndSolveBF(path string) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -182,6 +278,22 @@ func parse(path string) (pb *solver.Problem, printFn func(chan solver.Result), e
 		}
 		return pb, printDecisionResults, nil
 	}
+	if strings.HasSuffix(path, ".aag") || strings.HasSuffix(path, ".aig") {
+		c, err := aiger.Parse(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse AIGER file %q: %v", path, err)
+		}
+		pb, _ := c.Tseitin()
+		return pb, printDecisionResults, nil
+	}
+	if strings.HasSuffix(path, ".smt2") {
+		sc, err := smtlib.Parse(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse SMT-LIB file %q: %v", path, err)
+		}
+		pb, vars := sc.BitBlast()
+		return pb, func(results chan solver.Result) { printSMTResults(results, vars) }, nil
+	}
 	if strings.HasSuffix(path, ".opb") {
 		pb, err := solver.ParseOPB(f)
 		if err != nil {
@@ -232,19 +344,56 @@ func printDecisionResults(results chan solver.Result) {
 	}
 }
 
+// prints the result to an SMT-LIB script, along with the values of its constants if it is satisfiable.
+func printSMTResults(results chan solver.Result, vars *smtlib.VarMap) {
+	var res solver.Result
+	for res = range results {
+	}
+	switch res.Status {
+	case solver.Unsat:
+		fmt.Println("unsat")
+	case solver.Sat:
+		fmt.Println("sat")
+		fmt.Println(vars.Model(res.Model))
+	default:
+		fmt.Println("unknown")
+	}
+}
+
+// prints the result to a QBF problem in the QDIMACS format.
+func printQBFResult(pb *qbf.Problem, res qbf.Result) {
+	val := -1
+	switch res.Status {
+	case solver.Sat:
+		val = 1
+	case solver.Unsat:
+		val = 0
+	}
+	fmt.Printf("s cnf %d %d %d\n", val, pb.NbVars, len(pb.Clauses))
+	for _, lit := range res.Witness {
+		fmt.Printf("V %d 0\n", lit)
+	}
+}
+
 // prints the result to a PB optimization problem in the competition format.
 func printOptimizationResults(results chan solver.Result) {
 	var res solver.Result
+	lastWeight := -1
 	for res = range results {
-		if res.Status == solver.Sat {
+		if res.Status == solver.Sat && res.Weight != lastWeight {
 			fmt.Printf("o %d\n", res.Weight)
+			lastWeight = res.Weight
 		}
 	}
 	switch res.Status {
 	case solver.Unsat:
 		fmt.Println("s UNSATISFIABLE")
 	case solver.Sat:
-		fmt.Println("s OPTIMUM FOUND")
+		if res.Optim == solver.Optimum {
+			fmt.Println("s OPTIMUM FOUND")
+		} else {
+			fmt.Println("s SATISFIABLE")
+		}
 		fmt.Printf("v ")
 		for i := 0; i < len(res.Model); i++ {
 			var val string
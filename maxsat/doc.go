@@ -22,4 +22,15 @@
 // It will also give potentially suboptimal solutions as soon as it finds them.
 // So, the user can either get a good-enough solution after a given amount of time, or wait as long as needed
 // for the best possible solution.
+//
+// Drifting problems
+//
+// A problem can be solved several times. In between, new constraints can be added with AddConstrs,
+// and the weights of soft constraints can be decayed with DecayWeights, so that recent preferences
+// matter more than old ones. Each new solve starts from the last model found.
+//
+// Exporting problems
+//
+// Problems made of clauses can be written in the DIMACS WCNF format with WriteWCNF, either with the classic
+// "p wcnf" header or in the headerless format used since 2022, so that they can be given to other MAXSAT solvers.
 package maxsat
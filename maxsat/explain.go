@@ -0,0 +1,146 @@
+package maxsat
+
+import (
+	"fmt"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A Violation describes a soft constraint that is not satisfied by an optimal model.
+type Violation struct {
+	Constr int   // Index of the violated soft constraint, as given to New, then to AddConstrs.
+	Core   []int // Indices of the constraints that, together with the violated one, cannot all be satisfied.
+}
+
+// Selector value for constraints that are not represented in the selector solver.
+const noSelector = solver.Lit(-1)
+
+// Explain returns the list of soft constraints violated by model, which must be an optimal model
+// for pb, as returned by pb.Solve.
+// For each violated soft constraint, a core is provided: it is a minimal set of constraints
+// (hard constraints and soft constraints satisfied by the model) that prevents the violated constraint
+// from being satisfied. If the core only contains hard constraints, the soft constraint can never be satisfied;
+// otherwise, satisfying it would require violating at least one of the soft constraints in its core.
+// An empty core means the soft constraint cannot be satisfied at all.
+// If model is not optimal, an error is returned.
+func (pb *Problem) Explain(model Model) ([]Violation, error) {
+	if model == nil {
+		return nil, fmt.Errorf("cannot explain a nil model")
+	}
+	var violated []int
+	candidates := make([]int, 0, len(pb.constrs)) // Constraints that are always part of the tested subset
+	for i, constr := range pb.constrs {
+		if constr.Weight == 0 || satConstr(constr, model) {
+			candidates = append(candidates, i)
+		} else {
+			violated = append(violated, i)
+		}
+	}
+	if len(violated) == 0 {
+		return nil, nil
+	}
+	s, selectors := pb.selectorSolver()
+	res := make([]Violation, len(violated))
+	for i, idx := range violated {
+		if selectors[idx] == noSelector { // No assignment can satisfy that constraint
+			res[i] = Violation{Constr: idx}
+			continue
+		}
+		core, err := minimalCore(s, selectors, candidates, idx)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = Violation{Constr: idx, Core: core}
+	}
+	return res, nil
+}
+
+// satConstr returns true iff the constraint is satisfied by the model.
+func satConstr(constr Constr, model Model) bool {
+	sum := 0
+	for i, lit := range constr.Lits {
+		if model[lit.Var] != lit.Negated {
+			if len(constr.Coeffs) == 0 {
+				sum++
+			} else {
+				sum += constr.Coeffs[i]
+			}
+		}
+	}
+	return sum >= constr.AtLeast
+}
+
+// selectorSolver returns a solver where each constraint is associated with a selector literal.
+// When the selector is false, the constraint must be satisfied; when it is true, the constraint is relaxed.
+// For each constraint, the returned slice contains the negation of its selector,
+// or noSelector if the constraint is either trivially satisfied or trivially unsatisfiable.
+func (pb *Problem) selectorSolver() (*solver.Solver, []solver.Lit) {
+	constrs := make([]solver.PBConstr, 0, len(pb.constrs))
+	selectors := make([]solver.Lit, len(pb.constrs))
+	nbVars := len(pb.varInts)
+	for i, constr := range pb.constrs {
+		lits := make([]int, len(constr.Lits))
+		coeffs := make([]int, len(constr.Lits))
+		for j, lit := range constr.Lits {
+			lits[j] = pb.intVars[lit.Var]
+			if lit.Negated {
+				lits[j] = -lits[j]
+			}
+			if len(constr.Coeffs) == 0 {
+				coeffs[j] = 1
+			} else {
+				coeffs[j] = constr.Coeffs[j]
+			}
+		}
+		c := solver.GtEq(lits, coeffs, constr.AtLeast)
+		if c.AtLeast <= 0 || c.WeightSum() < c.AtLeast {
+			selectors[i] = noSelector
+			continue
+		}
+		nbVars++
+		c.Lits = append(c.Lits, nbVars)
+		c.Weights = append(c.Weights, c.AtLeast)
+		constrs = append(constrs, c)
+		selectors[i] = solver.IntToLit(int32(-nbVars))
+	}
+	return solver.New(solver.ParsePBConstrs(constrs)), selectors
+}
+
+// minimalCore returns a minimal subset of candidates that, along with the constraint idx, is unsatisfiable.
+// It uses the deletion method: each candidate is removed in turn, and put back
+// only if the problem becomes satisfiable without it.
+func minimalCore(s *solver.Solver, selectors []solver.Lit, candidates []int, idx int) ([]int, error) {
+	core := make([]int, 0, len(candidates))
+	for _, c := range candidates {
+		if selectors[c] != noSelector {
+			core = append(core, c)
+		}
+	}
+	if solveWith(s, selectors, core, idx) == solver.Sat {
+		return nil, fmt.Errorf("model is not optimal: soft constraint %d can be satisfied", idx)
+	}
+	for i := 0; i < len(core); {
+		tested := make([]int, 0, len(core)-1)
+		tested = append(tested, core[:i]...)
+		tested = append(tested, core[i+1:]...)
+		if solveWith(s, selectors, tested, idx) == solver.Unsat {
+			core = tested
+		} else {
+			i++
+		}
+	}
+	return core, nil
+}
+
+// solveWith solves the problem where only the given constraints and the constraint idx are active.
+func solveWith(s *solver.Solver, selectors []solver.Lit, active []int, idx int) solver.Status {
+	assumptions := make([]solver.Lit, 0, len(active)+1)
+	assumptions = append(assumptions, selectors[idx])
+	for _, c := range active {
+		assumptions = append(assumptions, selectors[c])
+	}
+	if s.Assume(assumptions) == solver.Unsat {
+		return solver.Unsat
+	}
+	return s.Solve()
+}
@@ -0,0 +1,41 @@
+package maxsat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	pb := New(
+		HardClause(Not("a"), Not("b")),
+		SoftClause(Var("a")),
+		WeightedClause([]Lit{Var("b")}, 2),
+		SoftClause(Var("c")),
+		HardClause(Not("c")),
+	)
+	model, cost := pb.Solve()
+	if cost != 2 {
+		t.Fatalf("invalid cost: expected 2, got %d", cost)
+	}
+	violations, err := pb.Explain(model)
+	if err != nil {
+		t.Fatalf("could not explain model: %v", err)
+	}
+	expected := []Violation{
+		{Constr: 1, Core: []int{0, 2}},
+		{Constr: 3, Core: []int{4}},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("invalid violations: expected %v, got %v", expected, violations)
+	}
+}
+
+func TestExplainNotOptimal(t *testing.T) {
+	pb := New(
+		HardClause(Not("a"), Not("b")),
+		SoftClause(Var("a")),
+	)
+	if _, err := pb.Explain(Model{"a": false, "b": false}); err == nil {
+		t.Errorf("expected an error for a non-optimal model")
+	}
+}
@@ -2,6 +2,7 @@ package maxsat
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/j-blue-arz/tiny-gophersat/solver"
 )
@@ -12,17 +13,30 @@ type Model map[string]bool
 // A Problem is a set of constraints.
 type Problem struct {
 	solver       *solver.Solver
-	intVars      map[string]int // for each var, its integer counterpart
-	varInts      []string       // for each int value, the associated variable
-	blockWeights map[int]int    // for each blocking literal, the weight of the associated constraint
-	maxWeight    int            // sum of all blockWeights
+	intVars      map[string]int    // for each var, its integer counterpart
+	varInts      []string          // for each int value, the associated variable
+	blockWeights map[int]int       // for each blocking literal, the weight of the associated constraint
+	maxWeight    int               // sum of all blockWeights
+	constrs      []Constr          // the constraints of the problem, in the order they were given
+	pbConstrs    []solver.PBConstr // the translation of each constraint, with its blocking literal if it is soft
+	blocks       []int             // for each constraint, its blocking literal, or 0 if it is hard
+	verbose      bool              // whether the solver is verbose
+	nbAdded      int               // number of translated constraints that were added to the solver
+	stale        bool              // true iff weights changed since the cost function of the solver was set
+	unsat        bool              // true iff the solver was made from unsatisfiable constraints, and cannot be updated
 }
 
 // New returns a new problem associated with the given constraints.
 func New(constrs ...Constr) *Problem {
 	pb := &Problem{intVars: make(map[string]int), blockWeights: make(map[int]int)}
-	clauses := make([]solver.PBConstr, len(constrs))
-	for i, constr := range constrs {
+	pb.add(constrs)
+	pb.build()
+	return pb
+}
+
+// add translates the given constraints, and adds them to pb. The solver is not updated.
+func (pb *Problem) add(constrs []Constr) {
+	for _, constr := range constrs {
 		lits := make([]int, len(constr.Lits))
 		for j, lit := range constr.Lits {
 			v := lit.Var
@@ -40,9 +54,10 @@ func New(constrs ...Constr) *Problem {
 			coeffs = make([]int, len(constr.Coeffs))
 			copy(coeffs, constr.Coeffs)
 		}
+		bl := 0
 		if constr.Weight != 0 { // Soft constraint: add blocking literal
 			pb.varInts = append(pb.varInts, "") // Create new blocking lit
-			bl := len(pb.varInts)
+			bl = len(pb.varInts)
 			pb.blockWeights[bl] = constr.Weight
 			pb.maxWeight += constr.Weight
 			lits = append(lits, bl)
@@ -51,46 +66,162 @@ func New(constrs ...Constr) *Problem {
 				coeffs = append(coeffs, constr.AtLeast)
 			}
 		}
-		clauses[i] = solver.GtEq(lits, coeffs, constr.AtLeast)
+		pb.constrs = append(pb.constrs, constr)
+		pb.pbConstrs = append(pb.pbConstrs, solver.GtEq(lits, coeffs, constr.AtLeast))
+		pb.blocks = append(pb.blocks, bl)
+	}
+}
+
+// build makes the solver for the translated constraints of pb.
+// The solver tracks antecedents, so that the clauses it learned and that do not depend on the bounds
+// added while optimizing are kept from one call to Solve to the next.
+func (pb *Problem) build() {
+	prob := solver.ParsePBConstrs(pb.pbConstrs)
+	pb.unsat = prob.Status == solver.Unsat
+	pb.solver = solver.New(prob)
+	pb.solver.Verbose = pb.verbose
+	pb.solver.TrackAntecedents = true
+	pb.nbAdded = len(pb.pbConstrs)
+	pb.setCostFunc()
+}
+
+// update appends to the solver the constraints that were added since it was last updated,
+// and sets its cost function again if weights changed.
+func (pb *Problem) update() {
+	if !pb.unsat { // Otherwise, the problem stays unsat no matter the new constraints
+		for _, c := range pb.pbConstrs[pb.nbAdded:] {
+			pb.solver.AppendClause(c.Clause())
+		}
+	}
+	pb.nbAdded = len(pb.pbConstrs)
+	if pb.stale {
+		pb.setCostFunc()
+	}
+}
+
+// setCostFunc sets the cost function of the solver to the current weights of the soft constraints.
+func (pb *Problem) setCostFunc() {
+	if pb.unsat {
+		return
 	}
 	optLits := make([]solver.Lit, 0, len(pb.blockWeights))
 	optWeights := make([]int, 0, len(pb.blockWeights))
-	for v, w := range pb.blockWeights {
-		optLits = append(optLits, solver.IntToLit(int32(v)))
-		optWeights = append(optWeights, w)
+	for _, bl := range pb.blocks {
+		if bl != 0 {
+			optLits = append(optLits, solver.IntToLit(int32(bl)))
+			optWeights = append(optWeights, pb.blockWeights[bl])
+		}
 	}
-	prob := solver.ParsePBConstrs(clauses)
-	prob.SetCostFunc(optLits, optWeights)
-	pb.solver = solver.New(prob)
-	return pb
+	pb.solver.SetCostFunc(optLits, optWeights)
+	pb.stale = false
+}
+
+// cost returns the sum of the weights of the soft constraints whose blocking literal is true in model.
+func (pb *Problem) cost(model []bool) int {
+	cost := 0
+	for _, bl := range pb.blocks {
+		if bl != 0 && model[bl-1] {
+			cost += pb.blockWeights[bl]
+		}
+	}
+	return cost
+}
+
+// bound returns the constraint stating that the cost of a model must be strictly less than cost.
+func (pb *Problem) bound(cost int) *solver.Clause {
+	var lits []solver.Lit
+	var weights []int
+	for _, bl := range pb.blocks {
+		if bl != 0 {
+			lits = append(lits, solver.IntToLit(int32(-bl)))
+			weights = append(weights, pb.blockWeights[bl])
+		}
+	}
+	return solver.NewPBClause(lits, weights, pb.maxWeight-cost+1)
+}
+
+// AddConstrs adds the given constraints, hard or soft, to pb, that can then be solved again.
+// Constraints that were already in pb keep their index, and the new ones are indexed after them.
+// The new constraints are appended to the existing solver, that keeps what it learned, so that repeatedly solving
+// a slowly drifting problem, such as an online configuration, is cheaper than creating a new problem each time.
+func (pb *Problem) AddConstrs(constrs ...Constr) {
+	pb.add(constrs)
+	pb.stale = true
+}
+
+// DecayWeights multiplies the weight of each soft constraint of pb by factor, which must be between 0 and 1,
+// so that, when new soft constraints are added, they matter more than the old ones.
+// Weights are rounded, but a soft constraint never becomes hard: weights are at least 1.
+func (pb *Problem) DecayWeights(factor float64) {
+	pb.maxWeight = 0
+	for i, bl := range pb.blocks {
+		if bl == 0 {
+			continue
+		}
+		w := int(math.Round(float64(pb.constrs[i].Weight) * factor))
+		if w < 1 {
+			w = 1
+		}
+		pb.constrs[i].Weight = w
+		pb.blockWeights[bl] = w
+		pb.maxWeight += w
+	}
+	pb.stale = true
 }
 
 // SetVerbose makes the underlying solver verbose, or not.
 func (pb *Problem) SetVerbose(verbose bool) {
+	pb.verbose = verbose
 	pb.solver.Verbose = verbose
 }
 
 // Output output the problem to stdout in the OPB format.
 func (pb *Problem) Output() {
-	fmt.Println(pb.solver.PBString())
+	fmt.Println(pb.Solver().PBString())
 }
 
 // Solver gives access to the solver.Solver used to solve the MAXSAT problem.
 // Unless you have specific needs, youè will usually not need to call this method,
 // and rather want to call pb.Solve() instead.
+// If constraints or weights changed since the last call to Solve, the solver is updated first.
+// Calling Minimize on it adds bounds on the cost that are never removed: to solve pb again, call pb.Solve().
+// The solver must not be made certified, since pb.Solve() removes the bounds it adds.
 func (pb *Problem) Solver() *solver.Solver {
+	pb.update()
 	return pb.solver
 }
 
 // Solve returns an optimal Model for the problem and the associated cost.
 // If the model is nil, the problem was not satisfiable (i.e hard clauses could not be satisfied).
+// It can be called again once constraints were added or weights decayed.
+// Each better model is looked for under a removable bound on the cost, so that the solver can be used again
+// once the optimum was found.
 func (pb *Problem) Solve() (Model, int) {
-	cost := pb.solver.Minimize()
-	if cost == -1 {
+	s := pb.Solver()
+	if pb.unsat || s.Solve() != solver.Sat {
 		return nil, -1
 	}
+	model := s.Model()
+	cost := pb.cost(model)
+	var bounds []int
+	for cost > 0 {
+		if pb.verbose {
+			fmt.Printf("o %d\n", cost)
+		}
+		bounds = append(bounds, s.AddClauseGroup([]*solver.Clause{pb.bound(cost)}))
+		if s.Solve() != solver.Sat {
+			break
+		}
+		model = s.Model()
+		cost = pb.cost(model)
+	}
+	for _, id := range bounds {
+		if err := s.RemoveClauseGroup(id); err != nil {
+			panic(err)
+		}
+	}
 	res := make(Model)
-	for i, binding := range pb.solver.Model() {
+	for i, binding := range model {
 		name := pb.varInts[i]
 		if name != "" { // Ignore blocking lits
 			res[name] = binding
@@ -130,3 +130,44 @@ func BenchmarkTSP(b *testing.B) {
 		New(generateTSP(9)...).Solve()
 	}
 }
+
+func TestDriftingProblem(t *testing.T) {
+	pb := New(
+		HardClause(Var("a"), Var("b")),
+		WeightedClause([]Lit{Not("a")}, 10),
+		WeightedClause([]Lit{Not("b")}, 4),
+	)
+	s := pb.Solver()
+	model, cost := pb.Solve()
+	if model == nil || model["a"] || !model["b"] || cost != 4 {
+		t.Fatalf("expected b only, with cost 4, got %v, cost %d", model, cost)
+	}
+	if model, cost := pb.Solve(); model == nil || cost != 4 { // Solving again gives the same answer
+		t.Fatalf("expected cost 4 when solving again, got %v, cost %d", model, cost)
+	}
+	pb.DecayWeights(0.5) // Weights are now 5 and 2
+	pb.AddConstrs(WeightedClause([]Lit{Var("a")}, 6))
+	model, cost = pb.Solve()
+	if model == nil || !model["a"] || cost != 5 {
+		t.Fatalf("expected a, with cost 5, got %v, cost %d", model, cost)
+	}
+	pb.DecayWeights(0.01) // All weights are now 1
+	if w := pb.constrs[1].Weight; w != 1 {
+		t.Errorf("expected weight 1 after decay, got %d", w)
+	}
+	pb.AddConstrs(HardClause(Not("a")))
+	model, cost = pb.Solve()
+	if model == nil || model["a"] || !model["b"] || cost != 2 {
+		t.Fatalf("expected b only, with cost 2, got %v, cost %d", model, cost)
+	}
+	if pb.Solver() != s {
+		t.Errorf("expected the solver to be updated rather than rebuilt")
+	}
+	violations, err := pb.Explain(model)
+	if err != nil {
+		t.Fatalf("could not explain model: %v", err)
+	}
+	if len(violations) != 2 || violations[0].Constr != 2 || violations[1].Constr != 3 {
+		t.Errorf("expected constraints 2 and 3 to be violated, got %v", violations)
+	}
+}
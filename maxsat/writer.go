@@ -0,0 +1,91 @@
+package maxsat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// This file deals with the export of problems in the DIMACS WCNF format, so that problems built
+// programmatically can be given to other MAXSAT solvers.
+
+// A WCNFFormat is a variant of the DIMACS WCNF format.
+type WCNFFormat byte
+
+const (
+	// ClassicWCNF is the format with a "p wcnf nbvars nbclauses top" header,
+	// where hard clauses are given the top weight.
+	ClassicWCNF WCNFFormat = iota
+	// HeaderlessWCNF is the format used by the MAXSAT Evaluation since 2022,
+	// where there is no header and hard clauses start with "h".
+	HeaderlessWCNF
+)
+
+// WriteWCNF writes pb on w in the given variant of the WCNF format.
+// Vars are numbered from 1, in the order they first appeared in the constraints of pb,
+// and the name of each var is given in a "c var <number> <name>" comment line.
+// Weights are the current ones, i.e once decayed by DecayWeights.
+// Trivially satisfied constraints, whose AtLeast is not strictly positive, are not written.
+// It returns an error if pb contains a constraint that is not a clause, since WCNF only describes clauses.
+func (pb *Problem) WriteWCNF(w io.Writer, format WCNFFormat) error {
+	var clauses []int
+	for i, constr := range pb.constrs {
+		if constr.AtLeast <= 0 {
+			continue
+		}
+		if !isClause(constr) {
+			return fmt.Errorf("cannot write constraint #%d in WCNF: it is not a clause", i)
+		}
+		clauses = append(clauses, i)
+	}
+	nums := make([]int, len(pb.varInts)) // For each int var of pb, its number in the WCNF file
+	nbVars := 0
+	bw := bufio.NewWriter(w)
+	for i, name := range pb.varInts {
+		if name != "" { // Blocking lits are not written
+			nbVars++
+			nums[i] = nbVars
+			fmt.Fprintf(bw, "c var %d %s\n", nbVars, name)
+		}
+	}
+	top := pb.maxWeight + 1
+	if format == ClassicWCNF {
+		fmt.Fprintf(bw, "p wcnf %d %d %d\n", nbVars, len(clauses), top)
+	}
+	var buf []byte
+	for _, i := range clauses {
+		constr := pb.constrs[i]
+		switch {
+		case constr.Weight != 0:
+			buf = strconv.AppendInt(buf[:0], int64(constr.Weight), 10)
+		case format == ClassicWCNF:
+			buf = strconv.AppendInt(buf[:0], int64(top), 10)
+		default:
+			buf = append(buf[:0], 'h')
+		}
+		for _, lit := range constr.Lits {
+			n := nums[pb.intVars[lit.Var]-1]
+			if lit.Negated {
+				n = -n
+			}
+			buf = append(buf, ' ')
+			buf = strconv.AppendInt(buf, int64(n), 10)
+		}
+		bw.Write(append(buf, " 0\n"...))
+	}
+	return bw.Flush()
+}
+
+// isClause returns true iff constr is a propositional clause: at least one of its lits must be true.
+func isClause(constr Constr) bool {
+	if constr.AtLeast != 1 {
+		return false
+	}
+	for _, coeff := range constr.Coeffs {
+		if coeff != 1 {
+			return false
+		}
+	}
+	return true
+}
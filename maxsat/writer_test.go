@@ -0,0 +1,44 @@
+package maxsat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestWriteWCNF(t *testing.T) {
+	pb := New(
+		HardClause(Var("a"), Var("b")),
+		WeightedClause([]Lit{Not("a")}, 3),
+		SoftClause(Not("b"), Var("c")),
+		HardClause(Not("c")),
+		HardPBConstr([]Lit{Var("d")}, nil, 0),
+	)
+	for format, expected := range map[WCNFFormat]string{
+		ClassicWCNF:    "c var 1 a\nc var 2 b\nc var 3 c\nc var 4 d\np wcnf 4 4 5\n5 1 2 0\n3 -1 0\n1 -2 3 0\n5 -3 0\n",
+		HeaderlessWCNF: "c var 1 a\nc var 2 b\nc var 3 c\nc var 4 d\nh 1 2 0\n3 -1 0\n1 -2 3 0\nh -3 0\n",
+	} {
+		var sb strings.Builder
+		if err := pb.WriteWCNF(&sb, format); err != nil {
+			t.Fatalf("format %d: could not write problem: %v", format, err)
+		}
+		if sb.String() != expected {
+			t.Errorf("format %d: expected %q, got %q", format, expected, sb.String())
+		}
+		prob, err := solver.ParseWCNF(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatalf("format %d: could not parse written problem: %v", format, err)
+		}
+		if cost := solver.New(prob).Minimize(); cost != 1 {
+			t.Errorf("format %d: expected cost 1, got %d", format, cost)
+		}
+	}
+	if _, cost := pb.Solve(); cost != 1 {
+		t.Errorf("expected cost 1, got %d", cost)
+	}
+	pb.AddConstrs(HardPBConstr([]Lit{Var("a"), Var("b")}, []int{1, 2}, 2))
+	if err := pb.WriteWCNF(&strings.Builder{}, ClassicWCNF); err == nil {
+		t.Errorf("expected an error for a PB constraint")
+	}
+}
@@ -0,0 +1,241 @@
+package qbf
+
+import "github.com/j-blue-arz/tiny-gophersat/solver"
+
+// This file deals with the resolution of 2QBF problems, of the form ∃X ∀Y ∃Z φ, by counterexample guided
+// abstraction refinement. The existential player looks for a candidate binding τ of X with an abstraction,
+// i.e a propositional problem that is initially empty. The universal player then looks for a binding μ of Y such that
+// φ(τ, μ, Z) is unsat. If there is none, τ is a winning move. Otherwise, φ(X, μ, Zμ), where Zμ is a fresh copy of Z,
+// is added to the abstraction, so that the same counterexample cannot be used against a later candidate.
+// The universal player uses the same method: each time a binding ζ of Z satisfies φ(τ, μ, ζ), the constraint
+// "one of the clauses not satisfied by τ and ζ must be falsified" is added to its own abstraction.
+
+// A Result is the result of solving a QBF.
+type Result struct {
+	Status solver.Status // Sat if the formula is true, Unsat if it is false, Indet if it could not be solved
+	// Bindings of the vars of the outermost block of the problem, as lits, when they prove the result,
+	// i.e when the outermost block is existential and the formula is true, or when it is universal and the formula is false.
+	// Free vars belong to the outermost block.
+	Witness []int
+}
+
+// Solve solves the problem and returns the result.
+// Problems with more than two quantifier alternations (e.g ∀X ∃Y ∀Z ∃T) can only be solved if their
+// inner universal blocks are small enough; otherwise, the Indet status is returned.
+func (pb *Problem) Solve() Result {
+	n := pb.normalize()
+	if n.unsat {
+		return Result{Status: solver.Unsat, Witness: n.witness}
+	}
+	if !n.expand() {
+		return Result{Status: solver.Indet}
+	}
+	return newGame(n).solve()
+}
+
+// var kinds in a game.
+const (
+	outerVar = iota // Var of X
+	univVar         // Var of Y
+	innerVar        // Var of Z
+)
+
+// A game holds the data needed to solve a problem of the form ∃X ∀Y ∃Z φ, where each block can be empty.
+type game struct {
+	nbVars   int
+	x, y     []int   // Vars of X and of Y
+	kind     []int   // For each var, its kind
+	clauses  [][]int // Clauses of φ
+	yClauses [][]int // Clauses of φ containing lits of Y
+	matrix   *solver.Solver
+}
+
+// newGame returns the game associated with n, whose prefix must contain at most 3 blocks,
+// and must be of the form ∃X ∀Y ∃Z if it contains 3 blocks.
+func newGame(n *normalized) *game {
+	g := game{nbVars: n.nbVars, kind: make([]int, n.nbVars+1), clauses: n.clauses}
+	offset := 0 // Index of X in the prefix
+	if len(n.prefix) > 0 && n.prefix[0].Quant == Forall {
+		offset = 1
+	}
+	for v := 1; v <= n.nbVars; v++ {
+		if n.level[v] != -1 {
+			g.kind[v] = n.level[v] + offset
+		}
+	}
+	for i, block := range n.prefix {
+		if i+offset == univVar {
+			g.y = block.Vars
+		} else if i+offset == outerVar {
+			g.x = block.Vars
+		}
+	}
+	for _, c := range n.clauses {
+		for _, lit := range c {
+			if g.kind[abs(lit)] == univVar {
+				g.yClauses = append(g.yClauses, c)
+				break
+			}
+		}
+	}
+	g.matrix = solver.New(solver.ParseSliceNb(n.clauses, n.nbVars))
+	return &g
+}
+
+// solve solves g and returns the result.
+func (g *game) solve() Result {
+	if g.matrix.Solve() != solver.Sat { // φ cannot be satisfied, no matter the bindings of X and Y
+		if len(g.x) == 0 && len(g.y) != 0 { // Any binding of Y is a winning move
+			return Result{Status: solver.Unsat, Witness: binding(g.y, make([]bool, g.nbVars))}
+		}
+		return Result{Status: solver.Unsat}
+	}
+	if len(g.y) == 0 { // Plain SAT problem
+		return Result{Status: solver.Sat, Witness: binding(g.x, g.matrix.Model())}
+	}
+	var initial [][]int // Clauses containing vars of X only
+	for _, c := range g.clauses {
+		if g.maxKind(c) == outerVar {
+			initial = append(initial, c)
+		}
+	}
+	abstraction := solver.New(solver.ParseSliceNb(initial, g.nbVars))
+	nbVars := g.nbVars // Nb of vars in the abstraction
+	for {
+		if abstraction.Solve() != solver.Sat {
+			return Result{Status: solver.Unsat}
+		}
+		tau := binding(g.x, abstraction.Model())
+		mu := g.counterexample(tau)
+		if mu == nil {
+			return Result{Status: solver.Sat, Witness: tau}
+		}
+		if len(g.x) == 0 { // ∀Y ∃Z φ: mu is a winning move for the universal player
+			return Result{Status: solver.Unsat, Witness: mu}
+		}
+		if nbVars = g.refineOuter(abstraction, mu, nbVars); nbVars == -1 {
+			return Result{Status: solver.Unsat}
+		}
+	}
+}
+
+// counterexample returns a binding of Y that, along with tau, makes φ unsat, or nil if there is none.
+func (g *game) counterexample(tau []int) []int {
+	abstraction := solver.New(solver.ParseSliceNb(nil, g.nbVars))
+	nbVars := g.nbVars // Nb of vars in the abstraction, including selectors
+	assumptions := make([]solver.Lit, len(tau), len(tau)+len(g.y))
+	for i, lit := range tau {
+		assumptions[i] = solver.IntToLit(int32(lit))
+	}
+	for {
+		if abstraction.Solve() != solver.Sat {
+			return nil
+		}
+		mu := binding(g.y, abstraction.Model())
+		lits := assumptions
+		for _, lit := range mu {
+			lits = append(lits, solver.IntToLit(int32(lit)))
+		}
+		if g.matrix.Assume(lits) == solver.Unsat || g.matrix.Solve() != solver.Sat {
+			return mu
+		}
+		model := g.matrix.Model()
+		// At least one of the clauses that are not satisfied by tau and the binding of Z must be falsified
+		var selectors []solver.Lit
+		for _, c := range g.yClauses {
+			if g.satisfiedOutsideY(c, model) {
+				continue
+			}
+			nbVars++
+			sel := solver.IntToLit(int32(nbVars))
+			for _, lit := range c {
+				if g.kind[abs(lit)] == univVar {
+					abstraction.AppendClause(solver.NewClause([]solver.Lit{sel.Negation(), solver.IntToLit(int32(-lit))}))
+				}
+			}
+			selectors = append(selectors, sel)
+		}
+		abstraction.AppendClause(solver.NewClause(selectors))
+	}
+}
+
+// refineOuter adds φ(X, mu, Zμ) to the abstraction of the existential player, where the vars of Zμ
+// are numbered after the nbVars vars of the abstraction. It returns the new number of vars in the abstraction,
+// or -1 if the abstraction became trivially unsat.
+func (g *game) refineOuter(abstraction *solver.Solver, mu []int, nbVars int) int {
+	bound := make([]bool, g.nbVars+1) // Binding of the vars of Y
+	for _, lit := range mu {
+		bound[abs(lit)] = lit > 0
+	}
+	rename := make([]int, g.nbVars+1)
+	for _, c := range g.clauses {
+		if g.maxKind(c) == outerVar {
+			continue // Already in the abstraction
+		}
+		lits := make([]solver.Lit, 0, len(c))
+		sat := false
+		for _, lit := range c {
+			v := abs(lit)
+			switch g.kind[v] {
+			case univVar:
+				sat = bound[v] == (lit > 0)
+			case innerVar:
+				if rename[v] == 0 {
+					nbVars++
+					rename[v] = nbVars
+				}
+				lits = append(lits, solver.IntToLit(int32(sign(lit)*rename[v])))
+			default:
+				lits = append(lits, solver.IntToLit(int32(lit)))
+			}
+			if sat {
+				break
+			}
+		}
+		if sat {
+			continue
+		}
+		if len(lits) == 0 {
+			return -1
+		}
+		abstraction.AppendClause(solver.NewClause(lits))
+	}
+	return nbVars
+}
+
+// maxKind returns the kind of the innermost var of c.
+func (g *game) maxKind(c []int) int {
+	res := outerVar
+	for _, lit := range c {
+		if k := g.kind[abs(lit)]; k > res {
+			res = k
+		}
+	}
+	return res
+}
+
+// satisfiedOutsideY returns true iff c is satisfied by the binding of its vars that are not in Y.
+func (g *game) satisfiedOutsideY(c []int, model []bool) bool {
+	for _, lit := range c {
+		if v := abs(lit); g.kind[v] != univVar && model[v-1] == (lit > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// binding returns the bindings of vars in model, as lits.
+func binding(vars []int, model []bool) []int {
+	if len(vars) == 0 {
+		return nil
+	}
+	res := make([]int, len(vars))
+	for i, v := range vars {
+		if model[v-1] {
+			res[i] = v
+		} else {
+			res[i] = -v
+		}
+	}
+	return res
+}
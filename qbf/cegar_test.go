@@ -0,0 +1,188 @@
+package qbf
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// bruteForce returns the truth value of pb, given a partial binding of its vars, by trying all bindings.
+func bruteForce(pb *Problem, binding map[int]bool) bool {
+	var vars []int
+	var quants []Quantifier
+	bound := make(map[int]bool)
+	for _, block := range pb.Prefix {
+		for _, v := range block.Vars {
+			bound[v] = true
+		}
+	}
+	for _, c := range pb.Clauses { // Free vars first
+		for _, lit := range c {
+			if v := abs(lit); !bound[v] {
+				bound[v] = true
+				vars = append(vars, v)
+				quants = append(quants, Exists)
+			}
+		}
+	}
+	for _, block := range pb.Prefix {
+		for _, v := range block.Vars {
+			vars = append(vars, v)
+			quants = append(quants, block.Quant)
+		}
+	}
+	model := make(map[int]bool)
+	for v, b := range binding {
+		model[v] = b
+	}
+	return eval(pb.Clauses, vars, quants, model, binding)
+}
+
+func eval(clauses [][]int, vars []int, quants []Quantifier, model, fixed map[int]bool) bool {
+	if len(vars) == 0 {
+		for _, c := range clauses {
+			sat := false
+			for _, lit := range c {
+				if model[abs(lit)] == (lit > 0) {
+					sat = true
+				}
+			}
+			if !sat {
+				return false
+			}
+		}
+		return true
+	}
+	v := vars[0]
+	if _, ok := fixed[v]; ok {
+		return eval(clauses, vars[1:], quants[1:], model, fixed)
+	}
+	model[v] = false
+	res1 := eval(clauses, vars[1:], quants[1:], model, fixed)
+	model[v] = true
+	res2 := eval(clauses, vars[1:], quants[1:], model, fixed)
+	delete(model, v)
+	if quants[0] == Exists {
+		return res1 || res2
+	}
+	return res1 && res2
+}
+
+// randomProblem returns a random problem with the given number of vars and clauses, and the given prefix length.
+func randomProblem(rng *rand.Rand, nbVars, nbClauses, nbBlocks int) *Problem {
+	pb := &Problem{NbVars: nbVars}
+	quant := Quantifier(rng.Intn(2))
+	for _, v := range rng.Perm(nbVars) {
+		if rng.Intn(5) == 0 { // Free var
+			continue
+		}
+		if len(pb.Prefix) == 0 || (len(pb.Prefix) < nbBlocks && rng.Intn(3) == 0) {
+			pb.Prefix = append(pb.Prefix, Block{Quant: quant})
+			quant = 1 - quant
+		}
+		last := len(pb.Prefix) - 1
+		pb.Prefix[last].Vars = append(pb.Prefix[last].Vars, v+1)
+	}
+	for i := 0; i < nbClauses; i++ {
+		c := make([]int, 1+rng.Intn(3))
+		for j := range c {
+			c[j] = rng.Intn(nbVars) + 1
+			if rng.Intn(2) == 0 {
+				c[j] = -c[j]
+			}
+		}
+		pb.Clauses = append(pb.Clauses, c)
+	}
+	return pb
+}
+
+func TestSolveRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		pb := randomProblem(rng, 2+rng.Intn(7), 1+rng.Intn(12), 1+rng.Intn(5))
+		expected := bruteForce(pb, nil)
+		res := pb.Solve()
+		if (res.Status == solver.Sat) != expected || res.Status == solver.Indet {
+			t.Fatalf("invalid status %v for problem\n%s", res.Status, pb.QDIMACS())
+		}
+		if res.Witness == nil {
+			continue
+		}
+		fixed := make(map[int]bool)
+		for _, lit := range res.Witness {
+			fixed[abs(lit)] = lit > 0
+		}
+		if bruteForce(pb, fixed) != expected {
+			t.Fatalf("invalid witness %v for problem\n%s", res.Witness, pb.QDIMACS())
+		}
+	}
+}
+
+func TestSolve2QBF(t *testing.T) {
+	// ∀x1 ∃x2: x2 ⇔ ¬x1 is true
+	pb := &Problem{
+		NbVars:  2,
+		Prefix:  []Block{{Quant: Forall, Vars: []int{1}}, {Quant: Exists, Vars: []int{2}}},
+		Clauses: [][]int{{1, 2}, {-1, -2}},
+	}
+	if res := pb.Solve(); res.Status != solver.Sat || res.Witness != nil {
+		t.Errorf("expected true formula without witness, got %+v", res)
+	}
+	// ∃x2 ∀x1: x2 ⇔ ¬x1 is false
+	pb.Prefix[0], pb.Prefix[1] = pb.Prefix[1], pb.Prefix[0]
+	if res := pb.Solve(); res.Status != solver.Unsat || res.Witness != nil {
+		t.Errorf("expected false formula without witness, got %+v", res)
+	}
+	// ∀x1 ∃x2: x1 ∧ x2 is false, with counterexample ¬x1
+	pb.Prefix[0], pb.Prefix[1] = pb.Prefix[1], pb.Prefix[0]
+	pb.Clauses = [][]int{{1}, {2}}
+	if res := pb.Solve(); res.Status != solver.Unsat || len(res.Witness) != 1 || res.Witness[0] != -1 {
+		t.Errorf("expected false formula with witness [-1], got %+v", res)
+	}
+}
+
+func TestSolveTooManyAlternations(t *testing.T) {
+	last := maxExpandedVars + 4
+	univ := make([]int, maxExpandedVars+1)
+	var clauses [][]int
+	for i := range univ {
+		univ[i] = i + 3
+		clauses = append(clauses, []int{univ[i], -last}, []int{-univ[i], 2, last})
+	}
+	pb := &Problem{
+		NbVars: last,
+		Prefix: []Block{
+			{Quant: Forall, Vars: []int{1}},
+			{Quant: Exists, Vars: []int{2}},
+			{Quant: Forall, Vars: univ},
+			{Quant: Exists, Vars: []int{last}},
+		},
+		Clauses: append(clauses, []int{1, -2, last}),
+	}
+	if res := pb.Solve(); res.Status != solver.Indet {
+		t.Errorf("expected indet status, got %v", res.Status)
+	}
+}
+
+func TestSolveExpansion(t *testing.T) {
+	// ∀x1 ∃x2 ∀x3 x4 ∃x5: (x1 ∨ x2) ∧ (¬x3 ∨ x5) ∧ (¬x4 ∨ x5) ∧ (¬x5 ∨ x2), true with x2 = x5 = true
+	pb := &Problem{
+		NbVars: 5,
+		Prefix: []Block{
+			{Quant: Forall, Vars: []int{1}},
+			{Quant: Exists, Vars: []int{2}},
+			{Quant: Forall, Vars: []int{3, 4}},
+			{Quant: Exists, Vars: []int{5}},
+		},
+		Clauses: [][]int{{1, 2}, {-3, 5}, {-4, 5}, {-5, 2}},
+	}
+	if res := pb.Solve(); res.Status != solver.Sat {
+		t.Errorf("expected true formula, got %v", res.Status)
+	}
+	// With (¬x2 ∨ ¬x5) instead of (¬x5 ∨ x2), x3 and x4 force x5, and thus ¬x2, and x1 = false wins
+	pb.Clauses[3] = []int{-2, -5}
+	if res := pb.Solve(); res.Status != solver.Unsat || len(res.Witness) != 1 || res.Witness[0] != -1 {
+		t.Errorf("expected false formula with witness [-1], got %+v", res)
+	}
+}
@@ -0,0 +1,27 @@
+// Package qbf provides a solver for quantified boolean formulas (QBF).
+//
+// A QBF is a propositional formula whose vars are bound by quantifiers: some vars are existentially
+// quantified ("there exists a binding of x such that..."), others are universally quantified
+// ("for all bindings of y..."). Problems are given in prenex conjunctive normal form, i.e as a
+// sequence of quantifier blocks, the prefix, followed by a set of clauses, the matrix.
+// Such problems are typically described in the QDIMACS format:
+//
+//     p cnf 3 2
+//     a 1 0
+//     e 2 3 0
+//     1 2 0
+//     -1 3 0
+//
+// which can be parsed with ParseQDIMACS, then solved:
+//
+//     pb, err := qbf.ParseQDIMACS(f)
+//     res := pb.Solve()
+//
+// The solver is designed for 2QBF problems, i.e problems whose prefixes are of the form ∃X ∀Y ∃Z
+// (where each block can be empty). They are solved by counterexample guided abstraction refinement,
+// using two propositional solvers: candidate bindings of X are checked against the universal player,
+// and each counterexample found for a binding of Y is added to the existential player's problem as
+// a copy of the matrix where Y is bound. Problems with more quantifier alternations are first turned
+// into 2QBF problems by expanding their inner universal blocks, which is only possible when these
+// blocks are small.
+package qbf
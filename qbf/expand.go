@@ -0,0 +1,104 @@
+package qbf
+
+// This file deals with the expansion of universal blocks, used to turn problems with many quantifier alternations
+// into 2QBF problems. The innermost universal block ∀Y, followed by the existential block ∃Z, is replaced by one copy
+// of the clauses containing lits of Y or Z for each binding μ of Y, where Y is bound to μ and Z is renamed
+// to fresh vars Zμ. The fresh vars are then existentially quantified in the block preceding ∀Y.
+// Each var in Y doubles the size of the inner part of the problem, so only small blocks can be expanded.
+
+// maxExpandedVars is the maximum number of vars in a universal block that can be expanded.
+const maxExpandedVars = 12
+
+// expand expands the inner universal blocks of n until its prefix has at most two quantifier alternations.
+// It returns false if a block was too big to be expanded.
+func (n *normalized) expand() bool {
+	for len(n.prefix) > 3 {
+		if !n.expandInnermost() {
+			return false
+		}
+	}
+	return true
+}
+
+// expandInnermost replaces the last two blocks of n's prefix, ∀Y ∃Z, by their expansion.
+// n's prefix must contain at least 3 blocks. It returns false if Y is too big to be expanded.
+func (n *normalized) expandInnermost() bool {
+	last := len(n.prefix) - 1
+	univ, inner := n.prefix[last-1].Vars, n.prefix[last].Vars
+	if len(univ) > maxExpandedVars {
+		return false
+	}
+	outer := last - 2 // Index of the block receiving the copies of Z
+	var kept, expanded [][]int
+	for _, c := range n.clauses {
+		if n.clauseLevel(c) >= last-1 {
+			expanded = append(expanded, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	binding := make([]bool, n.nbVars+1) // For each var of Y, its binding in the current copy
+	rename := make([]int, n.nbVars+1)   // For each var of Z, its name in the current copy
+	for mu := 0; mu < 1<<len(univ); mu++ {
+		for i, v := range univ {
+			binding[v] = mu&(1<<i) != 0
+		}
+		for _, v := range inner {
+			if mu == 0 { // The first copy can use the original vars
+				rename[v] = v
+			} else {
+				n.nbVars++
+				rename[v] = n.nbVars
+				n.level = append(n.level, outer)
+				n.prefix[outer].Vars = append(n.prefix[outer].Vars, n.nbVars)
+			}
+		}
+		for _, c := range expanded {
+			if copied, sat := n.copyClause(c, last-1, binding, rename); !sat {
+				kept = append(kept, copied)
+			}
+		}
+	}
+	for _, v := range univ {
+		n.level[v] = -1
+	}
+	for _, v := range inner {
+		n.level[v] = outer
+	}
+	n.prefix[outer].Vars = append(n.prefix[outer].Vars, inner...)
+	n.prefix = n.prefix[:last-1]
+	n.clauses = kept
+	return true
+}
+
+// clauseLevel returns the deepest level among the vars of c.
+func (n *normalized) clauseLevel(c []int) int {
+	res := -1
+	for _, lit := range c {
+		if lvl := n.level[abs(lit)]; lvl > res {
+			res = lvl
+		}
+	}
+	return res
+}
+
+// copyClause returns a copy of c where the lits of the universal vars at level univ are bound according
+// to binding, and the vars of the following level are renamed according to rename.
+// If c is satisfied by the binding, sat is true and no copy is made.
+func (n *normalized) copyClause(c []int, univ int, binding []bool, rename []int) (res []int, sat bool) {
+	res = make([]int, 0, len(c))
+	for _, lit := range c {
+		v := abs(lit)
+		switch n.level[v] {
+		case univ:
+			if binding[v] == (lit > 0) {
+				return nil, true
+			}
+		case univ + 1:
+			res = append(res, sign(lit)*rename[v])
+		default:
+			res = append(res, lit)
+		}
+	}
+	return res, false
+}
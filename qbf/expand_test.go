@@ -0,0 +1,41 @@
+package qbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandInnermost(t *testing.T) {
+	// ∃x1 ∀x2 ∃x3 ∀x4 ∃x5: (x1 ∨ x5) ∧ (x3 ∨ x4 ∨ ¬x5) ∧ (x2 ∨ x3)
+	pb := &Problem{
+		NbVars: 5,
+		Prefix: []Block{
+			{Quant: Exists, Vars: []int{1}},
+			{Quant: Forall, Vars: []int{2}},
+			{Quant: Exists, Vars: []int{3}},
+			{Quant: Forall, Vars: []int{4}},
+			{Quant: Exists, Vars: []int{5}},
+		},
+		Clauses: [][]int{{1, 5}, {3, 4, -5}, {2, 3}},
+	}
+	n := pb.normalize()
+	if !n.expand() {
+		t.Fatalf("could not expand problem")
+	}
+	// x4 = false keeps x5; x4 = true uses the fresh copy x6 of x5
+	expectedClauses := [][]int{{2, 3}, {1, 5}, {3, -5}, {1, 6}}
+	if !reflect.DeepEqual(n.clauses, expectedClauses) {
+		t.Errorf("invalid clauses: expected %v, got %v", expectedClauses, n.clauses)
+	}
+	expectedPrefix := []Block{
+		{Quant: Exists, Vars: []int{1}},
+		{Quant: Forall, Vars: []int{2}},
+		{Quant: Exists, Vars: []int{3, 6, 5}},
+	}
+	if !reflect.DeepEqual(n.prefix, expectedPrefix) {
+		t.Errorf("invalid prefix: expected %+v, got %+v", expectedPrefix, n.prefix)
+	}
+	if n.nbVars != 6 || n.level[4] != -1 || n.level[5] != 2 || n.level[6] != 2 {
+		t.Errorf("invalid vars after expansion: %d vars, levels %v", n.nbVars, n.level)
+	}
+}
@@ -0,0 +1,140 @@
+package qbf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// qdimacsParser holds the data used while parsing a QDIMACS file.
+type qdimacsParser struct {
+	pb      Problem
+	bound   []bool // For each var, whether it was already quantified
+	clause  []int  // Clause being parsed, that might span several lines
+	started bool   // True iff clauses started: no quantifier block can be parsed anymore
+}
+
+// ParseQDIMACS parses a QDIMACS file and returns the corresponding problem.
+// The file starts with a "p cnf nbVars nbClauses" header, followed by quantifier blocks, such as "a 1 2 0" or "e 3 0",
+// outermost first, and then by the clauses of the matrix, in the DIMACS format.
+// See http://www.qbflib.org/qdimacs.html for more details.
+func ParseQDIMACS(f io.Reader) (*Problem, error) {
+	var p qdimacsParser
+	sc := solver.NewScanner(f, 0)
+	headerFound := false
+	lineNb := 0
+	for sc.Scan() {
+		lineNb++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || fields[0][0] == 'c' {
+			continue
+		}
+		var err error
+		switch {
+		case fields[0] == "p":
+			if headerFound {
+				err = parseError(solver.ErrBadHeader, "unexpected QDIMACS header %q", sc.Text())
+			} else {
+				err = p.parseHeader(fields)
+				headerFound = true
+			}
+		case !headerFound:
+			err = parseError(solver.ErrBadHeader, "line %q found before QDIMACS header", sc.Text())
+		case fields[0] == "a" || fields[0] == "e":
+			err = p.parseBlock(fields)
+		default:
+			err = p.parseLits(fields)
+		}
+		if err != nil {
+			perr := err.(*solver.ParseError)
+			perr.Line = lineNb
+			return nil, perr
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not read QDIMACS file: %w", err)
+	}
+	if !headerFound {
+		return nil, &solver.ParseError{Line: lineNb, Msg: "no header in QDIMACS file", Err: solver.ErrBadHeader}
+	}
+	if len(p.clause) != 0 {
+		return nil, &solver.ParseError{Line: lineNb, Msg: "last clause is not terminated by 0", Err: solver.ErrTruncated}
+	}
+	return &p.pb, nil
+}
+
+// parseHeader parses the fields of a "p cnf nbVars nbClauses" line.
+func (p *qdimacsParser) parseHeader(fields []string) error {
+	if len(fields) != 4 || fields[1] != "cnf" {
+		return parseError(solver.ErrBadHeader, "invalid syntax %q in QDIMACS header", strings.Join(fields, " "))
+	}
+	var err error
+	if p.pb.NbVars, err = strconv.Atoi(fields[2]); err != nil || p.pb.NbVars < 0 {
+		return parseError(solver.ErrBadHeader, "nbVars not a positive int: %q", fields[2])
+	}
+	nbClauses, err := strconv.Atoi(fields[3])
+	if err != nil || nbClauses < 0 {
+		return parseError(solver.ErrBadHeader, "nbClauses not a positive int: %q", fields[3])
+	}
+	p.pb.Clauses = make([][]int, 0, nbClauses)
+	p.bound = make([]bool, p.pb.NbVars+1)
+	return nil
+}
+
+// parseBlock parses the fields of a quantifier block, such as "a 1 2 0".
+func (p *qdimacsParser) parseBlock(fields []string) error {
+	line := strings.Join(fields, " ")
+	if p.started {
+		return parseError(solver.ErrBadConstraint, "quantifier block %q found after clauses", line)
+	}
+	if fields[len(fields)-1] != "0" {
+		return parseError(solver.ErrBadConstraint, "quantifier block %q is not terminated by 0", line)
+	}
+	block := Block{Quant: Exists, Vars: make([]int, len(fields)-2)}
+	if fields[0] == "a" {
+		block.Quant = Forall
+	}
+	for i, field := range fields[1 : len(fields)-1] {
+		v, err := strconv.Atoi(field)
+		if err != nil || v <= 0 || v > p.pb.NbVars {
+			return parseError(solver.ErrBadLiteral, "invalid var %q in quantifier block %q", field, line)
+		}
+		if p.bound[v] {
+			return parseError(solver.ErrBadLiteral, "var %d quantified twice", v)
+		}
+		p.bound[v] = true
+		block.Vars[i] = v
+	}
+	p.pb.Prefix = append(p.pb.Prefix, block)
+	return nil
+}
+
+// parseLits parses lits of the matrix. Each clause is terminated by 0, and can span several lines.
+func (p *qdimacsParser) parseLits(fields []string) error {
+	p.started = true
+	for _, field := range fields {
+		val, err := strconv.Atoi(field)
+		if err != nil {
+			return parseError(solver.ErrBadLiteral, "invalid literal %q in line %q", field, strings.Join(fields, " "))
+		}
+		if val == 0 {
+			p.pb.Clauses = append(p.pb.Clauses, p.clause)
+			p.clause = nil
+			continue
+		}
+		if val > p.pb.NbVars || -val > p.pb.NbVars {
+			return parseError(solver.ErrBadLiteral, "invalid literal %d for problem with %d vars only", val, p.pb.NbVars)
+		}
+		p.clause = append(p.clause, val)
+	}
+	return nil
+}
+
+// parseError returns the *solver.ParseError about the given faulty part of the input, built from format and args.
+// Its line is set by ParseQDIMACS.
+func parseError(cause error, format string, args ...interface{}) error {
+	return &solver.ParseError{Err: cause, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,76 @@
+package qbf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestParseQDIMACS(t *testing.T) {
+	const qdimacs = `c A simple 2QBF
+p cnf 4 3
+a 1 2 0
+e 3 0
+1 3 -2 0
+-1 -3
+4 0
+c a comment in the middle
+2 -4 0
+`
+	pb, err := ParseQDIMACS(strings.NewReader(qdimacs))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	expected := &Problem{
+		NbVars:  4,
+		Prefix:  []Block{{Quant: Forall, Vars: []int{1, 2}}, {Quant: Exists, Vars: []int{3}}},
+		Clauses: [][]int{{1, 3, -2}, {-1, -3, 4}, {2, -4}},
+	}
+	if !reflect.DeepEqual(pb, expected) {
+		t.Errorf("invalid problem: expected %+v, got %+v", expected, pb)
+	}
+	pb2, err := ParseQDIMACS(strings.NewReader(pb.QDIMACS()))
+	if err != nil {
+		t.Fatalf("could not parse output of QDIMACS(): %v", err)
+	}
+	if !reflect.DeepEqual(pb2, expected) {
+		t.Errorf("invalid problem after round trip: expected %+v, got %+v", expected, pb2)
+	}
+	if res := pb.Solve(); res.Status != solver.Sat {
+		t.Errorf("expected true formula, got %v", res.Status)
+	}
+}
+
+func TestParseQDIMACSErrors(t *testing.T) {
+	for _, qdimacs := range []string{
+		"a 1 0\np cnf 1 0\n",
+		"p cnf 1\n",
+		"p cnf 2 1\np cnf 2 1\n",
+		"p cnf 2 1\na 1 2\n",
+		"p cnf 2 1\na 3 0\n",
+		"p cnf 2 1\na 1 0\ne 1 0\n",
+		"p cnf 2 1\n1 2 0\ne 1 0\n",
+		"p cnf 2 1\n1 x 0\n",
+		"p cnf 2 1\n1 -3 0\n",
+		"p cnf 2 1\n1 2\n",
+		"c no header\n",
+	} {
+		if _, err := ParseQDIMACS(strings.NewReader(qdimacs)); err == nil {
+			t.Errorf("expected error when parsing %q", qdimacs)
+		}
+	}
+}
+
+func TestParseQDIMACSErrorLine(t *testing.T) {
+	_, err := ParseQDIMACS(strings.NewReader("c comment\np cnf 2 1\ne 1 0\n\n1 x 0\n"))
+	if perr, ok := err.(*solver.ParseError); !ok || perr.Line != 5 || !errors.Is(err, solver.ErrBadLiteral) {
+		t.Errorf("expected an error on lit of line 5, got %v", err)
+	}
+	_, err = ParseQDIMACS(strings.NewReader("p cnf 2 1\n1 2\n"))
+	if perr, ok := err.(*solver.ParseError); !ok || perr.Recoverable() || !errors.Is(err, solver.ErrTruncated) {
+		t.Errorf("expected an error on truncated input, got %v", err)
+	}
+}
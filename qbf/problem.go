@@ -0,0 +1,204 @@
+package qbf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Quantifier is either Exists or Forall.
+type Quantifier byte
+
+const (
+	// Exists is the existential quantifier.
+	Exists Quantifier = iota
+	// Forall is the universal quantifier.
+	Forall
+)
+
+func (q Quantifier) String() string {
+	if q == Forall {
+		return "a"
+	}
+	return "e"
+}
+
+// A Block is a set of vars bound by the same quantifier.
+type Block struct {
+	Quant Quantifier
+	Vars  []int // Quantified vars, as strictly positive ints
+}
+
+// A Problem is a quantified boolean formula in prenex conjunctive normal form.
+// Vars appearing in clauses but not in the prefix are free: they are considered
+// as existentially quantified in an outermost block.
+type Problem struct {
+	NbVars  int     // Total nb of vars
+	Prefix  []Block // Quantifier blocks, outermost first
+	Clauses [][]int // Clauses, as lists of non-zero ints, as in DIMACS
+}
+
+// QDIMACS returns a QDIMACS representation of the problem.
+func (pb *Problem) QDIMACS() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "p cnf %d %d\n", pb.NbVars, len(pb.Clauses))
+	for _, block := range pb.Prefix {
+		sb.WriteString(block.Quant.String())
+		for _, v := range block.Vars {
+			fmt.Fprintf(&sb, " %d", v)
+		}
+		sb.WriteString(" 0\n")
+	}
+	for _, clause := range pb.Clauses {
+		for _, lit := range clause {
+			fmt.Fprintf(&sb, "%d ", lit)
+		}
+		sb.WriteString("0\n")
+	}
+	return sb.String()
+}
+
+// A normalized problem is a problem whose prefix binds all vars appearing in clauses,
+// whose blocks are not empty and alternate quantifiers, and whose innermost block, if any, is existential.
+// Its clauses contain no tautology, no duplicate lit, and were simplified by universal reduction.
+type normalized struct {
+	nbVars  int
+	prefix  []Block
+	level   []int // For each var, the index of its block in prefix, or -1 if it does not appear in the problem
+	clauses [][]int
+	unsat   bool  // True iff the matrix contains an empty clause
+	witness []int // If unsat is true and the outermost block is universal, a binding of it falsifying a clause
+}
+
+// normalize returns the normalized version of pb.
+func (pb *Problem) normalize() *normalized {
+	n := &normalized{nbVars: pb.NbVars, level: make([]int, pb.NbVars+1)}
+	for i := range n.level {
+		n.level[i] = -1
+	}
+	bound := make([]bool, pb.NbVars+1)
+	for _, block := range pb.Prefix {
+		for _, v := range block.Vars {
+			bound[v] = true
+		}
+	}
+	var free []int
+	for _, clause := range pb.Clauses {
+		for _, lit := range clause {
+			if v := abs(lit); !bound[v] {
+				bound[v] = true
+				free = append(free, v)
+			}
+		}
+	}
+	n.addBlock(Block{Quant: Exists, Vars: free})
+	for _, block := range pb.Prefix {
+		n.addBlock(block)
+	}
+	marks := make([]int, pb.NbVars+1) // For each var, i+1 if it appeared positively in clause i, -(i+1) if negatively
+	for i, clause := range pb.Clauses {
+		if c, ok := n.reduce(clause, i+1, marks); ok {
+			if len(c) == 0 && !n.unsat {
+				n.unsat = true
+				n.witness = n.falsifier(clause)
+			}
+			n.clauses = append(n.clauses, c)
+		}
+	}
+	if last := len(n.prefix) - 1; last >= 0 && n.prefix[last].Quant == Forall { // All its lits were reduced
+		for _, v := range n.prefix[last].Vars {
+			n.level[v] = -1
+		}
+		n.prefix = n.prefix[:last]
+	}
+	return n
+}
+
+// falsifier returns a binding of the vars of the outermost block of n that falsifies clause,
+// or nil if this block is not universal, or if some vars of clause do not belong to it.
+func (n *normalized) falsifier(clause []int) []int {
+	if len(n.prefix) == 0 || n.prefix[0].Quant != Forall {
+		return nil
+	}
+	falsified := make(map[int]bool, len(clause))
+	for _, lit := range clause {
+		if n.level[abs(lit)] != 0 {
+			return nil
+		}
+		falsified[abs(lit)] = lit < 0
+	}
+	res := make([]int, len(n.prefix[0].Vars))
+	for i, v := range n.prefix[0].Vars {
+		if falsified[v] {
+			res[i] = v
+		} else {
+			res[i] = -v
+		}
+	}
+	return res
+}
+
+// addBlock adds the vars of block that were not bound yet at the end of n's prefix,
+// either as a new block or in the last block if it has the same quantifier.
+func (n *normalized) addBlock(block Block) {
+	var vars []int
+	for _, v := range block.Vars {
+		if n.level[v] == -1 {
+			vars = append(vars, v)
+		}
+	}
+	if len(vars) == 0 {
+		return
+	}
+	last := len(n.prefix) - 1
+	if last < 0 || n.prefix[last].Quant != block.Quant {
+		n.prefix = append(n.prefix, Block{Quant: block.Quant})
+		last++
+	}
+	for _, v := range vars {
+		n.level[v] = last
+	}
+	n.prefix[last].Vars = append(n.prefix[last].Vars, vars...)
+}
+
+// reduce returns the given clause, without duplicate lits and without the universal lits
+// that are deeper than all its existential lits.
+// If the clause is a tautology, ok is false. mark is a value that is unique to this clause.
+func (n *normalized) reduce(clause []int, mark int, marks []int) (res []int, ok bool) {
+	maxLevel := -1 // Deepest existential level in the clause
+	for _, lit := range clause {
+		v := abs(lit)
+		switch marks[v] {
+		case sign(lit) * mark:
+			continue
+		case -sign(lit) * mark:
+			return nil, false
+		}
+		marks[v] = sign(lit) * mark
+		if lvl := n.level[v]; n.prefix[lvl].Quant == Exists && lvl > maxLevel {
+			maxLevel = lvl
+		}
+		res = append(res, lit)
+	}
+	j := 0
+	for _, lit := range res {
+		if lvl := n.level[abs(lit)]; n.prefix[lvl].Quant == Exists || lvl < maxLevel {
+			res[j] = lit
+			j++
+		}
+	}
+	return res[:j], true
+}
+
+func abs(lit int) int {
+	if lit < 0 {
+		return -lit
+	}
+	return lit
+}
+
+func sign(lit int) int {
+	if lit < 0 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,60 @@
+package qbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	pb := &Problem{
+		NbVars: 6,
+		Prefix: []Block{
+			{Quant: Forall, Vars: []int{1}},
+			{Quant: Forall, Vars: []int{2}},
+			{Quant: Exists},
+			{Quant: Exists, Vars: []int{3}},
+			{Quant: Forall, Vars: []int{4, 5}},
+		},
+		Clauses: [][]int{
+			{1, 3, 4, 3},  // 4 is reduced, 3 is duplicated
+			{2, -6},       // 6 is free, hence outermost: 2 is reduced
+			{3, -3, 1},    // Tautology
+			{-1, 2, 5, 6}, // 1, 2 and 5 are reduced
+		},
+	}
+	n := pb.normalize()
+	expectedPrefix := []Block{
+		{Quant: Exists, Vars: []int{6}},
+		{Quant: Forall, Vars: []int{1, 2}},
+		{Quant: Exists, Vars: []int{3}},
+	}
+	if !reflect.DeepEqual(n.prefix, expectedPrefix) {
+		t.Errorf("invalid prefix: expected %+v, got %+v", expectedPrefix, n.prefix)
+	}
+	expectedClauses := [][]int{{1, 3}, {-6}, {6}}
+	if !reflect.DeepEqual(n.clauses, expectedClauses) {
+		t.Errorf("invalid clauses: expected %v, got %v", expectedClauses, n.clauses)
+	}
+	expectedLevels := []int{-1, 1, 1, 2, -1, -1, 0}
+	if !reflect.DeepEqual(n.level, expectedLevels) {
+		t.Errorf("invalid levels: expected %v, got %v", expectedLevels, n.level)
+	}
+	if n.unsat {
+		t.Errorf("problem should not be trivially unsat")
+	}
+}
+
+func TestNormalizeUnsat(t *testing.T) {
+	pb := &Problem{
+		NbVars:  3,
+		Prefix:  []Block{{Quant: Forall, Vars: []int{1, 2}}, {Quant: Exists, Vars: []int{3}}},
+		Clauses: [][]int{{1, 3}, {-1, 2}},
+	}
+	n := pb.normalize()
+	if !n.unsat {
+		t.Fatalf("problem should be trivially unsat")
+	}
+	if expected := []int{1, -2}; !reflect.DeepEqual(n.witness, expected) {
+		t.Errorf("invalid witness: expected %v, got %v", expected, n.witness)
+	}
+}
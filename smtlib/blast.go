@@ -0,0 +1,505 @@
+package smtlib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// A VarMap tells which solver vars represent the bits of each constant declared by a script.
+type VarMap struct {
+	consts []Const
+	index  map[string]int // Index of each const, by name
+	bits   [][]solver.Var // Vars of the bits of each const, least significant first
+}
+
+// Bits returns the vars representing the bits of the constant called name, least significant first,
+// or nil if there is no such constant. A Bool constant is represented by a single var.
+func (vm *VarMap) Bits(name string) []solver.Var {
+	i, ok := vm.index[name]
+	if !ok {
+		return nil
+	}
+	return vm.bits[i]
+}
+
+// Value returns the value of the constant called name in the given model, as an SMT-LIB literal:
+// either true or false for a Bool constant, or a binary literal such as #b0101 for a bit-vector.
+// It returns "" if there is no such constant.
+func (vm *VarMap) Value(name string, model []bool) string {
+	i, ok := vm.index[name]
+	if !ok {
+		return ""
+	}
+	bits := vm.bits[i]
+	if vm.consts[i].Width == 0 {
+		if model[bits[0]] {
+			return "true"
+		}
+		return "false"
+	}
+	var sb strings.Builder
+	sb.WriteString("#b")
+	for j := len(bits) - 1; j >= 0; j-- {
+		if model[bits[j]] {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String()
+}
+
+// Model returns the given model as an SMT-LIB model, i.e a list of define-fun commands,
+// one per declared constant, such as (define-fun x () (_ BitVec 4) #b0101).
+func (vm *VarMap) Model(model []bool) string {
+	var sb strings.Builder
+	sb.WriteString("(\n")
+	for _, c := range vm.consts {
+		fmt.Fprintf(&sb, "  (define-fun %s () %s %s)\n", c.Name, sortString(c.Width), vm.Value(c.Name, model))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// BitBlast returns a problem that is satisfiable iff the assertions of sc are, along with the vars representing
+// the bits of the declared constants. Each bit-vector operator is replaced by a circuit, whose gates are
+// encoded in CNF; divisions and remainders are encoded with fresh vars standing for the quotient and the remainder.
+// Vars of the constants come first, in the order of their declarations, so that the var of bit i of
+// the k-th declared constant is the same in all scripts declaring the same constants.
+func (sc *Script) BitBlast() (pb *solver.Problem, vars *VarMap) {
+	b := blaster{memo: make(map[*term][]int)}
+	vars = &VarMap{consts: sc.Consts, index: make(map[string]int, len(sc.Consts))}
+	for i, c := range sc.Consts {
+		width := c.Width
+		if width == 0 {
+			width = 1
+		}
+		lits := make([]int, width)
+		bits := make([]solver.Var, width)
+		for j := range lits {
+			lits[j] = b.newLit()
+			bits[j] = solver.IntToVar(int32(lits[j]))
+		}
+		b.consts = append(b.consts, lits)
+		vars.index[c.Name] = i
+		vars.bits = append(vars.bits, bits)
+	}
+	b.t = b.newLit()
+	b.clauses = append(b.clauses, []int{b.t})
+	for _, t := range sc.asserts {
+		b.clauses = append(b.clauses, []int{b.blast(t)[0]})
+	}
+	return solver.ParseSliceNb(b.clauses, b.nbVars), vars
+}
+
+// A blaster translates terms to circuits. All lits are DIMACS lits.
+type blaster struct {
+	nbVars  int
+	clauses [][]int
+	t       int             // Lit that is always true
+	consts  [][]int         // Lits of the bits of each declared constant
+	memo    map[*term][]int // Lits of the bits of each already translated term
+}
+
+// newLit returns the lit of a fresh var.
+func (b *blaster) newLit() int {
+	b.nbVars++
+	return b.nbVars
+}
+
+// value returns the lit whose value is always val.
+func (b *blaster) value(val bool) int {
+	if val {
+		return b.t
+	}
+	return -b.t
+}
+
+// blast returns the lits of the bits of t, least significant first. A Bool term has a single bit.
+func (b *blaster) blast(t *term) []int {
+	if res, ok := b.memo[t]; ok {
+		return res
+	}
+	var args [][]int
+	for _, arg := range t.args {
+		args = append(args, b.blast(arg))
+	}
+	res := b.blastOp(t, args)
+	b.memo[t] = res
+	return res
+}
+
+// blastOp returns the lits of the bits of t, whose arguments have the given bits.
+func (b *blaster) blastOp(t *term, args [][]int) []int {
+	switch t.op {
+	case "const":
+		return b.consts[t.idx]
+	case "value":
+		res := make([]int, len(t.bits))
+		for i, bit := range t.bits {
+			res[i] = b.value(bit)
+		}
+		return res
+	case "not", "bvnot":
+		return not(args[0])
+	case "and", "or":
+		res := args[0][0]
+		for _, arg := range args[1:] {
+			if t.op == "and" {
+				res = b.and(res, arg[0])
+			} else {
+				res = b.or(res, arg[0])
+			}
+		}
+		return []int{res}
+	case "xor":
+		res := args[0][0]
+		for _, arg := range args[1:] {
+			res = b.xor(res, arg[0])
+		}
+		return []int{res}
+	case "=>": // Right-associative
+		res := args[len(args)-1][0]
+		for i := len(args) - 2; i >= 0; i-- {
+			res = b.or(-args[i][0], res)
+		}
+		return []int{res}
+	case "=":
+		res := b.t
+		for i := 1; i < len(args); i++ {
+			res = b.and(res, b.equal(args[i-1], args[i]))
+		}
+		return []int{res}
+	case "distinct":
+		res := b.t
+		for i := range args {
+			for j := i + 1; j < len(args); j++ {
+				res = b.and(res, -b.equal(args[i], args[j]))
+			}
+		}
+		return []int{res}
+	case "ite":
+		return b.iteVec(args[0][0], args[1], args[2])
+	case "bvand", "bvnand":
+		res := b.bitwise(args[0], args[1], b.and)
+		if t.op == "bvnand" {
+			return not(res)
+		}
+		return res
+	case "bvor", "bvnor":
+		res := b.bitwise(args[0], args[1], b.or)
+		if t.op == "bvnor" {
+			return not(res)
+		}
+		return res
+	case "bvxor", "bvxnor":
+		res := b.bitwise(args[0], args[1], b.xor)
+		if t.op == "bvxnor" {
+			return not(res)
+		}
+		return res
+	case "bvneg":
+		return b.neg(args[0])
+	case "bvadd":
+		res, _ := b.add(args[0], args[1], -b.t)
+		return res
+	case "bvsub":
+		res, _ := b.add(args[0], not(args[1]), b.t)
+		return res
+	case "bvmul":
+		return b.mul(args[0], args[1])
+	case "bvshl", "bvlshr", "bvashr":
+		return b.shift(t.op, args[0], args[1])
+	case "bvudiv", "bvurem":
+		q, r := b.divMod(args[0], args[1])
+		if t.op == "bvudiv" {
+			return q
+		}
+		return r
+	case "bvsdiv", "bvsrem", "bvsmod":
+		return b.signedDiv(t.op, args[0], args[1])
+	case "bvcomp":
+		return []int{b.equal(args[0], args[1])}
+	case "bvult":
+		return []int{b.less(args[0], args[1])}
+	case "bvule":
+		return []int{-b.less(args[1], args[0])}
+	case "bvugt":
+		return []int{b.less(args[1], args[0])}
+	case "bvuge":
+		return []int{-b.less(args[0], args[1])}
+	case "bvslt":
+		return []int{b.less(flipSign(args[0]), flipSign(args[1]))}
+	case "bvsle":
+		return []int{-b.less(flipSign(args[1]), flipSign(args[0]))}
+	case "bvsgt":
+		return []int{b.less(flipSign(args[1]), flipSign(args[0]))}
+	case "bvsge":
+		return []int{-b.less(flipSign(args[0]), flipSign(args[1]))}
+	case "concat":
+		return append(append([]int{}, args[1]...), args[0]...)
+	case "extract":
+		return args[0][t.idx2 : t.idx+1]
+	case "zero_extend", "sign_extend":
+		fill := -b.t
+		if t.op == "sign_extend" {
+			fill = args[0][len(args[0])-1]
+		}
+		res := append([]int{}, args[0]...)
+		for i := 0; i < t.idx; i++ {
+			res = append(res, fill)
+		}
+		return res
+	case "rotate_left", "rotate_right":
+		w := len(args[0])
+		res := make([]int, w)
+		for i := range res {
+			if t.op == "rotate_left" {
+				res[(i+t.idx)%w] = args[0][i]
+			} else {
+				res[i] = args[0][(i+t.idx)%w]
+			}
+		}
+		return res
+	case "repeat":
+		var res []int
+		for i := 0; i < t.idx; i++ {
+			res = append(res, args[0]...)
+		}
+		return res
+	default:
+		panic(fmt.Errorf("cannot bit-blast operator %q", t.op))
+	}
+}
+
+// not returns the negation of all the given lits.
+func not(x []int) []int {
+	res := make([]int, len(x))
+	for i, lit := range x {
+		res[i] = -lit
+	}
+	return res
+}
+
+// flipSign returns x, whose most significant bit is negated. Signed comparisons
+// between bit-vectors are unsigned comparisons between their sign-flipped versions.
+func flipSign(x []int) []int {
+	res := append([]int{}, x...)
+	res[len(res)-1] = -res[len(res)-1]
+	return res
+}
+
+// and returns a lit that is true iff both x and y are.
+func (b *blaster) and(x, y int) int {
+	switch {
+	case x == -b.t || y == -b.t || x == -y:
+		return -b.t
+	case x == b.t || x == y:
+		return y
+	case y == b.t:
+		return x
+	}
+	z := b.newLit()
+	b.clauses = append(b.clauses, []int{-z, x}, []int{-z, y}, []int{z, -x, -y})
+	return z
+}
+
+// or returns a lit that is true iff x or y is.
+func (b *blaster) or(x, y int) int {
+	return -b.and(-x, -y)
+}
+
+// xor returns a lit that is true iff exactly one of x and y is.
+func (b *blaster) xor(x, y int) int {
+	switch {
+	case x == -b.t:
+		return y
+	case x == b.t:
+		return -y
+	case y == -b.t:
+		return x
+	case y == b.t:
+		return -x
+	case x == y:
+		return -b.t
+	case x == -y:
+		return b.t
+	}
+	z := b.newLit()
+	b.clauses = append(b.clauses, []int{-z, x, y}, []int{-z, -x, -y}, []int{z, -x, y}, []int{z, x, -y})
+	return z
+}
+
+// ite returns a lit that is equal to x if c is true, and to y otherwise.
+func (b *blaster) ite(c, x, y int) int {
+	switch {
+	case c == b.t || x == y:
+		return x
+	case c == -b.t:
+		return y
+	case x == b.t:
+		return b.or(c, y)
+	case x == -b.t:
+		return b.and(-c, y)
+	case y == b.t:
+		return b.or(-c, x)
+	case y == -b.t:
+		return b.and(c, x)
+	}
+	z := b.newLit()
+	b.clauses = append(b.clauses, []int{-c, -z, x}, []int{-c, z, -x}, []int{c, -z, y}, []int{c, z, -y})
+	return z
+}
+
+// iteVec returns bits equal to x if c is true, and to y otherwise.
+func (b *blaster) iteVec(c int, x, y []int) []int {
+	res := make([]int, len(x))
+	for i := range res {
+		res[i] = b.ite(c, x[i], y[i])
+	}
+	return res
+}
+
+// bitwise returns the bits obtained by applying gate to each pair of bits of x and y.
+func (b *blaster) bitwise(x, y []int, gate func(x, y int) int) []int {
+	res := make([]int, len(x))
+	for i := range res {
+		res[i] = gate(x[i], y[i])
+	}
+	return res
+}
+
+// equal returns a lit that is true iff x and y have the same bits.
+func (b *blaster) equal(x, y []int) int {
+	res := b.t
+	for i := range x {
+		res = b.and(res, -b.xor(x[i], y[i]))
+	}
+	return res
+}
+
+// less returns a lit that is true iff x < y, both being unsigned.
+func (b *blaster) less(x, y []int) int {
+	res := -b.t
+	for i := range x { // The most significant bit where x and y differ decides
+		res = b.ite(b.xor(x[i], y[i]), y[i], res)
+	}
+	return res
+}
+
+// add returns the bits of x+y+carry, where carry is a lit, along with the carry out of the most significant bit.
+func (b *blaster) add(x, y []int, carry int) (sum []int, out int) {
+	sum = make([]int, len(x))
+	for i := range x {
+		half := b.xor(x[i], y[i])
+		sum[i] = b.xor(half, carry)
+		carry = b.or(b.and(x[i], y[i]), b.and(half, carry))
+	}
+	return sum, carry
+}
+
+// zeros returns w bits that are always false.
+func (b *blaster) zeros(w int) []int {
+	res := make([]int, w)
+	for i := range res {
+		res[i] = -b.t
+	}
+	return res
+}
+
+// neg returns the bits of -x, in two's complement.
+func (b *blaster) neg(x []int) []int {
+	res, _ := b.add(not(x), b.zeros(len(x)), b.t)
+	return res
+}
+
+// mul returns the bits of x*y, modulo 2^w, with a shift-and-add multiplier.
+func (b *blaster) mul(x, y []int) []int {
+	res := b.zeros(len(x))
+	for i := range y {
+		partial := b.zeros(len(x))
+		for j := i; j < len(x); j++ {
+			partial[j] = b.and(x[j-i], y[i])
+		}
+		res, _ = b.add(res, partial, -b.t)
+	}
+	return res
+}
+
+// shift returns the bits of x shifted by y, with a barrel shifter. op is one of bvshl, bvlshr and bvashr.
+func (b *blaster) shift(op string, x, y []int) []int {
+	w := len(x)
+	fill := -b.t
+	if op == "bvashr" {
+		fill = x[w-1]
+	}
+	res := x
+	over := -b.t // True iff the shift amount is at least w
+	for k := range y {
+		if k >= 30 || 1<<k >= w {
+			over = b.or(over, y[k])
+			continue
+		}
+		dist := 1 << k
+		shifted := make([]int, w)
+		for i := range shifted {
+			src := i + dist
+			if op == "bvshl" {
+				src = i - dist
+			}
+			if src >= 0 && src < w {
+				shifted[i] = res[src]
+			} else {
+				shifted[i] = fill
+			}
+		}
+		res = b.iteVec(y[k], shifted, res)
+	}
+	fills := make([]int, w)
+	for i := range fills {
+		fills[i] = fill
+	}
+	return b.iteVec(over, fills, res)
+}
+
+// divMod returns the bits of the unsigned quotient and remainder of x by y. As in SMT-LIB,
+// dividing by zero gives a quotient whose bits are all true and a remainder equal to x.
+// Fresh vars stand for the quotient and the remainder, constrained so that x = q*y + r and r < y if y != 0.
+func (b *blaster) divMod(x, y []int) (q, r []int) {
+	w := len(x)
+	q, r = make([]int, w), make([]int, w)
+	for i := range q {
+		q[i], r[i] = b.newLit(), b.newLit()
+	}
+	zero := b.equal(y, b.zeros(w))
+	ext := func(v []int) []int { return append(append([]int{}, v...), b.zeros(w)...) }
+	prod, _ := b.add(b.mul(ext(q), ext(y)), ext(r), -b.t) // Computed on 2w bits, so it cannot overflow
+	b.clauses = append(b.clauses,
+		[]int{zero, b.equal(prod, ext(x))},
+		[]int{zero, b.less(r, y)},
+		[]int{-zero, b.equal(q, not(b.zeros(w)))},
+		[]int{-zero, b.equal(r, x)})
+	return q, r
+}
+
+// signedDiv returns the bits of x op y, where op is one of bvsdiv, bvsrem and bvsmod,
+// as defined by SMT-LIB in terms of unsigned divisions of absolute values.
+func (b *blaster) signedDiv(op string, x, y []int) []int {
+	w := len(x)
+	sx, sy := x[w-1], y[w-1]
+	q, r := b.divMod(b.iteVec(sx, b.neg(x), x), b.iteVec(sy, b.neg(y), y))
+	switch op {
+	case "bvsdiv":
+		return b.iteVec(b.xor(sx, sy), b.neg(q), q)
+	case "bvsrem":
+		return b.iteVec(sx, b.neg(r), r)
+	default:
+		negR := b.neg(r)
+		negPlusY, _ := b.add(negR, y, -b.t)
+		plusY, _ := b.add(r, y, -b.t)
+		res := b.iteVec(sx, b.iteVec(sy, negR, negPlusY), b.iteVec(sy, plusY, r))
+		return b.iteVec(b.equal(r, b.zeros(w)), r, res)
+	}
+}
@@ -0,0 +1,219 @@
+package smtlib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// solve parses and bit-blasts the given script, and solves the resulting problem.
+func solve(t *testing.T, script string) (solver.Status, *VarMap, []bool) {
+	t.Helper()
+	sc, err := Parse(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("could not parse script %q: %v", script, err)
+	}
+	pb, vars := sc.BitBlast()
+	s := solver.New(pb)
+	status := s.Solve()
+	if status == solver.Sat {
+		return status, vars, s.Model()
+	}
+	return status, vars, nil
+}
+
+// Reference implementations of the SMT-LIB semantics of bit-vector operators, on 4 bits.
+func udiv4(x, y int) int {
+	if y == 0 {
+		return 15
+	}
+	return x / y
+}
+
+func urem4(x, y int) int {
+	if y == 0 {
+		return x
+	}
+	return x % y
+}
+
+func abs4(x int) int {
+	if x >= 8 {
+		return (16 - x) & 15
+	}
+	return x
+}
+
+func neg4(x int) int { return (16 - x) & 15 }
+
+func signed4(x int) int {
+	if x >= 8 {
+		return x - 16
+	}
+	return x
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var ops4 = map[string]func(x, y int) int{
+	"bvadd":  func(x, y int) int { return (x + y) & 15 },
+	"bvsub":  func(x, y int) int { return (x - y) & 15 },
+	"bvmul":  func(x, y int) int { return (x * y) & 15 },
+	"bvand":  func(x, y int) int { return x & y },
+	"bvor":   func(x, y int) int { return x | y },
+	"bvxor":  func(x, y int) int { return x ^ y },
+	"bvnand": func(x, y int) int { return ^(x & y) & 15 },
+	"bvnor":  func(x, y int) int { return ^(x | y) & 15 },
+	"bvxnor": func(x, y int) int { return ^(x ^ y) & 15 },
+	"bvshl":  func(x, y int) int { return (x << uint(y)) & 15 },
+	"bvlshr": func(x, y int) int { return x >> uint(y) },
+	"bvashr": func(x, y int) int { return (signed4(x) >> uint(y)) & 15 },
+	"bvudiv": udiv4,
+	"bvurem": urem4,
+	"bvsdiv": func(x, y int) int {
+		q := udiv4(abs4(x), abs4(y))
+		if (x >= 8) != (y >= 8) {
+			return neg4(q)
+		}
+		return q
+	},
+	"bvsrem": func(x, y int) int {
+		r := urem4(abs4(x), abs4(y))
+		if x >= 8 {
+			return neg4(r)
+		}
+		return r
+	},
+	"bvsmod": func(x, y int) int {
+		u := urem4(abs4(x), abs4(y))
+		switch {
+		case u == 0 || (x < 8 && y < 8):
+			return u
+		case x >= 8 && y < 8:
+			return (neg4(u) + y) & 15
+		case x < 8 && y >= 8:
+			return (u + y) & 15
+		default:
+			return neg4(u)
+		}
+	},
+	"bvcomp": func(x, y int) int { return b2i(x == y) },
+	"bvult":  func(x, y int) int { return b2i(x < y) },
+	"bvule":  func(x, y int) int { return b2i(x <= y) },
+	"bvugt":  func(x, y int) int { return b2i(x > y) },
+	"bvuge":  func(x, y int) int { return b2i(x >= y) },
+	"bvslt":  func(x, y int) int { return b2i(signed4(x) < signed4(y)) },
+	"bvsle":  func(x, y int) int { return b2i(signed4(x) <= signed4(y)) },
+	"bvsgt":  func(x, y int) int { return b2i(signed4(x) > signed4(y)) },
+	"bvsge":  func(x, y int) int { return b2i(signed4(x) >= signed4(y)) },
+}
+
+func TestBinaryOperators(t *testing.T) {
+	var ops []string
+	for op := range ops4 {
+		ops = append(ops, op)
+	}
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "(declare-const x (_ BitVec 4))\n(declare-const y (_ BitVec 4))\n")
+			fmt.Fprintf(&sb, "(assert (= x (_ bv%d 4)))\n(assert (= y (_ bv%d 4)))\n", x, y)
+			for _, op := range ops {
+				if comparisonOps[op] {
+					fmt.Fprintf(&sb, "(declare-const %s Bool)\n(assert (= %s (%s x y)))\n", op, op, op)
+				} else if op == "bvcomp" {
+					fmt.Fprintf(&sb, "(declare-const %s (_ BitVec 1))\n(assert (= %s (%s x y)))\n", op, op, op)
+				} else {
+					fmt.Fprintf(&sb, "(declare-const %s (_ BitVec 4))\n(assert (= %s (%s x y)))\n", op, op, op)
+				}
+			}
+			status, vars, model := solve(t, sb.String())
+			if status != solver.Sat {
+				t.Fatalf("x=%d, y=%d: expected sat, got %v", x, y, status)
+			}
+			for _, op := range ops {
+				var got int
+				switch val := vars.Value(op, model); val {
+				case "true", "false":
+					got = b2i(val == "true")
+				default:
+					n, err := strconv.ParseInt(val[2:], 2, 32)
+					if err != nil {
+						t.Fatalf("invalid value %q for %s", val, op)
+					}
+					got = int(n)
+				}
+				if expected := ops4[op](x, y); got != expected {
+					t.Errorf("(%s #x%x #x%x): expected %d, got %d", op, x, y, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestBitBlast(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		status solver.Status
+	}{
+		{"(declare-const x (_ BitVec 8)) (declare-const y (_ BitVec 8)) (assert (distinct (bvadd x y) (bvadd y x)))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (assert (bvult (bvadd x #x01) x))", solver.Sat}, // Overflow, for x = #xff
+		{"(declare-const x (_ BitVec 8)) (assert (bvslt (bvadd x #x01) x)) (assert (distinct x #x7f))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (assert (not (= (bvnot x) (bvsub (bvneg x) #x01))))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (assert (distinct (concat ((_ extract 7 4) x) ((_ extract 3 0) x)) x))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (assert (distinct ((_ rotate_left 3) ((_ rotate_right 3) x)) x))", solver.Unsat},
+		{"(declare-const x (_ BitVec 4)) (assert (distinct ((_ repeat 2) x) (concat x x)))", solver.Unsat},
+		{"(declare-const x (_ BitVec 4)) (assert (bvult ((_ sign_extend 4) x) ((_ zero_extend 4) x)))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (declare-const y (_ BitVec 8)) " +
+			"(assert (distinct x (bvadd (bvmul (bvudiv x y) y) (bvurem x y))))", solver.Unsat},
+		{"(declare-const x (_ BitVec 8)) (declare-const y (_ BitVec 8)) " +
+			"(assert (distinct x (bvadd (bvmul (bvsdiv x y) y) (bvsrem x y))))", solver.Unsat},
+		{"(define-fun max ((a (_ BitVec 8)) (b (_ BitVec 8))) (_ BitVec 8) (ite (bvuge a b) a b)) " +
+			"(declare-fun x () (_ BitVec 8)) (declare-fun y () (_ BitVec 8)) (assert (bvult (max x y) x))", solver.Unsat},
+		{"(declare-const p Bool) (declare-const q Bool) (assert (let ((a p) (p q)) (and a (not p) (=> a p))))", solver.Unsat},
+		{"(declare-const p Bool) (declare-const q Bool) (assert (xor p q true)) (assert (! (= p q) :named eq))", solver.Sat},
+		{"(assert false)", solver.Unsat},
+		{"(check-sat)", solver.Sat},
+	} {
+		if status, _, _ := solve(t, test.script); status != test.status {
+			t.Errorf("script %q: expected %v, got %v", test.script, test.status, status)
+		}
+	}
+}
+
+func TestModel(t *testing.T) {
+	const script = `(set-logic QF_BV)
+(declare-const x (_ BitVec 8))
+(declare-const y (_ BitVec 8))
+(declare-const big Bool)
+(assert (= (bvmul x y) #x0f))
+(assert (bvugt x #x01))
+(assert (bvugt y x))
+(assert (bvult y #x10))
+(assert (= big (bvugt y #x04)))
+(check-sat)
+(get-model)
+`
+	status, vars, model := solve(t, script)
+	if status != solver.Sat {
+		t.Fatalf("expected sat, got %v", status)
+	}
+	if x, y := vars.Value("x", model), vars.Value("y", model); x != "#b00000011" || y != "#b00000101" {
+		t.Errorf("expected x=3 and y=5, got %s and %s", x, y)
+	}
+	if len(vars.Bits("x")) != 8 || len(vars.Bits("big")) != 1 || vars.Bits("z") != nil || vars.Value("z", model) != "" {
+		t.Errorf("invalid bits")
+	}
+	const expected = "(\n  (define-fun x () (_ BitVec 8) #b00000011)\n  (define-fun y () (_ BitVec 8) #b00000101)\n  (define-fun big () Bool true)\n)"
+	if got := vars.Model(model); got != expected {
+		t.Errorf("expected model %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,29 @@
+// Package smtlib reads SMT-LIB2 scripts in the QF_BV logic, and bit-blasts them into SAT problems.
+//
+// QF_BV is the logic of quantifier-free formulas over fixed-size bit-vectors. A script declares constants,
+// either Bool or bit-vectors, asserts formulas about them and asks whether they are satisfiable:
+//
+//	(set-logic QF_BV)
+//	(declare-const x (_ BitVec 8))
+//	(declare-const y (_ BitVec 8))
+//	(assert (= (bvmul x y) #x0f))
+//	(assert (bvugt x #x01))
+//	(assert (bvugt y #x01))
+//	(check-sat)
+//
+// Only a subset of SMT-LIB is supported: constants, defined functions, let bindings, and the core
+// and bit-vector operators, including divisions and comparisons. Uninterpreted functions, arrays,
+// and incremental commands such as push and pop are not. Scripts are read by Parse, then bit-blasted:
+// each operator is replaced by a circuit on the bits of its arguments, encoded in CNF:
+//
+//	sc, err := smtlib.Parse(f)
+//	pb, vars := sc.BitBlast()
+//	s := solver.New(pb)
+//	if s.Solve() == solver.Sat {
+//	    fmt.Println(vars.Model(s.Model()))
+//	}
+//
+// The resulting problem is satisfiable iff the assertions of the script are, and vars indicates
+// which solver vars represent the bits of each constant. See https://smtlib.cs.uiowa.edu/ for
+// more details about the language.
+package smtlib
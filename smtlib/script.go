@@ -0,0 +1,509 @@
+package smtlib
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// maxWidth is the biggest width of a bit-vector term. Wider terms would produce too big problems anyway.
+const maxWidth = 1 << 16
+
+// A Const is a constant declared by a script, with declare-const or with declare-fun without arguments.
+type Const struct {
+	Name  string
+	Width int // Number of bits of a bit-vector constant, or 0 for a Bool constant
+}
+
+// A Script is a parsed SMT-LIB2 script: the constants it declares and the formulas it asserts.
+type Script struct {
+	Logic   string  // Logic set by set-logic, or "" if there was none
+	Consts  []Const // Declared constants, in the order of their declarations
+	asserts []*term // Asserted formulas
+}
+
+// A macro is a function defined with define-fun.
+type macro struct {
+	params []int // Width of each parameter
+	width  int   // Width of the body
+	body   *term // Body, where parameters are terms whose op is "param"
+}
+
+// A scope is a list of let bindings, the innermost first.
+type scope struct {
+	name string
+	t    *term
+	next *scope
+}
+
+// lookup returns the term bound to name, or nil if there is none.
+func (sc *scope) lookup(name string) *term {
+	for ; sc != nil; sc = sc.next {
+		if sc.name == name {
+			return sc.t
+		}
+	}
+	return nil
+}
+
+// A parser holds the data used while parsing a script.
+type parser struct {
+	sc         Script
+	consts     map[string]int    // Index of each declared constant
+	constTerms []*term           // Term of each declared constant, so that all its occurrences are shared
+	macros     map[string]*macro // Defined functions
+	params     []*term           // While a function is being defined, its parameters
+	checked    bool              // True iff check-sat was met
+}
+
+// Parse parses an SMT-LIB2 script in the QF_BV logic, made of declare-const, declare-fun, define-fun, assert
+// and check-sat commands. Constants can be Bool or bit-vectors; uninterpreted functions with arguments,
+// and commands such as push and pop, are not supported. Commands about the output, such as get-model,
+// and commands following the first check-sat, are ignored, except for asserts, that are rejected.
+// Syntax and sort errors are reported as *solver.ParseError.
+func Parse(r io.Reader) (*Script, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read SMT-LIB file: %w", err)
+	}
+	p := parser{consts: make(map[string]int), macros: make(map[string]*macro)}
+	rd := newReader(buf)
+	for {
+		cmd, err := rd.next()
+		if err != nil {
+			return nil, err
+		}
+		if cmd == nil {
+			break
+		}
+		exit, err := p.command(cmd)
+		if err != nil {
+			return nil, err
+		}
+		if exit {
+			break
+		}
+	}
+	return &p.sc, nil
+}
+
+// command parses a command of the script. It returns true if the command is exit.
+func (p *parser) command(cmd *sexpr) (exit bool, err error) {
+	if !cmd.isList || len(cmd.list) == 0 || cmd.list[0].isList || cmd.list[0].kind != symbolAtom {
+		return false, cmd.errorf(solver.ErrBadConstraint, "invalid command %v", cmd)
+	}
+	args := cmd.list[1:]
+	switch name := cmd.list[0].atom; name {
+	case "set-logic":
+		if len(args) != 1 || args[0].isList || args[0].kind != symbolAtom {
+			return false, cmd.errorf(solver.ErrBadHeader, "invalid command %v", cmd)
+		}
+		if args[0].atom != "QF_BV" {
+			return false, args[0].errorf(solver.ErrBadHeader, "unsupported logic %q: only QF_BV is supported", args[0].atom)
+		}
+		p.sc.Logic = args[0].atom
+	case "set-info", "set-option", "get-info", "get-option", "get-model", "get-value", "get-assignment", "echo":
+	case "exit":
+		return true, nil
+	case "check-sat":
+		p.checked = true
+	case "declare-const":
+		if len(args) != 2 {
+			return false, cmd.errorf(solver.ErrBadConstraint, "invalid command %v", cmd)
+		}
+		return false, p.declare(args[0], args[1])
+	case "declare-fun":
+		if len(args) != 3 || !args[1].isList {
+			return false, cmd.errorf(solver.ErrBadConstraint, "invalid command %v", cmd)
+		}
+		if len(args[1].list) != 0 {
+			return false, cmd.errorf(solver.ErrBadConstraint, "uninterpreted functions with arguments are not supported")
+		}
+		return false, p.declare(args[0], args[2])
+	case "define-fun":
+		if len(args) != 4 || !args[1].isList {
+			return false, cmd.errorf(solver.ErrBadConstraint, "invalid command %v", cmd)
+		}
+		return false, p.define(args[0], args[1].list, args[2], args[3])
+	case "assert":
+		if len(args) != 1 {
+			return false, cmd.errorf(solver.ErrBadConstraint, "invalid command %v", cmd)
+		}
+		if p.checked {
+			return false, cmd.errorf(solver.ErrBadConstraint, "assertions after check-sat are not supported")
+		}
+		t, err := p.elab(args[0], nil)
+		if err != nil {
+			return false, err
+		}
+		if t.width != 0 {
+			return false, args[0].errorf(solver.ErrBadConstraint, "asserted term %v is not a Bool", args[0])
+		}
+		p.sc.asserts = append(p.sc.asserts, t)
+	default:
+		return false, cmd.errorf(solver.ErrBadConstraint, "unsupported command %q", name)
+	}
+	return false, nil
+}
+
+// newSymbol checks e is a symbol that was not declared nor defined yet, and returns it.
+func (p *parser) newSymbol(e *sexpr) (string, error) {
+	if e.isList || e.kind != symbolAtom {
+		return "", e.errorf(solver.ErrBadLiteral, "%v is not a symbol", e)
+	}
+	_, isConst := p.consts[e.atom]
+	_, isMacro := p.macros[e.atom]
+	if isConst || isMacro {
+		return "", e.errorf(solver.ErrBadLiteral, "symbol %v declared several times", e)
+	}
+	return e.atom, nil
+}
+
+// declare declares a constant called name, whose sort is sort.
+func (p *parser) declare(name, sort *sexpr) error {
+	sym, err := p.newSymbol(name)
+	if err != nil {
+		return err
+	}
+	width, err := parseSort(sort)
+	if err != nil {
+		return err
+	}
+	p.consts[sym] = len(p.sc.Consts)
+	p.constTerms = append(p.constTerms, &term{op: "const", idx: len(p.sc.Consts), width: width})
+	p.sc.Consts = append(p.sc.Consts, Const{Name: sym, Width: width})
+	return nil
+}
+
+// define defines a function called name, whose parameters, sort and body are given.
+func (p *parser) define(name *sexpr, params []*sexpr, sort, body *sexpr) error {
+	sym, err := p.newSymbol(name)
+	if err != nil {
+		return err
+	}
+	m := &macro{}
+	var sc *scope
+	p.params = nil
+	for i, param := range params {
+		if !param.isList || len(param.list) != 2 || param.list[0].isList || param.list[0].kind != symbolAtom {
+			return param.errorf(solver.ErrBadConstraint, "invalid parameter %v", param)
+		}
+		width, err := parseSort(param.list[1])
+		if err != nil {
+			return err
+		}
+		m.params = append(m.params, width)
+		sc = &scope{name: param.list[0].atom, t: &term{op: "param", idx: i, width: width}, next: sc}
+	}
+	if m.width, err = parseSort(sort); err != nil {
+		return err
+	}
+	if m.body, err = p.elab(body, sc); err != nil {
+		return err
+	}
+	if m.body.width != m.width {
+		return body.errorf(solver.ErrBadConstraint, "body of %s is a %s, not a %s", sym, sortString(m.body.width), sortString(m.width))
+	}
+	p.macros[sym] = m
+	return nil
+}
+
+// parseSort returns the width of terms of the sort e, that is either Bool or (_ BitVec n).
+func parseSort(e *sexpr) (int, error) {
+	if e.isSymbol("Bool") {
+		return 0, nil
+	}
+	if e.isList && len(e.list) == 3 && e.list[0].isSymbol("_") && e.list[1].isSymbol("BitVec") {
+		if width, err := parseIndex(e.list[2]); err == nil && width > 0 && width <= maxWidth {
+			return width, nil
+		}
+	}
+	return 0, e.errorf(solver.ErrBadConstraint, "unsupported sort %v", e)
+}
+
+// parseIndex returns the value of the numeral e, that is the index of a sort or of an operator.
+func parseIndex(e *sexpr) (int, error) {
+	if e.isList || e.kind != numeralAtom {
+		return 0, e.errorf(solver.ErrBadLiteral, "%v is not a numeral", e)
+	}
+	n, err := strconv.Atoi(e.atom)
+	if err != nil || n > maxWidth {
+		return 0, e.errorf(solver.ErrBadLiteral, "numeral %v is too big", e)
+	}
+	return n, nil
+}
+
+// elab returns the term described by e, where symbols bound by let are looked up in sc.
+func (p *parser) elab(e *sexpr, sc *scope) (*term, error) {
+	if !e.isList {
+		return p.elabAtom(e, sc)
+	}
+	if len(e.list) == 0 {
+		return nil, e.errorf(solver.ErrBadConstraint, "empty term")
+	}
+	head := e.list[0]
+	switch {
+	case head.isSymbol("_"):
+		return elabValue(e)
+	case head.isSymbol("let"):
+		return p.elabLet(e, sc)
+	case head.isSymbol("!"): // Annotations, such as :named, are ignored
+		if len(e.list) < 2 {
+			return nil, e.errorf(solver.ErrBadConstraint, "invalid annotated term %v", e)
+		}
+		return p.elab(e.list[1], sc)
+	}
+	args := make([]*term, len(e.list)-1)
+	for i, sub := range e.list[1:] {
+		arg, err := p.elab(sub, sc)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+	if head.isList {
+		if len(head.list) < 3 || len(head.list) > 4 || !head.list[0].isSymbol("_") || head.list[1].isList {
+			return nil, head.errorf(solver.ErrBadOperator, "invalid operator %v", head)
+		}
+		var idx []int
+		for _, sub := range head.list[2:] {
+			n, err := parseIndex(sub)
+			if err != nil {
+				return nil, err
+			}
+			idx = append(idx, n)
+		}
+		return applyIndexed(head, head.list[1].atom, idx, args)
+	}
+	if head.kind != symbolAtom {
+		return nil, head.errorf(solver.ErrBadOperator, "invalid operator %v", head)
+	}
+	if m, ok := p.macros[head.atom]; ok {
+		if len(args) != len(m.params) {
+			return nil, e.errorf(solver.ErrBadConstraint, "%v expects %d arguments, got %d", head, len(m.params), len(args))
+		}
+		for i, arg := range args {
+			if arg.width != m.params[i] {
+				return nil, e.list[i+1].errorf(solver.ErrBadConstraint, "argument %v of %v is a %s, not a %s", e.list[i+1], head, sortString(arg.width), sortString(m.params[i]))
+			}
+		}
+		return m.body.subst(args, make(map[*term]*term)), nil
+	}
+	if head.quoted {
+		return nil, head.errorf(solver.ErrBadOperator, "unknown function %v", head)
+	}
+	return apply(e, head.atom, args)
+}
+
+// elabAtom returns the term described by the atom e.
+func (p *parser) elabAtom(e *sexpr, sc *scope) (*term, error) {
+	switch e.kind {
+	case binaryAtom:
+		digits := e.atom[2:]
+		if len(digits) > maxWidth {
+			return nil, e.errorf(solver.ErrBadLiteral, "literal %v is too wide", e)
+		}
+		t := &term{op: "value", width: len(digits), bits: make([]bool, len(digits))}
+		for i := range t.bits {
+			t.bits[i] = digits[len(digits)-1-i] == '1'
+		}
+		return t, nil
+	case hexAtom:
+		digits := e.atom[2:]
+		if 4*len(digits) > maxWidth {
+			return nil, e.errorf(solver.ErrBadLiteral, "literal %v is too wide", e)
+		}
+		t := &term{op: "value", width: 4 * len(digits), bits: make([]bool, 4*len(digits))}
+		for i := range t.bits {
+			t.bits[i] = digitValue(digits[len(digits)-1-i/4])&(1<<(i%4)) != 0
+		}
+		return t, nil
+	case symbolAtom:
+		if t := sc.lookup(e.atom); t != nil {
+			return t, nil
+		}
+		if !e.quoted && (e.atom == "true" || e.atom == "false") {
+			return boolValue(e.atom == "true"), nil
+		}
+		if i, ok := p.consts[e.atom]; ok {
+			return p.constTerms[i], nil
+		}
+		if m, ok := p.macros[e.atom]; ok && len(m.params) == 0 {
+			return m.body, nil
+		}
+		return nil, e.errorf(solver.ErrBadLiteral, "unknown symbol %v", e)
+	default:
+		return nil, e.errorf(solver.ErrBadLiteral, "%v is not a term", e)
+	}
+}
+
+// elabValue returns the bit-vector value described by e, such as (_ bv5 8).
+// As in SMT-LIB, the value is taken modulo 2^width.
+func elabValue(e *sexpr) (*term, error) {
+	if len(e.list) != 3 || e.list[1].isList || e.list[1].kind != symbolAtom || len(e.list[1].atom) < 3 || e.list[1].atom[:2] != "bv" {
+		return nil, e.errorf(solver.ErrBadLiteral, "invalid bit-vector literal %v", e)
+	}
+	val, ok := new(big.Int).SetString(e.list[1].atom[2:], 10)
+	if !ok || val.Sign() < 0 || !isDigits(e.list[1].atom[2:], 10) {
+		return nil, e.errorf(solver.ErrBadLiteral, "invalid bit-vector literal %v", e)
+	}
+	width, err := parseIndex(e.list[2])
+	if err != nil || width == 0 {
+		return nil, e.errorf(solver.ErrBadLiteral, "invalid width in bit-vector literal %v", e)
+	}
+	t := &term{op: "value", width: width, bits: make([]bool, width)}
+	for i := range t.bits {
+		t.bits[i] = val.Bit(i) == 1
+	}
+	return t, nil
+}
+
+// elabLet returns the term described by the let term e, whose bindings are made in parallel.
+func (p *parser) elabLet(e *sexpr, sc *scope) (*term, error) {
+	if len(e.list) != 3 || !e.list[1].isList || len(e.list[1].list) == 0 {
+		return nil, e.errorf(solver.ErrBadConstraint, "invalid let term %v", e)
+	}
+	inner := sc
+	for _, b := range e.list[1].list {
+		if !b.isList || len(b.list) != 2 || b.list[0].isList || b.list[0].kind != symbolAtom {
+			return nil, b.errorf(solver.ErrBadConstraint, "invalid binding %v", b)
+		}
+		t, err := p.elab(b.list[1], sc)
+		if err != nil {
+			return nil, err
+		}
+		inner = &scope{name: b.list[0].atom, t: t, next: inner}
+	}
+	return p.elab(e.list[2], inner)
+}
+
+// Operators taking bit-vector arguments of the same width.
+var (
+	leftAssocOps = map[string]bool{"bvand": true, "bvor": true, "bvxor": true, "bvadd": true, "bvmul": true}
+	binaryOps    = map[string]bool{
+		"bvsub": true, "bvnand": true, "bvnor": true, "bvxnor": true, "bvshl": true, "bvlshr": true, "bvashr": true,
+		"bvudiv": true, "bvurem": true, "bvsdiv": true, "bvsrem": true, "bvsmod": true,
+	}
+	comparisonOps = map[string]bool{
+		"bvult": true, "bvule": true, "bvugt": true, "bvuge": true, "bvslt": true, "bvsle": true, "bvsgt": true, "bvsge": true,
+	}
+)
+
+// apply returns the application of the operator op to args, described by e.
+func apply(e *sexpr, op string, args []*term) (*term, error) {
+	errorf := func(format string, args ...interface{}) (*term, error) {
+		return nil, e.errorf(solver.ErrBadConstraint, "invalid term %v: "+format, append([]interface{}{e}, args...)...)
+	}
+	nbArgs := func(min, max int) bool {
+		return len(args) >= min && (max == -1 || len(args) <= max)
+	}
+	sameWidth := func() bool {
+		for _, arg := range args[1:] {
+			if arg.width != args[0].width {
+				return false
+			}
+		}
+		return true
+	}
+	allBool := func() bool {
+		for _, arg := range args {
+			if arg.width != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case op == "not":
+		if !nbArgs(1, 1) || !allBool() {
+			return errorf("expected a Bool argument")
+		}
+	case op == "and" || op == "or" || op == "xor" || op == "=>":
+		if !nbArgs(2, -1) || !allBool() {
+			return errorf("expected at least 2 Bool arguments")
+		}
+	case op == "=" || op == "distinct":
+		if !nbArgs(2, -1) || !sameWidth() {
+			return errorf("expected at least 2 arguments of the same sort")
+		}
+	case op == "ite":
+		if !nbArgs(3, 3) || args[0].width != 0 || args[1].width != args[2].width {
+			return errorf("expected a Bool condition and 2 arguments of the same sort")
+		}
+		return &term{op: op, args: args, width: args[1].width}, nil
+	case op == "bvnot" || op == "bvneg":
+		if !nbArgs(1, 1) || args[0].width == 0 {
+			return errorf("expected a bit-vector argument")
+		}
+		return &term{op: op, args: args, width: args[0].width}, nil
+	case leftAssocOps[op] || binaryOps[op] || comparisonOps[op] || op == "bvcomp":
+		max := 2
+		if leftAssocOps[op] {
+			max = -1
+		}
+		if !nbArgs(2, max) || args[0].width == 0 || !sameWidth() {
+			return errorf("expected bit-vector arguments of the same width")
+		}
+		res := &term{op: op, args: args[:2], width: args[0].width}
+		for _, arg := range args[2:] { // Left-associative operators are binarized
+			res = &term{op: op, args: []*term{res, arg}, width: arg.width}
+		}
+		switch {
+		case comparisonOps[op]:
+			res.width = 0
+		case op == "bvcomp":
+			res.width = 1
+		}
+		return res, nil
+	case op == "concat":
+		if !nbArgs(2, -1) {
+			return errorf("expected at least 2 bit-vector arguments")
+		}
+		res := args[0]
+		for _, arg := range args[1:] {
+			if res.width == 0 || arg.width == 0 || res.width+arg.width > maxWidth {
+				return errorf("expected bit-vector arguments, whose concatenation is at most %d bits wide", maxWidth)
+			}
+			res = &term{op: op, args: []*term{res, arg}, width: res.width + arg.width}
+		}
+		return res, nil
+	default:
+		return nil, e.list[0].errorf(solver.ErrBadOperator, "unsupported operator %q", op)
+	}
+	return &term{op: op, args: args}, nil
+}
+
+// applyIndexed returns the application of the indexed operator (_ op idx...) described by head to args.
+func applyIndexed(head *sexpr, op string, idx []int, args []*term) (*term, error) {
+	if len(args) != 1 || args[0].width == 0 {
+		return nil, head.errorf(solver.ErrBadConstraint, "%v expects a bit-vector argument", head)
+	}
+	w := args[0].width
+	res := &term{op: op, args: args, idx: idx[0]}
+	switch {
+	case op == "extract" && len(idx) == 2:
+		if idx[0] < idx[1] || idx[0] >= w {
+			return nil, head.errorf(solver.ErrBadConstraint, "invalid indices in %v for a %s", head, sortString(w))
+		}
+		res.idx2 = idx[1]
+		res.width = idx[0] - idx[1] + 1
+	case (op == "zero_extend" || op == "sign_extend") && len(idx) == 1:
+		res.width = w + idx[0]
+	case (op == "rotate_left" || op == "rotate_right") && len(idx) == 1:
+		res.width = w
+	case op == "repeat" && len(idx) == 1:
+		if idx[0] == 0 {
+			return nil, head.errorf(solver.ErrBadConstraint, "invalid index in %v", head)
+		}
+		res.width = w * idx[0]
+	default:
+		return nil, head.errorf(solver.ErrBadOperator, "unsupported operator %v", head)
+	}
+	if res.width > maxWidth {
+		return nil, head.errorf(solver.ErrBadConstraint, "result of %v is more than %d bits wide", head, maxWidth)
+	}
+	return res, nil
+}
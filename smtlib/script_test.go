@@ -0,0 +1,85 @@
+package smtlib
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+func TestParse(t *testing.T) {
+	const script = `; A comment
+(set-info :status sat)
+(set-logic QF_BV)
+(declare-const |a b| (_ BitVec 4))
+(declare-fun p () Bool)
+(define-fun f ((x (_ BitVec 4))) Bool (= x #b0101))
+(assert (or p (f |a b|)))
+(echo "some ""text""")
+(check-sat)
+(exit)
+(assert unparsed)
+`
+	sc, err := Parse(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("could not parse script: %v", err)
+	}
+	if sc.Logic != "QF_BV" {
+		t.Errorf("expected logic QF_BV, got %q", sc.Logic)
+	}
+	if expected := []Const{{Name: "a b", Width: 4}, {Name: "p", Width: 0}}; !reflect.DeepEqual(sc.Consts, expected) {
+		t.Errorf("expected consts %v, got %v", expected, sc.Consts)
+	}
+	if len(sc.asserts) != 1 {
+		t.Errorf("expected 1 assertion, got %d", len(sc.asserts))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		cause  error
+	}{
+		{"(set-logic QF_LIA)", solver.ErrBadHeader},
+		{"(declare-const x (_ BitVec 4)", solver.ErrTruncated},
+		{"(echo \"text)", solver.ErrTruncated},
+		{"(declare-const x (_ BitVec 0))", solver.ErrBadConstraint},
+		{"(declare-const x Int)", solver.ErrBadConstraint},
+		{"(declare-fun f ((_ BitVec 4)) Bool)", solver.ErrBadConstraint},
+		{"(declare-const x Bool) (declare-const x Bool)", solver.ErrBadLiteral},
+		{"(push 1)", solver.ErrBadConstraint},
+		{"(check-sat) (assert true)", solver.ErrBadConstraint},
+		{"(assert x)", solver.ErrBadLiteral},
+		{"(assert #b012)", solver.ErrBadLiteral},
+		{"(assert (= 01 1))", solver.ErrBadLiteral},
+		{"(assert (bvfoo #b0 #b1))", solver.ErrBadOperator},
+		{"(assert (= ((_ foo 1) #b0) #b0))", solver.ErrBadOperator},
+		{"(assert #b01)", solver.ErrBadConstraint},
+		{"(assert (= #b01 #b001))", solver.ErrBadConstraint},
+		{"(assert (not #b0))", solver.ErrBadConstraint},
+		{"(assert (bvult #b01 #b01 #b01))", solver.ErrBadConstraint},
+		{"(assert (= ((_ extract 2 0) #b01) #b0))", solver.ErrBadConstraint},
+		{"(define-fun f ((x Bool)) Bool x) (assert (f #b0))", solver.ErrBadConstraint},
+		{"(define-fun f () (_ BitVec 2) true)", solver.ErrBadConstraint},
+		{"(assert true))", solver.ErrBadConstraint},
+	} {
+		_, err := Parse(strings.NewReader(test.script))
+		var parseErr *solver.ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, test.cause) {
+			t.Errorf("script %q: expected a parse error caused by %v, got %v", test.script, test.cause, err)
+		}
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := Parse(strings.NewReader("(declare-const x (_ BitVec 4))\n(assert (= x  (bvadd x y)))"))
+	var parseErr *solver.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if parseErr.Line != 2 || parseErr.Column != 24 {
+		t.Errorf("expected an error at line 2, column 24, got %v", err)
+	}
+}
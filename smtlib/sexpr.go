@@ -0,0 +1,235 @@
+package smtlib
+
+import (
+	"fmt"
+
+	"github.com/j-blue-arz/tiny-gophersat/solver"
+)
+
+// atomKind is the kind of an atom of an sexpr.
+type atomKind byte
+
+const (
+	symbolAtom  atomKind = iota // Simple or quoted symbol, such as x or |x y|
+	numeralAtom                 // Non-negative integer, such as 42
+	binaryAtom                  // Binary bit-vector literal, such as #b0101
+	hexAtom                     // Hexadecimal bit-vector literal, such as #x5
+	keywordAtom                 // Keyword, such as :named
+	stringAtom                  // String literal, such as "some text"
+)
+
+// An sexpr is a symbolic expression: either an atom, or a parenthesized list of sexprs.
+type sexpr struct {
+	list   []*sexpr // Elements of the list, or nil if this is an atom
+	isList bool
+	atom   string // Text of the atom, without the bars of quoted symbols or the quotes of strings
+	kind   atomKind
+	quoted bool // True iff the atom is a quoted symbol, that cannot be a reserved word or an operator
+	line   int  // Line of the first char of the sexpr, starting at 1
+	col    int  // Position of the first char of the sexpr on its line, in bytes, starting at 1
+}
+
+// isSymbol returns true iff e is the unquoted symbol sym.
+func (e *sexpr) isSymbol(sym string) bool {
+	return !e.isList && e.kind == symbolAtom && !e.quoted && e.atom == sym
+}
+
+func (e *sexpr) String() string {
+	if !e.isList {
+		switch {
+		case e.quoted:
+			return "|" + e.atom + "|"
+		case e.kind == stringAtom:
+			return fmt.Sprintf("%q", e.atom)
+		default:
+			return e.atom
+		}
+	}
+	res := "("
+	for i, sub := range e.list {
+		if i > 0 {
+			res += " "
+		}
+		res += sub.String()
+	}
+	return res + ")"
+}
+
+// errorf returns an error about e, that is the given faulty part of the input, built from format and args.
+func (e *sexpr) errorf(cause error, format string, args ...interface{}) error {
+	return &solver.ParseError{Line: e.line, Column: e.col, Msg: fmt.Sprintf(format, args...), Err: cause}
+}
+
+// A reader reads the sexprs of a script, one command at a time.
+type reader struct {
+	buf  []byte
+	pos  int // Index of the next byte to read
+	line int // Line of the next byte, starting at 1
+	col  int // Position of the next byte on its line, starting at 1
+}
+
+func newReader(buf []byte) *reader {
+	return &reader{buf: buf, line: 1, col: 1}
+}
+
+// advance moves to the next byte.
+func (r *reader) advance() {
+	if r.buf[r.pos] == '\n' {
+		r.line++
+		r.col = 0
+	}
+	r.pos++
+	r.col++
+}
+
+// skipBlanks skips spaces and comments, that start with ';' and end with the line.
+func (r *reader) skipBlanks() {
+	for r.pos < len(r.buf) {
+		switch b := r.buf[r.pos]; {
+		case b == ';':
+			for r.pos < len(r.buf) && r.buf[r.pos] != '\n' {
+				r.advance()
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			r.advance()
+		default:
+			return
+		}
+	}
+}
+
+// errorf returns an error at the current position, about the given faulty part of the input.
+func (r *reader) errorf(cause error, format string, args ...interface{}) error {
+	return &solver.ParseError{Line: r.line, Column: r.col, Msg: fmt.Sprintf(format, args...), Err: cause}
+}
+
+// next returns the next sexpr, or nil if the end of the input was reached.
+func (r *reader) next() (*sexpr, error) {
+	r.skipBlanks()
+	if r.pos == len(r.buf) {
+		return nil, nil
+	}
+	e := &sexpr{line: r.line, col: r.col}
+	switch r.buf[r.pos] {
+	case ')':
+		return nil, r.errorf(solver.ErrBadConstraint, "unexpected ')'")
+	case '(':
+		r.advance()
+		e.isList = true
+		for {
+			r.skipBlanks()
+			if r.pos == len(r.buf) {
+				return nil, r.errorf(solver.ErrTruncated, "unterminated list starting at line %d", e.line)
+			}
+			if r.buf[r.pos] == ')' {
+				r.advance()
+				return e, nil
+			}
+			sub, err := r.next()
+			if err != nil {
+				return nil, err
+			}
+			e.list = append(e.list, sub)
+		}
+	case '|':
+		r.advance()
+		start := r.pos
+		for r.pos < len(r.buf) && r.buf[r.pos] != '|' {
+			if r.buf[r.pos] == '\\' {
+				return nil, r.errorf(solver.ErrBadLiteral, "backslash in quoted symbol")
+			}
+			r.advance()
+		}
+		if r.pos == len(r.buf) {
+			return nil, r.errorf(solver.ErrTruncated, "unterminated quoted symbol starting at line %d", e.line)
+		}
+		e.atom, e.kind, e.quoted = string(r.buf[start:r.pos]), symbolAtom, true
+		r.advance()
+		return e, nil
+	case '"':
+		r.advance()
+		var str []byte
+		for {
+			if r.pos == len(r.buf) {
+				return nil, r.errorf(solver.ErrTruncated, "unterminated string starting at line %d", e.line)
+			}
+			b := r.buf[r.pos]
+			r.advance()
+			if b == '"' {
+				if r.pos == len(r.buf) || r.buf[r.pos] != '"' { // A doubled quote stands for a quote
+					break
+				}
+				r.advance()
+			}
+			str = append(str, b)
+		}
+		e.atom, e.kind = string(str), stringAtom
+		return e, nil
+	}
+	start := r.pos
+	for r.pos < len(r.buf) && !isDelimiter(r.buf[r.pos]) {
+		r.advance()
+	}
+	e.atom = string(r.buf[start:r.pos])
+	return e, e.classify()
+}
+
+// isDelimiter returns true iff b cannot be part of an unquoted atom.
+func isDelimiter(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '(', ')', ';', '"', '|':
+		return true
+	}
+	return false
+}
+
+// classify sets the kind of the unquoted atom e, and checks it is well-formed.
+func (e *sexpr) classify() error {
+	atom := e.atom
+	switch {
+	case atom[0] >= '0' && atom[0] <= '9':
+		e.kind = numeralAtom
+		if !isDigits(atom, 10) || (len(atom) > 1 && atom[0] == '0') {
+			return e.errorf(solver.ErrBadLiteral, "invalid numeral %q", atom)
+		}
+	case len(atom) >= 2 && atom[:2] == "#b":
+		e.kind = binaryAtom
+		if len(atom) == 2 || !isDigits(atom[2:], 2) {
+			return e.errorf(solver.ErrBadLiteral, "invalid binary literal %q", atom)
+		}
+	case len(atom) >= 2 && atom[:2] == "#x":
+		e.kind = hexAtom
+		if len(atom) == 2 || !isDigits(atom[2:], 16) {
+			return e.errorf(solver.ErrBadLiteral, "invalid hexadecimal literal %q", atom)
+		}
+	case atom[0] == ':':
+		e.kind = keywordAtom
+	default:
+		e.kind = symbolAtom
+	}
+	return nil
+}
+
+// isDigits returns true iff all the chars of str are digits in the given base, that is 2, 10 or 16.
+func isDigits(str string, base int) bool {
+	for i := 0; i < len(str); i++ {
+		if digitValue(str[i]) >= base {
+			return false
+		}
+	}
+	return true
+}
+
+// digitValue returns the value of the digit b, in any base up to 16, or 16 if b is not a digit.
+func digitValue(b byte) int {
+	switch {
+	case b >= '0' && b <= '9':
+		return int(b - '0')
+	case b >= 'a' && b <= 'f':
+		return int(b-'a') + 10
+	case b >= 'A' && b <= 'F':
+		return int(b-'A') + 10
+	default:
+		return 16
+	}
+}
@@ -0,0 +1,50 @@
+package smtlib
+
+import "strconv"
+
+// A term is a well-sorted term of a script, once symbols were resolved and let bindings and macros expanded.
+type term struct {
+	op    string  // Name of the operator, or one of "const", "value" and "param"
+	args  []*term // Arguments of the operator
+	width int     // Number of bits of the term, or 0 if it is a Bool term
+	idx   int     // Index of the declared constant or of the macro parameter, or first index of an indexed operator
+	idx2  int     // Second index of an indexed operator, such as j in (_ extract i j)
+	bits  []bool  // Bits of a value, least significant first; Bool values have a single bit
+}
+
+// boolValue returns the Bool term whose value is val.
+func boolValue(val bool) *term {
+	return &term{op: "value", bits: []bool{val}}
+}
+
+// subst returns t, where macro parameters are replaced by the given terms. Results are memoized in memo.
+func (t *term) subst(params []*term, memo map[*term]*term) *term {
+	if t.op == "param" {
+		return params[t.idx]
+	}
+	if res, ok := memo[t]; ok {
+		return res
+	}
+	res := t
+	for i, arg := range t.args {
+		if sub := arg.subst(params, memo); sub != arg {
+			if res == t {
+				cp := *t
+				cp.args = make([]*term, len(t.args))
+				copy(cp.args, t.args)
+				res = &cp
+			}
+			res.args[i] = sub
+		}
+	}
+	memo[t] = res
+	return res
+}
+
+// sortString returns the SMT-LIB sort of terms of the given width.
+func sortString(width int) string {
+	if width == 0 {
+		return "Bool"
+	}
+	return "(_ BitVec " + strconv.Itoa(width) + ")"
+}
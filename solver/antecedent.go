@@ -0,0 +1,128 @@
+package solver
+
+import "fmt"
+
+// This file deals with the explanation of top-level implications, i.e with finding which problem clauses
+// make a given literal true no matter what.
+// Since the reason of a top-level implication can be a learned clause, the clauses each learned clause
+// was derived from, its antecedents, are recorded during conflict analysis.
+
+// antecedents records the antecedents of learned clauses.
+type antecedents struct {
+	enabled bool                  // True iff antecedents are recorded during the current conflict analysis
+	current []*Clause             // Antecedents of the clause currently being learned
+	clauses map[*Clause][]*Clause // Antecedents of each learned clause
+	units   map[Var][]*Clause     // Antecedents of each learned unit, indexed by its var; nil if they were not recorded
+}
+
+// start starts recording the antecedents of a new learned clause, whose analysis starts with confl.
+func (a *antecedents) start(enabled bool, confl *Clause) {
+	a.enabled = enabled
+	if enabled {
+		a.current = append(a.current[:0], confl)
+	}
+}
+
+// mark indicates c is an antecedent of the clause currently being learned.
+func (a *antecedents) mark(c *Clause) {
+	if a.enabled {
+		a.current = append(a.current, c)
+	}
+}
+
+// copyCurrent returns a copy of the antecedents of the clause currently being learned.
+func (a *antecedents) copyCurrent() []*Clause {
+	res := make([]*Clause, len(a.current))
+	copy(res, a.current)
+	return res
+}
+
+// save associates the recorded antecedents with the learned clause.
+func (a *antecedents) save(learned *Clause) {
+	if !a.enabled {
+		return
+	}
+	if a.clauses == nil {
+		a.clauses = make(map[*Clause][]*Clause)
+	}
+	a.clauses[learned] = a.copyCurrent()
+}
+
+// saveUnit associates the recorded antecedents with the learned unit clause on v.
+// Learned units are always saved, even when antecedents are not recorded,
+// so that they can be told apart from the problem's own units.
+func (a *antecedents) saveUnit(v Var) {
+	if a.units == nil {
+		a.units = make(map[Var][]*Clause)
+	}
+	if a.enabled {
+		a.units[v] = a.copyCurrent()
+	} else {
+		a.units[v] = nil
+	}
+}
+
+// ExplainLit returns a set of problem clauses and of top-level lits that are sufficient to imply lit,
+// which must be true at the top level, i.e either because of the problem itself or because of the current assumptions.
+// units contains assumptions and unit clauses from the problem.
+// It is typically used to answer questions such as "why is this option forced?".
+// If lit was implied by learned clauses, TrackAntecedents must have been set to true before solving.
+// An error is returned if lit is not true at the top level, or if its explanation needs untracked antecedents.
+func (s *Solver) ExplainLit(lit Lit) (clauses []*Clause, units []Lit, err error) {
+	if v := lit.Var(); int(v) >= s.nbVars || abs(s.model[v]) != 1 || s.litStatus(lit) != Sat {
+		return nil, nil, fmt.Errorf("literal %d is not true at the top level", lit.Int())
+	}
+	seenVars := make(map[Var]bool)
+	seenClauses := make(map[*Clause]bool)
+	var addAntecedents func(ants []*Clause) error
+	addAntecedents = func(ants []*Clause) error {
+		for _, c := range ants {
+			if seenClauses[c] {
+				continue
+			}
+			seenClauses[c] = true
+			if !c.Learned() {
+				clauses = append(clauses, c)
+				continue
+			}
+			ants2, ok := s.antecedents.clauses[c]
+			if !ok {
+				return fmt.Errorf("antecedents of learned clause %s were not tracked", c.CNF())
+			}
+			if err := addAntecedents(ants2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	stack := []Lit{lit}
+	for len(stack) > 0 {
+		l := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		v := l.Var()
+		if seenVars[v] {
+			continue
+		}
+		seenVars[v] = true
+		reason := s.reason[v]
+		if reason == nil {
+			if ants, ok := s.antecedents.units[v]; !ok || s.assumptions[v] {
+				units = append(units, l)
+			} else if ants == nil {
+				return nil, nil, fmt.Errorf("antecedents of learned unit %d were not tracked", l.Int())
+			} else if err := addAntecedents(ants); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if err := addAntecedents([]*Clause{reason}); err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < reason.Len(); i++ { // All false lits of the reason must be explained, too
+			if l2 := reason.Get(i); s.litStatus(l2) == Unsat {
+				stack = append(stack, l2.Negation())
+			}
+		}
+	}
+	return clauses, units, nil
+}
@@ -0,0 +1,43 @@
+package solver
+
+import "testing"
+
+func TestExplainLit(t *testing.T) {
+	s := New(ParseSlice([][]int{{-1, 2}, {-2, 3}, {4, 5}, {-3, -4, 6}, {7, 8}}))
+	if s.Assume(IntsToLits(1, -6)) == Unsat {
+		t.Fatalf("problem should not be unsat")
+	}
+	clauses, units, err := s.ExplainLit(IntToLit(-4))
+	if err != nil {
+		t.Fatalf("could not explain lit: %v", err)
+	}
+	if len(clauses) != 3 || len(units) != 2 {
+		t.Errorf("invalid explanation: got clauses %v and units %v", clauses, units)
+	}
+	if _, _, err := s.ExplainLit(IntToLit(7)); err == nil {
+		t.Errorf("expected an error for a lit that is not implied")
+	}
+}
+
+func TestExplainLearnedLit(t *testing.T) {
+	clauses := [][]int{{1, 2}, {1, -2}, {-1, 3, 4}}
+	s := New(ParseSlice(clauses))
+	s.TrackAntecedents = true
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	expl, units, err := s.ExplainLit(IntToLit(1))
+	if err != nil {
+		t.Fatalf("could not explain lit: %v", err)
+	}
+	if len(expl) != 2 || len(units) != 0 {
+		t.Errorf("invalid explanation: got clauses %v and units %v", expl, units)
+	}
+	s = New(ParseSlice(clauses))
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	if _, _, err := s.ExplainLit(IntToLit(1)); err == nil {
+		t.Errorf("expected an error when antecedents are not tracked")
+	}
+}
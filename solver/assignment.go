@@ -0,0 +1,132 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Value is the binding of a var in a partial assignment.
+type Value byte
+
+const (
+	// Unassigned means the var has no binding.
+	Unassigned = Value(iota)
+	// True means the var is bound to true.
+	True
+	// False means the var is bound to false.
+	False
+)
+
+func (val Value) String() string {
+	switch val {
+	case Unassigned:
+		return "unassigned"
+	case True:
+		return "true"
+	case False:
+		return "false"
+	default:
+		panic("invalid value")
+	}
+}
+
+// An Assignment is a partial assignment of the vars of a problem: each var, in order, is associated with its value.
+type Assignment []Value
+
+// NewAssignment returns an assignment of nbVars vars, all of them unassigned.
+func NewAssignment(nbVars int) Assignment {
+	return make(Assignment, nbVars)
+}
+
+// AssignmentFromLits returns an assignment of nbVars vars where the given lits are true, and the other vars are unassigned.
+func AssignmentFromLits(nbVars int, lits []Lit) Assignment {
+	a := NewAssignment(nbVars)
+	for _, lit := range lits {
+		a.Set(lit)
+	}
+	return a
+}
+
+// Set makes lit true in a.
+func (a Assignment) Set(lit Lit) {
+	if lit.IsPositive() {
+		a[lit.Var()] = True
+	} else {
+		a[lit.Var()] = False
+	}
+}
+
+// LitValue returns the value of lit in a.
+func (a Assignment) LitValue(lit Lit) Value {
+	val := a[lit.Var()]
+	if val == Unassigned || lit.IsPositive() {
+		return val
+	}
+	if val == True {
+		return False
+	}
+	return True
+}
+
+// Lits returns the lits that are true in a, in increasing order of vars.
+func (a Assignment) Lits() []Lit {
+	var res []Lit
+	for v, val := range a {
+		if val != Unassigned {
+			res = append(res, Var(v).SignedLit(val == False))
+		}
+	}
+	return res
+}
+
+func (a Assignment) String() string {
+	lits := a.Lits()
+	strs := make([]string, len(lits))
+	for i, lit := range lits {
+		strs[i] = fmt.Sprintf("%d", lit.Int())
+	}
+	return "[" + strings.Join(strs, " ") + "]"
+}
+
+// Assignment returns the partial assignment equivalent to m.
+func (m Model) Assignment() Assignment {
+	a := NewAssignment(len(m))
+	for v, lvl := range m {
+		if lvl > 0 {
+			a[v] = True
+		} else if lvl < 0 {
+			a[v] = False
+		}
+	}
+	return a
+}
+
+// Propagate assumes the given lits (see Assume) and returns the assignment obtained by unit propagation,
+// without searching any further.
+// Vars that are not bound by propagation are unassigned.
+// If propagation leads to a conflict, nil is returned and Core indicates which lits are responsible for it.
+// As with Assume, lits remain assumed for the following calls to Solve.
+func (s *Solver) Propagate(lits []Lit) Assignment {
+	if s.Assume(lits) == Unsat {
+		return nil
+	}
+	return s.model.Assignment()[:s.nbUserVars]
+}
+
+// WarmStart indicates a, typically a model of a similar problem, is a good starting point for the search:
+// the first decisions made by s on the vars assigned in a will follow a.
+// Afterwards, the solver will prefer the values it assigned most recently, as usual.
+func (s *Solver) WarmStart(a Assignment) {
+	for v, val := range a {
+		if v < s.nbVars && val != Unassigned {
+			s.polarity[v] = val == True
+		}
+	}
+}
+
+// Prefer indicates the preferred values of vars: each time s branches on a var assigned in a,
+// it will first try its value in a. A nil assignment removes all preferences.
+// Contrary to WarmStart, preferences hold during the whole search.
+func (s *Solver) Prefer(a Assignment) {
+	s.preferred = a
+}
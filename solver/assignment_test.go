@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignment(t *testing.T) {
+	a := AssignmentFromLits(4, IntsToLits(1, -3))
+	if a[0] != True || a[1] != Unassigned || a[2] != False {
+		t.Errorf("invalid assignment %v", []Value(a))
+	}
+	if a.LitValue(IntToLit(-1)) != False || a.LitValue(IntToLit(-3)) != True || a.LitValue(IntToLit(2)) != Unassigned {
+		t.Errorf("invalid lit values in %v", a)
+	}
+	if str := a.String(); str != "[1 -3]" {
+		t.Errorf("invalid string representation %q", str)
+	}
+	if a2 := (Model{1, 0, -3, 0}).Assignment(); !reflect.DeepEqual(a, a2) {
+		t.Errorf("invalid assignment from model: expected %v, got %v", a, a2)
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	s := New(ParseSlice([][]int{{-1, 2}, {-2, 3}, {-3, -4}, {4, 5, 6}}))
+	a := s.Propagate(IntsToLits(1))
+	expected := AssignmentFromLits(6, IntsToLits(1, 2, 3, -4))
+	if !reflect.DeepEqual(a, expected) {
+		t.Errorf("invalid propagation: expected %v, got %v", expected, a)
+	}
+	if a := s.Propagate(IntsToLits(1, 4)); a != nil {
+		t.Errorf("expected a conflict, got %v", a)
+	}
+}
+
+func TestPrefer(t *testing.T) {
+	clauses := [][]int{{1, 2, 3}, {-1, -2}}
+	s := New(ParseSlice(clauses))
+	s.Prefer(AssignmentFromLits(3, IntsToLits(-1, 2, -3)))
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	if model := s.Model(); model[0] || !model[1] || model[2] {
+		t.Errorf("preferred values were not followed: got %v", model)
+	}
+	s = New(ParseSlice(clauses))
+	s.WarmStart(AssignmentFromLits(3, IntsToLits(1, -2, -3)))
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	if model := s.Model(); !model[0] || model[1] || model[2] {
+		t.Errorf("warm start was not followed: got %v", model)
+	}
+}
@@ -0,0 +1,59 @@
+package solver
+
+// This file deals with backbones, i.e with the lits that are true in all models of a problem.
+
+// Backbone returns the lits that are true in all models of the problem under the given assumptions,
+// assumptions included, in increasing order of vars. Reserved vars are not part of the backbone.
+// If the problem is unsat under the assumptions, nil is returned.
+// Once the call is over, the assumptions still hold for the following calls to Solve.
+func (s *Solver) Backbone(assumptions []Lit) []Lit {
+	if s.Assume(assumptions) == Unsat || s.Solve() != Sat {
+		return nil
+	}
+	candidates := s.LastModel().Assignment()[:s.nbUserVars]
+	return s.refineBackbone(assumptions, candidates)
+}
+
+// refineBackbone removes from candidates all lits that are not part of the backbone under the given assumptions,
+// and returns the remaining ones.
+// candidates must hold at least all the lits of the backbone, and the problem must be sat under the assumptions.
+func (s *Solver) refineBackbone(assumptions []Lit, candidates Assignment) []Lit {
+	assumptions = assumptions[:len(assumptions):len(assumptions)] // Appending must not modify the caller's slice
+	top := s.Propagate(assumptions)                               // Lits implied at the top level need not be checked
+	for v, val := range candidates {
+		if val == Unassigned || top[v] == val {
+			continue
+		}
+		lit := Var(v).SignedLit(val == False)
+		if s.Assume(append(assumptions, lit.Negation())) == Unsat || s.Solve() == Unsat {
+			continue
+		}
+		model := s.LastModel().Assignment()
+		for v2 := v; v2 < len(candidates); v2++ { // Lits that are false in the new model are not part of the backbone
+			if candidates[v2] != Unassigned && candidates[v2] != model[v2] {
+				candidates[v2] = Unassigned
+			}
+		}
+	}
+	s.Assume(assumptions)
+	return candidates.Lits()
+}
+
+// UpdateBackbone returns the backbone of the problem under the given assumptions, knowing that prev was its backbone
+// before clauses were added to the problem, under the same assumptions or a subset of them.
+// Since adding clauses or assumptions can only remove models, all lits from prev are still part of the backbone
+// and only the other lits need to be checked, which is usually much faster than calling Backbone again.
+// If the problem is now unsat under the assumptions, nil is returned.
+func (s *Solver) UpdateBackbone(prev []Lit, assumptions []Lit) []Lit {
+	known := make([]Lit, 0, len(assumptions)+len(prev))
+	known = append(append(known, assumptions...), prev...)
+	// Assuming lits from prev does not remove any model, but makes checking the other lits faster
+	if s.Assume(known) == Unsat || s.Solve() != Sat {
+		s.Assume(assumptions)
+		return nil
+	}
+	candidates := s.LastModel().Assignment()[:s.nbUserVars]
+	res := s.refineBackbone(known, candidates)
+	s.Assume(assumptions)
+	return res
+}
@@ -0,0 +1,35 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackbone(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {1, -2}, {3, 4}, {-3, 5}, {-4, 5}, {6, 7}}))
+	if bb := s.Backbone(nil); !reflect.DeepEqual(bb, IntsToLits(1, 5)) {
+		t.Errorf("invalid backbone: expected [1 5], got %v", bb)
+	}
+	if bb := s.Backbone(IntsToLits(-6)); !reflect.DeepEqual(bb, IntsToLits(1, 5, -6, 7)) {
+		t.Errorf("invalid backbone under assumptions: expected [1 5 -6 7], got %v", bb)
+	}
+	if bb := s.Backbone(IntsToLits(-5)); bb != nil {
+		t.Errorf("expected nil backbone for unsat assumptions, got %v", bb)
+	}
+}
+
+func TestUpdateBackbone(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {1, -2}, {3, 4}, {-3, 5}, {-4, 5}, {6, 7}}))
+	bb := s.Backbone(nil)
+	s.AppendClause(NewClause(IntsToLits(-6, -5)))
+	if bb = s.UpdateBackbone(bb, nil); !reflect.DeepEqual(bb, IntsToLits(1, 5, -6, 7)) {
+		t.Errorf("invalid updated backbone: expected [1 5 -6 7], got %v", bb)
+	}
+	s.AppendClause(NewClause(IntsToLits(-7, 3)))
+	if bb = s.UpdateBackbone(bb, nil); !reflect.DeepEqual(bb, IntsToLits(1, 3, 5, -6, 7)) {
+		t.Errorf("invalid updated backbone: expected [1 3 5 -6 7], got %v", bb)
+	}
+	if bb = s.UpdateBackbone(bb, IntsToLits(4)); !reflect.DeepEqual(bb, IntsToLits(1, 3, 4, 5, -6, 7)) {
+		t.Errorf("invalid updated backbone under assumptions: expected [1 3 4 5 -6 7], got %v", bb)
+	}
+}
@@ -0,0 +1,90 @@
+package solver
+
+// This file deals with the transitive reduction of the binary implication graph.
+// Each binary clause (a ∨ b) is a pair of implications ¬a → b and ¬b → a. When b can be reached from ¬a
+// through other binary clauses, the clause is redundant: unit propagation finds its implications anyway.
+// On problems with lots of binary clauses, such as problems with naive at-most-one encodings,
+// removing redundant clauses from the watch lists makes propagation significantly faster.
+// Redundant clauses are still part of the problem: they are just not watched anymore.
+
+const (
+	binReductionInterval = 200     // How many restarts between two transitive reductions.
+	binReductionSteps    = 1 << 20 // Max # of implications traversed during a single transitive reduction.
+	binSearchSteps       = 1000    // Max # of implications traversed when looking for an alternative path.
+)
+
+// reduceBinaries removes redundant binary clauses from the watch lists,
+// unless no binary clause was learned since the last call. It must only be called at the top level.
+// It returns how many clauses were removed.
+func (s *Solver) reduceBinaries() int {
+	if s.binReductionMark == s.Stats.NbBinaryLearned+1 {
+		return 0
+	}
+	s.binReductionMark = s.Stats.NbBinaryLearned + 1
+	seen := make([]bool, 2*s.nbVars)
+	nbRemoved := 0
+	steps := 0
+	for lit := range s.wl.wlistBin {
+		from := Lit(lit)
+		for i := 0; i < len(s.wl.wlistBin[from]) && steps < binReductionSteps; i++ {
+			w := s.wl.wlistBin[from][i]
+			if w.clause.First().Negation() != from { // Each clause is checked once, from its first lit
+				continue
+			}
+			reached, nbSteps := s.binReachable(from, w.other, w.clause, seen)
+			steps += nbSteps
+			if reached {
+				s.unwatchBinary(w.clause)
+				nbRemoved++
+				i-- // Another watcher was moved at index i
+			}
+		}
+	}
+	s.Stats.NbBinaryReduced += nbRemoved
+	return nbRemoved
+}
+
+// binReachable returns true iff to can be reached from from in the binary implication graph, without using skip.
+// It gives up after binSearchSteps implications were traversed. It also returns the number of traversed implications.
+// seen is a buffer with one false value per lit, and is reset when the function returns.
+func (s *Solver) binReachable(from, to Lit, skip *Clause, seen []bool) (reached bool, steps int) {
+	stack := []Lit{from}
+	visited := []Lit{from}
+	seen[from] = true
+	for len(stack) > 0 && !reached && steps < binSearchSteps {
+		lit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, w := range s.wl.wlistBin[lit] {
+			steps++
+			if w.clause == skip || seen[w.other] {
+				continue
+			}
+			if w.other == to {
+				reached = true
+				break
+			}
+			seen[w.other] = true
+			visited = append(visited, w.other)
+			stack = append(stack, w.other)
+		}
+	}
+	for _, lit := range visited {
+		seen[lit] = false
+	}
+	return reached, steps
+}
+
+// unwatchBinary removes the binary clause c from the watch lists.
+func (s *Solver) unwatchBinary(c *Clause) {
+	for i := 0; i < 2; i++ {
+		neg := c.Get(i).Negation()
+		lst := s.wl.wlistBin[neg]
+		j := 0
+		for lst[j].clause != c {
+			j++
+		}
+		last := len(lst) - 1
+		lst[j] = lst[last]
+		s.wl.wlistBin[neg] = lst[:last]
+	}
+}
@@ -0,0 +1,52 @@
+package solver
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestReduceBinaries(t *testing.T) {
+	// 1 -> 2 -> 3 makes 1 -> 3 redundant
+	s := New(ParseSlice([][]int{{-1, 2}, {-2, 3}, {-1, 3}, {-3, 4, 5}}))
+	if nb := s.reduceBinaries(); nb != 1 {
+		t.Fatalf("expected 1 redundant binary clause, got %d", nb)
+	}
+	if nb := s.reduceBinaries(); nb != 0 {
+		t.Errorf("expected no reduction when no binary clause was learned, got %d", nb)
+	}
+	for _, w := range s.wl.wlistBin[IntToLit(1)] {
+		if w.other == IntToLit(3) {
+			t.Errorf("redundant clause %s is still watched", w.clause.CNF())
+		}
+	}
+	if nb := s.CountModels(); nb != 13 {
+		t.Errorf("expected 13 models after reduction, got %d", nb)
+	}
+}
+
+func TestReduceBinariesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < 20; i++ {
+		var clauses [][]int
+		for j := 0; j < 30; j++ {
+			a, b := rng.Intn(10)+1, rng.Intn(10)+1
+			if a == b {
+				continue
+			}
+			if rng.Intn(2) == 0 {
+				a = -a
+			}
+			if rng.Intn(2) == 0 {
+				b = -b
+			}
+			clauses = append(clauses, []int{a, b})
+		}
+		clauses = append(clauses, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+		expected := New(ParseSlice(clauses)).CountModels()
+		s := New(ParseSlice(clauses))
+		s.reduceBinaries()
+		if nb := s.CountModels(); nb != expected {
+			t.Errorf("problem #%d: expected %d models after reduction, got %d", i, expected, nb)
+		}
+	}
+}
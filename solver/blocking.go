@@ -0,0 +1,158 @@
+package solver
+
+import "fmt"
+
+// This file deals with enumeration strategies based on blocking clauses: each time a model is found,
+// a clause preventing it from being found again is added to the problem.
+// Depending on the strategy, the blocked assignment is the whole model, its projection on a set of vars,
+// or a prime implicant of the problem, i.e a minimal partial assignment whose extensions are all models.
+
+// A BlockingStrategy indicates what part of a model is blocked once it was found.
+type BlockingStrategy byte
+
+const (
+	// BlockModel blocks the whole model: each model is enumerated exactly once.
+	BlockModel = BlockingStrategy(iota)
+	// BlockProjection blocks the binding of the projection vars only: each projection
+	// of the models on these vars is enumerated exactly once.
+	BlockProjection
+	// BlockImplicant reduces the model to a prime implicant before blocking it: each enumerated
+	// assignment represents all the models extending it. There are fewer assignments than models,
+	// but computing them is more expensive. The enumerated assignments never overlap.
+	BlockImplicant
+)
+
+// EnumerateOptions are the options of EnumerateAssignments.
+type EnumerateOptions struct {
+	Blocking   BlockingStrategy // How models are blocked
+	Projection []Var            // Vars models are projected on, when Blocking is BlockProjection
+}
+
+// Validate returns an error if opts are inconsistent: an unknown blocking strategy,
+// projection vars given to another strategy than BlockProjection, or negative projection vars.
+func (opts EnumerateOptions) Validate() error {
+	if opts.Blocking > BlockImplicant {
+		return fmt.Errorf("invalid enumerate options: unknown blocking strategy %d", opts.Blocking)
+	}
+	if opts.Blocking != BlockProjection && len(opts.Projection) != 0 {
+		return fmt.Errorf("invalid enumerate options: projection vars are only used by BlockProjection")
+	}
+	for _, v := range opts.Projection {
+		if v < 0 {
+			return fmt.Errorf("invalid enumerate options: invalid projection var %d", v)
+		}
+	}
+	return nil
+}
+
+// EnumerateAssignments enumerates the models of the problem according to the given options,
+// sends them on results as assignments of the problem's vars, and returns their number.
+// Contrary to Enumerate, a clause is added for each enumerated assignment
+// and the problem is unsat after the call, so s cannot be used to solve anything else.
+// results, if non-nil, will be closed at the end of the call.
+// If data is sent on stop or if the solver's budget is exhausted, enumeration stops
+// and the number of assignments found so far is returned.
+func (s *Solver) EnumerateAssignments(opts EnumerateOptions, results chan Assignment, stop chan struct{}) int {
+	if results != nil {
+		defer close(results)
+	}
+	s.stop = stop
+	defer func() { s.stop = nil }()
+	nb := 0
+	for s.Solve() == Sat {
+		var a Assignment
+		switch opts.Blocking {
+		case BlockModel:
+			a = s.fullAssignment()[:s.nbUserVars]
+		case BlockProjection:
+			model := s.fullAssignment()
+			a = NewAssignment(s.nbUserVars)
+			for _, v := range opts.Projection {
+				a[v] = model[v]
+			}
+		case BlockImplicant:
+			a = s.primeImplicant(s.fullAssignment())[:s.nbUserVars]
+		default:
+			panic("invalid blocking strategy")
+		}
+		if results != nil {
+			results <- a
+		}
+		nb++
+		lits := a.Lits()
+		for i, lit := range lits {
+			lits[i] = lit.Negation()
+		}
+		s.AppendClause(NewClause(lits))
+	}
+	return nb
+}
+
+// fullAssignment returns the last model as an assignment where all vars are bound.
+// Vars whose binding did not matter are bound to false.
+func (s *Solver) fullAssignment() Assignment {
+	a := s.lastModel.Assignment()
+	for v, val := range a {
+		if val == Unassigned {
+			a[v] = False
+		}
+	}
+	return a
+}
+
+// primeImplicant unbinds as many vars as possible from the total assignment model, so that all problem clauses,
+// including blocking clauses, are still satisfied by all extensions of the result.
+// Vars bound at the top level, and reserved vars, are never unbound.
+func (s *Solver) primeImplicant(model Assignment) Assignment {
+	slack := make([]int, len(s.wl.pbClauses)) // For each clause, weight of true lits minus cardinality
+	occurs := make(map[Lit][]int)             // For each lit, indices of clauses where it appears
+	for i, c := range s.wl.pbClauses {
+		if c.isBig() { // Its true lits are never unbound: a negative slack tells them apart
+			slack[i] = -1
+			for j := 0; j < c.Len(); j++ {
+				if lit := c.Get(j); model.LitValue(lit) == True {
+					occurs[lit] = append(occurs[lit], i)
+				}
+			}
+			continue
+		}
+		slack[i] = -c.Cardinality()
+		for j := 0; j < c.Len(); j++ {
+			lit := c.Get(j)
+			if model.LitValue(lit) == True {
+				slack[i] += c.Weight(j)
+				occurs[lit] = append(occurs[lit], i)
+			}
+		}
+	}
+	for v := 0; v < s.nbUserVars; v++ {
+		if abs(s.lastModel[v]) == 1 {
+			continue
+		}
+		lit := Var(v).SignedLit(model[v] == False)
+		removable := true
+		for _, i := range occurs[lit] {
+			if slack[i] < 0 || slack[i] < s.wl.pbClauses[i].weightOf(lit) {
+				removable = false
+				break
+			}
+		}
+		if removable {
+			for _, i := range occurs[lit] {
+				slack[i] -= s.wl.pbClauses[i].weightOf(lit)
+			}
+			model[v] = Unassigned
+		}
+	}
+	return model
+}
+
+// weightOf returns the weight of lit in c, which must contain it.
+func (c *Clause) weightOf(lit Lit) int {
+	for i := 0; i < c.Len(); i++ {
+		if c.Get(i) == lit {
+			return c.Weight(i)
+		}
+	}
+	panic("lit does not appear in clause")
+}
@@ -0,0 +1,91 @@
+package solver
+
+import "testing"
+
+// nbExtensions returns the number of total assignments of nbVars vars extending a.
+func nbExtensions(a Assignment) int {
+	nb := 1
+	for _, val := range a {
+		if val == Unassigned {
+			nb *= 2
+		}
+	}
+	return nb
+}
+
+func TestEnumerateAssignments(t *testing.T) {
+	cnf := [][]int{{1, 2}, {3, 4}}
+	tests := []struct {
+		opts     EnumerateOptions
+		nbAssign int // Expected number of assignments
+		nbModels int // Expected number of models they represent
+	}{
+		{EnumerateOptions{Blocking: BlockModel}, 9, 9},
+		{EnumerateOptions{Blocking: BlockProjection, Projection: []Var{0, 1}}, 3, 12},
+		{EnumerateOptions{Blocking: BlockImplicant}, -1, 9},
+	}
+	for _, test := range tests {
+		results := make(chan Assignment)
+		go New(ParseSlice(cnf)).EnumerateAssignments(test.opts, results, nil)
+		nbAssign, nbModels := 0, 0
+		seen := make(map[string]bool)
+		for a := range results {
+			nbAssign++
+			nbModels += nbExtensions(a)
+			if seen[a.String()] {
+				t.Errorf("strategy %d: assignment %v found twice", test.opts.Blocking, a)
+			}
+			seen[a.String()] = true
+		}
+		if test.nbAssign != -1 && nbAssign != test.nbAssign {
+			t.Errorf("strategy %d: expected %d assignments, got %d", test.opts.Blocking, test.nbAssign, nbAssign)
+		}
+		if nbModels != test.nbModels {
+			t.Errorf("strategy %d: expected %d models, got %d", test.opts.Blocking, test.nbModels, nbModels)
+		}
+		if test.opts.Blocking == BlockImplicant && nbAssign >= 9 {
+			t.Errorf("prime implicants should be fewer than models, got %d", nbAssign)
+		}
+	}
+}
+
+func TestEnumerateImplicantsCard(t *testing.T) {
+	pb := ParsePBConstrs([]PBConstr{AtLeast([]int{1, 2, 3}, 2), AtLeast([]int{-1, 4}, 1)})
+	results := make(chan Assignment)
+	go New(pb).EnumerateAssignments(EnumerateOptions{Blocking: BlockImplicant}, results, nil)
+	nbModels := 0
+	for a := range results {
+		nbModels += nbExtensions(a)
+		if a.LitValue(IntToLit(1)) == True && a.LitValue(IntToLit(4)) != True {
+			t.Errorf("implicant %v has a non-model extension", a)
+		}
+		nbTrue := 0
+		for _, val := range a[:3] {
+			if val == True {
+				nbTrue++
+			}
+		}
+		if nbTrue < 2 {
+			t.Errorf("implicant %v does not satisfy cardinality constraint", a)
+		}
+	}
+	// Models of the card constraint: 110, 101, 011, 111; 4 must be true when 1 is.
+	if expected := 5; nbModels != expected {
+		t.Errorf("expected %d models, got %d", expected, nbModels)
+	}
+}
+
+func TestEnumerateOptionsValidate(t *testing.T) {
+	valid := []EnumerateOptions{{}, {Blocking: BlockProjection, Projection: []Var{0, 2}}, {Blocking: BlockImplicant}}
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("unexpected error with options %+v: %v", opts, err)
+		}
+	}
+	invalid := []EnumerateOptions{{Blocking: BlockImplicant + 1}, {Projection: []Var{0}}, {Blocking: BlockProjection, Projection: []Var{-1}}}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected an error with options %+v", opts)
+		}
+	}
+}
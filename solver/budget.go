@@ -0,0 +1,48 @@
+package solver
+
+import "time"
+
+// This file deals with the limits put on the resources used by the solver.
+
+// budgetExhausted returns true iff the solver must stop searching, either because its deadline is passed,
+// because it reached its maximum number of conflicts, or because it was asked to stop, either on its stop channel
+// or by its Terminate function.
+func (s *Solver) budgetExhausted() bool {
+	if s.stopped {
+		return true
+	}
+	if s.MaxConflicts > 0 && s.Stats.NbConflicts >= s.MaxConflicts {
+		return true
+	}
+	if !s.Deadline.IsZero() && time.Now().After(s.Deadline) {
+		return true
+	}
+	if s.Terminate != nil && s.Terminate() {
+		return true
+	}
+	if s.stop != nil {
+		select {
+		case <-s.stop:
+			s.stopped = true
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// lowerBound returns a lower bound on the cost of any model of the problem,
+// i.e the cost of the lits from the optimization function that are true at the top level.
+func (s *Solver) lowerBound() int {
+	bound := 0
+	for i, lit := range s.minLits {
+		if abs(s.model[lit.Var()]) == 1 && s.litStatus(lit) == Sat {
+			if s.minWeights == nil {
+				bound++
+			} else {
+				bound += s.minWeights[i]
+			}
+		}
+	}
+	return bound
+}
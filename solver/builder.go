@@ -0,0 +1,118 @@
+package solver
+
+import "fmt"
+
+// This file deals with the construction of pure SAT problems, one clause at a time.
+// Huge problems can thus be streamed from their source, e.g from the network, chunk by chunk,
+// without being materialized first as a [][]int or as a file.
+
+// A ProblemBuilder builds a pure SAT problem from clauses added one at a time.
+// Its zero value is not usable: use NewProblemBuilder.
+type ProblemBuilder struct {
+	pb    *Problem
+	stats ParseStats
+	seen  []int // For each var, the number of the last clause it appeared in, negated if it appeared negatively
+}
+
+// NewProblemBuilder returns a builder for a problem whose memory is preallocated according to hint.
+func NewProblemBuilder(hint SizeHint) *ProblemBuilder {
+	return &ProblemBuilder{pb: newHintedProblem(hint)}
+}
+
+// AddClause adds the clause made of the given DIMACS lits to the problem.
+// lits can be reused once AddClause returned. AddClause panics if one of the lits is 0.
+// Duplicate lits are removed, and a clause containing both a lit and its negation is ignored, since it is always satisfied.
+// Once an empty clause was added, the problem is Unsat and the following clauses are ignored.
+func (b *ProblemBuilder) AddClause(lits []int) {
+	if b.pb.Status == Unsat {
+		return
+	}
+	pb := b.pb
+	b.stats.NbConstrs++
+	switch len(lits) {
+	case 0:
+		pb.Status = Unsat
+	case 1:
+		if lits[0] == 0 {
+			panic("null unit clause")
+		}
+		b.addUnit(IntToLit(int32(lits[0])))
+	default:
+		clause := make([]Lit, len(lits))
+		for j, val := range lits {
+			if val == 0 {
+				panic(fmt.Sprintf("null literal in clause %v", clause))
+			}
+			clause[j] = IntToLit(int32(val))
+			if v := int(clause[j].Var()); v >= pb.NbVars {
+				pb.NbVars = v + 1
+			}
+		}
+		if clause = b.normalize(clause); clause == nil {
+			return
+		}
+		if len(clause) == 1 {
+			b.addUnit(clause[0])
+		} else {
+			b.stats.appendClause(pb, NewClause(clause))
+		}
+	}
+}
+
+// normalize removes duplicate lits from the lits of the last clause, and returns the remaining ones.
+// It returns nil if the clause contains both a lit and its negation.
+func (b *ProblemBuilder) normalize(clause []Lit) []Lit {
+	if len(b.seen) < b.pb.NbVars {
+		b.seen = append(b.seen, make([]int, b.pb.NbVars-len(b.seen))...)
+	}
+	j := 0
+	for _, lit := range clause {
+		mark := b.stats.NbConstrs
+		if !lit.IsPositive() {
+			mark = -mark
+		}
+		switch b.seen[lit.Var()] {
+		case -mark:
+			b.stats.NbTautologies++
+			return nil
+		case mark:
+			b.stats.NbDuplicates++
+			continue
+		}
+		b.seen[lit.Var()] = mark
+		clause[j] = lit
+		j++
+	}
+	return clause[:j]
+}
+
+// AddUnit adds the unit clause made of the given DIMACS lit to the problem. It panics if lit is 0.
+func (b *ProblemBuilder) AddUnit(lit int) {
+	if b.pb.Status == Unsat {
+		return
+	}
+	if lit == 0 {
+		panic("null unit clause")
+	}
+	b.stats.NbConstrs++
+	b.addUnit(IntToLit(int32(lit)))
+}
+
+// addUnit adds lit, implied by the last constraint, to the units of the problem.
+func (b *ProblemBuilder) addUnit(lit Lit) {
+	if v := int(lit.Var()); v >= b.pb.NbVars {
+		b.pb.NbVars = v + 1
+	}
+	b.stats.appendUnit(b.pb, lit, b.stats.NbConstrs-1)
+}
+
+// Problem simplifies and returns the problem made of the clauses added so far, along with statistics about its construction.
+// The builder must not be used afterwards.
+func (b *ProblemBuilder) Problem() (*Problem, ParseStats) {
+	pb := b.pb
+	if pb.Status != Unsat && pb.bindUnits() {
+		pb.simplify2()
+	}
+	b.pb = nil
+	return pb, b.stats
+}
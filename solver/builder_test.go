@@ -0,0 +1,43 @@
+package solver
+
+import (
+	"testing"
+)
+
+func TestProblemBuilder(t *testing.T) {
+	cnf := [][]int{{1, 2, 3}, {-1, -2}, {-3}, {2, 4}}
+	b := NewProblemBuilder(SizeHint{NbVars: 5})
+	clause := make([]int, 0, 3)
+	for _, c := range cnf[:2] {
+		clause = append(clause[:0], c...) // Clauses can be reused
+		b.AddClause(clause)
+	}
+	b.AddUnit(-3)
+	b.AddClause(cnf[3])
+	pb, stats := b.Problem()
+	expected := ParseSliceNb(cnf, 5)
+	if pb.CNF() != expected.CNF() {
+		t.Errorf("expected problem %q, got %q", expected.CNF(), pb.CNF())
+	}
+	if stats.NbConstrs != 4 || stats.NbUnits != 1 || stats.NbClauses != 3 {
+		t.Errorf("invalid stats %+v", stats)
+	}
+	b = NewProblemBuilder(SizeHint{})
+	b.AddClause([]int{1, 2})
+	b.AddClause(nil)
+	b.AddClause([]int{3, 4})
+	if pb, stats := b.Problem(); pb.Status != Unsat || stats.NbConstrs != 2 {
+		t.Errorf("expected unsat problem after 2 constraints, got %v after %d constraints", pb.Status, stats.NbConstrs)
+	}
+}
+
+func TestProblemBuilderNormalize(t *testing.T) {
+	pb, stats := ParseSliceHint([][]int{{1, 2, 1}, {-1, 3, 1}, {3, 3}, {2, -3, 2, -3}, {-2, 1, -2, 2}}, SizeHint{})
+	const expected = "p cnf 3 2\n3 0\n2 0\n" // Once simplified by the unit 3
+	if pb.CNF() != expected {
+		t.Errorf("expected problem %q, got %q", expected, pb.CNF())
+	}
+	if stats.NbConstrs != 5 || stats.NbTautologies != 2 || stats.NbDuplicates != 5 || stats.NbClauses != 2 || stats.NbUnits != 1 {
+		t.Errorf("invalid stats %+v", stats)
+	}
+}
@@ -0,0 +1,50 @@
+package solver
+
+import "strings"
+
+// Features describes which optional features of the solver are available.
+type Features struct {
+	Proofs       bool // RUP certificates of unsatisfiability, through Certified and CertChan or CertFile
+	PB           bool // Pseudo-boolean constraints, parsed from OPB files or given as PBConstr
+	Optimization bool // Minimization of a cost function, through Optimal and Minimize
+	Counting     bool // Model counting without enumeration, through CountModelsTD; left out by the nocount build tag
+	Incremental  bool // Incremental solving, through IncrementalSolver and assumptions
+	Server       bool // Text and JSON-RPC protocols, through Serve and ServeJSON; left out by the noserver build tag
+	Parallel     bool // Work split between goroutines, in ParseCNFParallel and DisjointCores; left out by the noparallel build tag
+}
+
+// Capabilities returns the features available in this build of the solver.
+// Some of them depend on the build tags the solver was compiled with.
+func Capabilities() Features {
+	return Features{
+		Proofs:       true,
+		PB:           true,
+		Optimization: true,
+		Counting:     hasCount,
+		Incremental:  true,
+		Server:       hasServer,
+		Parallel:     hasParallel,
+	}
+}
+
+// String returns the names of the available features, separated by commas.
+func (f Features) String() string {
+	var names []string
+	for _, feat := range []struct {
+		name string
+		ok   bool
+	}{
+		{"proofs", f.Proofs},
+		{"pb", f.PB},
+		{"optimization", f.Optimization},
+		{"counting", f.Counting},
+		{"incremental", f.Incremental},
+		{"server", f.Server},
+		{"parallel", f.Parallel},
+	} {
+		if feat.ok {
+			names = append(names, feat.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
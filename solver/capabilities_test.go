@@ -0,0 +1,16 @@
+package solver
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	f := Capabilities()
+	if !f.PB || !f.Proofs {
+		t.Errorf("expected PB and proofs to be available, got %+v", f)
+	}
+	if f.Server != hasServer || f.Counting != hasCount || f.Parallel != hasParallel {
+		t.Errorf("expected features to match the build tags, got %+v", f)
+	}
+	if str := (Features{PB: true, Counting: true}).String(); str != "pb,counting" {
+		t.Errorf("expected \"pb,counting\", got %q", str)
+	}
+}
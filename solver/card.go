@@ -15,14 +15,31 @@ func AtLeast1(lits ...int) CardConstr {
 
 // AtMost1 returns a cardinality constraint stating that at most one of the given lits can be true.
 func AtMost1(lits ...int) CardConstr {
+	return CardAtMost(lits, 1)
+}
+
+// Exactly1 returns two cardinality constraints stating that exactly one of the given lits must be true.
+func Exactly1(lits ...int) []CardConstr {
+	return []CardConstr{AtLeast1(lits...), AtMost1(lits...)}
+}
+
+// CardAtLeast returns a cardinality constraint stating that at least n of the given lits must be true.
+func CardAtLeast(lits []int, n int) CardConstr {
+	return CardConstr{Lits: lits, AtLeast: n}
+}
+
+// CardAtMost returns a cardinality constraint stating that at most n of the given lits can be true.
+// As at most n lits are true iff at least len(lits)-n of their negations are true,
+// the returned constraint is made of the negation of lits. lits is not modified.
+func CardAtMost(lits []int, n int) CardConstr {
 	negated := make([]int, len(lits))
 	for i, lit := range lits {
 		negated[i] = -lit
 	}
-	return CardConstr{Lits: negated, AtLeast: len(lits) - 1}
+	return CardConstr{Lits: negated, AtLeast: len(lits) - n}
 }
 
-// Exactly1 returns two cardinality constraints stating that exactly one of the given lits must be true.
-func Exactly1(lits ...int) []CardConstr {
-	return []CardConstr{AtLeast1(lits...), AtMost1(lits...)}
+// CardExactly returns two cardinality constraints stating that exactly n of the given lits must be true.
+func CardExactly(lits []int, n int) []CardConstr {
+	return []CardConstr{CardAtLeast(lits, n), CardAtMost(lits, n)}
 }
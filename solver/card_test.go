@@ -48,3 +48,24 @@ func TestExactly1(t *testing.T) {
 		}
 	}
 }
+
+func TestAtMostExactly(t *testing.T) {
+	tests := []struct {
+		constrs  []CardConstr
+		nbModels int
+	}{
+		{[]CardConstr{CardAtLeast([]int{1, 2, 3}, 2)}, 4},
+		{[]CardConstr{CardAtMost([]int{1, 2, 3}, 1)}, 4},
+		{[]CardConstr{CardAtMost([]int{1, 2, 3}, 2)}, 7},
+		{[]CardConstr{CardAtMost([]int{1, 2, 3}, 0)}, 1},
+		{[]CardConstr{CardAtMost([]int{1, 2, 3}, 3), AtLeast1(1, 2, 3)}, 7},
+		{CardExactly([]int{1, 2, 3, 4}, 2), 6},
+		{CardExactly([]int{1, -2, 3}, 1), 3},
+	}
+	for i, test := range tests {
+		s := New(ParseCardConstrs(test.constrs))
+		if nb := s.CountModels(); nb != test.nbModels {
+			t.Errorf("test #%d: expected %d models for %v, got %d", i, test.nbModels, test.constrs, nb)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// This file deals with the generation of RUP certificates, i.e of the list of lemmas learned by the solver
+// when proving a problem is UNSAT.
+// If CertComments is true, lemmas are annotated with comments, that checkers ignore:
+//
+//	c lbd 2
+//	1 -3 0
+//	c deleted 4 5 -6 0 lbd 7 uses 0
+//	c kept 1 -3 0 lbd 2 uses 12
+//	0
+//
+// Each lemma is preceded by its LBD at the time it was learned.
+// Lemmas removed from the solver's database are reported with their final LBD and the number of times
+// they were used during conflict analysis, and so are the lemmas that were still kept when the empty clause was found.
+
+// certify writes line on the certificate.
+func (s *Solver) certify(line string) {
+	if s.certOut != nil {
+		s.certOut.WriteString(line)
+		s.certOut.WriteByte('\n')
+	} else if s.CertChan == nil {
+		fmt.Printf("%s\n", line)
+	} else {
+		s.CertChan <- line
+	}
+}
+
+// certifyLemma writes the learned clause c on the certificate.
+func (s *Solver) certifyLemma(c *Clause) {
+	if s.CertComments {
+		s.certify(fmt.Sprintf("c lbd %d", c.lbd()))
+	}
+	s.certify(c.CNF())
+}
+
+// certifyUnit writes the learned unit clause on the certificate.
+func (s *Solver) certifyUnit(unit Lit) {
+	if s.CertComments {
+		s.certify("c lbd 1")
+	}
+	s.certify(fmt.Sprintf("%d 0", unit.Int()))
+}
+
+// countLemmaUse indicates c is used during conflict analysis.
+func (s *Solver) countLemmaUse(c *Clause) {
+	if !s.Certified || !s.CertComments || !c.Learned() {
+		return
+	}
+	if s.lemmaUses == nil {
+		s.lemmaUses = make(map[*Clause]int)
+	}
+	s.lemmaUses[c]++
+}
+
+// certifyLemmaStats writes a comment describing the learned clause c, which is either being deleted or kept.
+func (s *Solver) certifyLemmaStats(c *Clause, kind string) {
+	s.certify(fmt.Sprintf("c %s %s lbd %d uses %d", kind, c.CNF(), c.lbd(), s.lemmaUses[c]))
+	delete(s.lemmaUses, c)
+}
+
+// certifyEmpty writes the empty clause on the certificate, once the problem was proved UNSAT.
+func (s *Solver) certifyEmpty() {
+	if s.CertComments {
+		for _, c := range s.wl.learned {
+			s.certifyLemmaStats(c, "kept")
+		}
+	}
+	s.certify("0")
+}
+
+// openCert opens s.CertFile, so that the certificate of the current call to Solve is written to it.
+// The file is truncated if it was not written to before, so that it holds the certificates of all the calls to Solve.
+func (s *Solver) openCert() error {
+	flags := os.O_WRONLY | os.O_APPEND
+	if s.certPath != s.CertFile {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		s.certErr = nil
+	}
+	f, err := os.OpenFile(s.CertFile, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open certificate file: %w", err)
+	}
+	s.certFile, s.certOut, s.certPath = f, bufio.NewWriter(f), s.CertFile
+	return nil
+}
+
+// closeCert flushes and closes the certificate file opened by openCert.
+// If the certificate could not be written, the error is recorded, so that it is returned by s.Err.
+func (s *Solver) closeCert() {
+	err := s.certOut.Flush()
+	if cerr := s.certFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil && s.certErr == nil {
+		s.certErr = fmt.Errorf("could not write certificate file: %w", err)
+	}
+	s.certFile, s.certOut = nil, nil
+}
@@ -0,0 +1,67 @@
+package solver
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pigeons returns the CNF problem stating that n+1 pigeons fit in n holes.
+func pigeons(n int) [][]int {
+	var cnf [][]int
+	v := func(p, h int) int { return p*n + h + 1 }
+	for p := 0; p <= n; p++ {
+		clause := make([]int, n)
+		for h := range clause {
+			clause[h] = v(p, h)
+		}
+		cnf = append(cnf, clause)
+	}
+	for h := 0; h < n; h++ {
+		for p1 := 0; p1 <= n; p1++ {
+			for p2 := p1 + 1; p2 <= n; p2++ {
+				cnf = append(cnf, []int{-v(p1, h), -v(p2, h)})
+			}
+		}
+	}
+	return cnf
+}
+
+func TestCertComments(t *testing.T) {
+	s := New(ParseSlice(pigeons(5)))
+	s.Certified = true
+	s.CertComments = true
+	s.CertChan = make(chan string)
+	go func() {
+		s.Solve()
+		close(s.CertChan)
+	}()
+	var lines []string
+	for line := range s.CertChan {
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 || lines[len(lines)-1] != "0" {
+		t.Fatalf("certificate does not end with the empty clause")
+	}
+	nbLemmas, nbUses := 0, 0
+	for i, line := range lines[:len(lines)-1] {
+		fields := strings.Fields(line)
+		if fields[0] != "c" {
+			nbLemmas++
+			if i == 0 || !strings.HasPrefix(lines[i-1], "c lbd ") {
+				t.Errorf("lemma %q is not preceded by its LBD", line)
+			}
+			continue
+		}
+		if fields[1] == "kept" || fields[1] == "deleted" {
+			uses, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || fields[len(fields)-2] != "uses" {
+				t.Errorf("invalid lemma stats %q", line)
+			}
+			nbUses += uses
+		}
+	}
+	if nbLemmas == 0 || nbUses == 0 {
+		t.Errorf("expected lemmas and uses, got %d lemmas used %d times", nbLemmas, nbUses)
+	}
+}
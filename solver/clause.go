@@ -10,6 +10,8 @@ import (
 type pbData struct {
 	weights []int  // weight of each literal. If nil, weights are all 1.
 	watched []bool // indices of watched literals.
+	card    int    // Minimal cardinality, if it is bigger than maxCardinality and cannot be stored in lbdValue; 0 otherwise.
+	big     *bigPB // Weights and cardinality, if they do not fit in ints; weights is nil then.
 }
 
 // A Clause is a list of Lit, associated with possible data (for learned clauses).
@@ -18,7 +20,9 @@ type Clause struct {
 	// lbdValue's bits are as follow:
 	// leftmost bit: learned flag.
 	// second bit: locked flag (if learned).
-	// last 30 bits: LBD value (if learned) or minimal cardinality - 1 (if !learned).
+	// third bit: deleted flag (if learned).
+	// last 29 bits: LBD value (if learned).
+	// last 30 bits: minimal cardinality - 1 (if !learned), unless it is stored in pbData.
 	// NOTE: actual cardinality is value + 1, since this is the default value and go defaults to 0.
 	lbdValue uint32
 	activity float32
@@ -29,6 +33,8 @@ const (
 	learnedMask uint32 = 1 << 31
 	lockedMask  uint32 = 1 << 30
 	bothMasks   uint32 = learnedMask | lockedMask
+	deletedMask uint32 = 1 << 29 // Only used by learned clauses
+	allMasks    uint32 = bothMasks | deletedMask
 )
 
 // NewClause returns a clause whose lits are given as an argument.
@@ -62,10 +68,39 @@ func (wl *weightedLits) Swap(i, j int) {
 }
 
 // NewPBClause returns a pseudo-boolean clause with the given lits, weights and minimal cardinality.
+// Will panic if card is not strictly positive, or if the weights or card are too big to be handled by the solver:
+// use NewPBClauseErr on untrusted input.
 func NewPBClause(lits []Lit, weights []int, card int) *Clause {
 	if card < 1 {
 		panic("Invalid cardinality value")
 	}
+	c, err := NewPBClauseErr(lits, weights, card)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewPBClauseErr is like NewPBClause, but it returns an error instead of panicking.
+// If the weights or card are too big, i.e if summing them during propagation could overflow,
+// the error wraps ErrOverflow.
+func NewPBClauseErr(lits []Lit, weights []int, card int) (*Clause, error) {
+	if card < 1 {
+		return nil, fmt.Errorf("invalid cardinality value %d", card)
+	}
+	if card > maxWeightSum {
+		return nil, fmt.Errorf("%w: cardinality %d is bigger than %d", ErrOverflow, card, maxWeightSum)
+	}
+	sum := 0
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("invalid negative weight %d", w)
+		}
+		if w > maxWeightSum-sum {
+			return nil, fmt.Errorf("%w: sum of weights is bigger than %d", ErrOverflow, maxWeightSum)
+		}
+		sum += w
+	}
 	wl := &weightedLits{lits: lits, weights: weights}
 	sort.Sort(wl)
 	pbData := pbData{weights: weights, watched: make([]bool, len(lits))}
@@ -75,7 +110,11 @@ func NewPBClause(lits []Lit, weights []int, card int) *Clause {
 			pbData.weights[i] = 1
 		}
 	}
-	return &Clause{lits: lits, lbdValue: uint32(card - 1), pbData: &pbData}
+	if card > maxCardinality {
+		pbData.card = card
+		return &Clause{lits: lits, pbData: &pbData}, nil
+	}
+	return &Clause{lits: lits, lbdValue: uint32(card - 1), pbData: &pbData}, nil
 }
 
 // NewLearnedClause returns a new clause marked as learned.
@@ -84,10 +123,19 @@ func NewLearnedClause(lits []Lit) *Clause {
 }
 
 // Cardinality returns the minimum number of literals that must be true to satisfy the clause.
+// Will panic if c has arbitrary-precision weights: its cardinality does not fit in an int then.
 func (c *Clause) Cardinality() int {
 	if c.Learned() {
 		return 1
 	}
+	if c.pbData != nil {
+		if c.pbData.big != nil {
+			panic("cardinality of a PB clause with arbitrary-precision weights")
+		}
+		if c.pbData.card != 0 {
+			return c.pbData.card
+		}
+	}
 	return int(c.lbdValue & ^bothMasks) + 1
 }
 
@@ -111,11 +159,11 @@ func (c *Clause) unlock() {
 }
 
 func (c *Clause) lbd() int {
-	return int(c.lbdValue & ^bothMasks)
+	return int(c.lbdValue & ^allMasks)
 }
 
 func (c *Clause) setLbd(lbd int) {
-	c.lbdValue = (c.lbdValue & bothMasks) | uint32(lbd)
+	c.lbdValue = (c.lbdValue & allMasks) | uint32(lbd)
 }
 
 func (c *Clause) incLbd() {
@@ -126,6 +174,16 @@ func (c *Clause) isLocked() bool {
 	return c.lbdValue&bothMasks == bothMasks
 }
 
+// markDeleted marks the learned clause c as deleted.
+// Its watchers are not removed at once, but when they are met during propagation or when watch lists are compacted.
+func (c *Clause) markDeleted() {
+	c.lbdValue = c.lbdValue | deletedMask
+}
+
+func (c *Clause) isDeleted() bool {
+	return c.lbdValue&(learnedMask|deletedMask) == learnedMask|deletedMask
+}
+
 // Len returns the nb of lits in the clause.
 func (c *Clause) Len() int {
 	return len(c.lits)
@@ -153,6 +211,7 @@ func (c *Clause) Set(i int, l Lit) {
 
 // Weight returns the weight of the ith literal.
 // In a propositional clause or a cardinality constraint, that value will always be 1.
+// Will panic if c has arbitrary-precision weights.
 func (c *Clause) Weight(i int) int {
 	if c.pbData == nil {
 		return 1
@@ -162,10 +221,14 @@ func (c *Clause) Weight(i int) int {
 
 // WeightSum returns the sum of the PB weights.
 // If c is a propositional clause, the function will return the length of the clause.
+// Will panic if c has arbitrary-precision weights.
 func (c *Clause) WeightSum() int {
 	if c.pbData == nil {
 		return len(c.lits)
 	}
+	if c.pbData.big != nil {
+		panic("sum of arbitrary-precision weights")
+	}
 	res := 0
 	for _, w := range c.pbData.weights {
 		res += w
@@ -176,7 +239,9 @@ func (c *Clause) WeightSum() int {
 // swap swaps the ith and jth lits from the clause.
 func (c *Clause) swap(i, j int) {
 	c.lits[i], c.lits[j] = c.lits[j], c.lits[i]
-	if c.pbData != nil {
+	if c.pbData != nil && c.pbData.big != nil {
+		c.pbData.big.weights[i], c.pbData.big.weights[j] = c.pbData.big.weights[j], c.pbData.big.weights[i]
+	} else if c.pbData != nil {
 		c.pbData.weights[i], c.pbData.weights[j] = c.pbData.weights[j], c.pbData.weights[i]
 	}
 }
@@ -184,6 +249,19 @@ func (c *Clause) swap(i, j int) {
 // updateCardinality adds "add" to c's cardinality.
 // Must not be called on learned clauses!
 func (c *Clause) updateCardinality(add int) {
+	if c.pbData != nil && c.pbData.card != 0 {
+		card := c.pbData.card + add
+		if card > maxCardinality {
+			c.pbData.card = card
+			return
+		}
+		c.pbData.card = 0
+		if card < 1 {
+			card = 1
+		}
+		c.lbdValue = uint32(card - 1)
+		return
+	}
 	if add < 0 && uint32(-add) > c.lbdValue {
 		c.lbdValue = 0
 	} else {
@@ -198,7 +276,11 @@ func (c *Clause) updateCardinality(add int) {
 func (c *Clause) removeLit(idx int) {
 	c.lits[idx] = c.lits[len(c.lits)-1]
 	c.lits = c.lits[:len(c.lits)-1]
-	if c.pbData != nil {
+	if c.pbData != nil && c.pbData.big != nil {
+		weights := c.pbData.big.weights
+		weights[idx] = weights[len(weights)-1]
+		c.pbData.big.weights = weights[:len(weights)-1]
+	} else if c.pbData != nil {
 		c.pbData.weights[idx] = c.pbData.weights[len(c.pbData.weights)-1]
 		c.pbData.weights = c.pbData.weights[:len(c.pbData.weights)-1]
 	}
@@ -209,27 +291,49 @@ func (c *Clause) removeLit(idx int) {
 func (c *Clause) Shrink(newLen int) {
 	c.lits = c.lits[:newLen]
 	if c.pbData != nil {
-		c.pbData.weights = c.pbData.weights[:newLen]
+		if c.pbData.big != nil {
+			c.pbData.big.weights = c.pbData.big.weights[:newLen]
+		} else {
+			c.pbData.weights = c.pbData.weights[:newLen]
+		}
 		c.pbData.watched = c.pbData.watched[:newLen]
 	}
 }
 
 // CNF returns a DIMACS CNF representation of the clause.
 func (c *Clause) CNF() string {
+	return cnfString(c.lits)
+}
+
+// PBString returns a string representation of c as a pseudo-boolean expression.
+func (c *Clause) PBString() string {
+	if c.isBig() {
+		return bigPBString(c.lits, c.pbData.big.weights, c.pbData.big.card)
+	}
+	var weights []int
+	if c.pbData != nil {
+		weights = c.pbData.weights
+	}
+	return pbString(c.lits, weights, c.Cardinality())
+}
+
+// cnfString returns a DIMACS CNF representation of the clause made of the given lits.
+func cnfString(lits []Lit) string {
 	res := ""
-	for _, lit := range c.lits {
+	for _, lit := range lits {
 		res += fmt.Sprintf("%d ", lit.Int())
 	}
 	return fmt.Sprintf("%s0", res)
 }
 
-// PBString returns a string representation of c as a pseudo-boolean expression.
-func (c *Clause) PBString() string {
-	terms := make([]string, c.Len())
-	for i, lit := range c.lits {
+// pbString returns a string representation of the pseudo-boolean constraint made of the given lits, weights and cardinality.
+// If all weights are 1, weights can be nil.
+func pbString(lits []Lit, weights []int, card int) string {
+	terms := make([]string, len(lits))
+	for i, lit := range lits {
 		weight := 1
-		if c.pbData != nil {
-			weight = c.pbData.weights[i]
+		if weights != nil {
+			weight = weights[i]
 		}
 		val := lit.Int()
 		sign := ""
@@ -239,5 +343,5 @@ func (c *Clause) PBString() string {
 		}
 		terms[i] = fmt.Sprintf("%d %sx%d", weight, sign, val)
 	}
-	return fmt.Sprintf("%s >= %d ;", strings.Join(terms, " +"), c.Cardinality())
+	return fmt.Sprintf("%s >= %d ;", strings.Join(terms, " +"), card)
 }
@@ -1,5 +1,7 @@
 package solver
 
+import "sync"
+
 // This file deals with an attempt for an efficient clause allocator/deallocator, to relax GC's work.
 
 const (
@@ -7,16 +9,22 @@ const (
 )
 
 type allocator struct {
-	lits    []Lit // A list of lits, that will be sliced to make []Lit
-	ptrFree int   // Index of the first free item in lits
+	mu      sync.Mutex // Several solvers can allocate lits concurrently
+	lits    []Lit      // A list of lits, that will be sliced to make []Lit
+	ptrFree int        // Index of the first free item in lits
 }
 
 var alloc allocator
 
 // newLits returns a slice of lits containing the given literals.
 // It is taken from the preinitialized pool if possible,
-// or is created from scratch.
+// or is created from scratch. Lits that would not fit in a whole pool are never taken from it.
 func (a *allocator) newLits(lits ...Lit) []Lit {
+	if len(lits) > nbLitsAlloc {
+		return append([]Lit(nil), lits...)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.ptrFree+len(lits) > len(a.lits) {
 		a.lits = make([]Lit, nbLitsAlloc)
 		copy(a.lits, lits)
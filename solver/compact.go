@@ -0,0 +1,91 @@
+package solver
+
+// This file deals with the renumbering of the vars of a problem, so that unused vars do not take room
+// in the arrays of solvers, nor in the headers of exported files. Unused vars are common once vars were eliminated
+// by preprocessing, or when users number their vars sparsely.
+
+// Compact renumbers the vars of pb so that there is no gap between them: vars that appear neither in the clauses,
+// the units, the cost function, the projection vars nor the XORs of pb are removed, and the other ones keep
+// their relative order.
+// Reserved vars are all kept, after the user-level ones, since they are meant to be used in assumptions.
+// It returns, for each var of the compacted problem, the var it stood for before, so that models can be translated back.
+// Comments kept in pb.Metadata are not rewritten.
+// It must be called before the problem is given to a solver.
+func (pb *Problem) Compact() []Var {
+	used := make([]bool, pb.NbVars)
+	for _, c := range pb.Clauses {
+		for _, lit := range c.lits {
+			used[lit.Var()] = true
+		}
+	}
+	for _, lits := range [][]Lit{pb.Units, pb.minLits} {
+		for _, lit := range lits {
+			used[lit.Var()] = true
+		}
+	}
+	for _, v := range pb.ProjectionVars {
+		used[v] = true
+	}
+	for _, x := range pb.Xors {
+		for _, v := range x.Vars {
+			used[v] = true
+		}
+	}
+	for v, binding := range pb.Model {
+		if binding != 0 {
+			used[v] = true
+		}
+	}
+	for v := pb.NbVars - pb.nbReserved; v < pb.NbVars; v++ {
+		used[v] = true
+	}
+	if pb.Conflict != nil {
+		used[pb.Conflict.Lit.Var()] = true
+	}
+	newVars := make([]Var, pb.NbVars) // For each var, its number once compacted
+	var orig []Var
+	for v := range used {
+		if used[v] {
+			newVars[v] = Var(len(orig))
+			orig = append(orig, Var(v))
+		}
+	}
+	if len(orig) == pb.NbVars {
+		return orig
+	}
+	rename := func(lit Lit) Lit {
+		return newVars[lit.Var()].SignedLit(!lit.IsPositive())
+	}
+	for _, c := range pb.Clauses {
+		for i, lit := range c.lits {
+			c.lits[i] = rename(lit)
+		}
+	}
+	for _, lits := range [][]Lit{pb.Units, pb.minLits} {
+		for i, lit := range lits {
+			lits[i] = rename(lit)
+		}
+	}
+	for i, v := range pb.ProjectionVars {
+		pb.ProjectionVars[i] = newVars[v]
+	}
+	for _, x := range pb.Xors { // Renaming keeps the order of vars
+		for i, v := range x.Vars {
+			x.Vars[i] = newVars[v]
+		}
+	}
+	if pb.Conflict != nil {
+		pb.Conflict.Lit = rename(pb.Conflict.Lit)
+	}
+	if pb.Model != nil {
+		model := make([]decLevel, len(orig))
+		for v, old := range orig {
+			if int(old) < len(pb.Model) {
+				model[v] = pb.Model[old]
+			}
+		}
+		pb.Model = model
+	}
+	pb.NbVars = len(orig)
+	return orig
+}
@@ -0,0 +1,44 @@
+package solver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	pb := ParseSliceNb([][]int{{1, -5}, {5, 9, -12}, {-1, 12}, {7}}, 14)
+	pb.ProjectionVars = []Var{IntToVar(1), IntToVar(3), IntToVar(5)}
+	pb.SetCostFunc([]Lit{IntToLit(-9)}, nil)
+	reserved := pb.ReserveVars(1)
+	nbModels := New(pb.clone()).CountModels()
+	orig := pb.Compact()
+	expected := []Var{IntToVar(1), IntToVar(3), IntToVar(5), IntToVar(7), IntToVar(9), IntToVar(12), reserved[0]}
+	if !reflect.DeepEqual(orig, expected) {
+		t.Fatalf("expected mapping %v, got %v", expected, orig)
+	}
+	if pb.NbVars != len(expected) || !pb.Reserved(6) || pb.Reserved(5) {
+		t.Errorf("invalid vars: %d vars, %d reserved", pb.NbVars, pb.nbReserved)
+	}
+	if cnf := pb.CNF(); cnf != "c ind 1 2 3 0\np cnf 7 4\n4 0\n1 -3 0\n3 5 -6 0\n-1 6 0\n" {
+		t.Errorf("invalid compacted problem: got %q", cnf)
+	}
+	if !reflect.DeepEqual(pb.ProjectionVars, []Var{0, 1, 2}) || pb.minLits[0] != IntToLit(-5) {
+		t.Errorf("invalid projection vars %v or cost function %v", pb.ProjectionVars, pb.minLits)
+	}
+	const nbRemoved = 8 // Each removed var doubled the number of models
+	if nb := New(pb).CountModels(); nb<<nbRemoved != nbModels {
+		t.Errorf("expected %d models once compacted, got %d", nbModels>>nbRemoved, nb)
+	}
+	if orig := pb.Compact(); len(orig) != pb.NbVars {
+		t.Errorf("compacting twice should not remove any var")
+	}
+	pb, err := ParseCNF(strings.NewReader("p cnf 6 2\nx2 4 -6 0\n4 6 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse XOR: %v", err)
+	}
+	pb.Compact()
+	if expected := []Xor{{Vars: []Var{0, 1, 2}, Parity: false}}; pb.NbVars != 3 || !reflect.DeepEqual(pb.Xors, expected) {
+		t.Errorf("invalid compacted XORs %v", pb.Xors)
+	}
+}
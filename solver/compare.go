@@ -0,0 +1,200 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// This file deals with the comparison of two configurations of the solver on the same instances,
+// so that a change of configuration can be justified with data rather than with a single lucky run.
+
+// A Config is a named configuration of the solver.
+type Config struct {
+	Name string
+	// Setup, if not nil, is called on each new solver before it solves an instance.
+	// It typically sets fields of the solver, such as its budget, or calls methods such as Randomize.
+	Setup func(s *Solver)
+}
+
+// An Instance is a named problem configurations are compared on.
+type Instance struct {
+	Name    string
+	Problem *Problem
+}
+
+// A Run describes how a configuration performed on an instance.
+type Run struct {
+	Status    Status
+	Stats     Stats
+	Duration  time.Duration
+	Allocated uint64 // Number of bytes allocated during the run, including the copy of the problem
+	// Size of the certificate, in bytes, if the configuration set Certified but no CertChan; 0 otherwise.
+	ProofSize int
+}
+
+// A Comparison holds the runs of two configurations, A and B, on the same instances.
+type Comparison struct {
+	A, B      string   // Names of the configurations
+	Instances []string // Names of the instances
+	RunsA     []Run    // Run of A on each instance
+	RunsB     []Run    // Run of B on each instance
+}
+
+// Sub returns the difference between st and other, field by field.
+// It is typically used to get the statistics of one call to Solve on an incremental solver.
+func (st Stats) Sub(other Stats) Stats {
+	return Stats{
+		NbRestarts:      st.NbRestarts - other.NbRestarts,
+		NbConflicts:     st.NbConflicts - other.NbConflicts,
+		NbDecisions:     st.NbDecisions - other.NbDecisions,
+		NbUnitLearned:   st.NbUnitLearned - other.NbUnitLearned,
+		NbBinaryLearned: st.NbBinaryLearned - other.NbBinaryLearned,
+		NbLearned:       st.NbLearned - other.NbLearned,
+		NbDeleted:       st.NbDeleted - other.NbDeleted,
+		NbCoreCacheHits: st.NbCoreCacheHits - other.NbCoreCacheHits,
+		NbBinaryReduced: st.NbBinaryReduced - other.NbBinaryReduced,
+		NbPurged:        st.NbPurged - other.NbPurged,
+		NbFiltered:      st.NbFiltered - other.NbFiltered,
+		NbRecycled:      st.NbRecycled - other.NbRecycled,
+	}
+}
+
+// Add returns the sum of st and other, field by field.
+func (st Stats) Add(other Stats) Stats {
+	return Stats{
+		NbRestarts:      st.NbRestarts + other.NbRestarts,
+		NbConflicts:     st.NbConflicts + other.NbConflicts,
+		NbDecisions:     st.NbDecisions + other.NbDecisions,
+		NbUnitLearned:   st.NbUnitLearned + other.NbUnitLearned,
+		NbBinaryLearned: st.NbBinaryLearned + other.NbBinaryLearned,
+		NbLearned:       st.NbLearned + other.NbLearned,
+		NbDeleted:       st.NbDeleted + other.NbDeleted,
+		NbCoreCacheHits: st.NbCoreCacheHits + other.NbCoreCacheHits,
+		NbBinaryReduced: st.NbBinaryReduced + other.NbBinaryReduced,
+		NbPurged:        st.NbPurged + other.NbPurged,
+		NbFiltered:      st.NbFiltered + other.NbFiltered,
+		NbRecycled:      st.NbRecycled + other.NbRecycled,
+	}
+}
+
+// RunConfig solves a copy of pb with a new solver set up by cfg, and describes the run.
+// pb itself is left untouched, so that it can be solved again with another configuration.
+// Optimization problems are solved as decision problems: the cost function is ignored.
+// If cfg makes the solver certified without giving it a CertChan, the certificate is measured, then discarded,
+// rather than written on stdout.
+func RunConfig(cfg Config, pb *Problem) Run {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	allocated := mem.TotalAlloc
+	start := time.Now()
+	s := New(pb.clone())
+	if cfg.Setup != nil {
+		cfg.Setup(s)
+	}
+	var proofSize chan int
+	if s.Certified && s.CertChan == nil {
+		s.CertChan = make(chan string)
+		proofSize = make(chan int)
+		go func(lines chan string) {
+			size := 0
+			for line := range lines {
+				size += len(line) + 1 // Lines are terminated by a newline
+			}
+			proofSize <- size
+		}(s.CertChan)
+	}
+	run := Run{Status: s.Solve()}
+	if proofSize != nil {
+		close(s.CertChan)
+		run.ProofSize = <-proofSize
+	}
+	run.Stats, run.Duration = s.Stats, time.Since(start)
+	runtime.ReadMemStats(&mem)
+	run.Allocated = mem.TotalAlloc - allocated
+	return run
+}
+
+// Compare runs configurations a and b on each instance, one after the other, and returns the comparison of their runs.
+// Since runs are timed, instances should not be solved concurrently with anything else.
+func Compare(a, b Config, instances []Instance) *Comparison {
+	cmp := &Comparison{A: a.Name, B: b.Name}
+	for _, inst := range instances {
+		cmp.Instances = append(cmp.Instances, inst.Name)
+		cmp.RunsA = append(cmp.RunsA, RunConfig(a, inst.Problem))
+		cmp.RunsB = append(cmp.RunsB, RunConfig(b, inst.Problem))
+	}
+	return cmp
+}
+
+// Disagreements returns the indices of the instances A and B found different answers for,
+// i.e one found them Sat and the other Unsat. Any such instance reveals a bug.
+func (cmp *Comparison) Disagreements() []int {
+	var res []int
+	for i := range cmp.Instances {
+		sa, sb := cmp.RunsA[i].Status, cmp.RunsB[i].Status
+		if sa != Indet && sb != Indet && sa != sb {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// Totals returns the sum of the stats and of the durations of the runs of A, then of B,
+// on the instances both configurations solved. Instances one of them could not solve are not taken into account,
+// since the comparison would depend on the budget rather than on the configurations.
+func (cmp *Comparison) Totals() (runA, runB Run) {
+	for i := range cmp.Instances {
+		ra, rb := cmp.RunsA[i], cmp.RunsB[i]
+		if ra.Status == Indet || rb.Status == Indet {
+			continue
+		}
+		runA.Stats, runA.Duration = runA.Stats.Add(ra.Stats), runA.Duration+ra.Duration
+		runB.Stats, runB.Duration = runB.Stats.Add(rb.Stats), runB.Duration+rb.Duration
+	}
+	return runA, runB
+}
+
+// WriteReport writes a human-readable report of cmp on w: one line per instance, giving the status,
+// the number of conflicts, the solving time and the number of learned clauses of each configuration,
+// followed by a summary.
+func (cmp *Comparison) WriteReport(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	const line = "%-20s %13s %13s %12d %12d %10d %10d %10d %10d\n"
+	const header = "%-20s %13s %13s %12s %12s %10s %10s %10s %10s\n"
+	fmt.Fprintf(bw, header, "instance",
+		"status", "status", "conflicts", "conflicts", "time(ms)", "time(ms)", "learned", "learned")
+	fmt.Fprintf(bw, header, "", cmp.A, cmp.B, cmp.A, cmp.B, cmp.A, cmp.B, cmp.A, cmp.B)
+	var onlyA, onlyB, winsA, winsB int
+	for i, name := range cmp.Instances {
+		ra, rb := cmp.RunsA[i], cmp.RunsB[i]
+		fmt.Fprintf(bw, line, name, ra.Status, rb.Status,
+			ra.Stats.NbConflicts, rb.Stats.NbConflicts, ra.Duration.Milliseconds(), rb.Duration.Milliseconds(),
+			ra.Stats.NbLearned, rb.Stats.NbLearned)
+		switch {
+		case ra.Status != Indet && rb.Status == Indet:
+			onlyA++
+		case ra.Status == Indet && rb.Status != Indet:
+			onlyB++
+		case ra.Status != Indet && ra.Stats.NbConflicts < rb.Stats.NbConflicts:
+			winsA++
+		case ra.Status != Indet && rb.Stats.NbConflicts < ra.Stats.NbConflicts:
+			winsB++
+		}
+	}
+	totalA, totalB := cmp.Totals()
+	fmt.Fprintf(bw, line, "total", "", "",
+		totalA.Stats.NbConflicts, totalB.Stats.NbConflicts, totalA.Duration.Milliseconds(), totalB.Duration.Milliseconds(),
+		totalA.Stats.NbLearned, totalB.Stats.NbLearned)
+	fmt.Fprintf(bw, "solved only by %s: %d, only by %s: %d\n", cmp.A, onlyA, cmp.B, onlyB)
+	fmt.Fprintf(bw, "fewer conflicts with %s: %d, with %s: %d\n", cmp.A, winsA, cmp.B, winsB)
+	if totalA.Stats.NbConflicts > 0 {
+		fmt.Fprintf(bw, "conflicts ratio %s/%s: %.3f\n", cmp.B, cmp.A, float64(totalB.Stats.NbConflicts)/float64(totalA.Stats.NbConflicts))
+	}
+	for _, i := range cmp.Disagreements() {
+		fmt.Fprintf(bw, "DISAGREEMENT on %s: %s says %s, %s says %s\n", cmp.Instances[i], cmp.A, cmp.RunsA[i].Status, cmp.B, cmp.RunsB[i].Status)
+	}
+	return bw.Flush()
+}
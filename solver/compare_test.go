@@ -0,0 +1,82 @@
+package solver
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	instances := []Instance{
+		{Name: "pigeons6", Problem: ParseSlice(pigeons(6))},
+		{Name: "random", Problem: ParseSlice(randomCNF(rng, 20, 60, 3))},
+	}
+	def := Config{Name: "default"}
+	random := Config{Name: "random", Setup: func(s *Solver) { s.Randomize(42) }}
+	cmp := Compare(def, random, instances)
+	if len(cmp.RunsA) != 2 || len(cmp.RunsB) != 2 {
+		t.Fatalf("expected 2 runs per configuration, got %d and %d", len(cmp.RunsA), len(cmp.RunsB))
+	}
+	for i := range instances {
+		if cmp.RunsA[i].Status == Indet || cmp.RunsA[i].Status != cmp.RunsB[i].Status {
+			t.Errorf("%s: expected the same status, got %v and %v", cmp.Instances[i], cmp.RunsA[i].Status, cmp.RunsB[i].Status)
+		}
+	}
+	if cmp.RunsA[0].Status != Unsat || cmp.RunsA[0].Stats.NbConflicts == 0 {
+		t.Errorf("expected pigeons to be unsat with conflicts, got %v", cmp.RunsA[0])
+	}
+	if again := RunConfig(def, instances[0].Problem); again.Status != Unsat || again.Stats != cmp.RunsA[0].Stats {
+		t.Errorf("expected problems to be left untouched, got %v then %v", cmp.RunsA[0], again)
+	}
+	if d := cmp.Disagreements(); d != nil {
+		t.Errorf("expected no disagreement, got %v", d)
+	}
+	totalA, _ := cmp.Totals()
+	if totalA.Stats.NbConflicts != cmp.RunsA[0].Stats.NbConflicts+cmp.RunsA[1].Stats.NbConflicts {
+		t.Errorf("invalid total %d", totalA.Stats.NbConflicts)
+	}
+	var buf bytes.Buffer
+	if err := cmp.WriteReport(&buf); err != nil {
+		t.Fatalf("could not write report: %v", err)
+	}
+	report := buf.String()
+	for _, str := range []string{"pigeons6", "UNSAT", "total", "solved only by default: 0, only by random: 0"} {
+		if !strings.Contains(report, str) {
+			t.Errorf("expected report to contain %q, got\n%s", str, report)
+		}
+	}
+	if strings.Contains(report, "DISAGREEMENT") {
+		t.Errorf("unexpected disagreement in report\n%s", report)
+	}
+}
+
+func TestCompareBudget(t *testing.T) {
+	instances := []Instance{{Name: "pigeons7", Problem: ParseSlice(pigeons(7))}}
+	limited := Config{Name: "limited", Setup: func(s *Solver) { s.MaxConflicts = 1 }}
+	cmp := Compare(limited, Config{Name: "default"}, instances)
+	if cmp.RunsA[0].Status != Indet || cmp.RunsB[0].Status != Unsat {
+		t.Fatalf("expected indet, then unsat, got %v and %v", cmp.RunsA[0].Status, cmp.RunsB[0].Status)
+	}
+	if totalA, totalB := cmp.Totals(); totalA.Stats.NbConflicts != 0 || totalB.Stats.NbConflicts != 0 {
+		t.Errorf("expected unsolved instances not to be totaled, got %v and %v", totalA, totalB)
+	}
+	var buf bytes.Buffer
+	if err := cmp.WriteReport(&buf); err != nil {
+		t.Fatalf("could not write report: %v", err)
+	}
+	if !strings.Contains(buf.String(), "solved only by limited: 0, only by default: 1") {
+		t.Errorf("invalid report\n%s", buf.String())
+	}
+}
+
+func TestStatsSub(t *testing.T) {
+	s := New(ParseSlice(pigeons(5)))
+	s.Solve()
+	before := s.Stats
+	s.Solve()
+	if diff := s.Stats.Sub(before); diff.NbConflicts != s.Stats.NbConflicts-before.NbConflicts || diff.Add(before) != s.Stats {
+		t.Errorf("invalid difference %v", diff)
+	}
+}
@@ -0,0 +1,204 @@
+package solver
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// This file deals with unsat cores, i.e subsets of assumptions that cannot all be true at the same time:
+// extraction of several disjoint cores, typically used by core-guided MAXSAT algorithms,
+// and caching of cores across several calls to the same solver.
+
+// clone returns a deep copy of pb, so that several solvers can work on the same problem concurrently.
+func (pb *Problem) clone() *Problem {
+	pb2 := &Problem{
+		NbVars:     pb.NbVars,
+		Clauses:    make([]*Clause, len(pb.Clauses)),
+		Status:     pb.Status,
+		Units:      make([]Lit, len(pb.Units)),
+		Model:      make([]decLevel, len(pb.Model)),
+		minLits:    pb.minLits,
+		minWeights: pb.minWeights,
+		nbReserved: pb.nbReserved,
+		Conflict:   pb.Conflict,
+		Metadata:   pb.Metadata.clone(),
+	}
+	copy(pb2.Units, pb.Units)
+	copy(pb2.Model, pb.Model)
+	for i, c := range pb.Clauses {
+		pb2.Clauses[i] = c.clone()
+	}
+	if pb.Warnings != nil {
+		pb2.Warnings = make([]*ParseError, len(pb.Warnings))
+		for i, w := range pb.Warnings {
+			w2 := *w
+			pb2.Warnings[i] = &w2
+		}
+	}
+	if pb.ProjectionVars != nil {
+		pb2.ProjectionVars = make([]Var, len(pb.ProjectionVars))
+		copy(pb2.ProjectionVars, pb.ProjectionVars)
+	}
+	if pb.Xors != nil {
+		pb2.Xors = make([]Xor, len(pb.Xors))
+		for i, x := range pb.Xors {
+			pb2.Xors[i] = Xor{Vars: make([]Var, len(x.Vars)), Parity: x.Parity}
+			copy(pb2.Xors[i].Vars, x.Vars)
+		}
+	}
+	return pb2
+}
+
+// clone returns a deep copy of c.
+func (c *Clause) clone() *Clause {
+	c2 := &Clause{lits: make([]Lit, len(c.lits)), lbdValue: c.lbdValue, activity: c.activity}
+	copy(c2.lits, c.lits)
+	if c.pbData != nil {
+		c2.pbData = &pbData{weights: make([]int, len(c.pbData.weights)), watched: make([]bool, len(c.pbData.watched)), card: c.pbData.card}
+		copy(c2.pbData.weights, c.pbData.weights)
+		copy(c2.pbData.watched, c.pbData.watched)
+		if c.pbData.big != nil {
+			c2.pbData.big = &bigPB{weights: make([]*big.Int, len(c.pbData.big.weights)), card: c.pbData.big.card}
+			copy(c2.pbData.big.weights, c.pbData.big.weights)
+		}
+	}
+	return c2
+}
+
+// disjointCores extracts cores from the given assumptions until the remaining assumptions are satisfiable.
+// It returns the list of cores and the list of remaining assumptions.
+// If the problem is unsat no matter the assumptions, an empty core is returned.
+func (s *Solver) disjointCores(assumptions []Lit) (cores [][]Lit, remaining []Lit) {
+	remaining = make([]Lit, len(assumptions))
+	copy(remaining, assumptions)
+	for s.Assume(remaining) == Unsat || s.Solve() == Unsat {
+		core := s.Core()
+		cores = append(cores, core)
+		if len(core) == 0 {
+			return cores, nil
+		}
+		inCore := make(map[Lit]bool, len(core))
+		for _, lit := range core {
+			inCore[lit] = true
+		}
+		j := 0
+		for _, lit := range remaining {
+			if !inCore[lit] {
+				remaining[j] = lit
+				j++
+			}
+		}
+		remaining = remaining[:j]
+	}
+	return cores, remaining
+}
+
+// DisjointCores returns a list of disjoint cores from the given assumptions, i.e a list of non-overlapping
+// subsets of assumptions that are unsatisfiable with pb.
+// The assumptions are split into nbWorkers distinct subsets, whose cores are extracted concurrently,
+// each by its own solver. The assumptions that were not part of any core are then checked together,
+// so as to find cores spanning over several subsets.
+// Once all cores are removed, the remaining assumptions are satisfiable.
+// If pb is unsatisfiable no matter the assumptions, a single, empty core is returned.
+// pb is not modified by the call. In builds using the noparallel build tag, a single worker is used.
+func DisjointCores(pb *Problem, assumptions []Lit, nbWorkers int) [][]Lit {
+	if pb.Status == Unsat {
+		return [][]Lit{{}}
+	}
+	if nbWorkers < 1 || !hasParallel {
+		nbWorkers = 1
+	}
+	if nbWorkers > len(assumptions) {
+		nbWorkers = len(assumptions)
+	}
+	allCores := make([][][]Lit, nbWorkers)
+	remaining := make([][]Lit, nbWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < nbWorkers; i++ {
+		subset := assumptions[i*len(assumptions)/nbWorkers : (i+1)*len(assumptions)/nbWorkers]
+		wg.Add(1)
+		go func(i int, subset []Lit) {
+			defer wg.Done()
+			s := New(pb.clone())
+			allCores[i], remaining[i] = s.disjointCores(subset)
+		}(i, subset)
+	}
+	wg.Wait()
+	var (
+		res  [][]Lit
+		rest []Lit
+	)
+	for i := range allCores {
+		for _, core := range allCores[i] {
+			if len(core) == 0 { // The problem itself is unsat
+				return [][]Lit{{}}
+			}
+			res = append(res, core)
+		}
+		rest = append(rest, remaining[i]...)
+	}
+	if nbWorkers > 1 {
+		cores, _ := New(pb.clone()).disjointCores(rest)
+		res = append(res, cores...)
+	}
+	return res
+}
+
+// A coreCache stores cores found so far, so that they can be reused when the same assumptions reappear.
+// Adding clauses never invalidates a core, but removing some does: the cache is emptied whenever a clause group
+// is removed (see RemoveClauseGroup), or vars are collected, since they may then stand for something else (see CollectVars).
+type coreCache struct {
+	keys  map[string]bool // Canonical representation of each stored core
+	cores [][]Lit         // Stored cores, with sorted lits
+}
+
+// litsKey returns a canonical representation of the given sorted lits.
+func litsKey(lits []Lit) string {
+	buf := make([]byte, 0, len(lits)*4)
+	for _, lit := range lits {
+		buf = append(buf, byte(lit), byte(lit>>8), byte(lit>>16), byte(lit>>24))
+	}
+	return string(buf)
+}
+
+// add stores the given core in the cache.
+func (cc *coreCache) add(core []Lit) {
+	sorted := make([]Lit, len(core))
+	copy(sorted, core)
+	sort.Sort(litSorter(sorted))
+	k := litsKey(sorted)
+	if cc.keys == nil {
+		cc.keys = make(map[string]bool)
+	}
+	if !cc.keys[k] {
+		cc.keys[k] = true
+		cc.cores = append(cc.cores, sorted)
+	}
+}
+
+// lookup returns a cached core included in the given assumptions, or nil if there is none.
+func (cc *coreCache) lookup(assumptions []Lit) []Lit {
+	if len(cc.cores) == 0 {
+		return nil
+	}
+	assumed := make(map[Lit]bool, len(assumptions))
+	for _, lit := range assumptions {
+		assumed[lit] = true
+	}
+	for _, core := range cc.cores {
+		included := true
+		for _, lit := range core {
+			if !assumed[lit] {
+				included = false
+				break
+			}
+		}
+		if included {
+			res := make([]Lit, len(core))
+			copy(res, core)
+			return res
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package solver
+
+import (
+	"sort"
+	"testing"
+)
+
+// selectorProblem returns a problem where each clause i is relaxed by the selector var nbVars+i+1.
+func selectorProblem(clauses [][]int, nbVars int) (*Problem, []Lit) {
+	relaxed := make([][]int, len(clauses))
+	assumptions := make([]Lit, len(clauses))
+	for i, clause := range clauses {
+		relaxed[i] = append(append([]int{}, clause...), nbVars+i+1)
+		assumptions[i] = IntToLit(int32(-(nbVars + i + 1)))
+	}
+	return ParseSlice(relaxed), assumptions
+}
+
+func TestCore(t *testing.T) {
+	pb, assumptions := selectorProblem([][]int{{1, 2}, {-1}, {3}, {-2}, {-3, 4}}, 4)
+	s := New(pb)
+	if s.Assume(assumptions) != Unsat && s.Solve() != Unsat {
+		t.Fatalf("problem should be unsat")
+	}
+	core := s.Core()
+	sort.Slice(core, func(i, j int) bool { return core[i] < core[j] })
+	expected := []Lit{assumptions[0], assumptions[1], assumptions[3]}
+	if len(core) != len(expected) {
+		t.Fatalf("invalid core: expected %v, got %v", expected, core)
+	}
+	for i := range core {
+		if core[i] != expected[i] {
+			t.Fatalf("invalid core: expected %v, got %v", expected, core)
+		}
+	}
+	if s.Assume(assumptions[1:]) == Unsat || s.Solve() != Sat {
+		t.Errorf("problem should be sat without first clause")
+	}
+	if core := s.Core(); core != nil {
+		t.Errorf("expected no core for sat problem, got %v", core)
+	}
+}
+
+func TestAssumeKeepsUnits(t *testing.T) {
+	s := New(ParseSlice([][]int{{1}, {-1, 2}, {-2, -3, 4}}))
+	if s.Assume(IntsToLits(3, -4)) != Unsat && s.Solve() != Unsat {
+		t.Fatalf("problem should be unsat under assumptions")
+	}
+	if core := s.Core(); len(core) != 2 {
+		t.Errorf("invalid core: expected 2 lits, got %v", core)
+	}
+}
+
+func TestDisjointCores(t *testing.T) {
+	var clauses [][]int
+	for i := 0; i < 8; i++ { // 8 independent pairs of contradictory clauses
+		clauses = append(clauses, []int{i + 1}, []int{-i - 1})
+	}
+	pb, assumptions := selectorProblem(clauses, 8)
+	for nbWorkers := 1; nbWorkers <= 4; nbWorkers++ {
+		cores := DisjointCores(pb, assumptions, nbWorkers)
+		if len(cores) != 8 {
+			t.Errorf("with %d workers: expected 8 cores, got %d: %v", nbWorkers, len(cores), cores)
+		}
+		seen := make(map[Lit]bool)
+		for _, core := range cores {
+			for _, lit := range core {
+				if seen[lit] {
+					t.Errorf("with %d workers: cores %v are not disjoint", nbWorkers, cores)
+				}
+				seen[lit] = true
+			}
+		}
+	}
+	// Cores spanning over several subsets are found, too.
+	pb, assumptions = selectorProblem([][]int{{1}, {2}, {3}, {-1, -2, -3}}, 3)
+	if cores := DisjointCores(pb, assumptions, 4); len(cores) != 1 || len(cores[0]) != 4 {
+		t.Errorf("expected one core of size 4, got %v", cores)
+	}
+}
+
+func TestCoreCache(t *testing.T) {
+	pb, assumptions := selectorProblem([][]int{{1, 2}, {-1}, {-2}, {3}}, 3)
+	s := New(pb)
+	s.CacheCores = true
+	if s.Assume(assumptions) != Unsat && s.Solve() != Unsat {
+		t.Fatalf("problem should be unsat")
+	}
+	if s.Stats.NbCoreCacheHits != 0 {
+		t.Errorf("no core should have been reused yet")
+	}
+	if s.Assume(assumptions[1:]) == Unsat || s.Solve() != Sat {
+		t.Fatalf("problem should be sat without first clause")
+	}
+	if s.Assume(assumptions[:3]) != Unsat {
+		t.Fatalf("cached core should have made the problem unsat")
+	}
+	if s.Stats.NbCoreCacheHits != 1 {
+		t.Errorf("expected 1 cache hit, got %d", s.Stats.NbCoreCacheHits)
+	}
+	if core := s.Core(); len(core) != 3 {
+		t.Errorf("invalid cached core %v", core)
+	}
+}
+
+func TestProblemClone(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {-1, 3}})
+	pb.Metadata = &Metadata{Header: "p cnf 3 2", Comments: []string{"a comment"}, Positions: []int{0}}
+	pb.Warnings = []*ParseError{{Line: 1, Msg: "a warning"}}
+	pb.ProjectionVars = []Var{0, 1}
+	pb.Xors = []Xor{NewXor([]int{1, 3})}
+	pb2 := pb.clone()
+	pb.Metadata.Comments[0] = "changed"
+	pb.Metadata.Positions[0] = 1
+	pb.Warnings[0].Msg = "changed"
+	pb.ProjectionVars[0] = 2
+	pb.Xors[0].Vars[0] = 1
+	if md := pb2.Metadata; md == nil || md.Header != "p cnf 3 2" || md.Comments[0] != "a comment" || md.Positions[0] != 0 {
+		t.Errorf("metadata was not deep copied: %+v", md)
+	}
+	if len(pb2.Warnings) != 1 || pb2.Warnings[0].Msg != "a warning" {
+		t.Errorf("warnings were not deep copied: %v", pb2.Warnings)
+	}
+	if len(pb2.ProjectionVars) != 2 || pb2.ProjectionVars[0] != 0 {
+		t.Errorf("projection vars were not deep copied: %v", pb2.ProjectionVars)
+	}
+	if len(pb2.Xors) != 1 || pb2.Xors[0].Vars[0] != 0 {
+		t.Errorf("xors were not deep copied: %v", pb2.Xors)
+	}
+	if pb3 := ParseSlice([][]int{{1}}).clone(); pb3.Metadata != nil || pb3.Warnings != nil || pb3.ProjectionVars != nil || pb3.Xors != nil {
+		t.Errorf("clone of a problem without metadata should not have any")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build nocount
+// +build nocount
+
+package solver
+
+import (
+	"errors"
+	"math/big"
+)
+
+// This file replaces count_td.go in builds using the nocount build tag,
+// so that embedded users do not pay for model counting with tree decompositions.
+
+// hasCount is true iff model counting with tree decompositions is part of the build.
+const hasCount = false
+
+// errNoCount is returned by CountModelsTD when model counting with tree decompositions is not part of the build.
+var errNoCount = errors.New("model counting with tree decompositions is disabled by the nocount build tag")
+
+// CountModelsTD always fails, as model counting with tree decompositions is not part of this build.
+func (pb *Problem) CountModelsTD(td *TreeDecomposition) (*big.Int, error) {
+	return nil, errNoCount
+}
@@ -0,0 +1,292 @@
+//go:build !nocount
+// +build !nocount
+
+package solver
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// This file deals with model counting by dynamic programming over a tree decomposition (see treedec.go).
+// It is left out of builds using the nocount build tag, and replaced by count_stub.go.
+
+// hasCount is true iff model counting with tree decompositions is part of the build.
+const hasCount = true
+
+// CountModelsTD counts the models of pb by dynamic programming over td.
+// If td is nil, a decomposition is computed first.
+// The memory needed is exponential in the width of the decomposition, so an error is returned
+// if it is bigger than MaxCountWidth, or if td is not a valid decomposition for pb.
+// Contrary to Solver.CountModels, the number of models of pb is computed without enumerating them,
+// so it can be huge.
+func (pb *Problem) CountModelsTD(td *TreeDecomposition) (*big.Int, error) {
+	if pb.Status == Unsat {
+		return new(big.Int), nil
+	}
+	if td == nil {
+		td = pb.TreeDecomposition()
+	}
+	if width := td.Width(); width > MaxCountWidth {
+		return nil, fmt.Errorf("width %d is too big to count models", width)
+	}
+	inBag, err := td.check(pb.NbVars)
+	if err != nil {
+		return nil, err
+	}
+	// Each clause is checked in a bag containing all its vars.
+	clauses := make([][]*Clause, len(td.Bags))
+	for _, c := range pb.Clauses {
+		found := false
+		for i := range td.Bags {
+			included := true
+			for j := 0; j < c.Len() && included; j++ {
+				_, included = inBag[i][c.Get(j).Var()]
+			}
+			if included {
+				clauses[i] = append(clauses[i], c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no bag contains all vars of clause %s", c.CNF())
+		}
+	}
+	counts := make([]*countTable, len(td.Bags))
+	children := make([][]int, len(td.Bags))
+	for i, p := range td.Parent {
+		if p != -1 {
+			children[p] = append(children[p], i)
+		}
+	}
+	for _, i := range td.postOrder() {
+		bag := td.Bags[i]
+		counts[i] = &countTable{small: make([]uint64, 1<<uint(len(bag)))}
+		for a := range counts[i].small {
+			if pb.bagAccepts(bag, inBag[i], clauses[i], a) {
+				counts[i].small[a] = 1
+			}
+		}
+		for _, child := range children[i] {
+			sums, mask := projectCounts(td.Bags[child], counts[child], inBag[i])
+			for a := 0; a < counts[i].len(); a++ {
+				counts[i].mul(a, sums, a&mask)
+			}
+			counts[child] = nil
+		}
+	}
+	res := big.NewInt(1)
+	for i, p := range td.Parent {
+		if p == -1 {
+			res.Mul(res, counts[i].sum())
+		}
+	}
+	covered := make([]bool, pb.NbVars)
+	for _, bag := range td.Bags {
+		for _, v := range bag {
+			covered[v] = true
+		}
+	}
+	nbFree := uint(0)
+	for v := range covered {
+		if !covered[v] && pb.Model[v] == 0 {
+			nbFree++
+		}
+	}
+	return res.Lsh(res, nbFree), nil
+}
+
+// check returns an error if td is not a tree decomposition whose vars are less than nbVars,
+// i.e if the parents of its bags do not form a forest, or if the bags containing a var do not form a subtree.
+// Otherwise, it returns the index of each var in each bag.
+func (td *TreeDecomposition) check(nbVars int) ([]map[Var]int, error) {
+	if len(td.Parent) != len(td.Bags) {
+		return nil, fmt.Errorf("%d bags but %d parents", len(td.Bags), len(td.Parent))
+	}
+	for i, p := range td.Parent {
+		if p < -1 || p >= len(td.Bags) || p == i {
+			return nil, fmt.Errorf("invalid parent %d for bag %d", p+1, i+1)
+		}
+	}
+	if len(td.postOrder()) != len(td.Bags) { // Bags in a cycle are not reachable from a root
+		return nil, fmt.Errorf("decomposition is not a tree: parents of bags form a cycle")
+	}
+	inBag := make([]map[Var]int, len(td.Bags)) // Index of each var in each bag
+	for i, bag := range td.Bags {
+		inBag[i] = make(map[Var]int, len(bag))
+		for j, v := range bag {
+			if v < 0 || int(v) >= nbVars {
+				return nil, fmt.Errorf("invalid var %d in bag %d", v.Int(), i+1)
+			}
+			inBag[i][v] = j
+		}
+	}
+	// The bags containing a var form a subtree iff only one of them, the root of the subtree,
+	// has no parent containing the var.
+	hasRoot := make([]bool, nbVars)
+	for i, bag := range td.Bags {
+		p := td.Parent[i]
+		for _, v := range bag {
+			if p != -1 {
+				if _, ok := inBag[p][v]; ok {
+					continue
+				}
+			}
+			if hasRoot[v] {
+				return nil, fmt.Errorf("bags containing var %d do not form a subtree", v.Int())
+			}
+			hasRoot[v] = true
+		}
+	}
+	return inBag, nil
+}
+
+// A countTable holds the number of models associated with each assignment of the vars of a bag.
+// Counts are stored as uint64 values, until one of them overflows: all of them are then stored as big ints.
+type countTable struct {
+	small []uint64
+	big   []*big.Int // Used instead of small once a count overflowed
+}
+
+// len returns the number of counts in t.
+func (t *countTable) len() int {
+	if t.big != nil {
+		return len(t.big)
+	}
+	return len(t.small)
+}
+
+// at returns the ith count of t.
+func (t *countTable) at(i int) *big.Int {
+	if t.big != nil {
+		return t.big[i]
+	}
+	return new(big.Int).SetUint64(t.small[i])
+}
+
+// promote stores the counts of t as big ints.
+func (t *countTable) promote() {
+	t.big = make([]*big.Int, len(t.small))
+	for i, nb := range t.small {
+		t.big[i] = new(big.Int).SetUint64(nb)
+	}
+	t.small = nil
+}
+
+// mul multiplies the ith count of t by the jth count of t2.
+func (t *countTable) mul(i int, t2 *countTable, j int) {
+	if t.big == nil && t2.big == nil {
+		hi, lo := bits.Mul64(t.small[i], t2.small[j])
+		if hi == 0 {
+			t.small[i] = lo
+			return
+		}
+		t.promote()
+	}
+	if t.big == nil {
+		t.promote()
+	}
+	t.big[i].Mul(t.big[i], t2.at(j))
+}
+
+// add adds the jth count of t2 to the ith count of t.
+func (t *countTable) add(i int, t2 *countTable, j int) {
+	if t.big == nil && t2.big == nil {
+		sum, carry := bits.Add64(t.small[i], t2.small[j], 0)
+		if carry == 0 {
+			t.small[i] = sum
+			return
+		}
+		t.promote()
+	}
+	if t.big == nil {
+		t.promote()
+	}
+	t.big[i].Add(t.big[i], t2.at(j))
+}
+
+// isZero returns true iff the ith count of t is 0.
+func (t *countTable) isZero(i int) bool {
+	if t.big != nil {
+		return t.big[i].Sign() == 0
+	}
+	return t.small[i] == 0
+}
+
+// sum returns the sum of the counts of t.
+func (t *countTable) sum() *big.Int {
+	res := new(big.Int)
+	for i := 0; i < t.len(); i++ {
+		res.Add(res, t.at(i))
+	}
+	return res
+}
+
+// bagAccepts returns true iff the assignment a of the vars in bag is consistent with the units of pb
+// and satisfies all given clauses.
+// The ith bit of a is the value of the ith var in bag.
+func (pb *Problem) bagAccepts(bag []Var, idx map[Var]int, clauses []*Clause, a int) bool {
+	for j, v := range bag {
+		if val := pb.Model[v]; val != 0 && (val > 0) != (a&(1<<uint(j)) != 0) {
+			return false
+		}
+	}
+	for _, c := range clauses {
+		if c.isBig() {
+			if _, sat := c.bigSlack(func(lit Lit) Status {
+				if (a&(1<<uint(idx[lit.Var()])) != 0) == lit.IsPositive() {
+					return Sat
+				}
+				return Unsat
+			}); !sat {
+				return false
+			}
+			continue
+		}
+		sum := 0
+		for j := 0; j < c.Len(); j++ {
+			lit := c.Get(j)
+			if (a&(1<<uint(idx[lit.Var()])) != 0) == lit.IsPositive() {
+				sum += c.Weight(j)
+			}
+		}
+		if sum < c.Cardinality() {
+			return false
+		}
+	}
+	return true
+}
+
+// projectCounts sums the counts of a child bag over the assignments of the vars that are not in its parent bag.
+// It returns the sums, indexed by the assignment of the common vars in the parent bag,
+// and the mask of the bits of the common vars in the parent bag.
+func projectCounts(bag []Var, counts *countTable, parentIdx map[Var]int) (sums *countTable, mask int) {
+	pos := make([]int, len(bag))
+	maxBit := 0
+	for j, v := range bag {
+		pos[j] = -1
+		if k, ok := parentIdx[v]; ok {
+			pos[j] = k
+			mask |= 1 << uint(k)
+			if k+1 > maxBit {
+				maxBit = k + 1
+			}
+		}
+	}
+	sums = &countTable{small: make([]uint64, 1<<uint(maxBit))}
+	for a := 0; a < counts.len(); a++ {
+		if counts.isZero(a) {
+			continue
+		}
+		key := 0
+		for j, k := range pos {
+			if k != -1 && a&(1<<uint(j)) != 0 {
+				key |= 1 << uint(k)
+			}
+		}
+		sums.add(key, counts, a)
+	}
+	return sums, mask
+}
@@ -0,0 +1,48 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// This file deals with compressed input files.
+// Benchmark instances are usually distributed compressed, so parsers detect the compression format
+// from the first bytes of their input and decompress it on the fly.
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// ErrUnsupportedCompression is returned when parsing an input compressed with a format that is detected,
+// but that cannot be decompressed, such as xz. Such inputs must be decompressed beforehand.
+var ErrUnsupportedCompression = errors.New("unsupported compression format")
+
+// decompress returns a reader on the decompressed content of f.
+// If f is not compressed, the returned reader simply reads f.
+// gzip and bzip2 streams are decompressed. xz streams are detected, but cannot be decompressed:
+// an error wrapping ErrUnsupportedCompression is returned.
+func decompress(f io.Reader) (*bufio.Reader, error) {
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(len(xzMagic)) // Errors, if any, will be returned by the next read
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not read gzip stream: %w", err)
+		}
+		return bufio.NewReader(zr), nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bufio.NewReader(bzip2.NewReader(r)), nil
+	case bytes.HasPrefix(magic, xzMagic):
+		return nil, fmt.Errorf("%w: xz streams must be decompressed before being parsed", ErrUnsupportedCompression)
+	default:
+		return r, nil
+	}
+}
@@ -0,0 +1,82 @@
+package solver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string, parse func(io.Reader) (*Problem, error)) *Problem {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open %q: %v", path, err)
+	}
+	defer f.Close()
+	pb, err := parse(f)
+	if err != nil {
+		t.Fatalf("could not parse %q: %v", path, err)
+	}
+	return pb
+}
+
+func gzipped(t *testing.T, path string) io.Reader {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %q: %v", path, err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("could not compress %q: %v", path, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("could not compress %q: %v", path, err)
+	}
+	return &buf
+}
+
+func TestParseCompressedCNF(t *testing.T) {
+	plain := parseFile(t, "testcnf/25.cnf", ParseCNF)
+	gz, err := ParseCNF(gzipped(t, "testcnf/25.cnf"))
+	if err != nil {
+		t.Fatalf("could not parse gzip CNF: %v", err)
+	}
+	bz := parseFile(t, "testcnf/25.cnf.bz2", ParseCNF)
+	for _, pb := range []*Problem{gz, bz} {
+		if pb.NbVars != plain.NbVars || len(pb.Clauses) != len(plain.Clauses) || pb.CNF() != plain.CNF() {
+			t.Errorf("compressed problem differs from plain one: expected %d vars and %d clauses, got %d and %d",
+				plain.NbVars, len(plain.Clauses), pb.NbVars, len(pb.Clauses))
+		}
+	}
+}
+
+func TestParseCompressedOPB(t *testing.T) {
+	plain := parseFile(t, "testcnf/ex1.opb", ParseOPB)
+	pb, err := ParseOPB(gzipped(t, "testcnf/ex1.opb"))
+	if err != nil {
+		t.Fatalf("could not parse gzip OPB: %v", err)
+	}
+	if pb.PBString() != plain.PBString() {
+		t.Errorf("compressed problem differs from plain one: expected %s, got %s", plain.PBString(), pb.PBString())
+	}
+}
+
+func TestParseXZ(t *testing.T) {
+	xz := string(xzMagic) + "rest of the stream"
+	if _, err := ParseCNF(strings.NewReader(xz)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected an unsupported compression error when parsing xz stream, got %v", err)
+	}
+	if _, err := ParseOPB(strings.NewReader(xz)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected an unsupported compression error when parsing xz stream, got %v", err)
+	}
+	if _, err := ParseCNFBytes([]byte(xz)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected an unsupported compression error when parsing xz bytes, got %v", err)
+	}
+	if _, err := ParseCNFParallel([]byte(xz), ParseOptions{}, 4); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected an unsupported compression error when parsing xz bytes in parallel, got %v", err)
+	}
+}
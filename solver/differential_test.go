@@ -0,0 +1,277 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// This file is a harness for differential testing: the answers of the solver on the problems of a corpus
+// are cross-checked against the ones of an external reference solver.
+// The reference solvers are given by the GOPHERSAT_REF_SAT environment variable, for CNF files,
+// and by the GOPHERSAT_REF_PB one, for OPB files. They are called with the path of the problem as their only argument,
+// and must write their answer on stdout, following the conventions of the SAT and PB competitions
+// ("s SATISFIABLE", "s UNSATISFIABLE", "s OPTIMUM FOUND", and "o <cost>" lines).
+// When the solvers disagree on a CNF file, the problem is minimized with a delta-debugger before being reported.
+
+// A refAnswer is the answer of a solver to a problem.
+type refAnswer struct {
+	status  Status
+	optimum bool // Whether the cost is known to be optimal
+	cost    int  // Cost of the best model found, for optimization problems
+}
+
+func (a refAnswer) String() string {
+	if a.optimum {
+		return fmt.Sprintf("%v, optimal cost %d", a.status, a.cost)
+	}
+	return a.status.String()
+}
+
+// agrees returns true iff a and a2 are consistent: same status and, if both are optimal, same cost.
+func (a refAnswer) agrees(a2 refAnswer) bool {
+	return a.status == a2.status && (!a.optimum || !a2.optimum || a.cost == a2.cost)
+}
+
+// A refSolver solves the problem written in the file at path.
+type refSolver func(path string) (refAnswer, error)
+
+// externalSolver returns a refSolver running the given command.
+func externalSolver(cmd string) refSolver {
+	return func(path string) (refAnswer, error) {
+		out, err := exec.Command(cmd, path).Output()
+		if _, ok := err.(*exec.ExitError); err != nil && !ok { // Solvers usually exit with 10 or 20
+			return refAnswer{}, fmt.Errorf("could not run %q: %v", cmd, err)
+		}
+		var res refAnswer
+		sc := bufio.NewScanner(strings.NewReader(string(out)))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			switch {
+			case line == "s SATISFIABLE":
+				res.status = Sat
+			case line == "s OPTIMUM FOUND":
+				res.status = Sat
+				res.optimum = true
+			case line == "s UNSATISFIABLE":
+				res.status = Unsat
+			case strings.HasPrefix(line, "o "):
+				if res.cost, err = strconv.Atoi(strings.TrimSpace(line[2:])); err != nil {
+					return refAnswer{}, fmt.Errorf("invalid cost line %q", line)
+				}
+			}
+		}
+		if res.status == Indet {
+			return refAnswer{}, fmt.Errorf("%q gave no answer on %q", cmd, path)
+		}
+		return res, nil
+	}
+}
+
+// ownSolver is the refSolver using this solver.
+func ownSolver(path string) (refAnswer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return refAnswer{}, err
+	}
+	defer f.Close()
+	parse := ParseCNF
+	if strings.HasSuffix(path, ".opb") {
+		parse = ParseOPB
+	}
+	pb, err := parse(f)
+	if err != nil {
+		return refAnswer{}, err
+	}
+	s := New(pb)
+	if s.minLits == nil {
+		return refAnswer{status: s.Solve()}, nil
+	}
+	res := s.Optimal(nil, nil)
+	return refAnswer{status: res.Status, optimum: res.Status == Sat, cost: res.Weight}, nil
+}
+
+// diffFile compares the answers of ref and of this solver on the problem at path,
+// and returns an error describing their disagreement, if any.
+// If the problem is a CNF, the error contains a minimized problem on which they still disagree.
+func diffFile(ref refSolver, path string, tmpDir string) error {
+	expected, err := ref(path)
+	if err != nil {
+		return err
+	}
+	got, err := ownSolver(path)
+	if err != nil {
+		return err
+	}
+	if expected.agrees(got) {
+		return nil
+	}
+	msg := fmt.Sprintf("%s: reference answered %v, got %v", path, expected, got)
+	if !strings.HasSuffix(path, ".cnf") {
+		return fmt.Errorf("%s", msg)
+	}
+	clauses, nbVars, err := readClauses(path)
+	if err != nil {
+		return fmt.Errorf("%s; could not minimize: %v", msg, err)
+	}
+	min := minimizeDisagreement(ref, clauses, nbVars, filepath.Join(tmpDir, "min.cnf"))
+	return fmt.Errorf("%s; minimized counterexample:\n%s", msg, dimacs(min, nbVars))
+}
+
+// minimizeDisagreement returns a 1-minimal subset of clauses on which ref and this solver disagree.
+// Each candidate subset is written at path before being solved.
+func minimizeDisagreement(ref refSolver, clauses [][]int, nbVars int, path string) [][]int {
+	return ddmin(clauses, func(subset [][]int) bool {
+		if err := os.WriteFile(path, []byte(dimacs(subset, nbVars)), 0644); err != nil {
+			return false
+		}
+		expected, err := ref(path)
+		if err != nil {
+			return false
+		}
+		got, err := ownSolver(path)
+		return err == nil && !expected.agrees(got)
+	})
+}
+
+// ddmin is the delta-debugging algorithm: it returns a subset of clauses on which fails is still true,
+// and such that removing any single clause from it makes fails false.
+// fails must be true on clauses.
+func ddmin(clauses [][]int, fails func([][]int) bool) [][]int {
+	n := 2
+	for len(clauses) >= 2 {
+		chunkSize := (len(clauses) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(clauses) && !reduced; start += chunkSize {
+			end := start + chunkSize
+			if end > len(clauses) {
+				end = len(clauses)
+			}
+			chunk := clauses[start:end]
+			complement := append(append([][]int{}, clauses[:start]...), clauses[end:]...)
+			switch {
+			case fails(chunk):
+				clauses, n, reduced = chunk, 2, true
+			case n > 2 && fails(complement):
+				clauses, n, reduced = complement, n-1, true
+			}
+		}
+		if !reduced {
+			if n >= len(clauses) {
+				break
+			}
+			n *= 2
+			if n > len(clauses) {
+				n = len(clauses)
+			}
+		}
+	}
+	return clauses
+}
+
+// readClauses reads the clauses of the DIMACS file at path, without simplifying them,
+// along with the number of vars announced in its header.
+func readClauses(path string) (clauses [][]int, nbVars int, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	var clause []int
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "c" || fields[0] == "%" {
+			continue
+		}
+		if fields[0] == "p" {
+			if len(fields) != 4 {
+				return nil, 0, fmt.Errorf("invalid header %q", line)
+			}
+			if nbVars, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, 0, fmt.Errorf("invalid header %q", line)
+			}
+			continue
+		}
+		for _, field := range fields {
+			val, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid lit %q", field)
+			}
+			if val == 0 {
+				clauses = append(clauses, clause)
+				clause = nil
+			} else {
+				clause = append(clause, val)
+			}
+		}
+	}
+	return clauses, nbVars, nil
+}
+
+// dimacs returns the DIMACS representation of the given clauses.
+func dimacs(clauses [][]int, nbVars int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "p cnf %d %d\n", nbVars, len(clauses))
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			fmt.Fprintf(&sb, "%d ", lit)
+		}
+		sb.WriteString("0\n")
+	}
+	return sb.String()
+}
+
+func TestDifferential(t *testing.T) {
+	refs := []struct {
+		env, pattern string
+	}{
+		{"GOPHERSAT_REF_SAT", "testcnf/*.cnf"},
+		{"GOPHERSAT_REF_PB", "testcnf/*.opb"},
+	}
+	for _, ref := range refs {
+		cmd := os.Getenv(ref.env)
+		if cmd == "" {
+			t.Logf("%s is not set: %s files are not cross-checked", ref.env, ref.pattern)
+			continue
+		}
+		paths, err := filepath.Glob(ref.pattern)
+		if err != nil {
+			t.Fatalf("could not list corpus: %v", err)
+		}
+		for _, path := range paths {
+			if err := diffFile(externalSolver(cmd), path, t.TempDir()); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestDiffFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pb.cnf")
+	clauses := [][]int{{1, 2}, {3, 4}, {-1, 2}, {5, -6}, {1, -2}, {-3, 6}, {-1, -2}, {2, 5, 6}}
+	if err := os.WriteFile(path, []byte(dimacs(clauses, 6)), 0644); err != nil {
+		t.Fatalf("could not write problem: %v", err)
+	}
+	alwaysSat := func(path string) (refAnswer, error) { return refAnswer{status: Sat}, nil }
+	if err := diffFile(ownSolver, path, dir); err != nil {
+		t.Errorf("solver disagrees with itself: %v", err)
+	}
+	if err := diffFile(alwaysSat, path, dir); err == nil || !strings.Contains(err.Error(), "p cnf 6 4\n") {
+		t.Errorf("expected an error with a 4-clause counterexample, got %v", err)
+	}
+	min := minimizeDisagreement(alwaysSat, clauses, 6, filepath.Join(dir, "min.cnf"))
+	if status := New(ParseSliceNb(min, 6)).Solve(); status != Unsat {
+		t.Fatalf("minimized problem %v should be unsat, got %v", min, status)
+	}
+	for i := range min {
+		subset := append(append([][]int{}, min[:i]...), min[i+1:]...)
+		if status := New(ParseSliceNb(subset, 6)).Solve(); status != Sat {
+			t.Errorf("minimized problem %v is not minimal: still %v without clause %v", min, status, min[i])
+		}
+	}
+}
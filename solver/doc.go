@@ -29,6 +29,13 @@ the programmer can create the Problem by doing:
 
     pb, err := solver.ParseCNF(f)
 
+After a "p cnf+" header, the stream can also contain cardinality constraints, such as "1 2 3 >= 2 0".
+After a "p knf" header, the stream is in Knuth's KNF format, where the same constraint is written "k 2 1 2 3 0".
+As in CryptoMiniSat, lines starting with "x", such as "x1 -2 3 0", are XOR constraints, stating that an odd number of their lits are true.
+The solver does not reason on XORs natively: they are translated into clauses, whose number is linear in their size.
+If the whole file is already in memory, ParseCNFBytes parses it directly from the byte slice, which is faster on huge files.
+For files with millions of clauses, ParseCNFParallel also splits the parsing between several goroutines.
+
 2. create the equivalent list of list of literals. The problem above can be created programatically this way:
 
     clauses := [][]int{
@@ -42,6 +49,12 @@ the programmer can create the Problem by doing:
     }
     pb := solver.ParseSlice(clauses)
 
+ParseSlice panics if a clause contains the literal 0. When clauses come from an untrusted source,
+ParseSliceErr returns an error identifying the faulty clause instead. So does ParseCardConstrsErr for cardinality constraints.
+
+Problems too big to be materialized as a slice can also be built clause by clause, as they are received,
+with NewProblemBuilder, then AddClause and AddUnit, and finally Problem.
+
 3. create a list of cardinality constraints (CardConstr), if the problem to be solved is better represented this way.
 For instance, the problem stating that at least two literals must be true among the literals 1, 2, 3 and 4 could be described as a set of clauses:
 
@@ -60,21 +73,109 @@ The number of clauses necessary to describe such a constrain can grow exponentia
     }
     pb := solver.ParseCardConstrs(clauses)
 
+Upper bounds need not be expressed by hand-negating lits: CardAtMost and CardExactly build the equivalent at-least constraints.
+
 Note that a propositional clause has an implicit cardinality constraint of 1, since at least one of its literals must be true.
 
-4. parse a PBS stream (io.Reader). If the io.Reader contains the following problem:
+4. parse an OPB stream (io.Reader). If the io.Reader contains the following problem:
 
     2 ~x1 +1 x2 +1 x3 >= 3 ;
 
 the programmer can create the Problem by doing:
 
-    pb, err := solver.ParsePBS(f)
+    pb, err := solver.ParseOPB(f)
+
+The stream can also contain an objective line, such as "min: 2 x1 -1 x3 ;", in which case the problem is an optimization problem.
+Weights of the objective can be negative. Optimal models and their cost are found with s.Optimal(nil, nil).
+Weights of constraints can be arbitrarily big: constraints whose weights do not fit in ints are normalized
+with arbitrary-precision arithmetic, and the ones that are still too big afterwards are propagated that way.
+Weights of the objective must fit in ints, and so must their sum.
 
 5. create a list of PBConstr. For instance, the following set of one PBConstrs will generate the same problem as above:
 
     constrs := []PBConstr{GtEq([]int{1, 2, 3}, []int{2, 1, 1}, 3)}
     pb := solver.ParsePBConstrs(constrs)
 
+Constraints with weights that do not fit in ints are built with BigGtEq, BigLtEq and BigEq, or by setting
+the BigWeights and BigAtLeast fields of a PBConstr.
+GtEq, LtEq and Eq panic if normalizing a constraint overflows: GtEqErr, LtEqErr and EqErr return an error wrapping ErrOverflow instead.
+
+6. parse a DIMACS WCNF stream (io.Reader), describing a weighted MAXSAT problem. If the io.Reader contains:
+
+    p wcnf 2 3 10
+    10 1 2 0
+    3 -1 0
+    4 -2 0
+
+the programmer can create the corresponding optimization problem by doing:
+
+    pb, err := solver.ParseWCNF(f)
+
+The headerless variant of the format, where hard clauses start with "h" instead of a weight, is detected and parsed, too.
+
+7. parse a WBO stream (io.Reader), describing a weighted boolean optimization problem, i.e an OPB problem with soft constraints.
+If the io.Reader contains:
+
+    soft: 10 ;
+    [3] +1 x1 +1 x2 >= 2 ;
+    +1 ~x1 +1 ~x2 >= 1 ;
+
+the programmer can create the corresponding optimization problem by doing:
+
+    pb, err := solver.ParseWBO(f)
+
+8. parse an iCNF stream (io.Reader), describing a sequence of queries on a growing set of clauses,
+each query being solved under its own assumptions:
+
+    p inccnf
+    1 2 0
+    a -1 0
+    -2 0
+    a 0
+
+the programmer can replay the queries on a single solver, and get the status of each of them, by doing:
+
+    ip, err := solver.ParseICNF(f)
+    statuses := ip.Solve(nil)
+
+The same kind of session can be driven interactively by another process, through the line-based protocol
+implemented by solver.Serve, with commands such as "add 1 2 0", "assume -1 0" and "solve".
+solver.ServeJSON implements the same protocol with JSON-RPC 2.0 messages, whose results also contain
+the model, the core and the statistics of the solver.
+Embedded users can leave both protocols, and their JSON dependencies, out of their binaries with the noserver build tag;
+Capabilities then reports the server as unavailable.
+Likewise, the nocount build tag leaves out model counting with tree decompositions, so that CountModelsTD always fails,
+and the noparallel build tag makes ParseCNFParallel and DisjointCores work on a single goroutine.
+
+Several DIMACS problems, each starting with its own header, can be concatenated in a single stream.
+ParseCNFStream parses them in order, and passes each of them to a function, that can solve it before the next one is read:
+
+    err := solver.ParseCNFStream(f, solver.ParseOptions{}, func(i int, pb *solver.Problem) error {
+        fmt.Println(i, solver.New(pb).Solve())
+        return nil
+    })
+
+A ProblemScanner reads the same streams one problem at a time, when the caller would rather pull problems than be called back:
+
+    ps := solver.NewProblemScanner(f, solver.ParseOptions{})
+    for ps.Scan() {
+        fmt.Println(solver.New(ps.Problem()).Solve())
+    }
+    err := ps.Err()
+
+DIMACS and OPB streams compressed with gzip or bzip2 are detected and decompressed on the fly by ParseCNF and ParseOPB.
+xz streams are detected too, but cannot be decompressed: parsing them fails with ErrUnsupportedCompression.
+
+Syntax errors are reported as a *ParseError, giving the faulty line, and wrapping an error such as ErrBadHeader,
+ErrBadLiteral, ErrBadWeight or ErrBadOperator, that tells which part of the input is faulty:
+
+    if errors.Is(err, solver.ErrBadHeader) {
+        // Parse again, with ParseOptions.Lenient set
+    }
+
+ParseError.Recoverable tells whether the error only affects its own line, as in files with a few malformed constraints,
+that can be skipped by parsing again with ParseOptions.Recover set, or whether the input is truncated or corrupted.
+
 Solving a problem
 
 To solve a problem, one simply creates a solver with said problem.
@@ -98,5 +199,15 @@ For the above problem described in the DIMACS format, the output can be:
     SATISFIABLE
     -1 2 -3 4 -5 -6
 
+Programs solving many problems in the same process, such as services, can set RecoverPanics in the Solver
+and in ParseOptions: a bug triggered by a problem then makes Solve return Indet, and Err return a *PanicError,
+rather than crashing the whole program. The servers started by Serve and ServeJSON always recover panics.
+
+Clauses that may have to be removed later, such as the current choices of a user, can be added as a group
+with AddClauseGroup. RemoveClauseGroup removes them, along with what the solver derived from them, and keeps
+the rest of what it learned, so that the problem can be solved again without building a new solver.
+In long-lived sessions, where each group uses its own vars, CollectVars frees the vars that do not appear
+in the problem anymore, and NewVar hands them back, so that the number of vars of the solver stays bounded.
+
 */
 package solver
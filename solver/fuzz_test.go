@@ -0,0 +1,182 @@
+//go:build go1.18
+// +build go1.18
+
+package solver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// This file contains the fuzz targets of the parsers. Whatever their input, parsers must not panic:
+// they either return a valid problem or an error.
+// Fuzzing needs Go 1.18; for instance, run go test -fuzz=FuzzParseCNF ./solver
+
+// checkParsed fails if a parser returned neither a problem nor an error, or a problem with inconsistent sizes.
+func checkParsed(t *testing.T, pb *Problem, err error) {
+	if err != nil {
+		return
+	}
+	if pb == nil {
+		t.Fatalf("no problem and no error")
+	}
+	if pb.Status != Unsat && len(pb.Model) != pb.NbVars {
+		t.Fatalf("problem has %d vars but a model of size %d", pb.NbVars, len(pb.Model))
+	}
+}
+
+// skipHuge skips inputs containing numbers between 1<<20 and maxVar: they can be valid vars or sizes,
+// but then the parsed problem is legitimately huge, and would only make fuzzing slow.
+// Bigger numbers are kept, as parsers must reject them.
+func skipHuge(t *testing.T, data []byte) {
+	n := 0
+	for i, b := range data {
+		if b >= '0' && b <= '9' {
+			if n <= maxVar {
+				n = 10*n + int(b-'0')
+			}
+			if i+1 < len(data) {
+				continue
+			}
+		}
+		if n > 1<<20 && n <= maxVar {
+			t.Skip("input contains a huge number")
+		}
+		n = 0
+	}
+}
+
+func FuzzParseCNF(f *testing.F) {
+	f.Add([]byte("p cnf 3 2\n1 -2 0\n2 3 0\n"), byte(0))
+	f.Add([]byte("c ind 1 2 0\np cnf+ 4 2\n1 2 3 >= 2 0\n-1 -4 0\n"), byte(1))
+	f.Add([]byte("p knf 3 1\nk 2 1 2 3 0\n"), byte(2))
+	f.Add([]byte("1 2 0\n-1 0\n"), byte(3))
+	f.Fuzz(func(t *testing.T, data []byte, flags byte) {
+		skipHuge(t, data)
+		opts := ParseOptions{Recover: flags&1 != 0, Lenient: flags&2 != 0, MaxLineSize: 1 << 16}
+		pb, err := ParseCNFOptions(bytes.NewReader(data), opts)
+		if opts.Recover && err != nil && pb != nil {
+			err = nil
+		}
+		checkParsed(t, pb, err)
+	})
+}
+
+func FuzzParseCNFStream(f *testing.F) {
+	f.Add([]byte("p cnf 2 1\n1 2 0\np cnf 1 1\n-1 0\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		skipHuge(t, data)
+		_ = ParseCNFStream(bytes.NewReader(data), ParseOptions{MaxLineSize: 1 << 16}, func(i int, pb *Problem) error {
+			checkParsed(t, pb, nil)
+			return nil
+		})
+	})
+}
+
+func FuzzParseOPB(f *testing.F) {
+	f.Add([]byte("* #variable= 3 #constraint= 2\nmin: +1 x1 -2 x2 ;\n+1 x1 +2 ~x3 >= 2 ;\n+1 x2 x3 <= 0 ;\n"))
+	f.Add([]byte("+1 x1 +1 x2 = 1 ;\n+1 x1 > 0 ;\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		skipHuge(t, data)
+		pb, err := ParseOPBOptions(bytes.NewReader(data), ParseOptions{MaxLineSize: 1 << 16})
+		checkParsed(t, pb, err)
+	})
+}
+
+func FuzzParseWBO(f *testing.F) {
+	f.Add([]byte(wboExample))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		skipHuge(t, data)
+		pb, err := ParseWBO(bytes.NewReader(data))
+		checkParsed(t, pb, err)
+	})
+}
+
+func FuzzParseWCNF(f *testing.F) {
+	f.Add([]byte("p wcnf 2 3 10\n10 1 2 0\n3 -1 0\n4 -2 0\n"))
+	f.Add([]byte("h 1 2 0\n3 -1 0\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		skipHuge(t, data)
+		pb, err := ParseWCNF(bytes.NewReader(data))
+		checkParsed(t, pb, err)
+	})
+}
+
+func FuzzParseICNF(f *testing.F) {
+	f.Add([]byte("p inccnf\n1 2 0\na -1 0\n-2 0\na 0\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		skipHuge(t, data)
+		ip, err := ParseICNF(bytes.NewReader(data))
+		if err == nil && ip == nil {
+			t.Fatalf("no problem and no error")
+		}
+	})
+}
+
+// fuzzedLits decodes data as a list of lits, in 16 bits little-endian, split in constraints of at most size lits.
+func fuzzedLits(data []byte, size byte) [][]int {
+	var res [][]int
+	var lits []int
+	n := int(size%8) + 1
+	for i := 0; i+1 < len(data); i += 2 {
+		lits = append(lits, int(int16(binary.LittleEndian.Uint16(data[i:]))))
+		if len(lits) == n {
+			res = append(res, lits)
+			lits = nil
+		}
+	}
+	if lits != nil {
+		res = append(res, lits)
+	}
+	return res
+}
+
+func FuzzParseSliceErr(f *testing.F) {
+	f.Add([]byte{1, 0, 2, 0, 0xff, 0xff, 3, 0}, byte(1))
+	f.Add([]byte{1, 0, 0, 0}, byte(3))
+	f.Fuzz(func(t *testing.T, data []byte, size byte) {
+		pb, err := ParseSliceErr(fuzzedLits(data, size))
+		checkParsed(t, pb, err)
+	})
+}
+
+func FuzzParseCardConstrsErr(f *testing.F) {
+	f.Add([]byte{1, 0, 2, 0, 0xff, 0xff, 3, 0}, byte(3), int8(2))
+	f.Fuzz(func(t *testing.T, data []byte, size byte, card int8) {
+		var constrs []CardConstr
+		for _, lits := range fuzzedLits(data, size) {
+			constrs = append(constrs, CardConstr{Lits: lits, AtLeast: int(card)})
+		}
+		pb, err := ParseCardConstrsErr(constrs)
+		checkParsed(t, pb, err)
+	})
+}
+
+// fuzzedWeights decodes data as a list of weights, in 64 bits little-endian, so that they can overflow when summed.
+func fuzzedWeights(data []byte) []int {
+	var res []int
+	for i := 0; i+7 < len(data); i += 8 {
+		res = append(res, int(int64(binary.LittleEndian.Uint64(data[i:]))))
+	}
+	return res
+}
+
+func FuzzParsePBConstrsErr(f *testing.F) {
+	f.Add([]byte{1, 0, 2, 0, 0xfd, 0xff}, byte(2), []byte{3, 0, 0, 0, 0, 0, 0, 0, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(2))
+	f.Add([]byte{1, 0, 2, 0}, byte(1), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, int64(1))
+	f.Add([]byte{1, 0, 2, 0}, byte(3), []byte(nil), int64(-1))
+	f.Fuzz(func(t *testing.T, data []byte, size byte, weightData []byte, atLeast int64) {
+		weights := fuzzedWeights(weightData)
+		var constrs []PBConstr
+		for _, lits := range fuzzedLits(data, size) {
+			constr := PBConstr{Lits: lits, AtLeast: int(atLeast)}
+			if weights != nil { // Weights are shared by all constraints, so that their length may not match
+				constr.Weights = weights
+			}
+			constrs = append(constrs, constr)
+		}
+		pb, err := ParsePBConstrsErr(constrs)
+		checkParsed(t, pb, err)
+	})
+}
@@ -0,0 +1,111 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// This file deals with the variable interaction graph of a problem,
+// i.e the graph where vertices are variables and where two variables are linked
+// iff they appear together in at least one clause.
+
+// An Edge links two variables of the interaction graph.
+// From is always smaller than To.
+// Weight is the number of clauses the two variables appear together in.
+type Edge struct {
+	From   Var
+	To     Var
+	Weight int
+}
+
+// A GraphFormat is a format used to export graphs.
+type GraphFormat byte
+
+const (
+	// DOT is the graphviz format.
+	DOT = GraphFormat(iota)
+	// EdgeList is a list of edges, one per line, with the two CNF variables separated by a space,
+	// followed by the weight of the edge if needed.
+	EdgeList
+)
+
+type edgeSorter []Edge
+
+func (es edgeSorter) Len() int { return len(es) }
+func (es edgeSorter) Less(i, j int) bool {
+	return es[i].From < es[j].From || (es[i].From == es[j].From && es[i].To < es[j].To)
+}
+func (es edgeSorter) Swap(i, j int) { es[i], es[j] = es[j], es[i] }
+
+// neighbors returns, for each var, the vars it shares clauses with, associated with the number of shared clauses.
+// Unit literals are not part of the graph.
+func (pb *Problem) neighbors() []map[Var]int {
+	res := make([]map[Var]int, pb.NbVars)
+	for _, c := range pb.Clauses {
+		for i := 0; i < c.Len(); i++ {
+			v1 := c.Get(i).Var()
+			for j := i + 1; j < c.Len(); j++ {
+				v2 := c.Get(j).Var()
+				if v1 == v2 {
+					continue
+				}
+				if res[v1] == nil {
+					res[v1] = make(map[Var]int)
+				}
+				if res[v2] == nil {
+					res[v2] = make(map[Var]int)
+				}
+				res[v1][v2]++
+				res[v2][v1]++
+			}
+		}
+	}
+	return res
+}
+
+// InteractionGraph returns the list of edges of the variable interaction graph of pb, sorted by variables.
+func (pb *Problem) InteractionGraph() []Edge {
+	var edges []Edge
+	for v, neighbors := range pb.neighbors() {
+		for v2, w := range neighbors {
+			if Var(v) < v2 {
+				edges = append(edges, Edge{From: Var(v), To: v2, Weight: w})
+			}
+		}
+	}
+	sort.Sort(edgeSorter(edges))
+	return edges
+}
+
+// WriteGraph writes the variable interaction graph of pb on w, in the given format.
+// Variables are designated by their CNF value.
+// If weighted is true, each edge is associated with the number of clauses both variables appear in.
+func (pb *Problem) WriteGraph(w io.Writer, format GraphFormat, weighted bool) error {
+	bw := bufio.NewWriter(w)
+	edges := pb.InteractionGraph()
+	switch format {
+	case DOT:
+		fmt.Fprintf(bw, "graph G {\n")
+		for _, e := range edges {
+			if weighted {
+				fmt.Fprintf(bw, "  %d -- %d [weight=%d];\n", e.From.Int(), e.To.Int(), e.Weight)
+			} else {
+				fmt.Fprintf(bw, "  %d -- %d;\n", e.From.Int(), e.To.Int())
+			}
+		}
+		fmt.Fprintf(bw, "}\n")
+	case EdgeList:
+		for _, e := range edges {
+			if weighted {
+				fmt.Fprintf(bw, "%d %d %d\n", e.From.Int(), e.To.Int(), e.Weight)
+			} else {
+				fmt.Fprintf(bw, "%d %d\n", e.From.Int(), e.To.Int())
+			}
+		}
+	default:
+		return fmt.Errorf("invalid graph format %d", format)
+	}
+	return bw.Flush()
+}
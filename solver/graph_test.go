@@ -0,0 +1,26 @@
+package solver
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteGraph(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2, 3}, {-1, -2}, {3, 4}, {5}})
+	var buf bytes.Buffer
+	if err := pb.WriteGraph(&buf, EdgeList, true); err != nil {
+		t.Fatalf("could not write graph: %v", err)
+	}
+	expected := "1 2 2\n1 3 1\n2 3 1\n3 4 1\n"
+	if buf.String() != expected {
+		t.Errorf("invalid edge list: expected %q, got %q", expected, buf.String())
+	}
+	buf.Reset()
+	if err := pb.WriteGraph(&buf, DOT, false); err != nil {
+		t.Fatalf("could not write graph: %v", err)
+	}
+	expected = "graph G {\n  1 -- 2;\n  1 -- 3;\n  2 -- 3;\n  3 -- 4;\n}\n"
+	if buf.String() != expected {
+		t.Errorf("invalid DOT graph: expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,267 @@
+package solver
+
+import "fmt"
+
+// This file deals with groups of clauses that can be removed from a running solver, such as the constraints
+// of a configuration that can be changed by a user, without having to build a new solver and losing what was learned.
+// When a group is removed, what was derived from it must be forgotten: learned clauses and units whose derivation
+// involves a clause of the group are removed. If TrackAntecedents is true, only these are removed;
+// otherwise, all the clauses and units learned since the group was added are removed, since they may depend on it.
+// Top-level bindings are then computed again from the remaining units, and all the remaining clauses are watched again.
+// As long as groups exist, clauses are never simplified with top-level bindings, since these bindings may be retracted later.
+
+// clauseGroups holds the removable groups of clauses of a solver.
+type clauseGroups struct {
+	groups    []clauseGroup   // All the groups added so far, removed or not, indexed by their identifier
+	nbLive    int             // Number of groups that were not removed yet
+	learnedAt map[*Clause]int // For each clause learned while a group exists, how many groups were added before it
+	unsat     bool            // True iff an unsatisfiable clause was appended while a group exists
+}
+
+// A clauseGroup is a set of clauses that were added together, and are removed together.
+type clauseGroup struct {
+	clauses []*Clause // Clauses of the group, nil once the group was removed
+	removed bool
+	nbUnits int // Number of units the solver knew when the group was added
+}
+
+// watchable returns true iff two lits of c can be watched, i.e iff c does not force all its lits to be true.
+// The weights of clauses with arbitrary-precision weights are always bigger than their cardinality.
+func (c *Clause) watchable() bool {
+	return c.Len() >= 2 && (c.isBig() || c.WeightSum() > c.Cardinality())
+}
+
+// AddClauseGroup adds the given clauses to the problem solved by s, as a group that can be removed later
+// with RemoveClauseGroup, and returns the identifier of the group.
+// Clauses must not be modified or added to another solver afterwards.
+// The current assumptions, if any, are kept.
+func (s *Solver) AddClauseGroup(clauses []*Clause) int {
+	g := &s.groups
+	id := len(g.groups)
+	if s.wl.wlist == nil { // The problem s was made from was unsat: it stays unsat, no matter the groups
+		g.groups = append(g.groups, clauseGroup{removed: true})
+		return id
+	}
+	g.groups = append(g.groups, clauseGroup{clauses: clauses, nbUnits: len(s.units)})
+	g.nbLive++
+	if g.learnedAt == nil {
+		g.learnedAt = make(map[*Clause]int)
+	}
+	for _, c := range clauses {
+		for i := 0; i < c.Len(); i++ {
+			s.newVar(c.Get(i).Var())
+		}
+		s.wl.pbClauses = append(s.wl.pbClauses, c)
+		if c.watchable() { // Any lits can be watched, since all bindings are computed again right after
+			s.watchClause(c)
+		}
+	}
+	s.resetTopLevel()
+	return id
+}
+
+// RemoveClauseGroup removes the clauses of the group whose identifier is id from the problem solved by s,
+// along with what was derived from them. s can then be used to solve the problem again, and what it learned
+// from the remaining clauses is kept. The current assumptions, if any, are kept.
+// Problem clauses added after the group have their index decreased by the size of the group.
+// An error is returned if there is no such group, if it was already removed, or if s is certified,
+// since certificates cannot describe the removal of problem clauses.
+func (s *Solver) RemoveClauseGroup(id int) error {
+	g := &s.groups
+	if id < 0 || id >= len(g.groups) {
+		return fmt.Errorf("unknown clause group %d", id)
+	}
+	if s.wl.wlist == nil { // Nothing was added
+		return nil
+	}
+	group := &g.groups[id]
+	if group.removed {
+		return fmt.Errorf("clause group %d was already removed", id)
+	}
+	if s.Certified {
+		return fmt.Errorf("cannot remove clause group %d from a certified solver", id)
+	}
+	removed := make(map[*Clause]bool, len(group.clauses))
+	for _, c := range group.clauses {
+		removed[c] = true
+	}
+	depends := s.groupDependency(id, removed)
+	j := 0
+	for _, c := range s.wl.learned {
+		if depends(c) {
+			c.markDeleted()
+			s.Stats.NbDeleted++
+			delete(s.antecedents.clauses, c)
+			delete(s.lemmaUses, c)
+			continue
+		}
+		s.wl.learned[j] = c
+		j++
+	}
+	for k := j; k < len(s.wl.learned); k++ {
+		s.wl.learned[k] = nil
+	}
+	s.wl.learned = s.wl.learned[:j]
+	nbKept := make([]int, len(s.units)+1) // For each unit, how many of the units before it are kept
+	units := s.units[:0]
+	for i, unit := range s.units {
+		nbKept[i] = len(units)
+		// Units known before the group was added and units that were not learned cannot depend on it.
+		// Learned units whose derivation is unknown have nil antecedents.
+		ants, learned := s.antecedents.units[unit.Var()]
+		if i >= group.nbUnits && learned && (ants == nil || dependsOnAny(ants, removed, depends)) {
+			delete(s.antecedents.units, unit.Var())
+			continue
+		}
+		units = append(units, unit)
+	}
+	nbKept[len(s.units)] = len(units)
+	s.units = units
+	for i := range g.groups {
+		g.groups[i].nbUnits = nbKept[g.groups[i].nbUnits]
+	}
+	j = 0
+	for _, c := range s.wl.pbClauses {
+		if removed[c] {
+			delete(s.usage, c)
+			continue
+		}
+		s.wl.pbClauses[j] = c
+		j++
+	}
+	for k := j; k < len(s.wl.pbClauses); k++ {
+		s.wl.pbClauses[k] = nil
+	}
+	s.wl.pbClauses = s.wl.pbClauses[:j]
+	*group = clauseGroup{removed: true}
+	if g.nbLive--; g.nbLive == 0 {
+		g.learnedAt = nil
+	}
+	s.coreCache = coreCache{} // Cores may depend on the removed clauses
+	s.nbPurgedUnits = 0
+	s.binReductionMark = 0
+	s.rewatch()
+	s.resetTopLevel()
+	return nil
+}
+
+// groupDependency returns a function telling whether a learned clause may depend on the group whose identifier is id,
+// whose clauses are removed. Results are memoized.
+func (s *Solver) groupDependency(id int, removed map[*Clause]bool) func(c *Clause) bool {
+	memo := make(map[*Clause]bool)
+	var depends func(c *Clause) bool
+	depends = func(c *Clause) bool {
+		if res, ok := memo[c]; ok {
+			return res
+		}
+		res := false
+		if at, ok := s.groups.learnedAt[c]; ok && at > id { // Learned after the group was added
+			ants, ok := s.antecedents.clauses[c]
+			res = !ok || dependsOnAny(ants, removed, depends)
+		}
+		memo[c] = res
+		return res
+	}
+	return depends
+}
+
+// dependsOnAny returns true iff one of the given clauses was removed, or is a learned clause that depends on them.
+func dependsOnAny(ants []*Clause, removed map[*Clause]bool, depends func(c *Clause) bool) bool {
+	for _, c := range ants {
+		if removed[c] || (c.Learned() && depends(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewatch watches all the clauses of s again, from scratch.
+// It must be followed by a call to resetTopLevel, since watched lits are chosen without considering bindings.
+func (s *Solver) rewatch() {
+	for _, lists := range [][][]watcher{s.wl.wlistBin, s.wl.wlist} {
+		for lit, wl := range lists {
+			for k := range wl {
+				wl[k] = watcher{}
+			}
+			lists[lit] = wl[:0]
+		}
+	}
+	for lit, wl := range s.wl.wlistPb {
+		for k := range wl {
+			wl[k] = nil
+		}
+		s.wl.wlistPb[lit] = wl[:0]
+	}
+	s.wl.nbDeleted = 0
+	for _, clauses := range [][]*Clause{s.wl.pbClauses, s.wl.learned} {
+		for _, c := range clauses {
+			if !c.watchable() {
+				continue
+			}
+			if c.pbData != nil {
+				for i := range c.pbData.watched {
+					c.pbData.watched[i] = false
+				}
+			}
+			s.watchClause(c)
+		}
+	}
+}
+
+// resetTopLevel unbinds all vars, then binds again the units of s and the current assumptions, and propagates them.
+func (s *Solver) resetTopLevel() {
+	var assumptions []Lit
+	for _, lit := range s.trail {
+		if s.assumptions[lit.Var()] {
+			assumptions = append(assumptions, lit)
+		}
+	}
+	s.Assume(assumptions)
+	if s.groups.unsat {
+		s.status = Unsat
+	}
+}
+
+// bindForced binds, at the top level, the lits of the clauses of live groups that cannot be watched,
+// since they force all their lits to be true. It returns a clause that cannot be satisfied, if any.
+func (s *Solver) bindForced() *Clause {
+	for _, group := range s.groups.groups {
+		for _, c := range group.clauses {
+			if c.watchable() {
+				continue
+			}
+			if c.WeightSum() < c.Cardinality() {
+				return c
+			}
+			for i := 0; i < c.Len(); i++ {
+				lit := c.Get(i)
+				switch s.litStatus(lit) {
+				case Unsat:
+					return c
+				case Indet:
+					s.model[lit.Var()] = lvlToSignedLvl(lit, 1)
+					s.reason[lit.Var()] = c
+					s.trail = append(s.trail, lit)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// appendGuarded appends clause to the problem of s while groups exist: it is not simplified with the current bindings,
+// that may be retracted once a group is removed.
+func (s *Solver) appendGuarded(clause *Clause) {
+	for i := 0; i < clause.Len(); i++ {
+		s.newVar(clause.Get(i).Var())
+	}
+	switch {
+	case clause.watchable():
+		s.appendClause(clause)
+	case clause.WeightSum() < clause.Cardinality():
+		s.groups.unsat = true
+	default: // All lits are true no matter what
+		s.units = append(s.units, clause.lits...)
+	}
+	s.resetTopLevel()
+}
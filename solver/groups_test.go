@@ -0,0 +1,83 @@
+package solver
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestClauseGroups(t *testing.T) {
+	for _, track := range []bool{false, true} {
+		rng := rand.New(rand.NewSource(1))
+		nbVars := 60
+		base := randomCNF(rng, nbVars, 150, 3)
+		s := New(ParseSliceNb(base, nbVars))
+		s.TrackAntecedents = track
+		groups := make(map[int][][]int)
+		var ids []int // Identifiers of live groups, in the order they were added
+		for step := 0; step < 300; step++ {
+			if len(ids) == 0 || rng.Intn(2) == 0 {
+				cnf := randomCNF(rng, nbVars, 10+rng.Intn(40), 3)
+				if rng.Intn(4) == 0 {
+					cnf = append(cnf, []int{rng.Intn(nbVars) + 1})
+				}
+				clauses := make([]*Clause, len(cnf))
+				for i, c := range cnf {
+					lits := make([]Lit, len(c))
+					for j, val := range c {
+						lits[j] = IntToLit(int32(val))
+					}
+					clauses[i] = NewClause(lits)
+				}
+				id := s.AddClauseGroup(clauses)
+				groups[id] = cnf
+				ids = append(ids, id)
+			} else {
+				i := rng.Intn(len(ids))
+				if err := s.RemoveClauseGroup(ids[i]); err != nil {
+					t.Fatalf("could not remove group %d: %v", ids[i], err)
+				}
+				delete(groups, ids[i])
+				ids = append(ids[:i], ids[i+1:]...)
+			}
+			cnf := base
+			for _, id := range ids {
+				cnf = append(cnf[:len(cnf):len(cnf)], groups[id]...)
+			}
+			expected, _, err := solveCNF(cnf, nbVars)
+			if err != nil {
+				t.Fatalf("reference solver failed: %v", err)
+			}
+			if status := s.Solve(); status != expected {
+				t.Fatalf("tracking %t, step %d: expected %v, got %v", track, step, expected, status)
+			} else if status == Sat && !satisfies(cnf, s.Model()) {
+				t.Fatalf("tracking %t, step %d: model does not satisfy the live clauses", track, step)
+			}
+		}
+		if err := s.RemoveClauseGroup(len(groups) + 1000); err == nil {
+			t.Errorf("expected an error for an unknown group")
+		}
+	}
+}
+
+func TestClauseGroupsAssumptions(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 3}}))
+	if s.Assume([]Lit{IntToLit(-2)}) == Unsat {
+		t.Fatalf("problem should not be unsat under assumptions")
+	}
+	id := s.AddClauseGroup([]*Clause{NewClause([]Lit{IntToLit(-3)})})
+	if status := s.Solve(); status != Unsat {
+		t.Errorf("expected Unsat with the group, got %v", status)
+	}
+	if err := s.RemoveClauseGroup(id); err != nil {
+		t.Fatalf("could not remove group: %v", err)
+	}
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("expected Sat once the group was removed, got %v", status)
+	}
+	if model := s.Model(); model[1] || !model[0] || !model[2] {
+		t.Errorf("assumption was not kept: got model %v", model)
+	}
+	if err := s.RemoveClauseGroup(id); err == nil {
+		t.Errorf("expected an error when removing a group twice")
+	}
+}
@@ -0,0 +1,38 @@
+package solver
+
+// This file deals with the split of watch lists between hot and cold clauses.
+// During long runs, most clauses are never involved in a conflict anymore. Keeping the clauses that were recently
+// involved in conflicts at the beginning of the watch lists improves cache behavior, since these are the clauses
+// that are the most likely to be visited, and to trigger propagations and conflicts.
+// A clause is hot if its activity was bumped since the last sort, and cold otherwise. Since clauseInc only grows,
+// between two rescales, a clause is hot iff its activity is at least the value clauseInc had during the last sort.
+
+const hotColdInterval = 16 // How many restarts between two sorts of watch lists by recency.
+
+// sortWatchersByRecency moves the watchers of hot clauses in front of the watchers of cold clauses in the watch lists
+// of non-binary propositional clauses, keeping the relative order of watchers otherwise.
+// Watchers of deleted clauses are removed along the way.
+func (s *Solver) sortWatchersByRecency() {
+	var cold []watcher
+	for lit, wl := range s.wl.wlist {
+		j := 0
+		cold = cold[:0]
+		for _, w := range wl {
+			if w.clause.isDeleted() {
+				continue
+			}
+			if w.clause.activity >= s.hotInc {
+				wl[j] = w
+				j++
+			} else {
+				cold = append(cold, w)
+			}
+		}
+		j += copy(wl[j:], cold)
+		for k := j; k < len(wl); k++ {
+			wl[k] = watcher{} // Let the GC collect deleted clauses
+		}
+		s.wl.wlist[lit] = wl[:j]
+	}
+	s.hotInc = s.clauseInc
+}
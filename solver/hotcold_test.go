@@ -0,0 +1,26 @@
+package solver
+
+import "testing"
+
+func TestSortWatchersByRecency(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2, 3}, {1, 4, 5}, {1, 6, 7}}))
+	s.sortWatchersByRecency() // Nothing has been used yet
+	hot := s.wl.pbClauses[2]
+	s.clauseDecayActivity()
+	s.clauseBumpActivity(hot)
+	s.sortWatchersByRecency()
+	wl := s.wl.wlist[IntToLit(-1)]
+	if len(wl) != 3 {
+		t.Fatalf("expected 3 watchers for -1, got %d", len(wl))
+	}
+	if wl[0].clause != hot {
+		t.Errorf("expected hot clause %s first, got %s", hot.CNF(), wl[0].clause.CNF())
+	}
+	if wl[1].clause != s.wl.pbClauses[0] || wl[2].clause != s.wl.pbClauses[1] {
+		t.Errorf("cold clauses should keep their relative order")
+	}
+	s.sortWatchersByRecency() // hot is not hot anymore, since it was not bumped since the last sort
+	if wl := s.wl.wlist[IntToLit(-1)]; wl[0].clause != hot {
+		t.Errorf("order of cold clauses should not change, got %s first", wl[0].clause.CNF())
+	}
+}
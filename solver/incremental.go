@@ -0,0 +1,165 @@
+package solver
+
+import "fmt"
+
+// This file deals with the replay of incremental problems, i.e solving a sequence of queries on a single solver,
+// so that what is learned while solving a query can be reused to solve the following ones.
+
+// DefaultMaxVar is the biggest var CheckLits accepts, unless the MaxVar field of the incremental solver says otherwise.
+// The solver allocates memory for every var up to the biggest one it is given, so a single lit about a huge var
+// could exhaust memory.
+const DefaultMaxVar = 1 << 20
+
+// An IncrementalSolver solves a growing set of clauses several times, each time under its own assumptions,
+// as IPASIR solvers do. What is learned while solving the problem is kept from one call to Solve to the next.
+// Clauses and lits are given in the DIMACS format.
+type IncrementalSolver struct {
+	// Biggest var accepted by CheckLits. If it is 0, DefaultMaxVar is used.
+	// Servers check the lits of every command with CheckLits, so that a client cannot make them run out of memory.
+	MaxVar int
+	s      *Solver
+	query  Query // Clauses and assumptions of the next call to Solve
+	unsat  bool  // True iff the problem is unsat, no matter the assumptions
+}
+
+// NewIncrementalSolver returns an incremental solver whose problem is empty.
+func NewIncrementalSolver() *IncrementalSolver {
+	return &IncrementalSolver{s: New(ParseSliceNb(nil, 0))}
+}
+
+// Solver returns the underlying solver, that can be used to read its statistics or to limit its resources.
+// Clauses must not be added to it directly.
+func (is *IncrementalSolver) Solver() *Solver {
+	return is.s
+}
+
+// CheckLits returns an error if one of the given lits is 0 or is about a var bigger than the limit of is.
+// AddClause and Assume do not check their lits: callers that do not trust them must call CheckLits first.
+func (is *IncrementalSolver) CheckLits(lits []int) error {
+	maxVar := is.MaxVar
+	if maxVar <= 0 {
+		maxVar = DefaultMaxVar
+	}
+	for _, lit := range lits {
+		if lit == 0 {
+			return fmt.Errorf("invalid literal 0")
+		}
+		if lit > maxVar || lit < -maxVar {
+			return fmt.Errorf("invalid literal %d: vars are limited to %d", lit, maxVar)
+		}
+	}
+	return nil
+}
+
+// AddClause adds the clause made of the given lits to the problem. lits must not be modified afterwards.
+func (is *IncrementalSolver) AddClause(lits []int) {
+	is.query.Clauses = append(is.query.Clauses, lits)
+}
+
+// Assume sets the assumptions of the next call to Solve, replacing the previous ones, if any.
+func (is *IncrementalSolver) Assume(lits []int) {
+	is.query.Assumptions = lits
+}
+
+// Solve solves the problem under the current assumptions, then forgets them, and returns the status of the problem.
+func (is *IncrementalSolver) Solve() Status {
+	if !is.unsat {
+		is.unsat = is.s.runQuery(is.query)
+	}
+	is.query = Query{}
+	if is.unsat {
+		return Unsat
+	}
+	return is.s.status
+}
+
+// Model returns the model found by the last call to Solve, as DIMACS lits, or nil if the problem was not Sat.
+func (is *IncrementalSolver) Model() []int {
+	if is.unsat || is.s.status != Sat {
+		return nil
+	}
+	model := is.s.Model()
+	res := make([]int, len(model))
+	for i, b := range model {
+		res[i] = i + 1
+		if !b {
+			res[i] = -res[i]
+		}
+	}
+	return res
+}
+
+// Core returns the failed assumptions of the last call to Solve, as DIMACS lits, or nil if the problem was not Unsat.
+// The core is empty if the problem is unsat, no matter the assumptions.
+func (is *IncrementalSolver) Core() []int {
+	if is.unsat {
+		return []int{}
+	}
+	core := is.s.Core()
+	if core == nil {
+		return nil
+	}
+	res := make([]int, len(core))
+	for i, lit := range core {
+		res[i] = int(lit.Int())
+	}
+	return res
+}
+
+// Result returns the result of the last call to Solve. Unlike what Core returns, its core is made of Lits.
+func (is *IncrementalSolver) Result() Result {
+	if is.unsat {
+		return Result{Status: Unsat, Core: []Lit{}, Stats: is.s.Stats}
+	}
+	return is.s.Result()
+}
+
+// Solve runs the queries of ip in order, on a single solver, and returns the status of each of them.
+// If fn is not nil, it is called after each query with the index of the query, its status and the solver,
+// that can be used to retrieve the model (s.Model()) or the core (s.Core()) of the query.
+// fn must not modify the solver.
+func (ip *IncrementalProblem) Solve(fn func(i int, status Status, s *Solver)) []Status {
+	s := New(ParseSliceNb(nil, ip.NbVars))
+	res := make([]Status, len(ip.Queries))
+	unsat := false // True iff the problem is unsat, no matter the assumptions
+	for i, q := range ip.Queries {
+		if !unsat {
+			unsat = s.runQuery(q)
+		}
+		if unsat {
+			s.status = Unsat
+			s.core = []Lit{}
+		}
+		res[i] = s.status
+		if fn != nil {
+			fn(i, res[i], s)
+		}
+	}
+	return res
+}
+
+// runQuery adds the clauses of q to s, then solves the problem under the assumptions of q.
+// It returns true iff the problem is unsat, no matter the assumptions.
+func (s *Solver) runQuery(q Query) bool {
+	if s.Assume(nil) == Unsat { // Clauses must not be simplified with the previous assumptions
+		return true
+	}
+	for _, c := range q.Clauses {
+		lits := make([]Lit, len(c))
+		for i, val := range c {
+			lits[i] = IntToLit(int32(val))
+		}
+		if s.AppendClause(NewClause(lits)); s.status == Unsat {
+			return true
+		}
+	}
+	assumptions := make([]Lit, len(q.Assumptions))
+	for i, val := range q.Assumptions {
+		assumptions[i] = IntToLit(int32(val))
+		s.newVar(assumptions[i].Var()) // Assumptions can be about vars that do not appear in any clause yet
+	}
+	if s.Assume(assumptions) != Unsat {
+		s.Solve()
+	}
+	return s.status == Unsat && len(s.core) == 0
+}
@@ -0,0 +1,137 @@
+package solver
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncrementalSolve(t *testing.T) {
+	ip, err := ParseICNF(strings.NewReader("p inccnf\n1 2 0\n-1 3 0\na -2 0\na -2 -3 0\n-3 0\na 0\na -2 0\n2 0\na 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	expected := []Status{Sat, Unsat, Sat, Unsat, Sat}
+	models := map[int][]bool{0: {true, false, true}, 2: {false, true, false}, 4: {false, true, false}}
+	var cores [][]Lit
+	statuses := ip.Solve(func(i int, status Status, s *Solver) {
+		if status == Sat {
+			if model := s.Model(); !reflect.DeepEqual(model, models[i]) {
+				t.Errorf("invalid model for query %d: expected %v, got %v", i, models[i], model)
+			}
+		} else {
+			cores = append(cores, s.Core())
+		}
+	})
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Errorf("invalid status for query %d: expected %v, got %v", i, expected[i], statuses[i])
+		}
+	}
+	if len(cores) != 2 || len(cores[0]) == 0 || len(cores[1]) != 1 || cores[1][0] != IntToLit(-2) {
+		t.Errorf("invalid cores %v", cores)
+	}
+}
+
+func TestIncrementalSolveUnsat(t *testing.T) {
+	ip := &IncrementalProblem{
+		NbVars: 2,
+		Queries: []Query{
+			{Clauses: [][]int{{1, 2}, {-1}}, Assumptions: []int{2}},
+			{Clauses: [][]int{{-2}}},
+			{Clauses: [][]int{{1, 2}}, Assumptions: []int{1}},
+		},
+	}
+	statuses := ip.Solve(func(i int, status Status, s *Solver) {
+		if status == Unsat && len(s.Core()) != 0 {
+			t.Errorf("expected empty core for query %d, got %v", i, s.Core())
+		}
+	})
+	if statuses[0] != Sat || statuses[1] != Unsat || statuses[2] != Unsat {
+		t.Errorf("invalid statuses %v", statuses)
+	}
+}
+
+func TestIncrementalSolveRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const nbVars = 12
+	ip := &IncrementalProblem{NbVars: nbVars}
+	var all [][]int
+	for i := 0; i < 40; i++ {
+		var q Query
+		for j := 0; j < 4; j++ {
+			c := make([]int, 3)
+			for k := range c {
+				c[k] = rng.Intn(nbVars) + 1
+				if rng.Intn(2) == 0 {
+					c[k] = -c[k]
+				}
+			}
+			q.Clauses = append(q.Clauses, c)
+		}
+		for j := rng.Intn(4); j > 0; j-- {
+			q.Assumptions = append(q.Assumptions, (rng.Intn(nbVars)+1)*(2*rng.Intn(2)-1))
+		}
+		ip.Queries = append(ip.Queries, q)
+	}
+	statuses := ip.Solve(nil)
+	for i, q := range ip.Queries {
+		all = append(all, q.Clauses...)
+		clauses := append([][]int{}, all...)
+		for _, lit := range q.Assumptions {
+			clauses = append(clauses, []int{lit})
+		}
+		if expected := New(ParseSliceNb(clauses, nbVars)).Solve(); statuses[i] != expected {
+			t.Errorf("invalid status for query %d: expected %v, got %v", i, expected, statuses[i])
+		}
+	}
+}
+
+func TestIncrementalSolver(t *testing.T) {
+	is := NewIncrementalSolver()
+	is.AddClause([]int{1, 2})
+	is.AddClause([]int{-1, 3})
+	is.Assume([]int{-2, -3})
+	if status := is.Solve(); status != Unsat || is.Model() != nil || len(is.Core()) == 0 {
+		t.Errorf("expected unsat with a core, got %v, core %v", status, is.Core())
+	}
+	if status := is.Solve(); status != Sat || is.Core() != nil {
+		t.Errorf("expected sat once assumptions were forgotten, got %v", status)
+	} else if m := is.Model(); len(m) != 3 || (m[0] < 0 && m[1] < 0) || (m[0] > 0 && m[2] < 0) {
+		t.Errorf("invalid model %v", m)
+	}
+	is.Assume([]int{4})
+	if status := is.Solve(); status != Sat || len(is.Model()) != 4 || is.Model()[3] != 4 {
+		t.Errorf("expected sat with a new var, got %v, model %v", status, is.Model())
+	}
+	is.AddClause([]int{-2})
+	is.AddClause([]int{-3})
+	if status := is.Solve(); status != Unsat || is.Core() == nil || len(is.Core()) != 0 {
+		t.Errorf("expected unsat with an empty core, got %v, core %v", status, is.Core())
+	}
+}
+
+func TestIncrementalSolverTerminate(t *testing.T) {
+	const nbPigeons = 10 // Too many pigeons for the holes, and quite hard to prove
+	is := NewIncrementalSolver()
+	v := func(pigeon, hole int) int { return pigeon*(nbPigeons-1) + hole + 1 }
+	for p := 0; p < nbPigeons; p++ {
+		clause := make([]int, nbPigeons-1)
+		for h := range clause {
+			clause[h] = v(p, h)
+			for p2 := 0; p2 < p; p2++ {
+				is.AddClause([]int{-v(p, h), -v(p2, h)})
+			}
+		}
+		is.AddClause(clause)
+	}
+	nbCalls := 0
+	is.Solver().Terminate = func() bool {
+		nbCalls++
+		return true
+	}
+	if status := is.Solve(); status != Indet || nbCalls == 0 {
+		t.Errorf("expected indet once Terminate was called, got %v after %d calls", status, nbCalls)
+	}
+}
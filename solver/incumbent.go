@@ -0,0 +1,41 @@
+package solver
+
+import "sync"
+
+// This file deals with the best model found so far while optimizing, that can be read from another goroutine,
+// so that a program can serve a good enough answer on demand, without waiting for the optimum to be proven.
+
+// An incumbent is the best model found so far by Minimize or Optimal, protected by a mutex.
+type incumbent struct {
+	mu    sync.Mutex
+	model []bool // Best model found so far, or nil if there is none yet. It is never modified once set.
+	cost  int    // Cost of model
+}
+
+// setBest records the last model found by s, whose cost is cost, as its best model so far.
+func (s *Solver) setBest(cost int) {
+	model := s.Model()
+	s.best.mu.Lock()
+	s.best.model, s.best.cost = model, cost
+	s.best.mu.Unlock()
+}
+
+// resetBest forgets the best model found so far, when a new optimization starts.
+func (s *Solver) resetBest() {
+	s.best.mu.Lock()
+	s.best.model, s.best.cost = nil, 0
+	s.best.mu.Unlock()
+}
+
+// BestModel returns the best model found so far by the running, or last, call to Minimize or Optimal,
+// along with its cost. ok is false if no model was found yet.
+// Unlike the other methods of s, it can be called from another goroutine while s is optimizing.
+// The returned model is a copy, that the caller is free to modify.
+func (s *Solver) BestModel() (model []bool, cost int, ok bool) {
+	s.best.mu.Lock()
+	defer s.best.mu.Unlock()
+	if s.best.model == nil {
+		return nil, 0, false
+	}
+	return append([]bool(nil), s.best.model...), s.best.cost, true
+}
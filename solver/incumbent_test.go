@@ -0,0 +1,44 @@
+package solver
+
+import "testing"
+
+func TestBestModel(t *testing.T) {
+	pb := parseFile(t, "testcnf/lo_8x8_009.opb", ParseOPB)
+	s := New(pb)
+	if _, _, ok := s.BestModel(); ok {
+		t.Fatalf("no model should be known before optimizing")
+	}
+	results := make(chan Result)
+	done := make(chan Result)
+	go func() { done <- s.Optimal(results, nil) }()
+	for res := range results {
+		// Optimal is blocked until the result is read, so the best model is the one of the result
+		model, cost, ok := s.BestModel()
+		if !ok || cost != res.Weight {
+			t.Errorf("expected best cost %d, got %d (%t)", res.Weight, cost, ok)
+		}
+		if len(model) != len(res.Model) {
+			t.Fatalf("expected a model with %d vars, got %d", len(res.Model), len(model))
+		}
+		for i := range model {
+			if model[i] != res.Model[i] {
+				t.Errorf("best model and result disagree on var %d", i+1)
+			}
+		}
+		model[0] = !model[0] // Must not modify the snapshot
+		if model2, _, _ := s.BestModel(); model2[0] == model[0] {
+			t.Errorf("best model was modified by the caller")
+		}
+	}
+	res := <-done
+	if _, cost, ok := s.BestModel(); !ok || cost != 27 || res.Weight != 27 {
+		t.Errorf("expected a best cost of 27, got %d (%t), result %d", cost, ok, res.Weight)
+	}
+	s = New(parseFile(t, "testcnf/lo_8x8_009.opb", ParseOPB)) // Once optimized, s is Unsat
+	if cost := s.Minimize(); cost != 27 {
+		t.Errorf("expected a cost of 27 after Minimize, got %d", cost)
+	}
+	if _, cost, ok := s.BestModel(); !ok || cost != 27 {
+		t.Errorf("expected a best cost of 27 after Minimize, got %d (%t)", cost, ok)
+	}
+}
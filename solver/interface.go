@@ -5,10 +5,52 @@ package solver
 // This value is typically used in optimization processes.
 // If the weight is 0, that means all constraints could be solved.
 // By definition, in decision problems, the cost will always be 0.
+// When the solver had to stop before proving optimality, Optim indicates what the result is worth,
+// and Bound holds the best proven lower bound on the cost of an optimal model.
+// If the status is Unsat, Core holds the failed assumptions, if any. Stats are the statistics of the solver
+// once the result was found.
+// If the solver wrote its certificate to a file (see Solver.CertFile) and the result is Unsat or a proven optimum,
+// Proof is the path of that file.
 type Result struct {
 	Status Status
 	Model  []bool
 	Weight int
+	Optim  OptimStatus
+	Bound  int
+	Core   []Lit
+	Stats  Stats
+	Proof  string
+}
+
+// An OptimStatus indicates the quality of the result of an optimization process.
+// Its zero value, NotOptim, is the status of results that say nothing about the cost of models.
+type OptimStatus byte
+
+const (
+	// NotOptim means the result holds no information about the cost: the problem is Unsat,
+	// or the result was not produced by an optimization process.
+	NotOptim = OptimStatus(iota)
+	// BoundOnly means no model could be found (yet), only a lower bound on the cost is known.
+	BoundOnly
+	// FeasibleOnly means a model was found, but it was not proven optimal.
+	FeasibleOnly
+	// Optimum means the model was proven optimal.
+	Optimum
+)
+
+func (o OptimStatus) String() string {
+	switch o {
+	case NotOptim:
+		return "not-optim"
+	case BoundOnly:
+		return "bound-only"
+	case FeasibleOnly:
+		return "feasible-only"
+	case Optimum:
+		return "optimal"
+	default:
+		panic("invalid optimization status")
+	}
 }
 
 // Interface is any type implementing a solver.
@@ -21,7 +63,8 @@ type Interface interface {
 	// It will stop as soon as a model of cost 0 is found, or the problem is not satisfiable anymore.
 	// The last satisfying model, if any, will be returned with the Sat status.
 	// If no model at all could be found, the Unsat status will be returned.
-	// If the solver prematurely stopped, the Indet status will be returned.
+	// If the solver prematurely stopped, the best model found so far, if any, will be returned with the Sat status
+	// and the FeasibleOnly optimization status; if there is no such model, the Indet status will be returned.
 	// If data is sent to stop, the method may stop prematurely.
 	// In any case, results will be closed before the function returns.
 	// NOTE: data sent on stop may be ignored by an implementation.
@@ -36,7 +36,7 @@ func (s *Solver) addClauseLits(confl *Clause, lvl decLevel, met, metLvl []bool,
 	return nbLvl
 }
 
-var bufLits = make([]Lit, 10000) // Buffer for lits in learnClause. Used to reduce allocations.
+const nbBufLits = 10000 // Initial size of the buffer for lits in learnClause.
 
 // learnClause creates a conflict clause and returns either:
 // - the clause itself, if its len is at least 2,
@@ -44,7 +44,12 @@ var bufLits = make([]Lit, 10000) // Buffer for lits in learnClause. Used to redu
 // - a nil clause and -1, if the empty clause was learned.
 func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit Lit) {
 	s.clauseBumpActivity(confl)
-	lits := bufLits[:1]             // Not 0: make room for asserting literal
+	s.countLemmaUse(confl)
+	s.antecedents.start(s.TrackAntecedents, confl)
+	if s.bufLits == nil {
+		s.bufLits = make([]Lit, nbBufLits)
+	}
+	lits := s.bufLits[:1]           // Not 0: make room for asserting literal
 	buf := make([]bool, s.nbVars*2) // Buffer for met and metLvl; reduces allocs/deallocs
 	met := buf[:s.nbVars]           // List of all vars already met
 	metLvl := buf[s.nbVars:]        // List of all vars from current level to deal with
@@ -67,6 +72,8 @@ func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit
 		nbLvl--
 		if reason := s.reason[v]; reason != nil {
 			s.clauseBumpActivity(reason)
+			s.countLemmaUse(reason)
+			s.antecedents.mark(reason)
 			for i := 0; i < reason.Len(); i++ {
 				lit := reason.Get(i)
 				if v2 := lit.Var(); !met[v2] {
@@ -96,10 +103,12 @@ func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit
 	sortLiterals(lits, s.model)
 	sz := s.minimizeLearned(met, lits)
 	if sz == 1 {
+		s.antecedents.saveUnit(lits[0].Var())
 		return nil, lits[0]
 	}
 	learned = NewLearnedClause(alloc.newLits(lits[0:sz]...))
 	learned.computeLbd(s.model)
+	s.antecedents.save(learned)
 	return learned, -1
 }
 
@@ -112,14 +121,19 @@ func (s *Solver) minimizeLearned(met []bool, learned []Lit) int {
 			learned[sz] = learned[i]
 			sz++
 		} else {
+			removed := true
 			for k := 0; k < reason.Len(); k++ {
 				lit := reason.Get(k)
 				if !met[lit.Var()] /*&& abs(s.model[lit.Var()]) > 1*/ {
 					learned[sz] = learned[i]
 					sz++
+					removed = false
 					break
 				}
 			}
+			if removed {
+				s.antecedents.mark(reason)
+			}
 		}
 	}
 	return sz
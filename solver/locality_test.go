@@ -0,0 +1,66 @@
+package solver
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSortByLocality(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	left := randomCNF(rng, 20, 60, 3)
+	right := randomCNF(rng, 20, 60, 3)
+	var cnf [][]int
+	for i := range left { // Clauses of two independent components are interleaved
+		shifted := make([]int, len(right[i]))
+		for j, val := range right[i] {
+			if val > 0 {
+				shifted[j] = val + 20
+			} else {
+				shifted[j] = val - 20
+			}
+		}
+		cnf = append(cnf, left[i], shifted)
+	}
+	pb := ParseSliceNb(cnf, 40)
+	nbClauses := len(pb.Clauses)
+	before := make(map[*Clause]bool, nbClauses)
+	for _, c := range pb.Clauses {
+		before[c] = true
+	}
+	pb.SortByLocality()
+	if len(pb.Clauses) != nbClauses {
+		t.Fatalf("expected %d clauses, got %d", nbClauses, len(pb.Clauses))
+	}
+	nbSwitches := 0 // How many times two consecutive clauses belong to different components
+	for i, c := range pb.Clauses {
+		if !before[c] {
+			t.Fatalf("clause %s was not part of the problem", c.CNF())
+		}
+		delete(before, c)
+		if i > 0 && (c.Get(0).Var() < 20) != (pb.Clauses[i-1].Get(0).Var() < 20) {
+			nbSwitches++
+		}
+	}
+	if nbSwitches > 1 {
+		t.Errorf("components should not be interleaved, got %d switches", nbSwitches)
+	}
+	expected, _, err := solveCNF(cnf, 40)
+	if err != nil {
+		t.Fatalf("reference solver failed: %v", err)
+	}
+	s := New(pb)
+	if status := s.Solve(); status != expected {
+		t.Errorf("expected %v, got %v", expected, status)
+	} else if status == Sat && !satisfies(cnf, s.Model()) {
+		t.Errorf("model does not satisfy the problem")
+	}
+	pb, err = ParseCNF(strings.NewReader("p cnf 3 3\n0\n1 2 0\n-2 3 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	pb.SortByLocality()
+	if len(pb.Clauses) != 3 || pb.Clauses[2].Len() != 0 || New(pb).Solve() != Unsat {
+		t.Errorf("empty clause was lost: %q", pb.CNF())
+	}
+}
@@ -0,0 +1,222 @@
+package solver
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// This file is a harness for metamorphic testing: a random problem generated from a seed is solved,
+// then transformed in ways that preserve its satisfiability, and all variants must get the same answer.
+// As everything derives from the seed, a failing seed can be replayed alone with checkMetamorphic.
+
+// randomCNF returns a random k-CNF with nbVars vars and nbClauses clauses.
+// Vars are distinct in each clause.
+func randomCNF(rng *rand.Rand, nbVars, nbClauses, k int) [][]int {
+	cnf := make([][]int, nbClauses)
+	for i := range cnf {
+		perm := rng.Perm(nbVars)
+		cnf[i] = make([]int, k)
+		for j := range cnf[i] {
+			cnf[i][j] = perm[j] + 1
+			if rng.Intn(2) == 0 {
+				cnf[i][j] = -cnf[i][j]
+			}
+		}
+	}
+	return cnf
+}
+
+// shuffleCNF returns a copy of cnf whose clauses, and lits in each clause, were shuffled.
+func shuffleCNF(rng *rand.Rand, cnf [][]int) [][]int {
+	res := make([][]int, len(cnf))
+	for i, j := range rng.Perm(len(cnf)) {
+		res[i] = make([]int, len(cnf[j]))
+		for k, l := range rng.Perm(len(cnf[j])) {
+			res[i][k] = cnf[j][l]
+		}
+	}
+	return res
+}
+
+// renameCNF returns a copy of cnf whose vars were permuted and whose polarities were randomly flipped,
+// along with the renaming: lit l of cnf became lit renaming[|l|-1] * sign(l).
+func renameCNF(rng *rand.Rand, cnf [][]int, nbVars int) (res [][]int, renaming []int) {
+	renaming = make([]int, nbVars)
+	for i, v := range rng.Perm(nbVars) {
+		renaming[i] = v + 1
+		if rng.Intn(2) == 0 {
+			renaming[i] = -renaming[i]
+		}
+	}
+	res = make([][]int, len(cnf))
+	for i, clause := range cnf {
+		res[i] = make([]int, len(clause))
+		for j, lit := range clause {
+			if lit > 0 {
+				res[i][j] = renaming[lit-1]
+			} else {
+				res[i][j] = -renaming[-lit-1]
+			}
+		}
+	}
+	return res, renaming
+}
+
+// addBlockedClause returns a copy of cnf with an extra clause blocked on a random lit l:
+// all its resolvents on l with the clauses of cnf are tautologies, so adding it preserves satisfiability.
+// If no blocked clause could be built on l, cnf is returned unchanged.
+func addBlockedClause(rng *rand.Rand, cnf [][]int, nbVars int) [][]int {
+	l := rng.Intn(nbVars) + 1
+	if rng.Intn(2) == 0 {
+		l = -l
+	}
+	blocked := []int{l}
+	inBlocked := map[int]bool{l: true}
+	for _, clause := range cnf {
+		var others []int // Lits of clause, other than -l, if it contains -l
+		found := false
+		for _, lit := range clause {
+			if lit == -l {
+				found = true
+			} else {
+				others = append(others, lit)
+			}
+		}
+		if !found {
+			continue
+		}
+		if len(others) == 0 {
+			return cnf
+		}
+		lit := -others[rng.Intn(len(others))]
+		if inBlocked[-lit] {
+			return cnf
+		}
+		if !inBlocked[lit] {
+			blocked = append(blocked, lit)
+			inBlocked[lit] = true
+		}
+	}
+	return append(cnf[:len(cnf):len(cnf)], blocked)
+}
+
+// satisfies returns true iff model satisfies all clauses of cnf.
+func satisfies(cnf [][]int, model []bool) bool {
+	for _, clause := range cnf {
+		sat := false
+		for _, lit := range clause {
+			if lit > 0 && model[lit-1] || lit < 0 && !model[-lit-1] {
+				sat = true
+				break
+			}
+		}
+		if !sat {
+			return false
+		}
+	}
+	return true
+}
+
+// solveCNF solves cnf and returns its status, along with a model if it is sat.
+// The model is checked against cnf.
+func solveCNF(cnf [][]int, nbVars int) (Status, []bool, error) {
+	s := New(ParseSliceNb(cnf, nbVars))
+	status := s.Solve()
+	if status != Sat {
+		return status, nil, nil
+	}
+	model := s.Model()
+	if !satisfies(cnf, model) {
+		return status, nil, fmt.Errorf("model %v does not satisfy the problem", model)
+	}
+	return status, model, nil
+}
+
+// checkMetamorphic generates a random problem from seed, solves it and all its variants,
+// and returns an error if their answers are inconsistent.
+func checkMetamorphic(seed int64) error {
+	rng := rand.New(rand.NewSource(seed))
+	nbVars := 5 + rng.Intn(20)
+	cnf := randomCNF(rng, nbVars, nbVars*4+rng.Intn(nbVars), 3)
+	expected, _, err := solveCNF(cnf, nbVars)
+	if err != nil {
+		return fmt.Errorf("original problem: %v", err)
+	}
+	renamed, renaming := renameCNF(rng, cnf, nbVars)
+	blocked := cnf
+	for i := 0; i < 5; i++ {
+		blocked = addBlockedClause(rng, blocked, nbVars)
+	}
+	variants := []struct {
+		name string
+		cnf  [][]int
+	}{
+		{"shuffled", shuffleCNF(rng, cnf)},
+		{"renamed", renamed},
+		{"blocked", blocked},
+		{"all", shuffleCNF(rng, addBlockedClause(rng, renamed, nbVars))},
+	}
+	for _, variant := range variants {
+		status, model, err := solveCNF(variant.cnf, nbVars)
+		if err != nil {
+			return fmt.Errorf("%s variant: %v", variant.name, err)
+		}
+		if status != expected {
+			return fmt.Errorf("%s variant: expected %v, got %v", variant.name, expected, status)
+		}
+		if variant.name == "renamed" && model != nil { // The model can be renamed back
+			orig := make([]bool, nbVars)
+			for i, lit := range renaming {
+				if lit > 0 {
+					orig[i] = model[lit-1]
+				} else {
+					orig[i] = !model[-lit-1]
+				}
+			}
+			if !satisfies(cnf, orig) {
+				return fmt.Errorf("renamed variant: model %v does not satisfy the original problem once renamed back", model)
+			}
+		}
+	}
+	return nil
+}
+
+func TestMetamorphic(t *testing.T) {
+	for seed := int64(0); seed < 300; seed++ {
+		if err := checkMetamorphic(seed); err != nil {
+			t.Errorf("seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestAddBlockedClause(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cnf := [][]int{{1, 2}, {-1, 3}, {-1, -2}}
+	for i := 0; i < 20; i++ {
+		res := addBlockedClause(rng, cnf, 3)
+		if len(res) == len(cnf) {
+			continue
+		}
+		blocked := res[len(res)-1]
+		l := blocked[0]
+		for _, clause := range cnf {
+			for _, lit := range clause {
+				if lit != -l {
+					continue
+				}
+				tauto := false
+				for _, lit2 := range clause {
+					for _, lit3 := range blocked {
+						if lit2 == -lit3 && lit2 != -l {
+							tauto = true
+						}
+					}
+				}
+				if !tauto {
+					t.Errorf("clause %v is not blocked on %d in %v", blocked, l, cnf)
+				}
+			}
+		}
+	}
+}
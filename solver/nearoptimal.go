@@ -0,0 +1,120 @@
+package solver
+
+// This file deals with the enumeration of near-optimal models, i.e of all models whose cost
+// is within a given distance of the optimal cost.
+// Contrary to Optimal, the cost bounds found during the optimization are guarded by reserved selectors,
+// so that the final bound can be relaxed once the optimum is known.
+
+// EnumerateNearOptimal computes the optimal cost of the problem, then sends on results all models
+// whose cost is at most the optimal cost plus delta, and returns their number.
+// Each model is sent exactly once, even when some of its vars did not need to be bound.
+// Results associated with an optimal model have the Optimum status, other ones have the FeasibleOnly status.
+// If the problem has no optimization function, all models are sent.
+// results, if non-nil, will be closed at the end of the call.
+// If data is sent on stop or if the solver's budget is exhausted, enumeration stops and the number of models
+// found so far is returned; if the optimum was not reached yet, no model is sent and 0 is returned.
+// The problem is unsat after the call, so s cannot be used to solve anything else.
+func (s *Solver) EnumerateNearOptimal(delta int, results chan Result, stop chan struct{}) int {
+	if results != nil {
+		defer close(results)
+	}
+	s.stop = stop
+	defer func() { s.stop = nil }()
+	if s.Solve() != Sat {
+		return 0
+	}
+	optimum, ok := s.guardedMinimize()
+	if !ok {
+		return 0
+	}
+	if s.minLits != nil {
+		s.appendBound(optimum+delta, nil)
+	}
+	nb := 0
+	for s.Solve() == Sat {
+		model := s.Model()
+		cost := s.cost(s.lastModel)
+		res := Result{Status: Sat, Model: model, Weight: cost + s.costOffset, Bound: optimum + s.costOffset, Optim: FeasibleOnly}
+		if cost == optimum {
+			res.Optim = Optimum
+		}
+		if results != nil {
+			results <- res
+		}
+		nb++
+		blocking := make([]Lit, len(model)) // Each model must be found only once
+		for v, val := range model {
+			blocking[v] = Var(v).SignedLit(val)
+		}
+		s.AppendClause(NewClause(blocking))
+	}
+	return nb
+}
+
+// guardedMinimize returns the optimal cost of the problem, which must be sat.
+// Each cost bound is guarded by a reserved selector: once the optimum is found, all selectors are made false,
+// so that the problem has the same models as before the call.
+// If the search was interrupted before the optimum was proved, ok is false.
+func (s *Solver) guardedMinimize() (optimum int, ok bool) {
+	optimum = s.cost(s.lastModel)
+	var selectors []Lit
+	for optimum > 0 && s.status == Sat {
+		s.Assume(nil) // Otherwise, the bound would be simplified by the previous selector
+		sel := s.ReserveVars(1)[0].Lit()
+		s.appendBound(optimum-1, &sel)
+		selectors = append(selectors, sel)
+		s.rebuildOrderHeap()
+		if s.Assume([]Lit{sel}) == Unsat || s.Solve() == Unsat {
+			break
+		}
+		if s.status == Indet {
+			return 0, false
+		}
+		optimum = s.cost(s.lastModel)
+	}
+	s.Assume(nil)
+	for _, sel := range selectors {
+		s.AppendClause(NewClause([]Lit{sel.Negation()}))
+	}
+	return optimum, true
+}
+
+// cost returns the cost of model wrt the optimization function of the problem.
+func (s *Solver) cost(model Model) int {
+	cost := 0
+	for i, lit := range s.minLits {
+		if model[lit.Var()] > 0 == lit.IsPositive() {
+			if s.minWeights == nil {
+				cost++
+			} else {
+				cost += s.minWeights[i]
+			}
+		}
+	}
+	return cost
+}
+
+// appendBound adds the constraint stating the cost of models is at most bound.
+// If sel is not nil, the constraint only holds when sel is true.
+func (s *Solver) appendBound(bound int, sel *Lit) {
+	maxCost := 0
+	lits := make([]Lit, len(s.minLits), len(s.minLits)+1)
+	weights := make([]int, len(s.minLits), len(s.minLits)+1)
+	for i, lit := range s.minLits {
+		lits[i] = lit.Negation()
+		weights[i] = 1
+		if s.minWeights != nil {
+			weights[i] = s.minWeights[i]
+		}
+		maxCost += weights[i]
+	}
+	card := maxCost - bound // Weight of the falsified minimization lits
+	if card <= 0 {
+		return
+	}
+	if sel != nil {
+		lits = append(lits, sel.Negation())
+		weights = append(weights, card)
+	}
+	s.AppendClause(NewPBClause(lits, weights, card))
+}
@@ -0,0 +1,41 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEnumerateNearOptimal(t *testing.T) {
+	const opb = `* #variable= 3 #constraint= 1
+min: +1 x1 +2 x2 +3 x3 ;
++1 x1 +1 x2 +1 x3 >= 1 ;
+`
+	for delta, expected := range []int{1, 2, 4, 5, 6} {
+		pb, err := ParseOPB(strings.NewReader(opb))
+		if err != nil {
+			t.Fatalf("could not parse problem: %v", err)
+		}
+		results := make(chan Result)
+		go New(pb).EnumerateNearOptimal(delta, results, nil)
+		nb := 0
+		seen := make(map[string]bool)
+		for res := range results {
+			nb++
+			if res.Weight > 1+delta {
+				t.Errorf("delta %d: model %v has cost %d", delta, res.Model, res.Weight)
+			}
+			if (res.Optim == Optimum) != (res.Weight == 1) {
+				t.Errorf("delta %d: invalid optimality status for model %v of cost %d", delta, res.Model, res.Weight)
+			}
+			key := fmt.Sprint(res.Model)
+			if seen[key] {
+				t.Errorf("delta %d: model %v found twice", delta, res.Model)
+			}
+			seen[key] = true
+		}
+		if nb != expected {
+			t.Errorf("delta %d: expected %d models, got %d", delta, expected, nb)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package solver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// This file deals with the exchange of learned constraints with other engines (CP solvers, MIP solvers, other SAT solvers, etc.).
+// Constraints are exchanged as JSON lines, one constraint per line, such as
+//
+//	{"lits":[1,-2,3]}
+//	{"lits":[1,2,-4],"bound":2}
+//	{"lits":[1,2,3],"weights":[3,2,1],"bound":3}
+//
+// The first line is the clause 1 ∨ ¬2 ∨ 3, i.e the nogood 1=false, 2=true, 3=false.
+// The second one means that at least 2 of the lits must be true, and the last one is the cut 3x1 + 2x2 + x3 ≥ 3.
+
+// A Nogood is a constraint that was learned by an engine and that all models of the problem satisfy.
+// Lits are CNF literals. If Bound is 0, at least one of the lits must be true.
+// Otherwise, the sum of the weights of true lits must be at least Bound. If Weights is nil, each lit weighs 1.
+type Nogood struct {
+	Lits    []int `json:"lits"`
+	Weights []int `json:"weights,omitempty"`
+	Bound   int   `json:"bound,omitempty"`
+	LBD     int   `json:"lbd,omitempty"` // LBD of the constraint when it was learned, if known
+}
+
+// An ImportFilter selects which imported nogoods are worth adding to a solver.
+// Nogoods that are long, or that had a high LBD when they were learned, rarely help the search
+// and slow propagation down, so that a warm start with them can be slower than a cold one.
+type ImportFilter struct {
+	MaxLen int // If strictly positive, nogoods with more lits are dropped
+	MaxLBD int // If strictly positive, nogoods whose LBD is known and bigger are dropped
+}
+
+// NogoodFromClause returns the nogood equivalent to c.
+// Will panic if c has arbitrary-precision weights, that nogoods cannot represent.
+func NogoodFromClause(c *Clause) Nogood {
+	ng := Nogood{Lits: make([]int, c.Len())}
+	for i := range ng.Lits {
+		ng.Lits[i] = int(c.Get(i).Int())
+	}
+	if c.PseudoBoolean() {
+		ng.Weights = make([]int, c.Len())
+		for i := range ng.Weights {
+			ng.Weights[i] = c.Weight(i)
+		}
+	}
+	if card := c.Cardinality(); card > 1 || c.PseudoBoolean() {
+		ng.Bound = card
+	}
+	if c.Learned() {
+		ng.LBD = c.lbd()
+	}
+	return ng
+}
+
+// Clause returns the clause equivalent to ng, or an error if ng is not well-formed.
+func (ng Nogood) Clause() (*Clause, error) {
+	lits := make([]Lit, len(ng.Lits))
+	for i, val := range ng.Lits {
+		if val == 0 {
+			return nil, fmt.Errorf("null literal in nogood %v", ng.Lits)
+		}
+		lits[i] = IntToLit(int32(val))
+	}
+	if ng.Bound < 0 {
+		return nil, fmt.Errorf("invalid negative bound %d", ng.Bound)
+	}
+	if ng.Weights == nil {
+		if ng.Bound > len(lits) {
+			return nil, fmt.Errorf("bound %d is bigger than the number of lits %d", ng.Bound, len(lits))
+		}
+		if ng.Bound <= 1 {
+			return NewClause(lits), nil
+		}
+		return NewCardClause(lits, ng.Bound), nil
+	}
+	if len(ng.Weights) != len(lits) {
+		return nil, fmt.Errorf("%d weights for %d lits", len(ng.Weights), len(lits))
+	}
+	weights := make([]int, len(ng.Weights))
+	copy(weights, ng.Weights)
+	bound := ng.Bound
+	if bound == 0 {
+		bound = 1
+	}
+	return NewPBClauseErr(lits, weights, bound)
+}
+
+// ReadNogoods reads nogoods from r, one JSON object per line, and returns the equivalent clauses.
+// Empty lines are ignored.
+func ReadNogoods(r io.Reader) ([]*Clause, error) {
+	_, clauses, err := readNogoods(r)
+	return clauses, err
+}
+
+// readNogoods is like ReadNogoods, but it also returns the nogoods the clauses are equivalent to.
+func readNogoods(r io.Reader) ([]Nogood, []*Clause, error) {
+	var ngs []Nogood
+	var res []*Clause
+	scanner := NewScanner(r, 0)
+	for nbLine := 1; scanner.Scan(); nbLine++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var ng Nogood
+		if err := json.Unmarshal(line, &ng); err != nil {
+			return nil, nil, fmt.Errorf("invalid nogood at line %d: %v", nbLine, err)
+		}
+		c, err := ng.Clause()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid nogood at line %d: %v", nbLine, err)
+		}
+		ngs = append(ngs, ng)
+		res = append(res, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("could not read nogoods: %v", err)
+	}
+	return ngs, res, nil
+}
+
+// WriteNogoods writes the given clauses on w, as nogoods, one JSON object per line.
+func WriteNogoods(w io.Writer, clauses []*Clause) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, c := range clauses {
+		if err := enc.Encode(NogoodFromClause(c)); err != nil {
+			return fmt.Errorf("could not write nogood: %v", err)
+		}
+	}
+	return bw.Flush()
+}
+
+// ImportNogoods reads nogoods from r and adds them to the problem solved by s.
+// Once the solver is running, nogoods can also be sent on s.ImportChan.
+// If s.ImportFilter is not nil, nogoods it rejects are ignored.
+// It returns the number of imported nogoods.
+func (s *Solver) ImportNogoods(r io.Reader) (int, error) {
+	ngs, clauses, err := readNogoods(r)
+	if err != nil {
+		return 0, err
+	}
+	nb := 0
+	units := s.unitVars()
+	for i, c := range clauses {
+		if s.keepImported(c, ngs[i].LBD, units) {
+			s.AppendClause(c)
+			nb++
+		}
+	}
+	return nb, nil
+}
+
+// ExportNogoods writes on w the constraints learned by s so far, i.e its learned clauses and top-level units.
+// They are satisfied by all models of the problem, no matter the assumptions.
+func (s *Solver) ExportNogoods(w io.Writer) error {
+	clauses := make([]*Clause, 0, len(s.units)+len(s.wl.learned))
+	for _, unit := range s.units {
+		clauses = append(clauses, NewClause([]Lit{unit}))
+	}
+	clauses = append(clauses, s.wl.learned...)
+	return WriteNogoods(w, clauses)
+}
+
+// importClauses adds to the problem all clauses that are pending on s.ImportChan, if any.
+func (s *Solver) importClauses() {
+	if s.ImportChan == nil {
+		return
+	}
+	var units []bool
+	for {
+		select {
+		case c := <-s.ImportChan:
+			if units == nil {
+				units = s.unitVars()
+			}
+			lbd := 0
+			if c.Learned() {
+				lbd = c.lbd()
+			}
+			if s.keepImported(c, lbd, units) {
+				s.AppendClause(c)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// unitVars returns, for each var of s, whether it is bound by a top-level unit.
+// It is meant to be built once per batch of imported clauses and given to keepImported.
+// It returns nil if s.ImportFilter is nil, since keepImported does not need it then.
+func (s *Solver) unitVars() []bool {
+	if s.ImportFilter == nil {
+		return nil
+	}
+	units := make([]bool, s.nbVars)
+	for _, unit := range s.units {
+		// Vars bound at level 1 can be bound because of assumptions: only s.units are true top-level bindings.
+		units[unit.Var()] = true
+	}
+	return units
+}
+
+// keepImported returns true iff the imported clause c, whose LBD is lbd, or 0 if unknown, passes s.ImportFilter.
+// Besides the limits of the filter, c is checked against the top-level bindings, assumptions excluded:
+// a satisfied clause is useless, and a clause with a falsified lit was learned in a context
+// that does not match the current one anymore.
+// units is the result of s.unitVars.
+func (s *Solver) keepImported(c *Clause, lbd int, units []bool) bool {
+	f := s.ImportFilter
+	if f == nil {
+		return true
+	}
+	keep := (f.MaxLen <= 0 || c.Len() <= f.MaxLen) && (f.MaxLBD <= 0 || lbd <= f.MaxLBD)
+	for i := 0; keep && i < c.Len(); i++ {
+		if v := c.Get(i).Var(); int(v) < len(units) && units[v] {
+			keep = false
+		}
+	}
+	if !keep {
+		s.Stats.NbFiltered++
+	}
+	return keep
+}
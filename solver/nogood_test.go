@@ -0,0 +1,114 @@
+package solver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadWriteNogoods(t *testing.T) {
+	const nogoods = `{"lits":[1,-2,3]}
+{"lits":[1,2,-4],"bound":2}
+
+{"lits":[1,2,3],"weights":[3,2,1],"bound":3}
+`
+	clauses, err := ReadNogoods(strings.NewReader(nogoods))
+	if err != nil {
+		t.Fatalf("could not read nogoods: %v", err)
+	}
+	if len(clauses) != 3 {
+		t.Fatalf("expected 3 nogoods, got %d", len(clauses))
+	}
+	if card := clauses[1].Cardinality(); card != 2 {
+		t.Errorf("invalid cardinality for second nogood: expected 2, got %d", card)
+	}
+	if !clauses[2].PseudoBoolean() || clauses[2].Weight(0) != 3 {
+		t.Errorf("third nogood should be a PB constraint, got %s", clauses[2].PBString())
+	}
+	var buf bytes.Buffer
+	if err := WriteNogoods(&buf, clauses); err != nil {
+		t.Fatalf("could not write nogoods: %v", err)
+	}
+	if expected := strings.Replace(nogoods, "\n\n", "\n", 1); buf.String() != expected {
+		t.Errorf("invalid output: expected %q, got %q", expected, buf.String())
+	}
+	for _, invalid := range []string{`{"lits":[1,0]}`, `{"lits":[1,2],"bound":3}`, `{"lits":[1,2],"weights":[1]}`, `[1,2]`} {
+		if _, err := ReadNogoods(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestImportNogoods(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 2}, {1, 3}}))
+	nb, err := s.ImportNogoods(strings.NewReader(`{"lits":[-2,-3]}`))
+	if err != nil || nb != 1 {
+		t.Fatalf("could not import nogoods: %d imported, error: %v", nb, err)
+	}
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	if model := s.Model(); model[2] {
+		t.Errorf("imported nogood was not enforced: got model %v", model)
+	}
+	s.ImportChan = make(chan *Clause, 1)
+	s.ImportChan <- NewClause([]Lit{IntToLit(3)})
+	if status := s.Solve(); status != Unsat {
+		t.Errorf("problem should be unsat after importing nogood, got %v", status)
+	}
+	var buf bytes.Buffer
+	if err := s.ExportNogoods(&buf); err != nil {
+		t.Fatalf("could not export nogoods: %v", err)
+	}
+	if _, err := ReadNogoods(&buf); err != nil {
+		t.Errorf("could not read exported nogoods: %v", err)
+	}
+}
+
+func TestImportFilter(t *testing.T) {
+	s := New(ParseSlice([][]int{{1}, {2, 3, 4}, {-2, 5}}))
+	s.ImportFilter = &ImportFilter{MaxLen: 3, MaxLBD: 2}
+	const nogoods = `{"lits":[1,3]}
+{"lits":[-1,3,4]}
+{"lits":[2,3,4,5]}
+{"lits":[3,5],"lbd":4}
+{"lits":[-3,-4],"lbd":2}
+`
+	nb, err := s.ImportNogoods(strings.NewReader(nogoods))
+	if err != nil {
+		t.Fatalf("could not import nogoods: %v", err)
+	}
+	if nb != 1 || s.Stats.NbFiltered != 4 {
+		t.Errorf("expected 1 nogood imported and 4 filtered, got %d and %d", nb, s.Stats.NbFiltered)
+	}
+	s.Assume([]Lit{IntToLit(-3)})
+	s.ImportChan = make(chan *Clause, 1)
+	s.ImportChan <- NewClause([]Lit{IntToLit(3), IntToLit(4)}) // Assumptions are not top-level bindings
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("problem should be sat, got %v", status)
+	}
+	if s.Stats.NbFiltered != 4 {
+		t.Errorf("nogood sent on ImportChan should not have been filtered")
+	}
+	if model := s.Model(); !model[3] {
+		t.Errorf("imported nogood was not enforced: got model %v", model)
+	}
+	var buf bytes.Buffer
+	learned := NewLearnedClause([]Lit{IntToLit(1), IntToLit(2)})
+	learned.setLbd(3)
+	if err := WriteNogoods(&buf, []*Clause{learned}); err != nil {
+		t.Fatalf("could not write nogoods: %v", err)
+	}
+	if expected := "{\"lits\":[1,2],\"lbd\":3}\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+	s = New(ParseSlice([][]int{{1}, {2, 3}}))
+	s.ImportFilter = &ImportFilter{}
+	s.Assume([]Lit{IntToLit(-2)}) // 3 is bound at level 1, but only because of the assumption
+	if !s.keepImported(NewClause([]Lit{IntToLit(-3), IntToLit(4)}), 0, s.unitVars()) {
+		t.Errorf("clause on a var propagated from an assumption should not have been filtered")
+	}
+	if s.keepImported(NewClause([]Lit{IntToLit(-1), IntToLit(4)}), 0, s.unitVars()) {
+		t.Errorf("clause on a top-level unit should have been filtered")
+	}
+}
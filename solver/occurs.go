@@ -0,0 +1,123 @@
+package solver
+
+// This file deals with occurrence lists, i.e with the list of clauses each lit appears in.
+// They are shared by the passes that simplify or reorder a problem before it is solved (see Simplify), so that each
+// pass does not rescan the whole clause database. Occurrence lists are maintained lazily: removing a clause is a
+// constant-time operation, and references to removed clauses are only dropped the next time the lists they appear in
+// are traversed, or when removed clauses are compacted away.
+
+// occurrences associates each lit with the indices of the clauses it appears in.
+type occurrences struct {
+	clauses []*Clause   // All clauses, indexed or not
+	lists   [][]int     // For each lit, the indices of the indexed clauses it appears in, possibly including removed ones
+	sigs    []signature // Signature of each indexed clause
+	removed []bool      // For each clause, whether it was removed
+	indexed []bool      // For each clause, whether it appears in the lists
+}
+
+// newOccurrences returns empty occurrence lists for the given clauses, with nbVars vars.
+// Clauses must be indexed before they appear in the lists.
+func newOccurrences(nbVars int, clauses []*Clause) *occurrences {
+	return &occurrences{
+		clauses: clauses,
+		lists:   make([][]int, 2*nbVars),
+		sigs:    make([]signature, len(clauses)),
+		removed: make([]bool, len(clauses)),
+		indexed: make([]bool, len(clauses)),
+	}
+}
+
+// occurrences returns the occurrence lists of pb, where all its clauses are indexed.
+func (pb *Problem) occurrences() *occurrences {
+	occ := newOccurrences(pb.NbVars, pb.Clauses)
+	for i := range pb.Clauses {
+		occ.index(i)
+	}
+	return occ
+}
+
+// index adds the clause #i to the lists of all its lits.
+func (occ *occurrences) index(i int) {
+	if occ.indexed[i] {
+		return
+	}
+	occ.indexed[i] = true
+	c := occ.clauses[i]
+	occ.sigs[i] = c.signature()
+	for _, lit := range c.lits {
+		occ.lists[lit] = append(occ.lists[lit], i)
+	}
+}
+
+// remove removes the clause #i. It will not appear in any list anymore.
+func (occ *occurrences) remove(i int) {
+	occ.removed[i] = true
+}
+
+// occurs returns the indices of the clauses lit appears in, that were not removed.
+// The returned slice must not be modified. Clauses removed while it is traversed still appear in it.
+func (occ *occurrences) occurs(lit Lit) []int {
+	list := occ.lists[lit]
+	j := 0
+	for _, i := range list {
+		if !occ.removed[i] {
+			list[j] = i
+			j++
+		}
+	}
+	occ.lists[lit] = list[:j]
+	return list[:j]
+}
+
+// nbOccurs returns an upper bound of the number of clauses lit appears in, without dropping removed clauses.
+func (occ *occurrences) nbOccurs(lit Lit) int {
+	return len(occ.lists[lit])
+}
+
+// varOccurs returns the indices of the clauses v appears in, in increasing order, that were not removed.
+// A clause containing both lits of v appears twice.
+func (occ *occurrences) varOccurs(v Var) []int {
+	pos, neg := occ.occurs(v.Lit()), occ.occurs(v.Lit().Negation())
+	res := make([]int, 0, len(pos)+len(neg))
+	for len(pos) > 0 && len(neg) > 0 {
+		if pos[0] <= neg[0] {
+			res, pos = append(res, pos[0]), pos[1:]
+		} else {
+			res, neg = append(res, neg[0]), neg[1:]
+		}
+	}
+	res = append(res, pos...)
+	return append(res, neg...)
+}
+
+// compact drops the removed clauses, so that the remaining ones are numbered from 0 in the same order,
+// and returns the remaining clauses. The slice of clauses the lists were made from is reused.
+func (occ *occurrences) compact() []*Clause {
+	newIdx := make([]int, len(occ.clauses))
+	j := 0
+	for i, c := range occ.clauses {
+		if occ.removed[i] {
+			newIdx[i] = -1
+			continue
+		}
+		newIdx[i] = j
+		occ.clauses[j], occ.sigs[j], occ.indexed[j] = c, occ.sigs[i], occ.indexed[i]
+		j++
+	}
+	for i := j; i < len(occ.clauses); i++ {
+		occ.clauses[i] = nil // Let the GC collect removed clauses
+	}
+	occ.clauses, occ.sigs, occ.indexed = occ.clauses[:j], occ.sigs[:j], occ.indexed[:j]
+	occ.removed = make([]bool, j)
+	for lit, list := range occ.lists {
+		n := 0
+		for _, i := range list {
+			if newIdx[i] != -1 {
+				list[n] = newIdx[i]
+				n++
+			}
+		}
+		occ.lists[lit] = list[:n]
+	}
+	return occ.clauses
+}
@@ -0,0 +1,44 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOccurrences(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {-1, 3}, {1, 3}, {2, 3}})
+	occ := newOccurrences(pb.NbVars, pb.Clauses)
+	for i := range pb.Clauses {
+		if i != 3 {
+			occ.index(i)
+		}
+	}
+	occ.index(0) // Indexing twice must not duplicate references
+	lit1, lit3 := IntToLit(1), IntToLit(3)
+	if got := occ.occurs(lit1); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("invalid occurrences of 1: expected [0 2], got %v", got)
+	}
+	if got := occ.occurs(lit3); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("invalid occurrences of 3: expected [1 2], got %v", got)
+	}
+	occ.remove(2)
+	if nb := occ.nbOccurs(lit1); nb != 2 {
+		t.Errorf("removed clauses should only be dropped lazily, got %d occurrences", nb)
+	}
+	if got := occ.occurs(lit1); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("invalid occurrences of 1 after removal: expected [0], got %v", got)
+	}
+	if nb := occ.nbOccurs(lit1); nb != 1 {
+		t.Errorf("removed clauses should have been dropped, got %d occurrences", nb)
+	}
+	if got := occ.varOccurs(lit3.Var()); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("invalid occurrences of var 3: expected [1], got %v", got)
+	}
+	occ.remove(0)
+	if clauses := occ.compact(); len(clauses) != 2 || clauses[0].CNF() != "-1 3 0" || clauses[1].CNF() != "2 3 0" {
+		t.Errorf("invalid compacted clauses %v", clauses)
+	}
+	if got := occ.varOccurs(lit1.Var()); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("invalid occurrences of var 1 after compaction: expected [0], got %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package solver
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -117,3 +118,155 @@ func runOptimBench(path string, b *testing.B) {
 func BenchmarkLo88(b *testing.B) {
 	runOptimBench("testcnf/lo_8x8_009.opb", b)
 }
+
+func TestOptimalBudget(t *testing.T) {
+	f, err := os.Open("testcnf/lo_8x8_009.opb")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() { _ = f.Close() }()
+	pb, err := ParseOPB(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s := New(pb)
+	s.MaxConflicts = 1
+	res := s.Optimal(nil, nil)
+	switch res.Status {
+	case Sat:
+		if res.Optim == Optimum { // Units found while parsing are enough to solve the problem
+			if res.Weight != 27 || res.Bound != 27 {
+				t.Errorf("invalid optimal result: cost %d, bound %d", res.Weight, res.Bound)
+			}
+		} else if res.Optim != FeasibleOnly || res.Weight < 27 || res.Bound > 27 {
+			t.Errorf("invalid result after budget exhaustion: %v with cost %d, bound %d", res.Optim, res.Weight, res.Bound)
+		}
+	case Indet:
+		if res.Optim != BoundOnly || res.Bound > 27 {
+			t.Errorf("invalid result after budget exhaustion: %v with bound %d", res.Optim, res.Bound)
+		}
+	default:
+		t.Errorf("invalid status after budget exhaustion: %v", res.Status)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err.Error())
+	}
+	if pb, err = ParseOPB(f); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res = New(pb).Optimal(nil, nil); res.Status != Sat || res.Optim != Optimum || res.Weight != 27 || res.Bound != 27 {
+		t.Errorf("invalid result: expected optimum of cost 27, got %v with cost %d", res.Optim, res.Weight)
+	}
+}
+
+func TestOptimalStop(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, -2}}))
+	stop := make(chan struct{}, 1)
+	stop <- struct{}{}
+	if res := s.Optimal(nil, stop); res.Status == Sat && res.Optim != Optimum {
+		t.Errorf("a decision problem solved in time should be optimal, got %v", res.Optim)
+	}
+}
+
+func TestOptimStatusZeroValue(t *testing.T) {
+	var res Result
+	if res.Optim != NotOptim || res.Optim.String() != "not-optim" {
+		t.Errorf("zero value of the optimization status should be not-optim, got %v", res.Optim)
+	}
+	if res := New(ParseSlice([][]int{{1}, {-1}})).Optimal(nil, nil); res.Status != Unsat || res.Optim != NotOptim {
+		t.Errorf("an unsat problem should be not-optim, got %v", res.Optim)
+	}
+}
+
+func TestOptimalSmallPB(t *testing.T) {
+	// The bound added after the first model, 3 ~x2 +2 ~x1 >= 4, is a PB constraint with only two lits
+	pb, err := ParseOPB(strings.NewReader("min: 2 x1 +3 x2 ;\n1 x1 +1 x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if res := New(pb).Optimal(nil, nil); res.Status != Sat || res.Weight != 2 || res.Optim != Optimum {
+		t.Errorf("expected optimal cost 2, got %+v", res)
+	}
+}
+
+func TestOptimalNegativeWeights(t *testing.T) {
+	const opb = "min: -1 x1 +2 x2 -3 x3 ;\n1 x1 +1 x2 >= 1 ;\n1 ~x1 +1 ~x3 >= 1 ;\n"
+	pb, err := ParseOPB(strings.NewReader(opb))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if res := New(pb).Optimal(nil, nil); res.Status != Sat || res.Weight != -1 || res.Optim != Optimum {
+		t.Errorf("expected optimal cost -1, got %+v", res)
+	}
+	pb, _ = ParseOPB(strings.NewReader(opb))
+	if cost := New(pb).Minimize(); cost != -1 {
+		t.Errorf("expected minimal cost -1, got %d", cost)
+	}
+}
+
+func TestResult(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 3}}))
+	s.Assume([]Lit{IntToLit(-2), IntToLit(-3)})
+	if s.Solve(); s.Result().Status != Unsat || len(s.Result().Core) == 0 || s.Result().Model != nil {
+		t.Errorf("expected unsat result with a core, got %+v", s.Result())
+	}
+	s.Assume(nil)
+	if s.Solve(); s.Result().Status != Sat || len(s.Result().Model) != 3 || s.Result().Optim != Optimum || s.Result().Core != nil {
+		t.Errorf("expected sat result with a model, got %+v", s.Result())
+	}
+	pb, err := ParseOPB(strings.NewReader("min: 2 x1 +3 x2 ;\n1 x1 +1 x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	s = New(pb)
+	if s.Solve(); s.Result().Status != Sat || s.Result().Optim != FeasibleOnly || (s.Result().Weight != 2 && s.Result().Weight != 3) {
+		t.Errorf("expected feasible result, got %+v", s.Result())
+	}
+	res := s.Optimal(nil, nil)
+	if res.Optim != Optimum || res.Stats != s.Stats || s.Result().Weight != 2 || s.Result().Optim != Optimum {
+		t.Errorf("expected optimal result, got %+v, then %+v", res, s.Result())
+	}
+	pb, _ = ParseOPB(strings.NewReader("min: 2 x1 +3 x2 ;\n1 x1 +1 x2 >= 1 ;\n"))
+	s = New(pb)
+	path := filepath.Join(t.TempDir(), "proof")
+	s.Certified, s.CertFile = true, path
+	if cost := s.Minimize(); cost != 2 || s.Result().Status != Sat || s.Result().Weight != 2 || s.Result().Optim != Optimum || s.Result().Model == nil {
+		t.Errorf("expected optimal result of cost 2 after Minimize, got %+v", s.Result())
+	}
+	if s.Result().Proof != path || s.Err() != nil {
+		t.Errorf("expected proof in %q, got %q and error %v", path, s.Result().Proof, s.Err())
+	}
+	s = New(ParseSlice(pigeons(4)))
+	s.Certified, s.CertFile = true, path
+	if s.Solve() != Unsat || s.Result().Proof != path {
+		t.Fatalf("expected unsat result with proof in %q, got %+v", path, s.Result())
+	}
+	if cert, err := os.ReadFile(path); err != nil || !strings.HasSuffix(string(cert), "\n0\n") {
+		t.Errorf("expected a certificate ending with the empty clause, got %q, %v", cert, err)
+	}
+	s = New(ParseSlice(pigeons(4)))
+	s.Certified, s.CertFile = true, filepath.Join(path, "proof") // path is not a directory
+	if s.Solve() != Indet || s.Err() == nil || s.Result().Proof != "" {
+		t.Errorf("expected an error when the certificate file cannot be created, got %+v, %v", s.Result(), s.Err())
+	}
+}
+
+func TestSetDistanceCostFunc(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2, 3}, {-1, -2}, {-2, 4}})
+	ref := []bool{true, true, false, false} // Violates the second and third clauses
+	pb.SetDistanceCostFunc(ref)
+	s := New(pb)
+	if cost := s.Minimize(); cost != 1 {
+		t.Fatalf("expected distance 1, got %d", cost)
+	}
+	model := s.Model()
+	dist := 0
+	for i := range ref {
+		if model[i] != ref[i] {
+			dist++
+		}
+	}
+	if dist != 1 || model[1] {
+		t.Errorf("expected a model at distance 1 with var 2 false, got %v", model)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build noparallel
+// +build noparallel
+
+package solver
+
+// This file replaces parser_parallel.go in builds using the noparallel build tag,
+// so that embedded users, typically on a single core, do not pay for splitting work between goroutines.
+
+// hasParallel is true iff work can be split between several goroutines in this build.
+const hasParallel = false
+
+// ParseCNFParallel is like ParseCNFBytesOptions, as the clauses are always parsed sequentially in this build.
+func ParseCNFParallel(buf []byte, opts ParseOptions, nbWorkers int) (*Problem, error) {
+	return ParseCNFBytesOptions(buf, opts)
+}
@@ -2,187 +2,1069 @@ package solver
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 )
 
 // ParseSlice parse a slice of slice of lits and returns the equivalent problem.
-// The argument is supposed to be a well-formed CNF.
+// The argument is supposed to be a well-formed CNF: use ParseSliceErr on untrusted input.
 func ParseSlice(cnf [][]int) *Problem {
-	var pb Problem
-	pb.parseSlice(cnf)
-	return &pb
+	pb, _ := ParseSliceHint(cnf, SizeHint{})
+	return pb
 }
 
 // ParseSliceNb parse a slice of slice of lits and returns the equivalent problem.
 // The argument is supposed to be a well-formed CNF.
 // The number of vars is provided because clauses might be added to it later.
 func ParseSliceNb(cnf [][]int, nbVars int) *Problem {
-	pb := Problem{NbVars: nbVars}
-	pb.parseSlice(cnf)
-	return &pb
+	pb, _ := ParseSliceHint(cnf, SizeHint{NbVars: nbVars})
+	return pb
 }
 
-func (pb *Problem) parseSlice(cnf [][]int) {
+// ParseSliceHint is like ParseSlice, but memory is preallocated according to hint.
+// It also returns statistics about the parsing.
+func ParseSliceHint(cnf [][]int, hint SizeHint) (*Problem, ParseStats) {
+	b := NewProblemBuilder(hint)
 	for _, line := range cnf {
-		switch len(line) {
-		case 0:
-			pb.Status = Unsat
-			return
-		case 1:
-			if line[0] == 0 {
-				panic("null unit clause")
-			}
-			lit := IntToLit(int32(line[0]))
-			v := lit.Var()
-			if int(v) >= pb.NbVars {
-				pb.NbVars = int(v) + 1
-			}
-			pb.Units = append(pb.Units, lit)
-		default:
-			lits := make([]Lit, len(line))
-			for j, val := range line {
-				if val == 0 {
-					panic(fmt.Sprintf("null literal in clause %v", lits))
-				}
-				lits[j] = IntToLit(int32(val))
-				if v := int(lits[j].Var()); v >= pb.NbVars {
-					pb.NbVars = v + 1
-				}
-			}
-			pb.Clauses = append(pb.Clauses, NewClause(lits))
+		b.AddClause(line)
+	}
+	return b.Problem()
+}
+
+// ParseSliceErr is like ParseSlice, but the argument is not supposed to be well-formed:
+// if a clause contains the lit 0, a *ConstrError identifying that clause is returned instead of panicking.
+func ParseSliceErr(cnf [][]int) (*Problem, error) {
+	b := NewProblemBuilder(SizeHint{})
+	for i, line := range cnf {
+		if err := checkLits(i, line); err != nil {
+			return nil, err
 		}
+		b.AddClause(line)
 	}
-	pb.Model = make([]decLevel, pb.NbVars)
-	for _, unit := range pb.Units {
-		v := unit.Var()
-		if pb.Model[v] == 0 {
-			if unit.IsPositive() {
-				pb.Model[v] = 1
-			} else {
-				pb.Model[v] = -1
-			}
-		} else if pb.Model[v] > 0 != unit.IsPositive() {
-			pb.Status = Unsat
-			return
+	pb, _ := b.Problem()
+	return pb, nil
+}
+
+// checkLits returns a *ConstrError if one of the lits of the constraint #i is 0, or is too big to be represented.
+func checkLits(i int, lits []int) error {
+	for j, val := range lits {
+		if val == 0 {
+			return &ConstrError{Index: i, Msg: fmt.Sprintf("literal 0 at position %d", j), Err: ErrBadLiteral}
+		}
+		if val > maxVar || val < -maxVar {
+			return &ConstrError{Index: i, Msg: fmt.Sprintf("literal %d at position %d is too big", val, j), Err: ErrBadLiteral}
+		}
+	}
+	return nil
+}
+
+// A ConstrError is an error found in a constraint given as a Go value, rather than read from a file.
+type ConstrError struct {
+	Index int    // Index of the faulty constraint in the input slice, starting at 0
+	Msg   string // Description of the error
+	Err   error  // Cause of the error, such as ErrBadLiteral or ErrOverflow, or nil if it is unknown
+}
+
+func (e *ConstrError) Error() string {
+	return fmt.Sprintf("constraint #%d: %s", e.Index, e.Msg)
+}
+
+// Unwrap returns the cause of the error.
+func (e *ConstrError) Unwrap() error {
+	return e.Err
+}
+
+// A SizeHint gives the expected size of a problem, so that parsing it does not need to grow slices repeatedly.
+// It matters when building very big problems, with millions of constraints.
+// A zero value means there is no hint. Hints that are too small are harmless: slices just grow as usual.
+// NbVars is not only a hint: vars up to NbVars are part of the problem, even if they do not appear in its constraints,
+// so that constraints using them can be added to the solver later without reallocating its internal data.
+type SizeHint struct {
+	NbVars    int // Minimum number of vars in the problem
+	NbUnits   int // Expected number of unit lits
+	NbClauses int // Expected number of non-unit clauses
+}
+
+// ParseStats gives counters about the parsing of a problem, before it was simplified.
+// NbUnits and NbClauses make a perfect SizeHint for parsing a similar problem.
+type ParseStats struct {
+	NbConstrs int // How many constraints were read
+	NbIgnored int // How many constraints were trivially satisfied, and thus ignored
+	NbUnits   int // How many unit lits were found, including duplicates
+	NbClauses int // How many non-unit clauses were created
+	NbGrowths int // How many times the list of units or the list of clauses had to be reallocated
+	// How many clauses containing both a lit and its negation were ignored, since they are always satisfied
+	NbTautologies int
+	NbDuplicates  int // How many duplicate lits were removed from clauses
+}
+
+// appendUnit appends lit, implied by the input constraint src, to pb's units and updates stats accordingly.
+func (stats *ParseStats) appendUnit(pb *Problem, lit Lit, src int) {
+	if len(pb.Units) == cap(pb.Units) {
+		stats.NbGrowths++
+	}
+	pb.appendUnit(lit, src)
+	stats.NbUnits++
+}
+
+// appendClause appends c to pb's clauses and updates stats accordingly.
+func (stats *ParseStats) appendClause(pb *Problem, c *Clause) {
+	if len(pb.Clauses) == cap(pb.Clauses) {
+		stats.NbGrowths++
+	}
+	pb.Clauses = append(pb.Clauses, c)
+	stats.NbClauses++
+}
+
+// newHintedProblem returns a problem with hint.NbVars vars and no constraint, whose units and clauses are preallocated according to hint.
+func newHintedProblem(hint SizeHint) *Problem {
+	pb := Problem{NbVars: hint.NbVars}
+	if hint.NbUnits > 0 {
+		pb.Units = make([]Lit, 0, hint.NbUnits)
+		pb.unitSources = make([]int, 0, hint.NbUnits)
+	}
+	if hint.NbClauses > 0 {
+		pb.Clauses = make([]*Clause, 0, hint.NbClauses)
+	}
+	return &pb
+}
+
+// ParseOptions are options changing the way files are parsed.
+// The zero value gives the default behavior.
+type ParseOptions struct {
+	// If Recover is true, the parsing does not stop at the first syntax error.
+	// The faulty constraint is ignored, the error is recorded, and the parsing goes on with the next line.
+	// The problem made of all valid constraints is then returned, along with a ParseErrors listing all errors.
+	Recover bool
+	// If Lenient is true, the header of a CNF file is optional, and the number of vars it announces is only a hint:
+	// the number of vars of the problem is inferred from the biggest var appearing in its clauses.
+	Lenient bool
+	// MaxLineSize is the maximum size of a line, in bytes. Longer lines make the parsing fail.
+	// If it is 0, defaultMaxLineSize is used.
+	MaxLineSize int
+	// If KeepComments is true, the comments and the header of the file are kept in the Metadata of the problem,
+	// with their positions, so that writing the problem again puts each comment back where it was,
+	// as long as the written constraints are the ones of the file: units found while parsing are written first.
+	KeepComments bool
+	// If RecoverPanics is true, internal panics of the parser are recovered, and returned as a *PanicError.
+	RecoverPanics bool
+	// The following fields tell how anomalies found in CNF files are handled.
+	// ClauseCount is used when the number of clauses differs from the one announced in the header. By default, it is Normalize.
+	ClauseCount Tolerance
+	// VarRange is used when a var is bigger than the number of vars announced in the header.
+	// Normalizing it means increasing the number of vars. By default, it is Reject, or Normalize in lenient mode.
+	VarRange Tolerance
+	// DuplicateLits is used when a lit appears several times in a clause.
+	// Normalizing it means keeping only one occurrence of the lit. By default, it is Normalize.
+	DuplicateLits Tolerance
+	// Tautologies is used when a clause contains both a lit and its negation.
+	// Normalizing it means removing the clause, that is always satisfied. By default, it is Normalize.
+	Tautologies Tolerance
+	// If ValidateHeader is true, the clauses of a CNF file must be consistent with its header: if a var is bigger than
+	// the number of vars it announces, or if the number of clauses differs from the announced one, the parsing fails
+	// with a *HeaderReport describing all such inconsistencies in the file. It takes precedence over VarRange
+	// and ClauseCount, and it cannot be used in lenient mode, where the header is only a hint.
+	ValidateHeader bool
+	// If Stats is not nil, it is set to statistics about the parsing of each CNF problem that was parsed successfully.
+	// Only NbConstrs, NbTautologies and NbDuplicates are computed.
+	Stats *ParseStats
+}
+
+// Validate returns an error if opts are inconsistent, e.g if they use unknown tolerances
+// or if they reject out-of-range vars in lenient mode, where the number of vars is inferred.
+// The parsing functions call it before parsing anything.
+func (opts ParseOptions) Validate() error {
+	if opts.MaxLineSize < 0 {
+		return fmt.Errorf("invalid parse options: negative maximum line size %d", opts.MaxLineSize)
+	}
+	tolerances := []struct {
+		name string
+		t    Tolerance
+	}{
+		{"ClauseCount", opts.ClauseCount},
+		{"VarRange", opts.VarRange},
+		{"DuplicateLits", opts.DuplicateLits},
+		{"Tautologies", opts.Tautologies},
+	}
+	for _, tol := range tolerances {
+		if tol.t > Normalize {
+			return fmt.Errorf("invalid parse options: unknown tolerance %d for %s", tol.t, tol.name)
+		}
+	}
+	if opts.Lenient && opts.VarRange == Reject {
+		return fmt.Errorf("invalid parse options: out-of-range vars cannot be rejected in lenient mode")
+	}
+	if opts.Lenient && opts.ValidateHeader {
+		return fmt.Errorf("invalid parse options: header cannot be validated in lenient mode")
+	}
+	return nil
+}
+
+// A Tolerance tells how an anomaly found while parsing a file is handled.
+type Tolerance byte
+
+const (
+	// DefaultTolerance is the default handling of the anomaly, as described in ParseOptions.
+	DefaultTolerance Tolerance = iota
+	// Reject makes the anomaly a parse error.
+	Reject
+	// Warn normalizes the anomaly and records a warning about it in the Warnings of the problem.
+	Warn
+	// Normalize silently normalizes the anomaly.
+	Normalize
+)
+
+// or returns t, or def if t is the default tolerance.
+func (t Tolerance) or(def Tolerance) Tolerance {
+	if t == DefaultTolerance {
+		return def
+	}
+	return t
+}
+
+// defaultMaxLineSize is the maximum size of a line in parsed files, unless specified otherwise.
+// Generated problems can have huge clauses, written on a single line.
+const defaultMaxLineSize = 1 << 30
+
+// NewScanner returns a scanner reading the lines of r, whose size must not exceed maxLineSize bytes.
+// If maxLineSize is 0, defaultMaxLineSize is used.
+// Only a small buffer is allocated first: it grows only when long lines are met.
+// It is used by all parsers, including the ones of other packages, so that they accept the same line sizes.
+func NewScanner(r io.Reader, maxLineSize int) *bufio.Scanner {
+	if maxLineSize <= 0 {
+		maxLineSize = defaultMaxLineSize
+	}
+	size := 64 * 1024
+	if size > maxLineSize {
+		size = maxLineSize
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, size), maxLineSize)
+	return sc
+}
+
+// scanError returns the error to report when the scanning of a file in the given format failed with err,
+// after lineNb lines were read. Errors from the underlying reader are wrapped.
+func scanError(err error, lineNb int, format string) error {
+	if err == bufio.ErrTooLong {
+		perr := newParseError(lineNb+1, ErrLineTooLong, "line is longer than the maximum line size")
+		perr.Kind = LineTooLong
+		return perr
+	}
+	return fmt.Errorf("could not read %s file: %w", format, err)
+}
+
+// A ParseErrorKind is the category of a ParseError.
+type ParseErrorKind byte
+
+const (
+	// InvalidSyntax is the kind of errors on lines that do not follow the syntax of the format, such as unterminated constraints.
+	InvalidSyntax ParseErrorKind = iota
+	// InvalidHeader is the kind of errors on malformed or misplaced headers.
+	InvalidHeader
+	// InvalidToken is the kind of errors on tokens that cannot be parsed, such as lits that are not ints.
+	InvalidToken
+	// OutOfRange is the kind of errors on well-formed tokens whose value is not allowed, such as vars bigger than the number of vars.
+	OutOfRange
+	// LineTooLong is the kind of errors on lines longer than the maximum line size.
+	LineTooLong
+	// Redundant is the kind of errors on constraints with useless content, such as duplicate lits or tautologies.
+	Redundant
+)
+
+func (k ParseErrorKind) String() string {
+	switch k {
+	case InvalidSyntax:
+		return "invalid syntax"
+	case InvalidHeader:
+		return "invalid header"
+	case InvalidToken:
+		return "invalid token"
+	case OutOfRange:
+		return "out of range"
+	case LineTooLong:
+		return "line too long"
+	case Redundant:
+		return "redundant content"
+	default:
+		return fmt.Sprintf("ParseErrorKind(%d)", k)
+	}
+}
+
+// Errors wrapped by parse errors, telling which part of the input is faulty. They can be tested with errors.Is.
+var (
+	ErrBadHeader     = errors.New("bad header")              // Malformed, misplaced, missing or inconsistent header
+	ErrBadLiteral    = errors.New("bad literal")             // Lit or var that cannot be parsed, or is out of range
+	ErrBadWeight     = errors.New("bad weight")              // Weight, cost or bound that cannot be parsed, or is out of range
+	ErrBadOperator   = errors.New("bad operator")            // Comparison operator of a constraint that cannot be parsed
+	ErrBadConstraint = errors.New("bad constraint")          // Constraint that is malformed, misplaced or redundant
+	ErrTruncated     = errors.New("unexpected end of input") // Constraint interrupted by the end of the input
+	ErrLineTooLong   = errors.New("line too long")           // Line longer than the maximum line size
+)
+
+// A ParseError is a syntax error found while parsing a file.
+type ParseError struct {
+	Line   int            // Number of the faulty line, starting at 1
+	Column int            // Position of the offending token in the line, in bytes, starting at 1, or 0 if it is unknown
+	Kind   ParseErrorKind // Category of the error
+	Token  string         // Offending token, if the error is about a single token
+	Msg    string         // Description of the error
+	Err    error          // Faulty part of the input, such as ErrBadLiteral, or nil if it is unknown
+}
+
+// newParseError returns the error about the given faulty part of the input found at the given line,
+// built from format and args.
+func newParseError(line int, cause error, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: line, Err: cause, Msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenError returns an error of the given kind about token, that is the given faulty part of the input,
+// built from format and args. The line of the error is not known yet: it is set by atLine.
+func tokenError(kind ParseErrorKind, cause error, token string, format string, args ...interface{}) *ParseError {
+	return &ParseError{Kind: kind, Err: cause, Token: token, Msg: fmt.Sprintf(format, args...)}
+}
+
+// atLine returns err as a ParseError found at the given line, whose content is text.
+// If err is not a ParseError, it is considered as a syntax error in a constraint.
+// If err is about a single token, its column is the position of that token in text.
+func atLine(err error, line int, text string) *ParseError {
+	perr, ok := err.(*ParseError)
+	if !ok {
+		return newParseError(line, ErrBadConstraint, "%v", err)
+	}
+	perr.Line = line
+	if perr.Column == 0 && perr.Token != "" {
+		perr.Column = tokenColumn(text, perr.Token)
+	}
+	return perr
+}
+
+// tokenColumn returns the position in text, in bytes, starting at 1, of the first field that is token,
+// or else of the first occurrence of token, as for a cost in brackets, or 0 if token is not in text.
+func tokenColumn(text, token string) int {
+	for i := 0; i < len(text); {
+		for i < len(text) && isSpace(text[i]) {
+			i++
+		}
+		j := i
+		for j < len(text) && !isSpace(text[j]) {
+			j++
 		}
+		if j > i && text[i:j] == token {
+			return i + 1
+		}
+		i = j
 	}
-	pb.simplify2()
+	return strings.Index(text, token) + 1
+}
+
+func (e *ParseError) Error() string {
+	if e.Column != 0 {
+		return fmt.Sprintf("line %d, byte %d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Unwrap returns the faulty part of the input, so that errors.Is(err, ErrBadLiteral) tells whether err is about a lit.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Recoverable returns true iff the error only affects its own line, so that the following lines can still be parsed,
+// as done in recovery mode. The input is then well-formed, except for a quirk that can be skipped or normalized.
+// Otherwise, as for errors that are not parse errors, such as I/O errors, the input is truncated or corrupted.
+func (e *ParseError) Recoverable() bool {
+	return e.Kind != LineTooLong && e.Err != ErrTruncated
+}
+
+// ParseErrors is the list of syntax errors found while parsing a file in recovery mode, in the order of the file.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d syntax errors: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// A HeaderReport describes the inconsistencies between the header of a CNF file and its clauses.
+type HeaderReport struct {
+	Line      int   // Line of the header
+	NbVars    int   // Number of vars announced in the header
+	NbClauses int   // Number of clauses announced in the header
+	MaxVar    int   // Biggest var used by the clauses, if it is bigger than NbVars, else 0
+	VarLines  []int // Lines where a var bigger than NbVars is used, in increasing order
+	NbRead    int   // Number of clauses found in the file
+}
+
+// consistent returns true iff r does not report any inconsistency.
+func (r *HeaderReport) consistent() bool {
+	return r.MaxVar == 0 && r.NbRead == r.NbClauses
+}
+
+// addVar records that var v, that is bigger than the number of vars announced, is used on the given line.
+func (r *HeaderReport) addVar(v, line int) {
+	if v > r.MaxVar {
+		r.MaxVar = v
+	}
+	if len(r.VarLines) == 0 || r.VarLines[len(r.VarLines)-1] != line {
+		r.VarLines = append(r.VarLines, line)
+	}
+}
+
+// Unwrap returns ErrBadHeader.
+func (r *HeaderReport) Unwrap() error {
+	return ErrBadHeader
+}
+
+func (r *HeaderReport) Error() string {
+	var msgs []string
+	if r.MaxVar != 0 {
+		msgs = append(msgs, fmt.Sprintf("header announced %d vars, but var %d is used, on %d lines starting at line %d",
+			r.NbVars, r.MaxVar, len(r.VarLines), r.VarLines[0]))
+	}
+	if r.NbRead != r.NbClauses {
+		msgs = append(msgs, fmt.Sprintf("header announced %d clauses, but %d were found", r.NbClauses, r.NbRead))
+	}
+	return fmt.Sprintf("line %d: inconsistent header: %s", r.Line, strings.Join(msgs, "; "))
+}
+
+// skip records err if opts allow to recover from it.
+// It returns false if the parsing must stop with err.
+func (opts ParseOptions) skip(errs *ParseErrors, err *ParseError) bool {
+	if !opts.Recover {
+		return false
+	}
+	*errs = append(*errs, err)
+	return true
 }
 
 func isSpace(b byte) bool {
 	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
 }
 
-// readInt reads an int from r.
-// 'b' is the last read byte. It can be a space, a '-' or a digit.
-// The int can be negated.
-// All spaces before the int value are ignored.
-// Can return EOF.
-func readInt(b *byte, r *bufio.Reader) (res int, err error) {
-	for err == nil && isSpace(*b) {
-		*b, err = r.ReadByte()
-	}
-	if err == io.EOF {
-		return res, io.EOF
+// skipSpaces returns the index of the first non-space byte of line starting from i, or len(line) if there is none.
+func skipSpaces(line []byte, i int) int {
+	for i < len(line) && isSpace(line[i]) {
+		i++
 	}
-	if err != nil {
-		return res, fmt.Errorf("could not read digit: %v", err)
+	return i
+}
+
+// maxVar is the biggest var a parsed problem can contain, as lits of bigger vars cannot be represented.
+// Parsers reject bigger vars, as well as headers announcing more vars.
+const maxVar = 1 << 30
+
+// maxPrealloc is the maximum number of constraints preallocated according to the header of a file,
+// so that a malformed header cannot make the parser allocate huge slices on its own.
+const maxPrealloc = 1 << 20
+
+// preallocSize returns the number of constraints to preallocate for a file announcing n constraints.
+func preallocSize(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
 	}
+	return n
+}
+
+// readInt reads the int starting at line[i], and returns it along with the index following it.
+// The int can be negated. Ints that do not fit in an int32 are rejected.
+func readInt(line []byte, i int) (res int, next int, err error) {
 	neg := 1
-	if *b == '-' {
+	if line[i] == '-' {
 		neg = -1
-		*b, err = r.ReadByte()
-		if err != nil {
-			return 0, fmt.Errorf("cannot read int: %v", err)
-		}
+		i++
 	}
-	for err == nil {
-		if *b < '0' || *b > '9' {
-			return 0, fmt.Errorf("cannot read int: %q is not a digit", *b)
+	start := i
+	for ; i < len(line) && !isSpace(line[i]); i++ {
+		if line[i] < '0' || line[i] > '9' {
+			return 0, i, fmt.Errorf("cannot read int: %q is not a digit", line[i])
 		}
-		res = 10*res + int(*b-'0')
-		*b, err = r.ReadByte()
-		if isSpace(*b) {
-			break
+		res = 10*res + int(line[i]-'0')
+		if res > math.MaxInt32 {
+			return 0, i, fmt.Errorf("cannot read int: value is too big")
 		}
 	}
-	res *= neg
-	return res, err
+	if i == start {
+		return 0, i, fmt.Errorf("cannot read int: no digit after '-'")
+	}
+	return res * neg, i, nil
 }
 
-func parseHeader(r *bufio.Reader) (nbVars, nbClauses int, err error) {
-	line, err := r.ReadString('\n')
-	if err != nil {
-		return 0, 0, fmt.Errorf("cannot read header: %v", err)
-	}
+func parseHeader(line string) (nbVars, nbClauses int, err error) {
 	fields := strings.Fields(line)
-	if len(fields) < 3 {
+	if len(fields) < 4 {
 		return 0, 0, fmt.Errorf("invalid syntax %q in header", line)
 	}
-	nbVars, err = strconv.Atoi(fields[1])
+	nbVars, err = strconv.Atoi(fields[2])
 	if err != nil {
-		return 0, 0, fmt.Errorf("nbvars not an int : %q", fields[1])
+		return 0, 0, fmt.Errorf("nbvars not an int : %q", fields[2])
 	}
-	nbClauses, err = strconv.Atoi(fields[2])
+	nbClauses, err = strconv.Atoi(fields[3])
 	if err != nil {
-		return 0, 0, fmt.Errorf("nbClauses not an int : '%s'", fields[2])
+		return 0, 0, fmt.Errorf("nbClauses not an int : '%s'", fields[3])
+	}
+	if nbVars < 0 || nbClauses < 0 {
+		return 0, 0, fmt.Errorf("negative value in header %q", line)
+	}
+	if nbVars > maxVar {
+		return 0, 0, fmt.Errorf("too many vars in header %q", line)
 	}
 	return nbVars, nbClauses, nil
 }
 
 // ParseCNF parses a CNF file and returns the corresponding Problem.
+// The file can be compressed with gzip or bzip2.
+// After a "p cnf+" header, the file can also contain cardinality constraints, such as "1 2 3 >= 2 0",
+// whose operator is either ">=", "<=" or "=". The terminating 0 is optional.
+// After a "p knf" header, the file is in Knuth's KNF format: clauses can be prefixed by a bound,
+// such as "k 2 1 2 3 0", stating that at least 2 lits among 1, 2 and 3 are true.
+// Comment lines starting with "c ind", listing the vars of the independent support, are parsed into pb.ProjectionVars.
+// As in CryptoMiniSat, clauses prefixed by "x", such as "x1 -2 3 0", are XOR constraints, stating that an odd number
+// of their lits are true. They are parsed into pb.Xors, and translated into clauses, whose number is linear in their size,
+// on fresh reserved vars: the solver only sees these clauses.
 func ParseCNF(f io.Reader) (*Problem, error) {
-	r := bufio.NewReader(f)
-	var (
-		nbClauses int
-		pb        Problem
-	)
-	b, err := r.ReadByte()
-	for err == nil {
-		if b == 'c' { // Ignore comment
-			b, err = r.ReadByte()
-			for err == nil && b != '\n' {
-				b, err = r.ReadByte()
+	return ParseCNFOptions(f, ParseOptions{})
+}
+
+// ParseCNFOptions is like ParseCNF, but the parsing is customized by opts.
+// A clause can span several lines, so in recovery mode, a syntax error makes the parser ignore
+// the clause being read, and the rest of the faulty line.
+func ParseCNFOptions(f io.Reader, opts ParseOptions) (*Problem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseCNFOptions(f, opts) })
+	}
+	r, err := decompress(f)
+	if err != nil {
+		return nil, err
+	}
+	p := newCNFParser(opts)
+	var errs ParseErrors
+	sc := NewScanner(r, opts.MaxLineSize)
+	for sc.Scan() {
+		p.lineNb++
+		if err := p.parseLine(sc.Bytes()); err != nil {
+			if !opts.skip(&errs, err) {
+				return nil, err
+			}
+			p.discard()
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, scanError(err, p.lineNb, "CNF")
+	}
+	return p.result(&errs)
+}
+
+// newCNFParser returns a parser for a CNF file, whose anomalies are handled according to opts.
+func newCNFParser(opts ParseOptions) *cnfParser {
+	p := cnfParser{opts: opts}
+	p.opts.ClauseCount = opts.ClauseCount.or(Normalize)
+	p.opts.DuplicateLits = opts.DuplicateLits.or(Normalize)
+	p.opts.Tautologies = opts.Tautologies.or(Normalize)
+	if opts.Lenient {
+		p.opts.VarRange = opts.VarRange.or(Normalize)
+	} else {
+		p.opts.VarRange = opts.VarRange.or(Reject)
+	}
+	if opts.KeepComments {
+		p.pb.Metadata = &Metadata{}
+	}
+	return &p
+}
+
+// problem is called once the whole file was read. It checks the clauses are complete and match the header,
+// then returns the simplified problem. Errors that were recovered from are appended to errs.
+func (p *cnfParser) problem(errs *ParseErrors) (*Problem, error) {
+	if len(p.lits) != 0 || p.hasKNFBound || p.isXor {
+		if err := newParseError(p.lineNb, ErrTruncated, "unfinished clause while EOF found"); !p.opts.skip(errs, err) {
+			return nil, err
+		}
+	}
+	if p.report != nil {
+		if p.report.NbRead = p.nbRead; !p.report.consistent() {
+			return nil, p.report
+		}
+	}
+	if p.hasHeader && p.nbRead != p.nbClauses {
+		err := newParseError(p.headerLine, ErrBadHeader, "%d clauses found, but header announced %d", p.nbRead, p.nbClauses)
+		err.Kind = InvalidHeader
+		if p.tolerate(p.opts.ClauseCount, err) != nil && !p.opts.skip(errs, err) {
+			return nil, err
+		}
+	}
+	pb := &p.pb
+	for i, v := range pb.ProjectionVars { // Checked at the end, since "c ind" lines can appear before the header
+		if int(v) >= pb.NbVars {
+			err := newParseError(p.indLines[i], ErrBadLiteral, "invalid projection var %d for problem with %d vars only", v.Int(), pb.NbVars)
+			err.Kind = OutOfRange
+			if err := p.tolerate(p.opts.VarRange, err); err != nil && !p.opts.skip(errs, err) {
+				return nil, err
+			}
+			pb.NbVars = int(v) + 1
+		}
+	}
+	pb.Model = make([]decLevel, pb.NbVars)
+	pb.encodeXors()
+	if p.opts.Stats != nil {
+		p.stats.NbConstrs = p.nbRead
+		*p.opts.Stats = p.stats
+	}
+	if p.hasCard {
+		pb.simplifyCard()
+	} else {
+		pb.simplify2()
+	}
+	if pb.Status == Unsat {
+		pb.Conflict = unitConflict(p.unitLits, p.unitLines)
+	}
+	return pb, nil
+}
+
+// unitConflict returns the first pair of contradictory unit clauses among the given ones, read at the given lines,
+// or nil if there is none.
+func unitConflict(lits []Lit, lines []int) *UnitConflict {
+	first := make(map[Lit]int, len(lits)) // Line where each lit first appeared as a unit clause
+	for i, lit := range lits {
+		if line, ok := first[lit.Negation()]; ok {
+			return &UnitConflict{Lit: lit.Negation(), First: line, Second: lines[i]}
+		}
+		if _, ok := first[lit]; !ok {
+			first[lit] = lines[i]
+		}
+	}
+	return nil
+}
+
+// result is like problem, but returns the errors that were recovered from as a ParseErrors, if any.
+func (p *cnfParser) result(errs *ParseErrors) (*Problem, error) {
+	pb, err := p.problem(errs)
+	if err != nil {
+		return nil, err
+	}
+	if len(*errs) != 0 {
+		return pb, *errs
+	}
+	return pb, nil
+}
+
+// A cnfParser parses a CNF file, line by line.
+type cnfParser struct {
+	pb          Problem
+	opts        ParseOptions
+	lits        []Lit        // Lits of the clause being read, that might span several lines
+	hasHeader   bool         // True iff the header was parsed
+	cnfPlus     bool         // True iff the header announced a CNF+ file, that can contain cardinality constraints
+	knf         bool         // True iff the header announced a KNF file, whose clauses can be prefixed by a bound
+	hasCard     bool         // True iff a cardinality constraint with a bound greater than 1 was parsed
+	hasKNFBound bool         // True iff the clause being read is a KNF cardinality clause
+	isXor       bool         // True iff the clause being read is an XOR constraint
+	knfBound    int          // Bound of the KNF cardinality clause being read
+	indLines    []int        // Line where each projection var was declared
+	indSeen     map[Var]bool // Projection vars declared so far
+	lineNb      int          // Number of the line being parsed
+	headerLine  int          // Number of the header line
+	nbClauses   int          // Number of clauses announced in the header
+	nbRead      int          // Number of clauses read so far, including the ones that were removed
+	seen        []int        // For each var, 1 + the index of the last clause it appeared in, negated if it appeared negatively
+	pooled      bool         // If true, lits of constraints are copied to the lits pool, and lits is reused for the next one
+	unitLits    []Lit        // Lit of each unit clause read so far, so that contradictory ones can be reported
+	unitLines   []int        // Line of each unit clause read so far
+	// If the header is validated, its inconsistencies with the clauses read so far
+	report *HeaderReport
+	stats  ParseStats // Normalizations done so far
+}
+
+// started returns true iff p already parsed the header or some clauses of a problem.
+func (p *cnfParser) started() bool {
+	return p.hasHeader || p.nbRead != 0 || len(p.lits) != 0 || p.hasKNFBound || p.isXor
+}
+
+// takeLits returns the lits of the constraint being read, that just ended, and resets lits for the next constraint.
+func (p *cnfParser) takeLits() []Lit {
+	lits := p.lits
+	if !p.pooled {
+		p.lits = nil
+		return lits
+	}
+	p.lits = lits[:0]
+	return alloc.newLits(lits...)
+}
+
+// discard discards the constraint being read, after a syntax error was recovered from.
+func (p *cnfParser) discard() {
+	p.lits = nil
+	p.hasKNFBound = false
+	p.isXor = false
+}
+
+// tolerate handles err, an anomaly, according to the tolerance t.
+// It returns err if the anomaly must be rejected, nil otherwise.
+func (p *cnfParser) tolerate(t Tolerance, err *ParseError) *ParseError {
+	switch t {
+	case Reject:
+		return err
+	case Warn:
+		p.pb.Warnings = append(p.pb.Warnings, err)
+	}
+	return nil
+}
+
+// endClause is called when the 0 terminating the current clause was found at the given index of line.
+// It handles duplicate lits and tautologies, and adds the clause to the problem.
+func (p *cnfParser) endClause(line []byte, idx int) *ParseError {
+	p.nbRead++
+	lits := p.takeLits()
+	if len(p.seen) < p.pb.NbVars {
+		p.seen = append(p.seen, make([]int, p.pb.NbVars-len(p.seen))...)
+	}
+	j := 0
+	for _, lit := range lits {
+		mark := p.nbRead
+		if !lit.IsPositive() {
+			mark = -mark
+		}
+		switch p.seen[lit.Var()] {
+		case -mark:
+			err := p.errorAt(line, idx, Redundant, ErrBadConstraint, "clause contains both %d and %d", lit.Int(), -lit.Int())
+			if err := p.tolerate(p.opts.Tautologies, err); err != nil {
+				return err
+			}
+			p.stats.NbTautologies++
+			return nil
+		case mark:
+			err := p.errorAt(line, idx, Redundant, ErrBadConstraint, "lit %d appears several times in clause", lit.Int())
+			if err := p.tolerate(p.opts.DuplicateLits, err); err != nil {
+				return err
+			}
+			p.stats.NbDuplicates++
+			continue
+		}
+		p.seen[lit.Var()] = mark
+		lits[j] = lit
+		j++
+	}
+	if j == 1 {
+		p.unitLits = append(p.unitLits, lits[0])
+		p.unitLines = append(p.unitLines, p.lineNb)
+	}
+	p.pb.Clauses = append(p.pb.Clauses, NewClause(lits[:j]))
+	return nil
+}
+
+// endXor is called when the 0 terminating the current XOR constraint was found. It adds the constraint to the problem.
+func (p *cnfParser) endXor() {
+	p.nbRead++
+	p.pb.Xors = append(p.pb.Xors, newXor(p.takeLits()))
+}
+
+// errorAt returns the error of the given kind about the given faulty part of the input, found at the given index
+// of the line being parsed, built from format and args. The offending token is the one starting at idx.
+func (p *cnfParser) errorAt(line []byte, idx int, kind ParseErrorKind, cause error, format string, args ...interface{}) *ParseError {
+	end := idx
+	for end < len(line) && !isSpace(line[end]) {
+		end++
+	}
+	err := tokenError(kind, cause, string(line[idx:end]), format, args...)
+	err.Line = p.lineNb
+	err.Column = idx + 1
+	return err
+}
+
+// parseLine parses a line of a CNF file.
+func (p *cnfParser) parseLine(line []byte) *ParseError {
+	i := 0
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+	if i == len(line) { // Ignore empty lines
+		return nil
+	}
+	if line[i] == 'c' {
+		if j := skipSpaces(line, i+1); j > i+1 && bytes.HasPrefix(line[j:], []byte("ind")) && (j+3 == len(line) || isSpace(line[j+3])) {
+			return p.parseInd(line, j+3)
+		}
+		if p.opts.KeepComments {
+			pos := p.nbRead
+			if p.hasHeader {
+				pos++
+			}
+			p.pb.Metadata.addComment(string(line[i+1:]), pos)
+		}
+		return nil
+	}
+	if line[i] == 'p' {
+		if err := p.parseHeader(string(line)); err != nil {
+			perr := newParseError(p.lineNb, ErrBadHeader, "%v", err)
+			perr.Kind = InvalidHeader
+			return perr
+		}
+		if p.opts.KeepComments {
+			p.pb.Metadata.Header = strings.TrimSpace(string(line))
+		}
+		return nil
+	}
+	if !p.hasHeader && !p.opts.Lenient {
+		return p.errorAt(line, i, InvalidHeader, ErrBadHeader, "clause found before the CNF header")
+	}
+	for i < len(line) {
+		if line[i] == 'k' && (i+1 == len(line) || isSpace(line[i+1])) {
+			next, err := p.parseKNFBound(line, i)
+			if err != nil {
+				return err
+			}
+			i = skipSpaces(line, next)
+			continue
+		}
+		if line[i] == 'x' && len(p.lits) == 0 && !p.hasKNFBound && !p.isXor { // Elsewhere, "x" is an invalid lit
+			p.isXor = true
+			i = skipSpaces(line, i+1)
+			continue
+		}
+		if op := cardOperator(line, i); op != "" {
+			next, err := p.endCard(line, i, op)
+			if err != nil {
+				return err
+			}
+			i = skipSpaces(line, next)
+			continue
+		}
+		val, next, err := readInt(line, i)
+		if err != nil {
+			return p.errorAt(line, i, InvalidToken, ErrBadLiteral, "cannot parse clause: %v", err)
+		}
+		if val == 0 {
+			var err *ParseError
+			if p.hasKNFBound {
+				p.hasKNFBound = false
+				err = p.endCardConstr(line, i, ">=", p.knfBound)
+			} else if p.isXor {
+				p.isXor = false
+				p.endXor()
+			} else {
+				err = p.endClause(line, i)
 			}
-		} else if b == 'p' { // Parse header
-			pb.NbVars, nbClauses, err = parseHeader(r)
 			if err != nil {
-				return nil, fmt.Errorf("cannot parse CNF header: %v", err)
+				return err
 			}
-			pb.Model = make([]decLevel, pb.NbVars)
-			pb.Clauses = make([]*Clause, 0, nbClauses)
 		} else {
-			lits := make([]Lit, 0, 3) // Make room for some lits to improve performance
-			for {
-				val, err := readInt(&b, r)
-				if err == io.EOF {
-					if len(lits) != 0 { // This is not a trailing space at the end...
-						return nil, fmt.Errorf("unfinished clause while EOF found")
-					}
-					break // When there are only several useless spaces at the end of the file, that is ok
-				}
-				if err != nil {
-					return nil, fmt.Errorf("cannot parse clause: %v", err)
+			v := val
+			if v < 0 {
+				v = -v
+			}
+			if v > maxVar {
+				return p.errorAt(line, i, OutOfRange, ErrBadLiteral, "literal %d is too big", val)
+			}
+			if p.report != nil && v > p.report.NbVars {
+				p.report.addVar(v, p.lineNb)
+				if v > p.pb.NbVars {
+					p.pb.NbVars = v
 				}
-				if val == 0 {
-					pb.Clauses = append(pb.Clauses, NewClause(lits))
-					break
-				} else {
-					if val > pb.NbVars || -val > pb.NbVars {
-						return nil, fmt.Errorf("invalid literal %d for problem with %d vars only", val, pb.NbVars)
-					}
-					lits = append(lits, IntToLit(int32(val)))
+			} else if v > p.pb.NbVars {
+				err := p.errorAt(line, i, OutOfRange, ErrBadLiteral, "invalid literal %d for problem with %d vars only", val, p.pb.NbVars)
+				if err := p.tolerate(p.opts.VarRange, err); err != nil {
+					return err
 				}
+				p.pb.NbVars = v
+			}
+			if p.lits == nil {
+				p.lits = make([]Lit, 0, 3) // Make room for some lits to improve performance
 			}
+			p.lits = append(p.lits, IntToLit(int32(val)))
 		}
-		b, err = r.ReadByte()
+		i = skipSpaces(line, next)
 	}
-	if err != io.EOF {
-		return nil, err
+	return nil
+}
+
+// parseInd parses the vars of a "c ind" line, starting at the given index of line.
+// The line can be terminated by 0; a problem can declare its projection vars on several such lines.
+func (p *cnfParser) parseInd(line []byte, i int) *ParseError {
+	if p.pb.ProjectionVars == nil {
+		p.pb.ProjectionVars = []Var{}
+		p.indSeen = make(map[Var]bool)
+	}
+	for i = skipSpaces(line, i); i < len(line); i = skipSpaces(line, i) {
+		val, next, err := readInt(line, i)
+		if err != nil {
+			return p.errorAt(line, i, InvalidToken, ErrBadLiteral, "cannot parse projection var: %v", err)
+		}
+		if val == 0 {
+			if j := skipSpaces(line, next); j != len(line) {
+				return p.errorAt(line, j, InvalidSyntax, ErrBadConstraint, "projection vars found after 0")
+			}
+			return nil
+		}
+		if val < 0 || val > maxVar {
+			return p.errorAt(line, i, OutOfRange, ErrBadLiteral, "invalid projection var %d", val)
+		}
+		v := IntToVar(int32(val))
+		if p.indSeen[v] {
+			err := p.errorAt(line, i, Redundant, ErrBadLiteral, "projection var %d already declared", val)
+			if err := p.tolerate(p.opts.DuplicateLits, err); err != nil {
+				return err
+			}
+		} else {
+			p.indSeen[v] = true
+			p.pb.ProjectionVars = append(p.pb.ProjectionVars, v)
+			p.indLines = append(p.indLines, p.lineNb)
+		}
+		i = next
+	}
+	return nil
+}
+
+// parseKNFBound parses the bound of a KNF cardinality clause, such as "k 2" in "k 2 1 2 3 0",
+// whose "k" was found at the given index of line, and returns the index following it.
+func (p *cnfParser) parseKNFBound(line []byte, idx int) (int, *ParseError) {
+	if !p.knf && !p.opts.Lenient {
+		return 0, p.errorAt(line, idx, InvalidToken, ErrBadConstraint, "cardinality clause found without a KNF header")
+	}
+	if len(p.lits) != 0 || p.hasKNFBound {
+		return 0, p.errorAt(line, idx, InvalidSyntax, ErrBadConstraint, "bound found inside a clause")
+	}
+	i := skipSpaces(line, idx+1)
+	if i == len(line) {
+		return 0, p.errorAt(line, idx, InvalidSyntax, ErrBadWeight, "no bound after \"k\"")
+	}
+	bound, next, err := readInt(line, i)
+	if err != nil {
+		return 0, p.errorAt(line, i, InvalidToken, ErrBadWeight, "cannot parse bound: %v", err)
+	}
+	if bound < 0 {
+		return 0, p.errorAt(line, i, OutOfRange, ErrBadWeight, "negative bound %d", bound)
+	}
+	p.hasKNFBound = true
+	p.knfBound = bound
+	return next, nil
+}
+
+// cardOperator returns the comparison operator of a CNF+ cardinality constraint starting at line[i],
+// or "" if there is none.
+func cardOperator(line []byte, i int) string {
+	for _, op := range []string{">=", "<=", "="} {
+		end := i + len(op)
+		if end <= len(line) && string(line[i:end]) == op && (end == len(line) || isSpace(line[end])) {
+			return op
+		}
+	}
+	return ""
+}
+
+// endCard is called when the operator op of a CNF+ cardinality constraint, such as ">=" in "1 2 3 >= 2 0",
+// was found at the given index of line. It reads the bound and the optional terminating 0,
+// adds the constraint to the problem and returns the index following it.
+func (p *cnfParser) endCard(line []byte, idx int, op string) (int, *ParseError) {
+	if !p.cnfPlus && !p.opts.Lenient {
+		return 0, p.errorAt(line, idx, InvalidToken, ErrBadConstraint, "cardinality constraint found without a CNF+ header")
+	}
+	i := skipSpaces(line, idx+len(op))
+	if i == len(line) {
+		return 0, p.errorAt(line, idx, InvalidSyntax, ErrBadWeight, "no bound after %q", op)
+	}
+	bound, next, err := readInt(line, i)
+	if err != nil {
+		return 0, p.errorAt(line, i, InvalidToken, ErrBadWeight, "cannot parse bound: %v", err)
+	}
+	if bound < 0 {
+		return 0, p.errorAt(line, i, OutOfRange, ErrBadWeight, "negative bound %d", bound)
+	}
+	if j := skipSpaces(line, next); j < len(line) && line[j] == '0' && (j+1 == len(line) || isSpace(line[j+1])) {
+		next = j + 1
+	}
+	return next, p.endCardConstr(line, idx, op, bound)
+}
+
+// endCardConstr is called when the constraint stating that the lits read so far compare to bound
+// according to the operator op ended at the given index of line. It adds the constraint to the problem.
+func (p *cnfParser) endCardConstr(line []byte, idx int, op string, bound int) *ParseError {
+	p.nbRead++
+	lits := p.takeLits()
+	if len(p.seen) < p.pb.NbVars {
+		p.seen = append(p.seen, make([]int, p.pb.NbVars-len(p.seen))...)
+	}
+	for _, lit := range lits {
+		// Unlike in clauses, duplicate lits cannot be removed without changing the meaning of the constraint
+		mark := p.nbRead
+		if !lit.IsPositive() {
+			mark = -mark
+		}
+		if p.seen[lit.Var()] == mark {
+			return p.errorAt(line, idx, Redundant, ErrBadConstraint, "lit %d appears several times in cardinality constraint", lit.Int())
+		}
+		p.seen[lit.Var()] = mark
+	}
+	switch op {
+	case ">=":
+		p.addCard(lits, bound)
+	case "<=": // At most k lits are true iff at least n-k of their negations are true
+		p.addCard(negatedLits(lits), len(lits)-bound)
+	case "=":
+		p.addCard(lits, bound)
+		p.addCard(negatedLits(lits), len(lits)-bound)
+	}
+	return nil
+}
+
+// addCard adds to the problem the constraint stating that at least card of the given lits are true.
+func (p *cnfParser) addCard(lits []Lit, card int) {
+	switch {
+	case card <= 0: // Trivially SAT, ignore
+	case len(lits) < card: // Cannot be satisfied
+		p.pb.Clauses = append(p.pb.Clauses, NewClause(nil))
+	case len(lits) == card: // All lits must be true
+		for _, lit := range lits {
+			p.pb.Clauses = append(p.pb.Clauses, NewClause([]Lit{lit}))
+		}
+	case card == 1:
+		p.pb.Clauses = append(p.pb.Clauses, NewClause(lits))
+	default:
+		p.pb.Clauses = append(p.pb.Clauses, NewCardClause(lits, card))
+		p.hasCard = true
+	}
+}
+
+// negatedLits returns a new slice containing the negation of each of the given lits.
+func negatedLits(lits []Lit) []Lit {
+	res := make([]Lit, len(lits))
+	for i, lit := range lits {
+		res[i] = lit.Negation()
+	}
+	return res
+}
+
+// parseHeader parses the header line of a CNF file.
+// In lenient mode, the header is only a hint: the number of vars is at least the one announced in the header.
+func (p *cnfParser) parseHeader(line string) error {
+	if p.started() {
+		return fmt.Errorf("unexpected CNF header %q", line)
+	}
+	nbVars, nbClauses, err := parseHeader(line)
+	if err != nil {
+		return fmt.Errorf("cannot parse CNF header: %v", err)
+	}
+	p.hasHeader = true
+	format := strings.Fields(line)[1]
+	p.cnfPlus = format == "cnf+"
+	p.knf = format == "knf"
+	p.headerLine = p.lineNb
+	p.nbClauses = nbClauses
+	if p.opts.ValidateHeader {
+		p.report = &HeaderReport{Line: p.lineNb, NbVars: nbVars, NbClauses: nbClauses}
+	}
+	if nbVars > p.pb.NbVars {
+		p.pb.NbVars = nbVars
 	}
-	pb.simplify2()
-	return &pb, nil
+	p.pb.Clauses = make([]*Clause, 0, preallocSize(nbClauses))
+	return nil
 }
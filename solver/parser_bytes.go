@@ -0,0 +1,70 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// This file deals with the parsing of CNF problems that are already in memory.
+// Lines are tokenized directly over the given buffer: unlike ParseCNF, no line is copied in a scanner buffer.
+// For huge instances, where the garbage collector would otherwise be busier than the parser,
+// lits are also copied to the lits pool used for learned clauses, rather than each clause getting its own slice.
+
+// ParseCNFBytes is like ParseCNF, but parses the content of buf.
+// buf is not modified, and is not referenced by the returned problem.
+func ParseCNFBytes(buf []byte) (*Problem, error) {
+	return ParseCNFBytesOptions(buf, ParseOptions{})
+}
+
+// ParseCNFBytesOptions is like ParseCNFBytes, but the parsing is customized by opts.
+// Compressed content is supported, but is decompressed through a reader, as ParseCNFOptions does.
+func ParseCNFBytesOptions(buf []byte, opts ParseOptions) (*Problem, error) {
+	if bytes.HasPrefix(buf, gzipMagic) || bytes.HasPrefix(buf, bzip2Magic) || bytes.HasPrefix(buf, xzMagic) {
+		return ParseCNFOptions(bytes.NewReader(buf), opts)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseCNFBytesOptions(buf, opts) })
+	}
+	p := newCNFParser(opts)
+	p.pooled = len(buf) > nbLitsAlloc // Small problems would not be worth allocating a new pool
+	var errs ParseErrors
+	if _, err := p.parseBytes(buf, false, &errs); err != nil {
+		return nil, err
+	}
+	return p.result(&errs)
+}
+
+// parseBytes parses the lines of buf until its end or, if untilHeader is true, until the header was parsed.
+// It returns what remains to be parsed in buf. Errors that were recovered from are appended to errs.
+func (p *cnfParser) parseBytes(buf []byte, untilHeader bool, errs *ParseErrors) ([]byte, error) {
+	maxLineSize := p.opts.MaxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = defaultMaxLineSize
+	}
+	for len(buf) != 0 && !(untilHeader && p.hasHeader) {
+		line := buf
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line, buf = buf[:i], buf[i+1:]
+		} else {
+			buf = nil
+		}
+		if len(line) > maxLineSize {
+			return nil, scanError(bufio.ErrTooLong, p.lineNb, "CNF")
+		}
+		if n := len(line); n > 0 && line[n-1] == '\r' { // Same as bufio.ScanLines
+			line = line[:n-1]
+		}
+		p.lineNb++
+		if err := p.parseLine(line); err != nil {
+			if !p.opts.skip(errs, err) {
+				return nil, err
+			}
+			p.discard()
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,101 @@
+package solver
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCNFBytes(t *testing.T) {
+	paths, err := filepath.Glob("testcnf/*.cnf")
+	if err != nil {
+		t.Fatalf("could not list files: %v", err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("could not read %q: %v", path, err)
+		}
+		expected := parseFile(t, path, ParseCNF)
+		pb, err := ParseCNFBytes(content)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", path, err)
+		} else if !reflect.DeepEqual(pb, expected) {
+			t.Errorf("%q: ParseCNFBytes and ParseCNF disagree", path)
+		}
+	}
+	gz, err := io.ReadAll(gzipped(t, "testcnf/25.cnf"))
+	if err != nil {
+		t.Fatalf("could not read compressed file: %v", err)
+	}
+	if pb, err := ParseCNFBytes(gz); err != nil {
+		t.Errorf("could not parse compressed file: %v", err)
+	} else if expected := parseFile(t, "testcnf/25.cnf", ParseCNF); !reflect.DeepEqual(pb, expected) {
+		t.Errorf("compressed file: ParseCNFBytes and ParseCNF disagree")
+	}
+}
+
+func TestParseCNFBytesLongClause(t *testing.T) {
+	// The clause does not fit in the lits pool; duplicate lits are only removed once it was read
+	cnf := "p cnf 2 1\n" + strings.Repeat("1 ", nbLitsAlloc) + "2 0\n"
+	pb, err := ParseCNFBytes([]byte(cnf))
+	if err != nil {
+		t.Fatalf("could not parse long clause: %v", err)
+	}
+	if len(pb.Clauses) != 1 || pb.Clauses[0].Len() != 2 {
+		t.Errorf("invalid clauses %q", pb.CNF())
+	}
+}
+
+func TestParseCNFBytesLines(t *testing.T) {
+	for _, input := range []string{
+		"p cnf 3 2\r\n1 -2 0\r\n2 3 0\r\n",
+		"p cnf 3 2\n1 -2 0\n2 3 0",
+		"c comment\n\np cnf 3 2\n1 -2\n0 2 3 0\n\n",
+		"p cnf 3 2\n1 -2 0\n2 x 0\n",
+		"p cnf 3 2\n1 -2 0\n2 3\n",
+	} {
+		expected, expectedErr := ParseCNF(strings.NewReader(input))
+		pb, err := ParseCNFBytes([]byte(input))
+		if !reflect.DeepEqual(err, expectedErr) {
+			t.Errorf("%q: expected error %v, got %v", input, expectedErr, err)
+		} else if !reflect.DeepEqual(pb, expected) {
+			t.Errorf("%q: ParseCNFBytes and ParseCNF disagree", input)
+		}
+	}
+	input := "p cnf 3 1\n1 2 3 -1 -2 -3 0\n"
+	_, err := ParseCNFBytesOptions([]byte(input), ParseOptions{MaxLineSize: 12})
+	if perr, ok := err.(*ParseError); !ok || perr.Kind != LineTooLong || perr.Line != 2 {
+		t.Errorf("expected a too long line 2, got %v", err)
+	}
+	if _, expectedErr := ParseCNFOptions(strings.NewReader(input), ParseOptions{MaxLineSize: 12}); !reflect.DeepEqual(err, expectedErr) {
+		t.Errorf("expected error %v, got %v", expectedErr, err)
+	}
+}
+
+func BenchmarkParseCNF(b *testing.B) {
+	content, err := os.ReadFile("testcnf/hsat_vc11803.cnf")
+	if err != nil {
+		b.Fatalf("could not read file: %v", err)
+	}
+	b.Run("reader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseCNF(bytes.NewReader(content)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseCNFBytes(content); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
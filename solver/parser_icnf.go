@@ -0,0 +1,126 @@
+package solver
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file deals with the parsing of incremental problems in the iCNF format.
+// After a "p inccnf" header, clauses are interleaved with "a" lines, such as "a 1 -2 0", each describing
+// a query: the problem made of all the clauses read so far must be solved under the given assumptions.
+// iCNF files are typically used to record the incremental queries made by a program, so they can be replayed later.
+
+// A Query is a set of clauses added to an incremental problem, followed by a call to the solver under some assumptions.
+type Query struct {
+	Clauses     [][]int // Clauses added to the problem before the query, in the DIMACS format
+	Assumptions []int   // Lits assumed during the query, in the DIMACS format
+}
+
+// An IncrementalProblem is a sequence of queries on a growing set of clauses.
+type IncrementalProblem struct {
+	NbVars  int     // Biggest var appearing in the problem, or in the file it was parsed from
+	Queries []Query // Queries, in the order they must be run
+}
+
+// ParseICNF parses an iCNF file and returns the corresponding incremental problem.
+// Clauses appearing after the last query do not affect any query, and are ignored.
+func ParseICNF(f io.Reader) (*IncrementalProblem, error) {
+	return ParseICNFOptions(f, ParseOptions{})
+}
+
+// ParseICNFOptions is like ParseICNF, but the parsing is customized by opts.
+// Only MaxLineSize and RecoverPanics apply to iCNF files.
+func ParseICNFOptions(f io.Reader, opts ParseOptions) (*IncrementalProblem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		var ip *IncrementalProblem
+		err := protectFunc(func() (err error) {
+			ip, err = ParseICNFOptions(f, opts)
+			return err
+		})
+		return ip, err
+	}
+	var (
+		ip      IncrementalProblem
+		query   Query // Query being parsed
+		clause  []int // Clause being parsed, that might span several lines
+		started bool  // True iff the header was parsed
+	)
+	sc := NewScanner(f, opts.MaxLineSize)
+	lineNb := 0
+	for sc.Scan() {
+		lineNb++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || fields[0][0] == 'c' {
+			continue
+		}
+		switch {
+		case fields[0] == "p":
+			if started || len(fields) != 2 || fields[1] != "inccnf" {
+				return nil, newParseError(lineNb, ErrBadHeader, "invalid iCNF header %q", sc.Text())
+			}
+			started = true
+		case !started:
+			return nil, newParseError(lineNb, ErrBadHeader, "line %q found before iCNF header", sc.Text())
+		case fields[0] == "a":
+			if len(clause) != 0 {
+				return nil, newParseError(lineNb, ErrBadConstraint, "query %q found inside a clause", sc.Text())
+			}
+			if fields[len(fields)-1] != "0" {
+				return nil, newParseError(lineNb, ErrBadConstraint, "query %q is not terminated by 0", sc.Text())
+			}
+			lits, err := ip.parseLits(fields[1 : len(fields)-1])
+			if err != nil {
+				return nil, atLine(err, lineNb, sc.Text())
+			}
+			query.Assumptions = lits
+			ip.Queries = append(ip.Queries, query)
+			query = Query{}
+		default:
+			for _, field := range fields {
+				if field == "0" {
+					query.Clauses = append(query.Clauses, clause)
+					clause = nil
+					continue
+				}
+				lits, err := ip.parseLits([]string{field})
+				if err != nil {
+					return nil, atLine(err, lineNb, sc.Text())
+				}
+				clause = append(clause, lits[0])
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, scanError(err, lineNb, "iCNF")
+	}
+	if !started {
+		return nil, newParseError(lineNb, ErrBadHeader, "no header in iCNF file")
+	}
+	if len(clause) != 0 {
+		return nil, newParseError(lineNb, ErrTruncated, "last clause is not terminated by 0")
+	}
+	return &ip, nil
+}
+
+// parseLits parses the given non-zero lits, and updates the number of vars in ip accordingly.
+func (ip *IncrementalProblem) parseLits(fields []string) ([]int, error) {
+	lits := make([]int, len(fields))
+	for i, field := range fields {
+		val, err := strconv.Atoi(field)
+		if err != nil || val == 0 || val > maxVar || val < -maxVar {
+			return nil, tokenError(InvalidToken, ErrBadLiteral, field, "invalid literal %q in iCNF file", field)
+		}
+		if val > ip.NbVars {
+			ip.NbVars = val
+		} else if -val > ip.NbVars {
+			ip.NbVars = -val
+		}
+		lits[i] = val
+	}
+	return lits, nil
+}
@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseICNF(t *testing.T) {
+	const icnf = `c An incremental problem
+p inccnf
+1 2 0
+-1 3
+0
+a -2 0
+a 0
+-3 0
+a -2 0
+4 0
+`
+	ip, err := ParseICNF(strings.NewReader(icnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	expected := &IncrementalProblem{
+		NbVars: 4, // x4 only appears in an ignored clause
+		Queries: []Query{
+			{Clauses: [][]int{{1, 2}, {-1, 3}}, Assumptions: []int{-2}},
+			{Assumptions: []int{}},
+			{Clauses: [][]int{{-3}}, Assumptions: []int{-2}},
+		},
+	}
+	if !reflect.DeepEqual(ip, expected) {
+		t.Errorf("invalid problem: expected %+v, got %+v", expected, ip)
+	}
+}
+
+func TestParseICNFErrors(t *testing.T) {
+	for _, icnf := range []string{
+		"1 2 0\np inccnf\n",
+		"p cnf 2 1\n",
+		"p inccnf\np inccnf\n",
+		"p inccnf\n1 2\na 1 0\n",
+		"p inccnf\na 1\n",
+		"p inccnf\na x 0\n",
+		"p inccnf\n1 x 0\n",
+		"p inccnf\n1 2\n",
+		"c no header\n",
+	} {
+		if _, err := ParseICNF(strings.NewReader(icnf)); err == nil {
+			t.Errorf("expected error when parsing %q", icnf)
+		}
+	}
+}
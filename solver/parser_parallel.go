@@ -0,0 +1,156 @@
+//go:build !noparallel
+// +build !noparallel
+
+package solver
+
+import (
+	"bytes"
+	"sync"
+)
+
+// This file deals with the parsing of huge CNF problems on several goroutines.
+// Once the header was parsed, the rest of the file is split in chunks, at line boundaries where no clause is pending,
+// and each chunk is parsed by its own goroutine. Clauses of all chunks are then merged, in order, as if
+// the file had been parsed sequentially.
+// Only plain "p cnf" files can be split: in CNF+ and KNF files, a 0 does not always end a constraint.
+// It is left out of builds using the noparallel build tag, and replaced by parallel_stub.go.
+
+// hasParallel is true iff work can be split between several goroutines in this build.
+const hasParallel = true
+
+// minChunkSize is the minimum size of a chunk parsed on its own goroutine, in bytes.
+// Smaller chunks are not worth starting a goroutine.
+const minChunkSize = 1 << 20
+
+// ParseCNFParallel is like ParseCNFBytesOptions, but the clauses are parsed by at most nbWorkers goroutines.
+// The result is the same as ParseCNFBytesOptions', errors included.
+// When the file cannot be split, i.e when it is small or when it is not a plain CNF file,
+// when comments are kept or the header is validated, or when the number of vars is not fixed by the header,
+// it is parsed sequentially. "c ind" lines found before the header are parsed along with it; if some are found
+// by the goroutines, after the header, the file is parsed again sequentially, so that they are merged in order.
+func ParseCNFParallel(buf []byte, opts ParseOptions, nbWorkers int) (*Problem, error) {
+	if bytes.HasPrefix(buf, gzipMagic) || bytes.HasPrefix(buf, bzip2Magic) || bytes.HasPrefix(buf, xzMagic) {
+		return ParseCNFOptions(bytes.NewReader(buf), opts)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseCNFParallel(buf, opts, nbWorkers) })
+	}
+	p := newCNFParser(opts)
+	if nbWorkers < 2 || opts.KeepComments || opts.ValidateHeader || p.opts.VarRange != Reject {
+		return ParseCNFBytesOptions(buf, opts)
+	}
+	p.pooled = len(buf) > nbLitsAlloc
+	var errs ParseErrors
+	body, err := p.parseBytes(buf, true, &errs)
+	if err != nil {
+		return nil, err
+	}
+	if p.cnfPlus || p.knf || len(body) < 2*minChunkSize {
+		if _, err := p.parseBytes(body, false, &errs); err != nil {
+			return nil, err
+		}
+		return p.result(&errs)
+	}
+	chunks := splitClauses(body, nbWorkers)
+	parsers := make([]*cnfParser, len(chunks))
+	chunkErrs := make([]ParseErrors, len(chunks))
+	fatal := make([]error, len(chunks))
+	lineNb := p.lineNb
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		parsers[i] = p.chunkParser(lineNb)
+		lineNb += bytes.Count(chunk, []byte{'\n'})
+		wg.Add(1)
+		go func(i int, chunk []byte) {
+			defer wg.Done()
+			_, fatal[i] = parsers[i].parseBytes(chunk, false, &chunkErrs[i])
+		}(i, chunk)
+	}
+	wg.Wait()
+	for _, q := range parsers {
+		if q.pb.ProjectionVars != nil {
+			return ParseCNFBytesOptions(buf, opts)
+		}
+	}
+	for i, q := range parsers {
+		errs = append(errs, chunkErrs[i]...)
+		if fatal[i] != nil { // Later chunks would not have been parsed by a sequential parser
+			return nil, fatal[i]
+		}
+		p.pb.Clauses = append(p.pb.Clauses, q.pb.Clauses...)
+		p.pb.Xors = append(p.pb.Xors, q.pb.Xors...)
+		p.unitLits = append(p.unitLits, q.unitLits...)
+		p.unitLines = append(p.unitLines, q.unitLines...)
+		p.pb.Warnings = append(p.pb.Warnings, q.pb.Warnings...)
+		p.nbRead += q.nbRead
+		p.stats.NbTautologies += q.stats.NbTautologies
+		p.stats.NbDuplicates += q.stats.NbDuplicates
+		p.lineNb = q.lineNb
+		p.lits = q.lits
+		p.isXor = q.isXor
+	}
+	return p.result(&errs)
+}
+
+// chunkParser returns a parser for a chunk of the file p is parsing, once its header was parsed.
+// lineNb is the number of lines before the chunk.
+func (p *cnfParser) chunkParser(lineNb int) *cnfParser {
+	q := cnfParser{opts: p.opts, hasHeader: true, lineNb: lineNb}
+	q.pb.NbVars = p.pb.NbVars
+	return &q
+}
+
+// splitClauses splits body, the content of a plain CNF file following its header, in at most n chunks
+// of roughly equal size. Each chunk but the last one ends with a line whose last token is the 0 terminating a clause.
+func splitClauses(body []byte, n int) [][]byte {
+	size := len(body) / n
+	if size < minChunkSize {
+		size = minChunkSize
+	}
+	var chunks [][]byte
+	for len(chunks) < n-1 && len(body) > size {
+		end := clauseBoundary(body, size)
+		if end == len(body) {
+			break
+		}
+		chunks = append(chunks, body[:end])
+		body = body[end:]
+	}
+	return append(chunks, body)
+}
+
+// clauseBoundary returns the index following the first line of body, ending at or after index from,
+// that is a clause ended by a 0. It returns len(body) if there is no such line.
+func clauseBoundary(body []byte, from int) int {
+	for from < len(body) {
+		i := bytes.IndexByte(body[from:], '\n')
+		if i < 0 {
+			return len(body)
+		}
+		end := from + i
+		start := bytes.LastIndexByte(body[:end], '\n') + 1
+		if endsClause(body[start:end]) {
+			return end + 1
+		}
+		from = end + 1
+	}
+	return len(body)
+}
+
+// endsClause returns true iff line, in a plain CNF file, is not a comment nor a header and its last token is 0.
+// Once it was parsed, no clause is pending.
+func endsClause(line []byte) bool {
+	i := skipSpaces(line, 0)
+	if i == len(line) || line[i] == 'c' || line[i] == 'p' {
+		return false
+	}
+	j := len(line)
+	for j > i && isSpace(line[j-1]) {
+		j--
+	}
+	return line[j-1] == '0' && (j-1 == i || isSpace(line[j-2]))
+}
@@ -0,0 +1,98 @@
+//go:build !noparallel
+// +build !noparallel
+
+package solver
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hugeCNF returns a random 3-CNF file of at least the given size, whose clauses can span several lines.
+// Each line of the body for which bad returns true is replaced by an invalid one.
+func hugeCNF(rng *rand.Rand, size int, bad func(line int) bool) []byte {
+	var sb strings.Builder
+	nbVars := 1000
+	var clauses [][]int
+	for sb.Len() < size {
+		clause := []int{rng.Intn(nbVars) + 1, -rng.Intn(nbVars) - 1, rng.Intn(nbVars) + 1}
+		clauses = append(clauses, clause)
+		for _, lit := range clause {
+			sb.WriteString(strconv.Itoa(lit))
+			sb.WriteByte(' ')
+			if rng.Intn(8) == 0 {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString("0\n")
+		if rng.Intn(100) == 0 {
+			sb.WriteString("c comment 0\n")
+		}
+	}
+	lines := strings.Split(sb.String(), "\n")
+	for i := range lines {
+		if bad(i) {
+			lines[i] = "1 x 0"
+		}
+	}
+	return []byte(fmt.Sprintf("c random problem\np cnf %d %d\n%s", nbVars, len(clauses), strings.Join(lines, "\n")))
+}
+
+func TestParseCNFParallel(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	never := func(int) bool { return false }
+	for _, test := range []struct {
+		name    string
+		content []byte
+		opts    ParseOptions
+	}{
+		{"random", hugeCNF(rng, 3*minChunkSize, never), ParseOptions{}},
+		{"small", hugeCNF(rng, minChunkSize, never), ParseOptions{}},
+		{"error", hugeCNF(rng, 3*minChunkSize, func(line int) bool { return line == 200000 }), ParseOptions{}},
+		{"recover", hugeCNF(rng, 3*minChunkSize, func(line int) bool { return line%40000 == 7 }), ParseOptions{Recover: true}},
+		{"count", append(hugeCNF(rng, 3*minChunkSize, never), "1 2 0\n"...), ParseOptions{ClauseCount: Warn}},
+		{"unfinished", append(hugeCNF(rng, 3*minChunkSize, never), "1 2\n"...), ParseOptions{}},
+		{"ind header", append([]byte("c ind 1 2 0\n"), hugeCNF(rng, 3*minChunkSize, never)...), ParseOptions{}},
+		{"ind body", bytes.ReplaceAll(hugeCNF(rng, 3*minChunkSize, never), []byte("c comment 0"), []byte("c ind 3 4 0")), ParseOptions{DuplicateLits: Warn}},
+		{"ind comment", bytes.ReplaceAll(hugeCNF(rng, 3*minChunkSize, never), []byte("c comment 0"), []byte("c indicator 0")), ParseOptions{}},
+		{"xor", bytes.ReplaceAll(hugeCNF(rng, 3*minChunkSize, never), []byte("c comment 0"), []byte("x1 -2 3 4 5 0")), ParseOptions{}},
+	} {
+		expected, expectedErr := ParseCNFBytesOptions(test.content, test.opts)
+		for _, nbWorkers := range []int{1, 4} {
+			pb, err := ParseCNFParallel(test.content, test.opts, nbWorkers)
+			if !reflect.DeepEqual(err, expectedErr) {
+				t.Errorf("%s, %d workers: expected error %v, got %v", test.name, nbWorkers, expectedErr, err)
+			} else if !reflect.DeepEqual(pb, expected) {
+				t.Errorf("%s, %d workers: ParseCNFParallel and ParseCNFBytesOptions disagree", test.name, nbWorkers)
+			}
+		}
+	}
+}
+
+func TestSplitClauses(t *testing.T) {
+	body := []byte(strings.Repeat("1 2\n-3 0\nc 0\n", minChunkSize/4))
+	chunks := splitClauses(body, 3)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	total := 0
+	for i, chunk := range chunks {
+		total += len(chunk)
+		if i < len(chunks)-1 && !strings.HasSuffix(string(chunk), "\n-3 0\n") {
+			t.Errorf("chunk #%d does not end with a clause: %q", i, chunk[len(chunk)-20:])
+		}
+	}
+	if total != len(body) {
+		t.Errorf("chunks have %d bytes, expected %d", total, len(body))
+	}
+	for line, expected := range map[string]bool{"1 2 0": true, "0": true, "  -1 0 \r": true, "1 20": false, "1 -0": false, "c 0": false, "": false} {
+		if got := endsClause([]byte(line)); got != expected {
+			t.Errorf("endsClause(%q): expected %t, got %t", line, expected, got)
+		}
+	}
+}
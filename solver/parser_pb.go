@@ -1,25 +1,48 @@
 package solver
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // ParseCardConstrs parses the given cardinality constraints.
-// Will panic if a zero value appears in the literals.
+// Will panic if a zero value appears in the literals: use ParseCardConstrsErr on untrusted input.
 func ParseCardConstrs(constrs []CardConstr) *Problem {
-	var pb Problem
+	pb, _ := ParseCardConstrsHint(constrs, SizeHint{})
+	return pb
+}
+
+// ParseCardConstrsErr is like ParseCardConstrs, but if a constraint contains the lit 0,
+// a *ConstrError identifying that constraint is returned instead of panicking.
+func ParseCardConstrsErr(constrs []CardConstr) (*Problem, error) {
+	for i, constr := range constrs {
+		if err := checkLits(i, constr.Lits); err != nil {
+			return nil, err
+		}
+	}
+	return ParseCardConstrs(constrs), nil
+}
+
+// ParseCardConstrsHint is like ParseCardConstrs, but memory is preallocated according to hint.
+// It also returns statistics about the parsing.
+func ParseCardConstrsHint(constrs []CardConstr, hint SizeHint) (*Problem, ParseStats) {
+	pb := newHintedProblem(hint)
+	var stats ParseStats
 	for _, constr := range constrs {
+		stats.NbConstrs++
 		card := constr.AtLeast
 		if card <= 0 { // Clause is trivially SAT, ignore
+			stats.NbIgnored++
 			continue
 		}
 		if len(constr.Lits) < card { // Clause cannot be satsfied
 			pb.Status = Unsat
-			return &pb
+			return pb, stats
 		}
 		if len(constr.Lits) == card { // All lits must be true
 			for i := range constr.Lits {
@@ -31,7 +54,7 @@ func ParseCardConstrs(constrs []CardConstr) *Problem {
 				if int(v) >= pb.NbVars {
 					pb.NbVars = int(v) + 1
 				}
-				pb.Units = append(pb.Units, lit)
+				stats.appendUnit(pb, lit, stats.NbConstrs-1)
 			}
 		} else {
 			lits := make([]Lit, len(constr.Lits))
@@ -44,39 +67,64 @@ func ParseCardConstrs(constrs []CardConstr) *Problem {
 					pb.NbVars = v + 1
 				}
 			}
-			pb.Clauses = append(pb.Clauses, NewCardClause(lits, card))
+			stats.appendClause(pb, NewCardClause(lits, card))
 		}
 	}
-	pb.Model = make([]decLevel, pb.NbVars)
-	for _, unit := range pb.Units {
-		v := unit.Var()
-		if pb.Model[v] == 0 {
-			if unit.IsPositive() {
-				pb.Model[v] = 1
-			} else {
-				pb.Model[v] = -1
-			}
-		} else if pb.Model[v] > 0 != unit.IsPositive() {
-			pb.Status = Unsat
-			return &pb
-		}
+	if pb.bindUnits() {
+		pb.simplifyCard()
 	}
-	pb.simplifyCard()
-	return &pb
+	return pb, stats
 }
 
-func (pb *Problem) appendClause(constr PBConstr) {
+// newClause returns the PB clause associated with constr.
+func newClause(constr PBConstr) *Clause {
+	if constr.BigAtLeast != nil {
+		return constr.bigClause()
+	}
 	lits := make([]Lit, len(constr.Lits))
 	for j, val := range constr.Lits {
 		lits[j] = IntToLit(int32(val))
 	}
-	pb.Clauses = append(pb.Clauses, NewPBClause(lits, constr.Weights, constr.AtLeast))
+	var weights []int
+	if constr.Weights != nil { // NewPBClause sorts weights: constr must not be modified
+		weights = make([]int, len(constr.Weights))
+		copy(weights, constr.Weights)
+	}
+	return NewPBClause(lits, weights, constr.AtLeast)
 }
 
 // ParsePBConstrs parses and returns a PB problem from PBConstr values.
+// Constraints whose weights are too big to be summed as ints, or that have arbitrary-precision weights,
+// are normalized first.
 func ParsePBConstrs(constrs []PBConstr) *Problem {
-	var pb Problem
+	pb, _ := ParsePBConstrsHint(constrs, SizeHint{})
+	return pb
+}
+
+// ParsePBConstrsErr is like ParsePBConstrs, but if a constraint contains the lit 0, or has weights but not as many
+// as lits, a *ConstrError identifying that constraint is returned instead of panicking.
+func ParsePBConstrsErr(constrs []PBConstr) (*Problem, error) {
+	for i, constr := range constrs {
+		if err := checkLits(i, constr.Lits); err != nil {
+			return nil, err
+		}
+		if constr.BigAtLeast == nil && constr.Weights != nil && len(constr.Weights) != len(constr.Lits) {
+			return nil, weightsCountError(i, len(constr.Lits), len(constr.Weights))
+		}
+		if constr.BigAtLeast != nil && constr.BigWeights != nil && len(constr.BigWeights) != len(constr.Lits) {
+			return nil, weightsCountError(i, len(constr.Lits), len(constr.BigWeights))
+		}
+	}
+	return ParsePBConstrs(constrs), nil
+}
+
+// ParsePBConstrsHint is like ParsePBConstrs, but memory is preallocated according to hint.
+// It also returns statistics about the parsing.
+func ParsePBConstrsHint(constrs []PBConstr, hint SizeHint) (*Problem, ParseStats) {
+	pb := newHintedProblem(hint)
+	var stats ParseStats
 	for _, constr := range constrs {
+		stats.NbConstrs++
 		for i := range constr.Lits {
 			lit := IntToLit(int32(constr.Lits[i]))
 			v := lit.Var()
@@ -84,183 +132,479 @@ func ParsePBConstrs(constrs []PBConstr) *Problem {
 				pb.NbVars = int(v) + 1
 			}
 		}
+		if !constr.fitsSolver() {
+			if constr = constr.normalized(); constr.BigAtLeast != nil { // Neither trivial nor unit
+				stats.appendClause(pb, constr.bigClause())
+				continue
+			}
+		}
 		card := constr.AtLeast
 		if card <= 0 { // Clause is trivially SAT, ignore
+			stats.NbIgnored++
 			continue
 		}
 		sumW := constr.WeightSum()
 		if sumW < card { // Clause cannot be satsfied
 			pb.Status = Unsat
-			return &pb
+			return pb, stats
 		}
 		if sumW == card { // All lits must be true
 			for i := range constr.Lits {
-				lit := IntToLit(int32(constr.Lits[i]))
-				found := false
-				for _, u := range pb.Units {
-					if u == lit {
-						found = true
-						break
-					}
-				}
-				if !found {
-					pb.Units = append(pb.Units, lit)
-				}
+				stats.appendUnit(pb, IntToLit(int32(constr.Lits[i])), stats.NbConstrs-1)
 			}
 		} else {
-			pb.appendClause(constr)
+			stats.appendClause(pb, newClause(constr))
 		}
 	}
-	pb.Model = make([]decLevel, pb.NbVars)
-	for _, unit := range pb.Units {
-		v := unit.Var()
-		if pb.Model[v] == 0 {
-			if unit.IsPositive() {
-				pb.Model[v] = 1
-			} else {
-				pb.Model[v] = -1
-			}
-		} else if pb.Model[v] > 0 != unit.IsPositive() {
-			pb.Status = Unsat
-			return &pb
-		}
+	if pb.bindUnits() {
+		pb.simplifyPB()
 	}
-	pb.simplifyPB()
-	return &pb
+	return pb, stats
+}
+
+// An opbParser parses the lines of an OPB file into a problem.
+type opbParser struct {
+	pb *Problem
+	// The sorted lits of each product of lits met so far, and the index of each product in productDefs.
+	productDefs [][]int
+	products    map[string]int
 }
 
 // parsePBOptim parses the "min:" instruction.
-func (pb *Problem) parsePBOptim(fields []string, line string) error {
-	weights, lits, err := pb.parseTerms(fields[1:], line)
+func (p *opbParser) parsePBOptim(fields []string, line string) error {
+	weights, lits, bigWeights, err := p.parseTerms(fields[1:], line)
 	if err != nil {
 		return err
 	}
-	pb.minLits = make([]Lit, len(lits))
+	// Costs cannot be normalized, since that would change them: their sum is the sum of the weights of the constraints
+	// bounding the cost, so it cannot be bigger than maxWeightSum.
+	sum := 0
+	for _, w := range weights {
+		if w < 0 {
+			w = -w
+		}
+		if bigWeights != nil || w < 0 || w > maxWeightSum-sum {
+			return tokenError(OutOfRange, ErrBadWeight, "", "weights of objective are too big in %q", line)
+		}
+		sum += w
+	}
+	p.pb.minLits = make([]Lit, len(lits))
 	for i, lit := range lits {
-		pb.minLits[i] = IntToLit(int32(lit))
+		p.pb.minLits[i] = IntToLit(int32(lit))
 	}
-	pb.minWeights = weights
+	p.pb.minWeights = weights
 	return nil
 }
 
-func (pb *Problem) parsePBLine(line string) error {
+// parsePBLine parses the line of an OPB file whose number is lineNb.
+func (p *opbParser) parsePBLine(line string, lineNb int) error {
 	if line[len(line)-1] != ';' {
-		return fmt.Errorf("line %q does not end with semicolon", line)
+		return tokenError(InvalidSyntax, ErrBadConstraint, "", "line %q does not end with semicolon", line)
 	}
 	fields := strings.Fields(line[:len(line)-1])
 	if len(fields) == 0 {
-		return fmt.Errorf("empty line in file")
+		return tokenError(InvalidSyntax, ErrBadConstraint, "", "empty line in file")
 	}
 	if fields[0] == "min:" { // Optimization constraint
-		return pb.parsePBOptim(fields, line)
+		return p.parsePBOptim(fields, line)
 	}
-	return pb.parsePBConstrLine(fields, line)
+	return p.parsePBConstrLine(fields, line, lineNb)
 }
 
-func (pb *Problem) parsePBConstrLine(fields []string, line string) error {
+// parsePBConstrLine parses the fields of the constraint line whose number is lineNb, and adds the constraint to the problem.
+func (p *opbParser) parsePBConstrLine(fields []string, line string, lineNb int) error {
+	constrs, err := p.parsePBConstrs(fields, line)
+	if err != nil {
+		return err
+	}
+	for _, constr := range constrs {
+		p.pb.appendPBConstr(constr, lineNb)
+	}
+	return nil
+}
+
+// parsePBConstrs parses the fields of a constraint line, without its final semicolon,
+// and returns the equivalent normalized constraints.
+func (p *opbParser) parsePBConstrs(fields []string, line string) ([]PBConstr, error) {
 	if len(fields) < 3 {
-		return fmt.Errorf("invalid syntax %q", line)
+		return nil, tokenError(InvalidSyntax, ErrBadConstraint, "", "invalid syntax %q", line)
 	}
 	operator := fields[len(fields)-2]
-	if operator != ">=" && operator != "=" {
-		return fmt.Errorf("invalid operator %q in %q: expected \">=\" or \"=\"", operator, line)
+	switch operator {
+	case ">=", ">", "<=", "<", "=":
+	default:
+		return nil, tokenError(InvalidToken, ErrBadOperator, operator, "invalid operator %q in %q: expected \">=\", \">\", \"<=\", \"<\" or \"=\"", operator, line)
 	}
-	rhs, err := strconv.Atoi(fields[len(fields)-1])
-	if err != nil {
-		return fmt.Errorf("invalid value %q in %q: %v", fields[len(fields)-1], line, err)
+	rhs, rhsErr := strconv.Atoi(fields[len(fields)-1])
+	if rhsErr != nil && !errors.Is(rhsErr, strconv.ErrRange) {
+		return nil, tokenError(InvalidToken, ErrBadWeight, fields[len(fields)-1], "invalid value %q in %q: %v", fields[len(fields)-1], line, rhsErr)
 	}
-	weights, lits, err := pb.parseTerms(fields[:len(fields)-2], line)
+	weights, lits, bigWeights, err := p.parseTerms(fields[:len(fields)-2], line)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var constrs []PBConstr
-	if operator == ">=" {
-		constrs = []PBConstr{GtEq(lits, weights, rhs)}
-	} else {
-		constrs = Eq(lits, weights, rhs)
+	if rhsErr != nil || bigWeights != nil || !smallTerms(weights, rhs) {
+		return bigPBConstrs(operator, lits, weights, bigWeights, fields[len(fields)-1]), nil
 	}
-	for _, constr := range constrs {
-		card := constr.AtLeast
-		sumW := constr.WeightSum()
-		if sumW < card { // Clause cannot be satsfied
-			pb.Status = Unsat
-			return nil
+	// As weights and rhs are ints, strict inequalities are shifted to non-strict ones.
+	switch operator {
+	case ">=":
+		return []PBConstr{GtEq(lits, weights, rhs)}, nil
+	case ">":
+		return []PBConstr{GtEq(lits, weights, rhs+1)}, nil
+	case "<=":
+		return []PBConstr{LtEq(lits, weights, rhs)}, nil
+	case "<":
+		return []PBConstr{LtEq(lits, weights, rhs-1)}, nil
+	default:
+		return Eq(lits, weights, rhs), nil
+	}
+}
+
+// bigPBConstrs is like the end of parsePBConstrs, for constraints whose weights or rhs are too big
+// to be normalized with int arithmetic. If bigWeights is nil, all weights fit in weights.
+func bigPBConstrs(operator string, lits, weights []int, bigWeights []*big.Int, rhsToken string) []PBConstr {
+	if bigWeights == nil {
+		bigWeights = make([]*big.Int, len(weights))
+		for i, w := range weights {
+			bigWeights[i] = big.NewInt(int64(w))
 		}
-		if sumW == card { // All lits must be true
-			for i := range constr.Lits {
-				lit := IntToLit(int32(constr.Lits[i]))
-				pb.Units = append(pb.Units, lit)
-			}
-		} else {
-			lits := make([]Lit, len(constr.Lits))
-			for j, val := range constr.Lits {
-				lits[j] = IntToLit(int32(val))
-			}
-			pb.Clauses = append(pb.Clauses, NewPBClause(lits, constr.Weights, card))
+	}
+	rhs, _ := new(big.Int).SetString(rhsToken, 10) // Token was already checked by strconv.Atoi
+	switch operator {
+	case ">=":
+		return []PBConstr{BigGtEq(lits, bigWeights, rhs)}
+	case ">":
+		return []PBConstr{BigGtEq(lits, bigWeights, rhs.Add(rhs, big.NewInt(1)))}
+	case "<=":
+		return []PBConstr{BigLtEq(lits, bigWeights, rhs)}
+	case "<":
+		return []PBConstr{BigLtEq(lits, bigWeights, rhs.Sub(rhs, big.NewInt(1)))}
+	default:
+		return BigEq(lits, bigWeights, rhs)
+	}
+}
+
+// appendPBConstr adds the normalized constraint to pb, either as units or as a clause,
+// or makes pb Unsat if it cannot be satisfied. src identifies the constraint in the input.
+func (pb *Problem) appendPBConstr(constr PBConstr, src int) {
+	if constr.BigAtLeast != nil { // Neither trivial nor unit, since it was normalized
+		pb.Clauses = append(pb.Clauses, constr.bigClause())
+		return
+	}
+	card := constr.AtLeast
+	if card <= 0 { // Constraint is trivially SAT, ignore
+		return
+	}
+	sumW := constr.WeightSum()
+	if sumW < card { // Clause cannot be satsfied
+		pb.Status = Unsat
+		return
+	}
+	if sumW == card { // All lits must be true
+		for i := range constr.Lits {
+			pb.appendUnit(IntToLit(int32(constr.Lits[i])), src)
+		}
+	} else {
+		lits := make([]Lit, len(constr.Lits))
+		for j, val := range constr.Lits {
+			lits[j] = IntToLit(int32(val))
 		}
+		pb.Clauses = append(pb.Clauses, NewPBClause(lits, constr.Weights, card))
 	}
-	return nil
 }
 
-func (pb *Problem) parseTerms(terms []string, line string) (weights []int, lits []int, err error) {
+// parseTerms parses the terms of a constraint or of an objective function, appearing in the given line.
+// A term is a lit, such as "x1" or "~x1", optionally preceded by its weight, such as "+2 x1"; a lit without a weight has weight 1.
+// A term can also be a product of lits, such as "+2 x1 ~x2": it is then replaced by a placeholder lit, see productLit.
+// Null weights, and vars or products appearing in several terms, are rejected.
+// bigWeights is nil, unless a weight is too big to be represented as an int: it then contains all the weights,
+// and weights must not be used.
+func (p *opbParser) parseTerms(terms []string, line string) (weights []int, lits []int, bigWeights []*big.Int, err error) {
 	weights = make([]int, 0, len(terms)/2)
 	lits = make([]int, 0, len(terms)/2)
-	i := 0
-	for i < len(terms) {
-		var l string
-		w, err := strconv.Atoi(terms[i])
-		if err != nil {
-			l = terms[i]
-			if !strings.HasPrefix(l, "x") && !strings.HasPrefix(l, "~x") {
-				return nil, nil, fmt.Errorf("invalid weight %q in %q: %v", terms[i*2], line, err)
+	seen := make(map[int]bool, len(terms)/2) // Vars already met in the terms
+	for i := 0; i < len(terms); i++ {
+		w := 1
+		var bigW *big.Int // Set iff w is too big for an int
+		if !isPBLit(terms[i]) {
+			if w, err = strconv.Atoi(terms[i]); errors.Is(err, strconv.ErrRange) {
+				bigW, _ = new(big.Int).SetString(terms[i], 10)
+			} else if err != nil {
+				return nil, nil, nil, tokenError(InvalidToken, ErrBadWeight, terms[i], "invalid weight %q in %q: %v", terms[i], line, err)
+			}
+			if w == 0 && bigW == nil {
+				return nil, nil, nil, tokenError(OutOfRange, ErrBadWeight, terms[i], "null weight in %q", line)
 			}
-			// This is a weightless lit, i.e a lit with weight 1.
-			weights = append(weights, 1)
-		} else {
-			weights = append(weights, w)
 			i++
-			l = terms[i]
-			if !strings.HasPrefix(l, "x") && !strings.HasPrefix(l, "~x") || len(l) < 2 {
-				return nil, nil, fmt.Errorf("invalid variable name %q in %q", l, line)
+			if i == len(terms) {
+				var shown interface{} = w
+				if bigW != nil {
+					shown = bigW
+				}
+				return nil, nil, nil, tokenError(InvalidSyntax, ErrBadConstraint, "", "missing variable after weight %v in %q", shown, line)
 			}
 		}
-		var lit int
-		if l[0] == '~' {
-			lit, err = strconv.Atoi(l[2:])
-			lits = append(lits, -lit)
-		} else {
-			lit, err = strconv.Atoi(l[1:])
-			lits = append(lits, lit)
+		first := i
+		var product []int // Lits of the term
+		for ; i < len(terms) && (len(product) == 0 || isPBLit(terms[i])); i++ {
+			lit, err := parsePBLit(terms[i], line)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			product = append(product, lit)
+		}
+		i--
+		lit := product[0]
+		if len(product) > 1 {
+			if lit, err = p.productLit(product, line); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		v := lit
+		if v < 0 {
+			v = -v
+		}
+		if seen[v] {
+			term := strings.Join(terms[first:i+1], " ")
+			return nil, nil, nil, tokenError(InvalidSyntax, ErrBadConstraint, term, "%q appears several times in %q", term, line)
 		}
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid variable %q in %q: %v", l, line, err)
+		seen[v] = true
+		if v > p.pb.NbVars && len(product) == 1 {
+			p.pb.NbVars = v
 		}
-		if lit > pb.NbVars {
-			pb.NbVars = lit
+		if bigW != nil && bigWeights == nil {
+			bigWeights = make([]*big.Int, len(weights), cap(weights))
+			for j, w := range weights {
+				bigWeights[j] = big.NewInt(int64(w))
+			}
+		}
+		if bigWeights != nil {
+			if bigW == nil {
+				bigW = big.NewInt(int64(w))
+			}
+			bigWeights = append(bigWeights, bigW)
 		}
-		i++
+		weights = append(weights, w)
+		lits = append(lits, lit)
+	}
+	return weights, lits, bigWeights, nil
+}
+
+// productBase is the DIMACS var of the placeholder of the first product of lits met while parsing a file.
+// Vars of OPB files must be smaller.
+const productBase = 1 << 29
+
+// productLit returns the placeholder lit standing for the product, i.e the conjunction, of the given lits,
+// appearing in the given line. The same product, whatever the order of its lits, always gets the same placeholder.
+// Once the whole file was parsed, defineProducts replaces placeholders by fresh vars.
+func (p *opbParser) productLit(lits []int, line string) (int, error) {
+	sorted := make([]int, len(lits))
+	copy(sorted, lits)
+	sort.Ints(sorted)
+	for i, lit := range sorted {
+		v := lit
+		if v < 0 {
+			v = -v
+		}
+		if i > 0 && (sorted[i-1] == lit || sorted[i-1] == -lit) || i < len(sorted)-1 && sorted[i+1] == -lit {
+			return 0, tokenError(InvalidSyntax, ErrBadConstraint, "", "var x%d appears several times in product in %q", v, line)
+		}
+		if v > p.pb.NbVars {
+			p.pb.NbVars = v
+		}
+	}
+	key := fmt.Sprint(sorted)
+	idx, ok := p.products[key]
+	if !ok {
+		if p.products == nil {
+			p.products = make(map[string]int)
+		}
+		idx = len(p.productDefs)
+		p.products[key] = idx
+		p.productDefs = append(p.productDefs, sorted)
+	}
+	return productBase + idx, nil
+}
+
+// defineProducts replaces the placeholders of the products found while parsing by fresh reserved vars,
+// allocated after all vars of the file, and adds the clauses stating each of them is the AND of its lits.
+// It must be called once the whole file was parsed.
+func (p *opbParser) defineProducts() {
+	if len(p.productDefs) == 0 {
+		return
+	}
+	for i, lit := range p.pb.Units {
+		p.pb.Units[i] = IntToLit(int32(p.productInt(int(lit.Int()))))
+	}
+	for _, c := range p.pb.Clauses {
+		for j := 0; j < c.Len(); j++ {
+			c.Set(j, IntToLit(int32(p.productInt(int(c.Get(j).Int())))))
+		}
+	}
+	for i, lit := range p.pb.minLits {
+		p.pb.minLits[i] = IntToLit(int32(p.productInt(int(lit.Int()))))
+	}
+	for i, def := range p.productDefs {
+		and := IntToLit(int32(p.pb.NbVars + i + 1))
+		clause := []Lit{and}
+		for _, val := range def {
+			lit := IntToLit(int32(val))
+			p.pb.Clauses = append(p.pb.Clauses, NewClause([]Lit{and.Negation(), lit}))
+			clause = append(clause, lit.Negation())
+		}
+		p.pb.Clauses = append(p.pb.Clauses, NewClause(clause))
+	}
+	p.pb.NbVars += len(p.productDefs)
+	p.pb.nbReserved += len(p.productDefs)
+	p.products, p.productDefs = nil, nil
+}
+
+// productInt returns the DIMACS lit val, or, if it is the placeholder of a product,
+// the lit of the var that will be allocated to it by defineProducts.
+func (p *opbParser) productInt(val int) int {
+	switch {
+	case val >= productBase:
+		return val - productBase + p.pb.NbVars + 1
+	case val <= -productBase:
+		return val + productBase - p.pb.NbVars - 1
+	default:
+		return val
+	}
+}
+
+// isPBLit returns true iff term looks like a lit, i.e starts with "x" or "~x".
+func isPBLit(term string) bool {
+	return strings.HasPrefix(term, "x") || strings.HasPrefix(term, "~x")
+}
+
+// parsePBLit parses a lit such as "x1" or "~x1", appearing in the given line, and returns its value in the DIMACS format.
+func parsePBLit(term, line string) (int, error) {
+	if !isPBLit(term) {
+		return 0, tokenError(InvalidToken, ErrBadLiteral, term, "invalid variable name %q in %q", term, line)
+	}
+	sign := 1
+	name := term[1:]
+	if term[0] == '~' {
+		sign = -1
+		name = term[2:]
+	}
+	v, err := strconv.Atoi(name)
+	if err != nil || name[0] == '+' {
+		return 0, tokenError(InvalidToken, ErrBadLiteral, term, "invalid variable %q in %q", term, line)
 	}
-	return weights, lits, nil
+	if v <= 0 || v >= productBase {
+		return 0, tokenError(OutOfRange, ErrBadLiteral, term, "invalid variable %q in %q", term, line)
+	}
+	return sign * v, nil
 }
 
 // ParseOPB parses a file corresponding to the OPB syntax.
 // See http://www.cril.univ-artois.fr/PB16/format.pdf for more details.
+// Besides the standard ">=" and "=" operators, constraints can use "<=", "<" and ">",
+// that are normalized into at-least constraints.
+// Non-linear terms, such as "+2 x1 ~x2", are products of lits: each distinct product is replaced by a fresh reserved var,
+// defined by clauses as the AND of its lits, so that it does not appear in models.
+// The file can be compressed with gzip or bzip2.
 func ParseOPB(f io.Reader) (*Problem, error) {
-	scanner := bufio.NewScanner(f)
-	var pb Problem
+	return ParseOPBOptions(f, ParseOptions{})
+}
+
+// ParseOPBOptions is like ParseOPB, but the parsing is customized by opts.
+func ParseOPBOptions(f io.Reader, opts ParseOptions) (*Problem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseOPBOptions(f, opts) })
+	}
+	r, err := decompress(f)
+	if err != nil {
+		return nil, err
+	}
+	scanner := NewScanner(r, opts.MaxLineSize)
+	var (
+		pb   Problem
+		errs ParseErrors
+	)
+	p := opbParser{pb: &pb}
+	if opts.KeepComments {
+		pb.Metadata = &Metadata{}
+	}
+	lineNb := 0
+	nbStatements := 0 // Number of non-comment lines read so far
 	for scanner.Scan() {
+		lineNb++
 		line := scanner.Text()
-		if line == "" || line[0] == '*' {
+		if line == "" {
 			continue
 		}
-		if err := pb.parsePBLine(line); err != nil {
-			return nil, err
+		if line[0] == '*' {
+			if opts.KeepComments {
+				if lineNb == 1 && strings.HasPrefix(line, "* #variable=") { // Header of PB competition files
+					pb.Metadata.Header = strings.TrimSpace(line)
+				} else {
+					pb.Metadata.addComment(line[1:], nbStatements)
+				}
+			}
+			continue
+		}
+		nbStatements++
+		if err := p.parsePBLine(line, lineNb); err != nil {
+			if perr := atLine(err, lineNb, line); !opts.skip(&errs, perr) {
+				return nil, perr
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("could not parse OPB: %v", err)
+		return nil, scanError(err, lineNb, "OPB")
+	}
+	p.defineProducts()
+	if pb.bindUnits() {
+		pb.simplifyPB()
+	}
+	if len(errs) != 0 {
+		return &pb, errs
 	}
-	pb.Model = make([]decLevel, pb.NbVars)
-	pb.simplifyPB()
 	return &pb, nil
 }
+
+// appendUnit appends lit, implied by the input constraint src, to pb's units.
+func (pb *Problem) appendUnit(lit Lit, src int) {
+	pb.Units = append(pb.Units, lit)
+	pb.unitSources = append(pb.unitSources, src)
+}
+
+// bindUnits creates pb's model and binds the units found while parsing, removing duplicate units.
+// If two units are contradictory, pb's status is set to Unsat, the conflicting constraints are recorded
+// in pb.Conflict, and false is returned.
+func (pb *Problem) bindUnits() bool {
+	defer func() { pb.unitSources = nil }()
+	pb.Model = make([]decLevel, pb.NbVars)
+	j := 0
+	for i, unit := range pb.Units {
+		v := unit.Var()
+		if pb.Model[v] == 0 {
+			if unit.IsPositive() {
+				pb.Model[v] = 1
+			} else {
+				pb.Model[v] = -1
+			}
+			pb.Units[j] = unit
+			pb.unitSources[j] = pb.unitSources[i]
+			j++
+		} else if pb.Model[v] > 0 != unit.IsPositive() {
+			pb.Status = Unsat
+			for k, prev := range pb.Units[:j] {
+				if prev.Var() == v {
+					pb.Conflict = &UnitConflict{Lit: prev, First: pb.unitSources[k], Second: pb.unitSources[i]}
+					break
+				}
+			}
+			return false
+		}
+	}
+	pb.Units = pb.Units[:j]
+	return true
+}
@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"bufio"
+	"io"
+	"runtime/debug"
+)
+
+// This file deals with the parsing of streams made of several concatenated CNF problems.
+// Each problem starts with its own "p cnf" header, that marks the end of the previous problem, if any.
+// Such streams are typically produced by a program generating many problems, and piped to the solver,
+// that solves them in order without having to start a new process for each of them.
+
+// ParseCNFStream parses a stream of concatenated CNF problems, and calls fn with each of them,
+// in the order they appear in the stream, as soon as they were parsed. i is the index of the problem in the stream.
+// The parsing is customized by opts, that apply to each problem. Line numbers of errors are relative to the stream.
+// Comments found between two problems belong to the first one.
+// Parsing stops as soon as fn returns a non-nil error, which is then returned.
+// In recovery mode, problems are passed to fn even if some of their lines were ignored,
+// and the recovered errors are returned once the whole stream was read.
+// If opts.RecoverPanics is true, panics of fn are recovered too.
+func ParseCNFStream(f io.Reader, opts ParseOptions, fn func(i int, pb *Problem) error) error {
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		_, err := protect(func() (*Problem, error) { return nil, ParseCNFStream(f, opts, fn) })
+		return err
+	}
+	ps := NewProblemScanner(f, opts)
+	for i := 0; ps.Scan(); i++ {
+		if err := fn(i, ps.Problem()); err != nil {
+			return err
+		}
+	}
+	return ps.Err()
+}
+
+// A ProblemScanner reads successive CNF problems from a stream of concatenated problems,
+// such as a regression suite packaged as a single file. As with a bufio.Scanner, Scan is called
+// until it returns false, each problem being available through Problem; Err then tells whether
+// the whole stream could be read. Problems are parsed as with ParseCNFStream.
+type ProblemScanner struct {
+	opts   ParseOptions
+	sc     *bufio.Scanner
+	p      *cnfParser  // Parser of the problem being read
+	header []byte      // Header of the next problem, if it was read while looking for the end of the previous one
+	pb     *Problem    // Last problem that was read
+	errs   ParseErrors // Errors that were recovered from
+	err    error       // Error that stopped the scanning, if any
+}
+
+// NewProblemScanner returns a scanner reading problems from r, parsed according to opts.
+// If opts are invalid or if r cannot be decompressed, the first call to Scan returns false, and Err returns the error.
+func NewProblemScanner(r io.Reader, opts ParseOptions) *ProblemScanner {
+	ps := &ProblemScanner{opts: opts, p: newCNFParser(opts)}
+	if err := opts.Validate(); err != nil {
+		ps.err = err
+		return ps
+	}
+	dr, err := decompress(r)
+	if err != nil {
+		ps.err = err
+		return ps
+	}
+	ps.sc = NewScanner(dr, opts.MaxLineSize)
+	return ps
+}
+
+// Scan reads the next problem, which is then available through Problem.
+// It returns false once the stream was read entirely, or when an error stopped the scanning.
+// In recovery mode, problems are returned even if some of their lines were ignored.
+// If opts.RecoverPanics is true, internal panics of the parser stop the scanning, and Err returns them.
+func (ps *ProblemScanner) Scan() (ok bool) {
+	if ps.err != nil || ps.sc == nil {
+		return false
+	}
+	ps.pb = nil
+	if ps.opts.RecoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				ps.err = &PanicError{Value: r, Stack: debug.Stack()}
+				ps.pb, ok = nil, false
+			}
+		}()
+	}
+	p := ps.p
+	for {
+		var line []byte
+		if ps.header != nil {
+			line, ps.header = ps.header, nil
+		} else if ps.sc.Scan() {
+			line = ps.sc.Bytes()
+		} else {
+			break
+		}
+		if isHeaderLine(line) && p.started() {
+			ps.header = append([]byte(nil), line...)
+			return ps.next()
+		}
+		p.lineNb++
+		if err := p.parseLine(line); err != nil {
+			if !ps.opts.skip(&ps.errs, err) {
+				ps.err = err
+				return false
+			}
+			p.discard()
+		}
+	}
+	if err := ps.sc.Err(); err != nil {
+		ps.err = scanError(err, p.lineNb, "CNF")
+		return false
+	}
+	if p.started() {
+		return ps.next()
+	}
+	if len(ps.errs) != 0 {
+		ps.err = ps.errs
+	}
+	ps.sc = nil
+	return false
+}
+
+// next ends the problem being read, and prepares the parser of the next one.
+// It returns false if the problem cannot be built.
+func (ps *ProblemScanner) next() bool {
+	pb, err := ps.p.problem(&ps.errs)
+	if err != nil {
+		ps.err = err
+		return false
+	}
+	ps.pb = pb
+	lineNb := ps.p.lineNb
+	ps.p = newCNFParser(ps.opts)
+	ps.p.lineNb = lineNb
+	return true
+}
+
+// Problem returns the problem read by the last call to Scan, or nil if it returned false.
+func (ps *ProblemScanner) Problem() *Problem {
+	return ps.pb
+}
+
+// Err returns the error that stopped the scanning, if any.
+// In recovery mode, once the stream was read entirely, it returns the errors that were recovered from, if any.
+func (ps *ProblemScanner) Err() error {
+	return ps.err
+}
+
+// isHeaderLine returns true iff line is the header of a DIMACS problem.
+func isHeaderLine(line []byte) bool {
+	i := skipSpaces(line, 0)
+	return i < len(line) && line[i] == 'p'
+}
@@ -0,0 +1,90 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseCNFStream(t *testing.T) {
+	const stream = "c first problem\np cnf 2 2\n1 2 0\n-1 0\np cnf 1 2\n1 0\n-1 0\n\np cnf 3 1\n1 -2 3 0\n"
+	var statuses []Status
+	err := ParseCNFStream(strings.NewReader(stream), ParseOptions{}, func(i int, pb *Problem) error {
+		if i != len(statuses) {
+			t.Errorf("expected problem #%d, got #%d", len(statuses), i)
+		}
+		statuses = append(statuses, New(pb).Solve())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not parse stream: %v", err)
+	}
+	expected := []Status{Sat, Unsat, Sat}
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected %d problems, got %d", len(expected), len(statuses))
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Errorf("problem #%d: expected %v, got %v", i, expected[i], statuses[i])
+		}
+	}
+}
+
+func TestParseCNFStreamErrors(t *testing.T) {
+	const stream = "p cnf 2 1\n1 2 0\np cnf 2 1\n1 x 0\np cnf 2 1\n-1 0\n"
+	nb := 0
+	err := ParseCNFStream(strings.NewReader(stream), ParseOptions{}, func(i int, pb *Problem) error {
+		nb++
+		return nil
+	})
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 4 || nb != 1 {
+		t.Errorf("expected an error on line 4 after 1 problem, got %v after %d problems", err, nb)
+	}
+	nb = 0
+	err = ParseCNFStream(strings.NewReader(stream), ParseOptions{Recover: true}, func(i int, pb *Problem) error {
+		nb++
+		return nil
+	})
+	if errs, ok := err.(ParseErrors); !ok || len(errs) != 1 || errs[0].Line != 4 || nb != 3 {
+		t.Errorf("expected 1 recovered error after 3 problems, got %v after %d problems", err, nb)
+	}
+	stop := fmt.Errorf("stop")
+	nb = 0
+	err = ParseCNFStream(strings.NewReader(stream), ParseOptions{}, func(i int, pb *Problem) error {
+		nb++
+		return stop
+	})
+	if err != stop || nb != 1 {
+		t.Errorf("expected parsing to stop after the first problem, got %v after %d problems", err, nb)
+	}
+}
+
+func TestProblemScanner(t *testing.T) {
+	const stream = "c first problem\np cnf 2 2\n1 2 0\n-1 0\np cnf 1 2\n1 0\n-1 0\n\np cnf 3 1\n1 -2 3 0\n"
+	ps := NewProblemScanner(strings.NewReader(stream), ParseOptions{})
+	var statuses []Status
+	for ps.Scan() {
+		statuses = append(statuses, New(ps.Problem()).Solve())
+	}
+	if err := ps.Err(); err != nil {
+		t.Fatalf("could not scan stream: %v", err)
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint([]Status{Sat, Unsat, Sat}) {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if ps.Scan() || ps.Problem() != nil {
+		t.Errorf("scanning should have ended")
+	}
+	ps = NewProblemScanner(strings.NewReader("p cnf 2 1\n1 2 0\np cnf 2 1\n1 x 0\n"), ParseOptions{})
+	nb := 0
+	for ps.Scan() {
+		nb++
+	}
+	if perr, ok := ps.Err().(*ParseError); !ok || perr.Line != 4 || nb != 1 {
+		t.Errorf("expected an error on line 4 after 1 problem, got %v after %d problems", ps.Err(), nb)
+	}
+	ps = NewProblemScanner(strings.NewReader(stream), ParseOptions{ClauseCount: 42})
+	if ps.Scan() || ps.Err() == nil {
+		t.Errorf("expected an error for invalid options")
+	}
+}
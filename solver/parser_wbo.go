@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file deals with the parsing of weighted boolean optimization problems in the WBO format.
+// It is the OPB format, with soft constraints: each soft constraint starts with its violation cost, between brackets,
+// as in "[3] +1 x1 +2 x2 >= 2 ;". The problem is to minimize the sum of the costs of violated soft constraints.
+// A "soft: top ;" line can declare a top cost: models whose cost is top or more are not solutions.
+// Each soft constraint is relaxed with a fresh, reserved var r, as in "+1 x1 +2 x2 +2 r >= 2 ;",
+// and the cost function is the weighted sum of relax vars.
+
+// ParseWBO parses a file corresponding to the WBO syntax and returns the corresponding optimization problem.
+// See http://www.cril.univ-artois.fr/PB12/format.pdf for more details.
+// Relax vars are reserved, so they do not appear in the models of the problem.
+func ParseWBO(f io.Reader) (*Problem, error) {
+	return ParseWBOOptions(f, ParseOptions{})
+}
+
+// ParseWBOOptions is like ParseWBO, but the parsing is customized by opts.
+// Only MaxLineSize and RecoverPanics apply to WBO files.
+func ParseWBOOptions(f io.Reader, opts ParseOptions) (*Problem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseWBOOptions(f, opts) })
+	}
+	scanner := NewScanner(f, opts.MaxLineSize)
+	var (
+		pb      Problem
+		softs   [][]PBConstr // Normalized soft constraints, before relaxation
+		costs   []int        // Violation cost of each soft constraint
+		sumCost int          // Sum of costs, bounded by maxWeightSum since it is the sum of the weights of cost constraints
+		lines   []int        // Line number of each soft constraint
+		top     = -1         // Top cost, or -1 if there is none
+		topLine int          // Line number of the top cost
+		lineNb  int
+	)
+	p := opbParser{pb: &pb}
+	for scanner.Scan() {
+		lineNb++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '*' {
+			continue
+		}
+		if line[len(line)-1] != ';' {
+			return nil, newParseError(lineNb, ErrBadConstraint, "line %q does not end with semicolon", line)
+		}
+		fields := strings.Fields(line[:len(line)-1])
+		if len(fields) == 0 {
+			return nil, newParseError(lineNb, ErrBadConstraint, "empty constraint in file")
+		}
+		switch {
+		case fields[0] == "soft:":
+			if topLine != 0 {
+				return nil, newParseError(lineNb, ErrBadHeader, "duplicate soft line %q", line)
+			}
+			topLine = lineNb
+			if len(fields) > 2 {
+				return nil, newParseError(lineNb, ErrBadHeader, "invalid syntax %q", line)
+			}
+			if len(fields) == 2 {
+				var err error
+				if top, err = strconv.Atoi(fields[1]); err != nil || top <= 0 {
+					return nil, atLine(tokenError(InvalidToken, ErrBadWeight, fields[1], "invalid top cost %q in %q", fields[1], line), lineNb, scanner.Text())
+				}
+			}
+		case fields[0] == "min:":
+			return nil, newParseError(lineNb, ErrBadConstraint, "objective function %q not allowed in WBO file", line)
+		case line[0] == '[':
+			end := strings.IndexByte(line, ']')
+			if end == -1 {
+				return nil, newParseError(lineNb, ErrBadWeight, "unterminated cost in %q", line)
+			}
+			cost, err := strconv.Atoi(strings.TrimSpace(line[1:end]))
+			if err != nil || cost <= 0 {
+				return nil, atLine(tokenError(InvalidToken, ErrBadWeight, line[1:end], "invalid cost %q in %q", line[1:end], line), lineNb, scanner.Text())
+			}
+			if cost > maxWeightSum-sumCost {
+				return nil, atLine(tokenError(OutOfRange, ErrBadWeight, line[1:end], "sum of costs is too big in %q", line), lineNb, scanner.Text())
+			}
+			sumCost += cost
+			constrs, err := p.parsePBConstrs(strings.Fields(line[end+1:len(line)-1]), line)
+			if err != nil {
+				return nil, atLine(err, lineNb, scanner.Text())
+			}
+			softs = append(softs, constrs)
+			costs = append(costs, cost)
+			lines = append(lines, lineNb)
+		default:
+			if err := p.parsePBConstrLine(fields, line, lineNb); err != nil {
+				return nil, atLine(err, lineNb, scanner.Text())
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, scanError(err, lineNb, "WBO")
+	}
+	for _, constrs := range softs {
+		for _, constr := range constrs {
+			for j, val := range constr.Lits {
+				constr.Lits[j] = p.productInt(val)
+			}
+		}
+	}
+	p.defineProducts()
+	pb.relaxSoft(softs, costs, lines, top, topLine)
+	if pb.bindUnits() {
+		pb.simplifyPB()
+	}
+	return &pb, nil
+}
+
+// relaxSoft adds the given soft constraints to pb, each relaxed by a new reserved var,
+// and sets the cost function as the weighted sum of relax vars.
+// lines are the line numbers of the soft constraints.
+// If top is not -1, the cost of models must be strictly less than top, that was declared on line topLine.
+func (pb *Problem) relaxSoft(softs [][]PBConstr, costs, lines []int, top, topLine int) {
+	nbUserVars := pb.NbVars
+	relaxLits := make([]Lit, len(softs))
+	relaxInts := make([]int, len(softs))
+	for i, constrs := range softs {
+		relax := nbUserVars + i + 1
+		relaxInts[i] = relax
+		relaxLits[i] = IntToLit(int32(relax))
+		for _, constr := range constrs {
+			if constr.BigAtLeast == nil && constr.AtLeast <= 0 { // Trivially satisfied: it does not need any relaxation
+				continue
+			}
+			constr.Lits = append(constr.Lits, relax)
+			if constr.BigAtLeast != nil { // The relax lit satisfies the constraint on its own
+				constr.BigWeights = append(constr.BigWeights, constr.BigAtLeast)
+			} else {
+				constr.Weights = append(constr.Weights, constr.AtLeast)
+			}
+			pb.appendPBConstr(constr, lines[i])
+		}
+	}
+	pb.NbVars += len(softs)
+	pb.nbReserved += len(softs)
+	if top != -1 {
+		weights := make([]int, len(costs))
+		copy(weights, costs)
+		pb.appendPBConstr(LtEq(relaxInts, weights, top-1), topLine)
+	}
+	pb.SetCostFunc(relaxLits, costs)
+}
@@ -0,0 +1,72 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+const wboExample = `* #variable= 3 #constraint= 4 #soft= 3 mincost= 2 maxcost= 5 sumcost= 10
+soft: 10 ;
+[2] +1 x1 >= 1 ;
+[3] +1 x2 +1 x3 >= 2 ;
+[5] +1 ~x1 = 1 ;
++1 x1 +1 x2 >= 1 ;
+-1 x2 -1 x3 >= -1 ;
+`
+
+func TestParseWBO(t *testing.T) {
+	pb, err := ParseWBO(strings.NewReader(wboExample))
+	if err != nil {
+		t.Fatalf("could not parse WBO problem: %v", err)
+	}
+	if pb.NbVars != 6 || !pb.Reserved(Var(3)) || pb.Reserved(Var(2)) {
+		t.Errorf("expected 3 vars and 3 reserved relax vars, got %d vars", pb.NbVars)
+	}
+	// x2 and x3 cannot both be true, so the second soft constraint is always violated.
+	// Then, either x1 is true (cost 5) or x1 is false and x2 is true (cost 2).
+	res := New(pb).Optimal(nil, nil)
+	if res.Status != Sat || res.Weight != 5 || res.Optim != Optimum {
+		t.Fatalf("expected optimal cost 5, got %+v", res)
+	}
+	if len(res.Model) != 3 || res.Model[0] || !res.Model[1] {
+		t.Errorf("expected model with -x1 and x2, got %v", res.Model)
+	}
+}
+
+func TestParseWBOTop(t *testing.T) {
+	// Violating the only soft constraint costs 4, which is the top cost: the problem is unsat
+	pb, err := ParseWBO(strings.NewReader("soft: 4 ;\n[4] +1 x1 >= 1 ;\n+1 ~x1 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse WBO problem: %v", err)
+	}
+	if res := New(pb).Optimal(nil, nil); res.Status != Unsat {
+		t.Errorf("expected unsat problem because of top cost, got %+v", res)
+	}
+}
+
+func TestParseWBOConflict(t *testing.T) {
+	pb, err := ParseWBO(strings.NewReader("* comment\nsoft: 4 ;\n+1 x1 >= 1 ;\n[2] +1 x2 >= 1 ;\n+1 ~x1 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse WBO problem: %v", err)
+	}
+	if expected := (UnitConflict{Lit: IntToLit(1), First: 3, Second: 5}); pb.Conflict == nil || *pb.Conflict != expected {
+		t.Errorf("invalid conflict: expected %+v, got %+v", expected, pb.Conflict)
+	}
+}
+
+func TestParseWBOErrors(t *testing.T) {
+	for _, wbo := range []string{
+		"soft: 4 ;\nsoft: 4 ;\n",
+		"soft: x ;\n",
+		"min: +1 x1 ;\n",
+		"[2 +1 x1 >= 1 ;\n",
+		"[-2] +1 x1 >= 1 ;\n",
+		"[2] +1 x1 >= 1\n",
+		"[2] +1 x1 ;\n",
+		";\n",
+	} {
+		if _, err := ParseWBO(strings.NewReader(wbo)); err == nil {
+			t.Errorf("expected an error when parsing %q", wbo)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package solver
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file deals with the parsing of weighted MAXSAT problems in the DIMACS WCNF format.
+// Each line is a clause, preceded by its weight. Clauses whose weight is at least the top weight
+// declared in the header are hard clauses, that must be satisfied. Other clauses are soft:
+// the problem is to minimize the sum of the weights of unsatisfied soft clauses.
+// Since 2022, the MAXSAT Evaluation uses a headerless variant of the format, where hard clauses
+// start with "h" instead of a weight, and the number of vars is the biggest var appearing in clauses.
+// Both variants are supported, the variant of a file being detected from its first non-comment line.
+// Each soft clause c is relaxed with a fresh, reserved var r, turning it into the hard clause c ∨ r,
+// and the cost function is the weighted sum of relax vars.
+
+// wcnfParser holds the data used while parsing a WCNF file.
+type wcnfParser struct {
+	nbVars     int     // Number of vars declared in the header, or biggest var met so far if there is no header
+	topWeight  int     // Weight of hard clauses. If 0, and there is a header, all clauses are soft.
+	headerless bool    // True iff this is the headerless variant of the format
+	hard       [][]int // Hard clauses, including relaxed soft clauses
+	soft       [][]int // Soft clauses that must be relaxed
+	weights    []int   // Weight of each soft clause
+}
+
+// ParseWCNF parses a DIMACS WCNF file, describing a [partial] [weighted] MAXSAT problem,
+// and returns the corresponding optimization problem.
+// Both the classical format, with a "p wcnf" header, and the headerless format used since 2022 are accepted.
+// Relax vars are reserved, so they do not appear in the models of the problem.
+func ParseWCNF(f io.Reader) (*Problem, error) {
+	return ParseWCNFOptions(f, ParseOptions{})
+}
+
+// ParseWCNFOptions is like ParseWCNF, but the parsing is customized by opts.
+// Only MaxLineSize and RecoverPanics apply to WCNF files.
+func ParseWCNFOptions(f io.Reader, opts ParseOptions) (*Problem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RecoverPanics {
+		opts.RecoverPanics = false
+		return protect(func() (*Problem, error) { return ParseWCNFOptions(f, opts) })
+	}
+	var p wcnfParser
+	sc := NewScanner(f, opts.MaxLineSize)
+	headerFound := false
+	lineNb := 0
+	for sc.Scan() {
+		lineNb++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || fields[0][0] == 'c' {
+			continue
+		}
+		if fields[0] == "p" {
+			if headerFound || p.headerless {
+				return nil, newParseError(lineNb, ErrBadHeader, "unexpected WCNF header %q", sc.Text())
+			}
+			if err := p.parseHeader(fields); err != nil {
+				return nil, atLine(err, lineNb, sc.Text())
+			}
+			headerFound = true
+			continue
+		}
+		if !headerFound { // Clauses without a header: this is the headerless format
+			p.headerless = true
+		}
+		if err := p.parseClause(fields); err != nil {
+			return nil, atLine(err, lineNb, sc.Text())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, scanError(err, lineNb, "WCNF")
+	}
+	return p.problem(), nil
+}
+
+// parseHeader parses the fields of a "p wcnf nbVars nbClauses [topWeight]" line.
+func (p *wcnfParser) parseHeader(fields []string) error {
+	if len(fields) < 4 || len(fields) > 5 || fields[1] != "wcnf" {
+		return tokenError(InvalidHeader, ErrBadHeader, "", "invalid syntax %q in WCNF header", strings.Join(fields, " "))
+	}
+	var err error
+	if p.nbVars, err = strconv.Atoi(fields[2]); err != nil || p.nbVars < 0 || p.nbVars > maxVar {
+		return tokenError(InvalidHeader, ErrBadHeader, fields[2], "nbVars not a positive int: %q", fields[2])
+	}
+	nbClauses, err := strconv.Atoi(fields[3])
+	if err != nil || nbClauses < 0 {
+		return tokenError(InvalidHeader, ErrBadHeader, fields[3], "nbClauses not a positive int: %q", fields[3])
+	}
+	p.hard = make([][]int, 0, preallocSize(nbClauses))
+	if len(fields) == 5 {
+		if p.topWeight, err = strconv.Atoi(fields[4]); err != nil || p.topWeight <= 0 {
+			return tokenError(InvalidHeader, ErrBadHeader, fields[4], "top weight not a strictly positive int: %q", fields[4])
+		}
+	}
+	return nil
+}
+
+// parseClause parses the fields of a line containing a weight, or "h" in the headerless format,
+// and a clause, terminated by 0.
+func (p *wcnfParser) parseClause(fields []string) error {
+	line := strings.Join(fields, " ")
+	if len(fields) < 2 || fields[len(fields)-1] != "0" {
+		return tokenError(InvalidSyntax, ErrBadConstraint, "", "WCNF clause %q is not terminated by 0", line)
+	}
+	lits, err := p.parseLits(fields[1:len(fields)-1], line)
+	if err != nil {
+		return err
+	}
+	if p.headerless && fields[0] == "h" {
+		p.hard = append(p.hard, lits)
+		return nil
+	}
+	weight, err := strconv.Atoi(fields[0])
+	if err != nil || weight < 0 {
+		return tokenError(InvalidToken, ErrBadWeight, fields[0], "invalid weight %q in WCNF clause %q", fields[0], line)
+	}
+	if p.topWeight != 0 && weight >= p.topWeight {
+		p.hard = append(p.hard, lits)
+	} else if weight > 0 { // Soft clauses with a null weight are useless
+		p.soft = append(p.soft, lits)
+		p.weights = append(p.weights, weight)
+	}
+	return nil
+}
+
+// parseLits parses the lits of a clause, that appeared in the given line.
+func (p *wcnfParser) parseLits(fields []string, line string) ([]int, error) {
+	lits := make([]int, len(fields), len(fields)+1) // Make room for a relax lit
+	for i, field := range fields {
+		val, err := strconv.Atoi(field)
+		if err != nil || val == 0 || val > maxVar || val < -maxVar {
+			return nil, tokenError(InvalidToken, ErrBadLiteral, field, "invalid literal %q in WCNF clause %q", field, line)
+		}
+		if p.headerless {
+			if val > p.nbVars {
+				p.nbVars = val
+			} else if -val > p.nbVars {
+				p.nbVars = -val
+			}
+		} else if val > p.nbVars || -val > p.nbVars {
+			return nil, tokenError(OutOfRange, ErrBadLiteral, field, "invalid literal %d for problem with %d vars only", val, p.nbVars)
+		}
+		lits[i] = val
+	}
+	return lits, nil
+}
+
+// problem returns the optimization problem made of the parsed clauses.
+func (p *wcnfParser) problem() *Problem {
+	nbRelax := len(p.soft)
+	clauses := p.hard
+	relaxLits := make([]Lit, nbRelax)
+	for i, soft := range p.soft {
+		relax := p.nbVars + i + 1
+		clauses = append(clauses, append(soft, relax))
+		relaxLits[i] = IntToLit(int32(relax))
+	}
+	pb := ParseSliceNb(clauses, p.nbVars+nbRelax)
+	pb.nbReserved = nbRelax
+	pb.SetCostFunc(relaxLits, p.weights)
+	return pb
+}
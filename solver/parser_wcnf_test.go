@@ -0,0 +1,92 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+const wcnfExample = `c A small partial weighted MAXSAT problem
+p wcnf 3 6 10
+10 1 2 0
+10 -1 -2 0
+3 1 0
+4 2 0
+2 -3 0
+5 3 -2 0
+`
+
+func TestParseWCNF(t *testing.T) {
+	pb, err := ParseWCNF(strings.NewReader(wcnfExample))
+	if err != nil {
+		t.Fatalf("could not parse WCNF problem: %v", err)
+	}
+	if pb.NbVars != 7 {
+		t.Errorf("expected 3 vars and 4 relax vars, got %d vars", pb.NbVars)
+	}
+	if !pb.Reserved(Var(3)) || pb.Reserved(Var(2)) {
+		t.Errorf("relax vars should be reserved, and only them")
+	}
+	s := New(pb)
+	res := s.Optimal(nil, nil)
+	if res.Status != Sat || res.Weight != 4 {
+		t.Fatalf("expected optimal cost 4, got status %v and cost %d", res.Status, res.Weight)
+	}
+	if len(res.Model) != 3 || !res.Model[0] || res.Model[1] || res.Model[2] {
+		t.Errorf("expected model [true false false], got %v", res.Model)
+	}
+}
+
+func TestParseWCNFNoTop(t *testing.T) {
+	pb, err := ParseWCNF(strings.NewReader("p wcnf 2 3\n1 1 0\n2 -1 0\n0 2 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse WCNF problem: %v", err)
+	}
+	if res := New(pb).Optimal(nil, nil); res.Status != Sat || res.Weight != 1 {
+		t.Errorf("expected optimal cost 1, got status %v and cost %d", res.Status, res.Weight)
+	}
+}
+
+func TestParseWCNFErrors(t *testing.T) {
+	for _, wcnf := range []string{
+		"1 1 0\np wcnf 2 1 10\n",
+		"h 1 x 0\n",
+		"h 1 2\n",
+		"p wcnf 2 1 10\nh 1 2 0\n",
+		"p wcnf 2\n",
+		"p cnf 2 1\n1 1 0\n",
+		"p wcnf 2 1 10\n10 1 2\n",
+		"p wcnf 2 1 10\n10 1 3 0\n",
+		"p wcnf 2 1 10\nx 1 2 0\n",
+		"p wcnf 2 1 10\n-1 1 2 0\n",
+		"p wcnf 2 1 10\np wcnf 2 1 10\n",
+	} {
+		if _, err := ParseWCNF(strings.NewReader(wcnf)); err == nil {
+			t.Errorf("expected an error when parsing %q", wcnf)
+		}
+	}
+}
+
+func TestParseWCNFHeaderless(t *testing.T) {
+	const wcnf = `c The same problem as wcnfExample, in the headerless format
+h 1 2 0
+h -1 -2 0
+3 1 0
+4 2 0
+2 -3 0
+5 3 -2 0
+`
+	pb, err := ParseWCNF(strings.NewReader(wcnf))
+	if err != nil {
+		t.Fatalf("could not parse headerless WCNF problem: %v", err)
+	}
+	if pb.NbVars != 7 {
+		t.Errorf("expected 3 vars and 4 relax vars, got %d vars", pb.NbVars)
+	}
+	res := New(pb).Optimal(nil, nil)
+	if res.Status != Sat || res.Weight != 4 {
+		t.Fatalf("expected optimal cost 4, got status %v and cost %d", res.Status, res.Weight)
+	}
+	if len(res.Model) != 3 {
+		t.Errorf("expected 3 vars in model, got %v", res.Model)
+	}
+}
@@ -0,0 +1,225 @@
+package solver
+
+import "sort"
+
+// This file deals with the decomposition of a problem into loosely coupled parts.
+// The interaction graph is split by recursive bisection: each bisection is first obtained by
+// a breadth-first traversal starting from a peripheral variable, then refined by greedily
+// moving boundary variables when it reduces the number of cut edges.
+
+const (
+	nbRefinePasses  = 4   // How many refinement passes for each bisection.
+	maxImbalance    = 0.1 // Maximal relative difference between the size of a part and its target size.
+	unassignedPart  = -1  // Part of a var that is not assigned to any part yet.
+	excludedFromSet = -2  // Part of a var that is not part of the currently bisected set.
+)
+
+// A Partition is a split of the variables of a problem into several parts.
+// Cut contains all variables that share at least one clause with a variable from another part.
+// Branching on cut variables first (see Solver.BranchFirst) makes the remaining parts independent from each other.
+type Partition struct {
+	Parts [][]Var // The variables of each part, sorted
+	Cut   []Var   // The variables on the border of their part, sorted
+}
+
+type varSorter []Var
+
+func (vs varSorter) Len() int           { return len(vs) }
+func (vs varSorter) Less(i, j int) bool { return vs[i] < vs[j] }
+func (vs varSorter) Swap(i, j int)      { vs[i], vs[j] = vs[j], vs[i] }
+
+// Partition splits the variables of pb into nbParts parts of roughly equal sizes,
+// trying to minimize the number of clauses shared by several parts.
+// Variables that do not appear in any clause are not part of the partition.
+func (pb *Problem) Partition(nbParts int) Partition {
+	if nbParts < 1 {
+		nbParts = 1
+	}
+	neighbors := pb.neighbors()
+	vars := make([]Var, 0, pb.NbVars)
+	for v := range neighbors {
+		if len(neighbors[v]) > 0 {
+			vars = append(vars, Var(v))
+		}
+	}
+	part := make([]int, pb.NbVars)
+	for i := range part {
+		part[i] = excludedFromSet
+	}
+	for _, v := range vars {
+		part[v] = 0
+	}
+	bisect(neighbors, part, vars, 0, nbParts)
+	res := Partition{Parts: make([][]Var, nbParts)}
+	for _, v := range vars {
+		res.Parts[part[v]] = append(res.Parts[part[v]], v)
+		for v2 := range neighbors[v] {
+			if part[v2] != part[v] {
+				res.Cut = append(res.Cut, v)
+				break
+			}
+		}
+	}
+	sort.Sort(varSorter(res.Cut))
+	return res
+}
+
+// bisect recursively splits vars into nbParts parts, numbered from firstPart, and updates part accordingly.
+func bisect(neighbors []map[Var]int, part []int, vars []Var, firstPart, nbParts int) {
+	if nbParts == 1 || len(vars) == 0 {
+		for _, v := range vars {
+			part[v] = firstPart
+		}
+		return
+	}
+	nbLeft := nbParts / 2
+	target := len(vars) * nbLeft / nbParts
+	for _, v := range vars {
+		part[v] = unassignedPart
+	}
+	left := growPart(neighbors, part, vars, target)
+	for _, v := range vars {
+		if part[v] == unassignedPart {
+			part[v] = 1
+		}
+	}
+	refineBisection(neighbors, part, vars, left, target)
+	var leftVars, rightVars []Var
+	for _, v := range vars {
+		if part[v] == 0 {
+			leftVars = append(leftVars, v)
+		} else {
+			rightVars = append(rightVars, v)
+		}
+		part[v] = excludedFromSet
+	}
+	bisect(neighbors, part, leftVars, firstPart, nbLeft)
+	bisect(neighbors, part, rightVars, firstPart+nbLeft, nbParts-nbLeft)
+	for _, v := range vars {
+		if part[v] == excludedFromSet {
+			part[v] = firstPart
+		}
+	}
+}
+
+// growPart assigns target vars to part 0 through breadth-first traversals, and returns the number of assigned vars.
+// Each traversal starts from a peripheral var, i.e a var far from the other ones.
+func growPart(neighbors []map[Var]int, part []int, vars []Var, target int) int {
+	size := 0
+	for _, start := range vars {
+		if size >= target {
+			break
+		}
+		if part[start] != unassignedPart {
+			continue
+		}
+		queue := []Var{peripheral(neighbors, part, start)}
+		part[queue[0]] = 0
+		size++
+		for len(queue) > 0 && size < target {
+			v := queue[0]
+			queue = queue[1:]
+			for _, v2 := range sortedNeighbors(neighbors, v) {
+				if size < target && part[v2] == unassignedPart {
+					part[v2] = 0
+					size++
+					queue = append(queue, v2)
+				}
+			}
+		}
+	}
+	return size
+}
+
+// sortedNeighbors returns the neighbors of v, sorted, so that results are deterministic.
+func sortedNeighbors(neighbors []map[Var]int, v Var) []Var {
+	res := make([]Var, 0, len(neighbors[v]))
+	for v2 := range neighbors[v] {
+		res = append(res, v2)
+	}
+	sort.Sort(varSorter(res))
+	return res
+}
+
+// peripheral returns the unassigned var that is the farthest from start, in the same connected component.
+func peripheral(neighbors []map[Var]int, part []int, start Var) Var {
+	dist := map[Var]int{start: 0}
+	queue := []Var{start}
+	last := start
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		last = v
+		for _, v2 := range sortedNeighbors(neighbors, v) {
+			if _, ok := dist[v2]; !ok && part[v2] == unassignedPart {
+				dist[v2] = dist[v] + 1
+				queue = append(queue, v2)
+			}
+		}
+	}
+	return last
+}
+
+// refineBisection greedily moves vars from one side of the bisection to the other one
+// as long as it reduces the weight of the cut and keeps the bisection balanced.
+func refineBisection(neighbors []map[Var]int, part []int, vars []Var, leftSize, target int) {
+	tolerance := int(maxImbalance*float64(len(vars))) + 1
+	for pass := 0; pass < nbRefinePasses; pass++ {
+		moved := false
+		for _, v := range vars {
+			internal, external := 0, 0
+			for v2, w := range neighbors[v] {
+				if part[v2] == part[v] {
+					internal += w
+				} else if part[v2] == 1-part[v] {
+					external += w
+				}
+			}
+			if external <= internal {
+				continue
+			}
+			newLeftSize := leftSize + 1
+			if part[v] == 0 {
+				newLeftSize = leftSize - 1
+			}
+			if newLeftSize < target-tolerance || newLeftSize > target+tolerance {
+				continue
+			}
+			part[v] = 1 - part[v]
+			leftSize = newLeftSize
+			moved = true
+		}
+		if !moved {
+			return
+		}
+	}
+}
+
+// BranchFirst indicates the solver must branch on the given vars before branching on any other var.
+// Among those vars, as well as among the other ones, the usual activity-based heuristic still applies.
+// This is typically used with the cut of a partition of the problem:
+// once all cut vars are bound, the remaining parts of the problem are independent from each other,
+// and conflicts in one part do not interfere with the search in the other parts.
+//
+//	s := New(pb)
+//	s.BranchFirst(pb.Partition(4).Cut)
+//	status := s.Solve()
+//
+// Calling BranchFirst again replaces the previous set of vars. Calling it with an empty set restores the default behavior.
+// Like assumptions, vars can be vars that do not appear in the problem yet: the solver then grows to include them.
+func (s *Solver) BranchFirst(vars []Var) {
+	if len(vars) == 0 {
+		s.priority = nil
+		s.prioQueue = queue{}
+	} else {
+		s.priority = nil
+		for _, v := range vars {
+			s.newVar(v)
+		}
+		s.priority = make([]bool, s.nbVars)
+		for _, v := range vars {
+			s.priority[v] = true
+		}
+	}
+	s.initQueues()
+}
@@ -0,0 +1,74 @@
+package solver
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// twoChains returns a problem made of two implication chains of size n each, linked by a single clause.
+func twoChains(n int) *Problem {
+	var clauses [][]int
+	for i := 1; i < n; i++ {
+		clauses = append(clauses, []int{-i, i + 1}, []int{-(n + i), n + i + 1})
+	}
+	clauses = append(clauses, []int{n, n + 1})
+	return ParseSlice(clauses)
+}
+
+func TestPartition(t *testing.T) {
+	pb := twoChains(5)
+	part := pb.Partition(2)
+	expectedParts := [][]Var{{0, 1, 2, 3, 4}, {5, 6, 7, 8, 9}}
+	if len(part.Parts) == 2 && part.Parts[0][0] != 0 { // Parts can be in any order
+		part.Parts[0], part.Parts[1] = part.Parts[1], part.Parts[0]
+	}
+	if !reflect.DeepEqual(part.Parts, expectedParts) {
+		t.Errorf("invalid parts: expected %v, got %v", expectedParts, part.Parts)
+	}
+	if expectedCut := []Var{4, 5}; !reflect.DeepEqual(part.Cut, expectedCut) {
+		t.Errorf("invalid cut: expected %v, got %v", expectedCut, part.Cut)
+	}
+	if part := pb.Partition(1); len(part.Parts) != 1 || len(part.Parts[0]) != 10 || len(part.Cut) != 0 {
+		t.Errorf("invalid trivial partition %v", part)
+	}
+	if part := pb.Partition(3); len(part.Parts) != 3 {
+		t.Errorf("expected 3 parts, got %v", part)
+	}
+}
+
+func TestBranchFirst(t *testing.T) {
+	pb := twoChains(5)
+	cut := pb.Partition(2).Cut
+	s := New(pb)
+	s.BranchFirst(cut)
+	for i := range cut {
+		lit := s.chooseLit()
+		if lit == -1 || (lit.Var() != cut[0] && lit.Var() != cut[1]) {
+			t.Fatalf("decision #%d: expected a cut var, got %d", i, lit.Int())
+		}
+		s.model[lit.Var()] = 2
+	}
+	f, err := os.Open("testcnf/125.cnf")
+	if err != nil {
+		t.Fatalf("could not open file: %v", err)
+	}
+	defer f.Close()
+	pb, err = ParseCNF(f)
+	if err != nil {
+		t.Fatalf("could not parse file: %v", err)
+	}
+	s = New(pb)
+	s.BranchFirst(pb.Partition(4).Cut)
+	if status := s.Solve(); status != Unsat {
+		t.Errorf("expected unsat, got %v", status)
+	}
+	s = New(ParseSlice([][]int{{1, 2}}))
+	s.BranchFirst([]Var{4})
+	if lit := s.chooseLit(); lit == -1 || lit.Var() != 4 {
+		t.Errorf("expected a decision on new var 5, got %d", lit.Int())
+	}
+	if status := s.Solve(); status != Sat || len(s.Model()) != 5 {
+		t.Errorf("expected sat with 5 vars, got %v with %d vars", status, len(s.Model()))
+	}
+}
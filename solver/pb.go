@@ -1,13 +1,30 @@
 package solver
 
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
 // A PBConstr is a Pseudo-Boolean constraint.
 type PBConstr struct {
 	Lits    []int // List of literals, designed with integer values. A positive value means the literal is true, a negative one it is false.
 	Weights []int // Weight of each lit from Lits. If nil, all lits == 1
 	AtLeast int   // Sum of all lits must be at least this value
+	// If BigAtLeast is not nil, the weights and the degree of the constraint do not fit in ints:
+	// BigWeights and BigAtLeast are used instead of Weights and AtLeast, that are ignored.
+	BigWeights []*big.Int // Weight of each lit from Lits. If nil, all lits == 1
+	BigAtLeast *big.Int   // Sum of all lits must be at least this value
 }
 
+// ErrOverflow is wrapped by the errors returned when the weights of a PB constraint are too big
+// to be summed as ints.
+// Constraints with such weights can be built with BigGtEq, BigLtEq and BigEq instead.
+var ErrOverflow = errors.New("PB constraint is too big")
+
 // WeightSum returns the sum of the weight of all terms.
+// The result is wrong if the sum overflows: use WeightSumErr on untrusted input.
+// It is meaningless if c has arbitrary-precision weights.
 func (c PBConstr) WeightSum() int {
 	if c.Weights == nil { // All weights = 1
 		return len(c.Lits)
@@ -19,13 +36,55 @@ func (c PBConstr) WeightSum() int {
 	return res
 }
 
+// WeightSumErr is like WeightSum, but it returns an error wrapping ErrOverflow if the sum overflows.
+func (c PBConstr) WeightSumErr() (int, error) {
+	if c.Weights == nil {
+		return len(c.Lits), nil
+	}
+	return sumInts(c.Weights)
+}
+
+// sumInts returns the sum of the given values, or an error wrapping ErrOverflow if it overflows.
+func sumInts(vals []int) (int, error) {
+	res := 0
+	for _, val := range vals {
+		var ok bool
+		if res, ok = addInts(res, val); !ok {
+			return 0, fmt.Errorf("%w: sum of weights overflows", ErrOverflow)
+		}
+	}
+	return res, nil
+}
+
+// addInts returns a+b, and false if the sum overflows.
+func addInts(a, b int) (int, bool) {
+	sum := a + b
+	return sum, (b >= 0) == (sum >= a)
+}
+
+// subInts returns a-b, and false if the difference overflows.
+func subInts(a, b int) (int, bool) {
+	diff := a - b
+	return diff, (b >= 0) == (diff <= a)
+}
+
 // Clause returns the clause associated with the given constraint.
 func (c PBConstr) Clause() *Clause {
+	if c.BigAtLeast != nil { // Arbitrary-precision weights must be normalized first
+		if c = c.normalized(); c.BigAtLeast != nil {
+			return c.bigClause()
+		}
+	}
 	lits := make([]Lit, len(c.Lits))
 	for i, val := range c.Lits {
 		lits[i] = IntToLit(int32(val))
 	}
-	return NewPBClause(lits, c.Weights, c.AtLeast)
+	var weights []int
+	if c.Weights != nil { // NewPBClause sorts weights: c must not be modified
+		weights = make([]int, len(c.Weights))
+		copy(weights, c.Weights)
+	}
+	return NewPBClause(lits, weights, c.AtLeast)
 }
 
 // PropClause returns a PB constraint equivalent to a propositional clause: at least one of the given
@@ -52,15 +111,30 @@ func AtMost(lits []int, n int) PBConstr {
 
 // GtEq returns a PB constraint stating that the sum of all literals multiplied by their weight
 // must be at least n.
-// Will panic if len(weights) != len(lits).
+// Will panic if len(weights) != len(lits), or if normalizing the constraint overflows: use GtEqErr on untrusted input.
 func GtEq(lits []int, weights []int, n int) PBConstr {
-	if len(weights) != 0 && len(lits) != len(weights) {
-		panic("not as many lits as weights")
+	res, err := GtEqErr(lits, weights, n)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// GtEqErr is like GtEq, but it returns an error wrapping ErrOverflow if normalizing the constraint overflows,
+// and a *ConstrError wrapping ErrBadWeight if weights is neither nil nor as long as lits, instead of panicking.
+func GtEqErr(lits []int, weights []int, n int) (PBConstr, error) {
+	if weights != nil && len(lits) != len(weights) {
+		return PBConstr{}, weightsCountError(0, len(lits), len(weights))
 	}
 	for i := 0; i < len(weights); i++ {
 		if weights[i] < 0 {
-			weights[i] = -weights[i]
-			n += weights[i]
+			if weights[i] = -weights[i]; weights[i] < 0 { // Opposite of the smallest int
+				return PBConstr{}, fmt.Errorf("%w: weight %d cannot be negated", ErrOverflow, weights[i])
+			}
+			var ok bool
+			if n, ok = addInts(n, weights[i]); !ok {
+				return PBConstr{}, fmt.Errorf("%w: degree overflows", ErrOverflow)
+			}
 			lits[i] = -lits[i]
 		}
 		if weights[i] == 0 {
@@ -69,32 +143,72 @@ func GtEq(lits []int, weights []int, n int) PBConstr {
 			i--
 		}
 	}
-	return PBConstr{Lits: lits, Weights: weights, AtLeast: n}
+	return PBConstr{Lits: lits, Weights: weights, AtLeast: n}, nil
+}
+
+// weightsCountError returns the error stating that the constraint #i, made of nbLits lits and nbWeights weights,
+// does not have as many weights as lits.
+func weightsCountError(i int, nbLits, nbWeights int) error {
+	return &ConstrError{Index: i, Msg: fmt.Sprintf("%d lits but %d weights", nbLits, nbWeights), Err: ErrBadWeight}
 }
 
 // LtEq returns a PB constraint stating that the sum of all literals multiplied by their weight
 // must be at most n.
-// Will panic if len(weights) != len(lits).
+// Will panic if len(weights) != len(lits), or if normalizing the constraint overflows: use LtEqErr on untrusted input.
 func LtEq(lits []int, weights []int, n int) PBConstr {
-	sum := 0
+	res, err := LtEqErr(lits, weights, n)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// LtEqErr is like LtEq, but it returns an error wrapping ErrOverflow if normalizing the constraint overflows,
+// and a *ConstrError wrapping ErrBadWeight if len(weights) != len(lits), instead of panicking.
+func LtEqErr(lits []int, weights []int, n int) (PBConstr, error) {
+	if len(lits) != len(weights) {
+		return PBConstr{}, weightsCountError(0, len(lits), len(weights))
+	}
+	sum, err := sumInts(weights)
+	if err != nil {
+		return PBConstr{}, err
+	}
 	for i := range lits {
 		lits[i] = -lits[i]
-		sum += weights[i]
 	}
-	n = sum - n
-	return GtEq(lits, weights, n)
+	n, ok := subInts(sum, n)
+	if !ok {
+		return PBConstr{}, fmt.Errorf("%w: degree overflows", ErrOverflow)
+	}
+	return GtEqErr(lits, weights, n)
 }
 
 // Eq returns a set of PB constraints stating that the sum of all literals multiplied by their weight
 // must be exactly n.
-// Will panic if len(weights) != len(lits).
+// Will panic if len(weights) != len(lits), or if normalizing the constraints overflows: use EqErr on untrusted input.
 func Eq(lits []int, weights []int, n int) []PBConstr {
+	res, err := EqErr(lits, weights, n)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// EqErr is like Eq, but it returns an error wrapping ErrOverflow if normalizing the constraints overflows,
+// and a *ConstrError wrapping ErrBadWeight if len(weights) != len(lits), instead of panicking.
+func EqErr(lits []int, weights []int, n int) ([]PBConstr, error) {
 	lits2 := make([]int, len(lits))
 	weights2 := make([]int, len(weights))
 	copy(lits2, lits)
 	copy(weights2, weights)
-	ge := GtEq(lits2, weights2, n)
-	le := LtEq(lits, weights, n)
+	ge, err := GtEqErr(lits2, weights2, n)
+	if err != nil {
+		return nil, err
+	}
+	le, err := LtEqErr(lits, weights, n)
+	if err != nil {
+		return nil, err
+	}
 	var res []PBConstr
 	if ge.AtLeast > 0 {
 		res = append(res, ge)
@@ -102,5 +216,5 @@ func Eq(lits []int, weights []int, n int) []PBConstr {
 	if le.AtLeast > 0 {
 		res = append(res, le)
 	}
-	return res
+	return res, nil
 }
@@ -0,0 +1,370 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// This file deals with PB constraints whose weights are too big to be represented or summed as ints,
+// as found in some industrial instances. Such constraints are normalized with arbitrary-precision arithmetic:
+// negative weights are removed, weights bigger than the degree are lowered to the degree, and all weights
+// and the degree are divided by their greatest common divisor. Huge weights usually become small that way,
+// since they tend to share big factors, and the constraint is then handled as any other one.
+// Normalized constraints that are still too big keep their arbitrary-precision weights: the solver watches
+// all their lits, and propagates them with arbitrary-precision arithmetic. Conflict analysis only deals with
+// the lits of reason clauses, not with their weights, so it handles them as any other PB clause.
+
+const (
+	// maxCardinality is the biggest cardinality that is stored on the low bits of lbdValue.
+	// PB clauses with bigger cardinalities store it along with their weights.
+	maxCardinality = 1 << 30
+	// maxWeightSum is the biggest sum of the weights, and thus the biggest cardinality, of a problem clause,
+	// so that sums computed during propagation cannot overflow.
+	maxWeightSum = math.MaxInt >> 1
+)
+
+// BigGtEq is like GtEq, except weights and n are arbitrary-precision integers.
+// The returned constraint is normalized. If its weights and its degree do not fit in ints once normalized,
+// they are stored in its BigWeights and BigAtLeast fields.
+// weights and n are not modified. It takes ownership of lits.
+// Will panic if len(weights) != len(lits).
+func BigGtEq(lits []int, weights []*big.Int, n *big.Int) PBConstr {
+	if len(lits) != len(weights) {
+		panic("not as many lits as weights")
+	}
+	deg := new(big.Int).Set(n)
+	ws := make([]*big.Int, 0, len(weights))
+	j := 0
+	for i, w := range weights {
+		switch w.Sign() {
+		case 0:
+			continue
+		case -1:
+			deg.Sub(deg, w)
+			lits[j] = -lits[i]
+			ws = append(ws, new(big.Int).Neg(w))
+		default:
+			lits[j] = lits[i]
+			ws = append(ws, new(big.Int).Set(w))
+		}
+		j++
+	}
+	return normalizeBig(lits[:j], ws, deg)
+}
+
+// BigLtEq is like LtEq, except weights and n are arbitrary-precision integers.
+// weights and n are not modified. It takes ownership of lits.
+// Will panic if len(weights) != len(lits).
+func BigLtEq(lits []int, weights []*big.Int, n *big.Int) PBConstr {
+	if len(lits) != len(weights) {
+		panic("not as many lits as weights")
+	}
+	sum := new(big.Int)
+	for i := range lits {
+		lits[i] = -lits[i]
+		sum.Add(sum, weights[i])
+	}
+	return BigGtEq(lits, weights, sum.Sub(sum, n))
+}
+
+// BigEq is like Eq, except weights and n are arbitrary-precision integers.
+// weights and n are not modified.
+// Will panic if len(weights) != len(lits).
+func BigEq(lits []int, weights []*big.Int, n *big.Int) []PBConstr {
+	lits2 := make([]int, len(lits))
+	copy(lits2, lits)
+	var res []PBConstr
+	if ge := BigGtEq(lits2, weights, n); ge.AtLeast > 0 || ge.BigAtLeast != nil {
+		res = append(res, ge)
+	}
+	if le := BigLtEq(lits, weights, n); le.AtLeast > 0 || le.BigAtLeast != nil {
+		res = append(res, le)
+	}
+	return res
+}
+
+// normalizeBig returns the normalized constraint stating that the sum of the lits multiplied by their weight
+// must be at least deg. All weights must be strictly positive. It takes ownership of all its arguments.
+// If the result has arbitrary-precision weights, its degree is strictly positive and strictly less than
+// the sum of its weights.
+func normalizeBig(lits []int, weights []*big.Int, deg *big.Int) PBConstr {
+	if deg.Sign() <= 0 { // Trivially satisfied
+		return PBConstr{Lits: lits, AtLeast: 0}
+	}
+	gcd := new(big.Int)
+	sum := new(big.Int)
+	for _, w := range weights {
+		if w.Cmp(deg) > 0 { // A lit whose weight is at least deg satisfies the constraint on its own
+			w.Set(deg)
+		}
+		gcd.GCD(nil, nil, gcd, w)
+		sum.Add(sum, w)
+	}
+	switch sum.Cmp(deg) {
+	case -1: // Cannot be satisfied
+		return PBConstr{AtLeast: 1}
+	case 0: // All lits must be true
+		return PBConstr{Lits: lits, AtLeast: len(lits)}
+	}
+	if gcd.BitLen() > 1 {
+		for _, w := range weights {
+			w.Quo(w, gcd)
+		}
+		sum.Quo(sum, gcd)
+		deg.Add(deg, gcd)
+		deg.Sub(deg, big.NewInt(1))
+		deg.Quo(deg, gcd) // Degree is rounded up, since the sum of weights is a multiple of gcd
+	}
+	if sum.Cmp(big.NewInt(maxWeightSum)) > 0 { // The degree is smaller than the sum
+		return PBConstr{Lits: lits, BigWeights: weights, BigAtLeast: deg}
+	}
+	res := PBConstr{Lits: lits, Weights: make([]int, len(weights)), AtLeast: int(deg.Int64())}
+	for i, w := range weights {
+		res.Weights[i] = int(w.Int64())
+	}
+	return res
+}
+
+// fitsSolver returns true iff the weights and the cardinality of c can be handled by the solver
+// without being normalized first.
+func (c PBConstr) fitsSolver() bool {
+	if c.BigAtLeast != nil || c.AtLeast > maxWeightSum {
+		return false
+	}
+	sum := 0
+	for _, w := range c.Weights {
+		if w < 0 || w > maxWeightSum-sum {
+			return false
+		}
+		sum += w
+	}
+	return true
+}
+
+// normalized returns a normalized constraint equivalent to c. c is not modified.
+func (c PBConstr) normalized() PBConstr {
+	lits := make([]int, len(c.Lits))
+	copy(lits, c.Lits)
+	if c.BigAtLeast != nil {
+		return BigGtEq(lits, c.bigTerms(), c.BigAtLeast)
+	}
+	weights := make([]*big.Int, len(c.Lits))
+	for i := range weights {
+		if c.Weights == nil {
+			weights[i] = big.NewInt(1)
+		} else {
+			weights[i] = big.NewInt(int64(c.Weights[i]))
+		}
+	}
+	return BigGtEq(lits, weights, big.NewInt(int64(c.AtLeast)))
+}
+
+// bigTerms returns the arbitrary-precision weights of c, that must have a BigAtLeast value.
+func (c PBConstr) bigTerms() []*big.Int {
+	if c.BigWeights != nil {
+		return c.BigWeights
+	}
+	weights := make([]*big.Int, len(c.Lits))
+	for i := range weights {
+		weights[i] = big.NewInt(1)
+	}
+	return weights
+}
+
+// bigClause returns the clause associated with c, that must be normalized and have arbitrary-precision weights.
+// c is not modified.
+func (c PBConstr) bigClause() *Clause {
+	lits := make([]Lit, len(c.Lits))
+	for i, val := range c.Lits {
+		lits[i] = IntToLit(int32(val))
+	}
+	weights := make([]*big.Int, len(c.BigWeights))
+	copy(weights, c.BigWeights)
+	return newBigPBClause(lits, weights, c.BigAtLeast)
+}
+
+// smallTerms returns true iff the weights and the right-hand side of a constraint line are small enough
+// for the constraint to be normalized with int arithmetic without overflowing, or exceeding maxCardinality.
+func smallTerms(weights []int, rhs int) bool {
+	if rhs < -maxCardinality || rhs > maxCardinality {
+		return false
+	}
+	sum := rhs
+	if sum < 0 {
+		sum = -sum
+	}
+	for _, w := range weights {
+		if w < -maxCardinality || w > maxCardinality {
+			return false
+		}
+		if w < 0 {
+			w = -w
+		}
+		if sum += w; sum >= maxCardinality {
+			return false
+		}
+	}
+	return true
+}
+
+// bigPB holds the weights and the cardinality of a normalized PB clause, when they do not fit in ints.
+// The values themselves are never modified, so they can be shared by copies of the clause.
+type bigPB struct {
+	weights []*big.Int // Weight of each lit
+	card    *big.Int   // Minimal cardinality, strictly less than the sum of the weights
+}
+
+// newBigPBClause returns a PB clause with the given lits, arbitrary-precision weights and minimal cardinality,
+// that must have been normalized. It takes ownership of lits and weights.
+func newBigPBClause(lits []Lit, weights []*big.Int, card *big.Int) *Clause {
+	sort.Sort(&bigWeightedLits{lits: lits, weights: weights})
+	data := pbData{watched: make([]bool, len(lits)), big: &bigPB{weights: weights, card: card}}
+	return &Clause{lits: lits, pbData: &data}
+}
+
+// Used to sort literals by decreasing weight when constructing big PB clauses.
+type bigWeightedLits struct {
+	lits    []Lit
+	weights []*big.Int
+}
+
+func (wl *bigWeightedLits) Less(i, j int) bool { return wl.weights[i].Cmp(wl.weights[j]) > 0 }
+func (wl *bigWeightedLits) Len() int           { return len(wl.lits) }
+func (wl *bigWeightedLits) Swap(i, j int) {
+	wl.lits[i], wl.lits[j] = wl.lits[j], wl.lits[i]
+	wl.weights[i], wl.weights[j] = wl.weights[j], wl.weights[i]
+}
+
+// isBig returns true iff c is a PB clause with arbitrary-precision weights.
+func (c *Clause) isBig() bool {
+	return c.pbData != nil && c.pbData.big != nil
+}
+
+// bigSlack returns the sum of the weights of the lits of the big PB clause c that are not false according to status,
+// minus the cardinality of c. If the weights of its true lits are already enough to satisfy c,
+// it returns true and the slack is meaningless.
+func (c *Clause) bigSlack(status func(Lit) Status) (slack *big.Int, sat bool) {
+	data := c.pbData.big
+	slack = new(big.Int).Neg(data.card)
+	sumSat := new(big.Int)
+	for i, w := range data.weights {
+		switch status(c.lits[i]) {
+		case Sat:
+			if sumSat.Add(sumSat, w).Cmp(data.card) >= 0 {
+				return slack, true
+			}
+			slack.Add(slack, w)
+		case Indet:
+			slack.Add(slack, w)
+		}
+	}
+	return slack, false
+}
+
+// reduceBig removes from the big PB clause c the lits that are bound according to status, and lowers its cardinality
+// accordingly. Then, it also removes the lits that must be true for c to be satisfied, and returns them.
+// The returned status is Sat if c is satisfied once they are true, Unsat if c cannot be satisfied,
+// and Indet if c must be kept.
+func (c *Clause) reduceBig(status func(Lit) Status) (units []Lit, st Status) {
+	data := c.pbData.big
+	card := data.card
+	sum := new(big.Int)
+	j := 0
+	for i, lit := range c.lits {
+		switch status(lit) {
+		case Sat:
+			card = new(big.Int).Sub(card, data.weights[i])
+		case Indet:
+			c.lits[j] = lit
+			data.weights[j] = data.weights[i]
+			sum.Add(sum, data.weights[i])
+			j++
+		}
+	}
+	c.Shrink(j)
+	if card.Sign() <= 0 {
+		return nil, Sat
+	}
+	slack := sum.Sub(sum, card)
+	if slack.Sign() < 0 {
+		return nil, Unsat
+	}
+	j = 0
+	for i, lit := range c.lits {
+		if w := data.weights[i]; w.Cmp(slack) > 0 { // Lit must be true for the clause to be satisfiable
+			units = append(units, lit)
+			card = new(big.Int).Sub(card, w)
+		} else {
+			c.lits[j] = lit
+			data.weights[j] = w
+			j++
+		}
+	}
+	c.Shrink(j)
+	data.card = card
+	if card.Sign() <= 0 {
+		return units, Sat
+	}
+	return units, Indet
+}
+
+// equalBig is like equal, for clauses that have arbitrary-precision weights.
+func (c *Clause) equalBig(c2 *Clause) bool {
+	if !c.isBig() || !c2.isBig() || c.Len() != c2.Len() || c.pbData.big.card.Cmp(c2.pbData.big.card) != 0 {
+		return false
+	}
+	weights := make(map[Lit]*big.Int, c.Len())
+	for i, lit := range c.lits {
+		weights[lit] = c.pbData.big.weights[i]
+	}
+	for i, lit := range c2.lits {
+		if w, ok := weights[lit]; !ok || w.Cmp(c2.pbData.big.weights[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bigPBString is like pbString, for arbitrary-precision weights and cardinality.
+func bigPBString(lits []Lit, weights []*big.Int, card *big.Int) string {
+	terms := make([]string, len(lits))
+	for i, lit := range lits {
+		val := lit.Int()
+		sign := ""
+		if val < 0 {
+			val = -val
+			sign = "~"
+		}
+		terms[i] = fmt.Sprintf("%v %sx%d", weights[i], sign, val)
+	}
+	return fmt.Sprintf("%s >= %v ;", strings.Join(terms, " +"), card)
+}
+
+// lessBig is like less, for clauses with the same lits when one of them has arbitrary-precision weights.
+func (c writtenClause) lessBig(c2 writtenClause) bool {
+	for i := range c.lits {
+		if cmp := c.bigWeight(i).Cmp(c2.bigWeight(i)); cmp != 0 {
+			return cmp < 0
+		}
+	}
+	return c.bigCard().Cmp(c2.bigCard()) < 0
+}
+
+// bigWeight returns the weight of the ith lit of c, as an arbitrary-precision integer.
+func (c writtenClause) bigWeight(i int) *big.Int {
+	if c.big != nil {
+		return c.big.weights[i]
+	}
+	return big.NewInt(int64(c.weight(i)))
+}
+
+// bigCard returns the cardinality of c, as an arbitrary-precision integer.
+func (c writtenClause) bigCard() *big.Int {
+	if c.big != nil {
+		return c.big.card
+	}
+	return big.NewInt(int64(c.card))
+}
@@ -1,7 +1,13 @@
 package solver
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -143,6 +149,38 @@ func TestPBTrivial(t *testing.T) {
 	}
 }
 
+func TestParseOPBContradictoryUnits(t *testing.T) {
+	pb, err := ParseOPB(strings.NewReader("+1 x1 >= 1 ;\n+1 ~x1 +1 x2 >= 2 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if status := New(pb).Solve(); status != Unsat {
+		t.Errorf("problem should be unsat, got %v", status)
+	}
+	if expected := (UnitConflict{Lit: IntToLit(1), First: 1, Second: 2}); pb.Conflict == nil || *pb.Conflict != expected {
+		t.Errorf("invalid conflict: expected %+v, got %+v", expected, pb.Conflict)
+	}
+}
+
+func TestParsePBConstrsConflict(t *testing.T) {
+	constrs := []PBConstr{
+		GtEq([]int{1, 2}, []int{1, 2}, 3),
+		PropClause(1, 3),
+		GtEq([]int{-3, 1}, nil, 1),
+		GtEq([]int{-2}, nil, 1),
+	}
+	pb := ParsePBConstrs(constrs)
+	if pb.Status != Unsat {
+		t.Errorf("problem should be unsat, got %v", pb.Status)
+	}
+	if expected := (UnitConflict{Lit: IntToLit(2), First: 0, Second: 3}); pb.Conflict == nil || *pb.Conflict != expected {
+		t.Errorf("invalid conflict: expected %+v, got %+v", expected, pb.Conflict)
+	}
+	if pb = ParsePBConstrs(constrs[:3]); pb.Conflict != nil {
+		t.Errorf("expected no conflict, got %+v", pb.Conflict)
+	}
+}
+
 func TestEnumeratePB(t *testing.T) {
 	pb1 := AtMost([]int{1, 2, 3, 4}, 3)
 	pb2 := AtLeast([]int{1, 2, 3, 4}, 2)
@@ -198,6 +236,63 @@ func TestPB(t *testing.T) {
 	}
 }
 
+// randomPBConstrs returns a random set of clauses, cardinality and PB constraints on nbVars vars.
+func randomPBConstrs(rng *rand.Rand, nbVars int) []PBConstr {
+	var res []PBConstr
+	for i := 0; i < 1+rng.Intn(4); i++ {
+		perm := rng.Perm(nbVars)[:3]
+		lits := make([]int, len(perm))
+		weights := make([]int, len(perm))
+		for j, v := range perm {
+			lits[j] = v + 1
+			if rng.Intn(2) == 0 {
+				lits[j] = -lits[j]
+			}
+			weights[j] = 1 + rng.Intn(3)
+		}
+		switch rng.Intn(3) {
+		case 0:
+			res = append(res, PropClause(lits...))
+		case 1:
+			res = append(res, AtLeast(lits, 2))
+		case 2:
+			res = append(res, GtEq(lits, weights, 1+rng.Intn(4)))
+		}
+	}
+	return res
+}
+
+func TestCountModelsPB(t *testing.T) {
+	if !hasCount {
+		t.Skip("model counting with tree decompositions is not part of this build")
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		constrs := randomPBConstrs(rng, 6)
+		pb := ParsePBConstrs(constrs)
+		expected, err := pb.CountModelsTD(nil)
+		if err != nil {
+			t.Fatalf("could not count models with tree decomposition: %v", err)
+		}
+		if nb := New(ParsePBConstrs(constrs)).CountModels(); int64(nb) != expected.Int64() {
+			t.Errorf("%s: expected %d models, CountModels found %d", pb.PBString(), expected, nb)
+		}
+		models := make(chan []bool)
+		go New(ParsePBConstrs(constrs)).Enumerate(models, nil)
+		seen := make(map[string]bool)
+		for model := range models {
+			key := fmt.Sprint(model)
+			if seen[key] {
+				t.Errorf("%s: model %v enumerated twice", pb.PBString(), model)
+			}
+			seen[key] = true
+		}
+		if int64(len(seen)) != expected.Int64() {
+			t.Errorf("%s: expected %d models, Enumerate found %d", pb.PBString(), expected, len(seen))
+		}
+	}
+}
+
 func runPBBench(path string, b *testing.B) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -225,3 +320,424 @@ func Benchmark3Col(b *testing.B) {
 func BenchmarkBandwidth(b *testing.B) {
 	runPBBench("testcnf/fixed-bandwidth-10.cnf.gz-extracted.pb", b)
 }
+
+func TestParsePBConstrsHint(t *testing.T) {
+	constrs := []PBConstr{
+		GtEq([]int{1, 2, 3}, []int{2, 1, 1}, 2),
+		GtEq([]int{4}, nil, 1),
+		GtEq([]int{4, 5}, nil, 2),
+		GtEq([]int{1, 5}, nil, 0),
+		AtLeast([]int{1, 3, 5}, 2),
+	}
+	_, stats := ParsePBConstrsHint(constrs, SizeHint{})
+	if stats.NbConstrs != 5 || stats.NbIgnored != 1 || stats.NbUnits != 3 || stats.NbClauses != 2 || stats.NbGrowths == 0 {
+		t.Errorf("invalid stats without hint: %+v", stats)
+	}
+	pb, stats2 := ParsePBConstrsHint(constrs, SizeHint{NbUnits: stats.NbUnits, NbClauses: stats.NbClauses})
+	if stats2.NbGrowths != 0 {
+		t.Errorf("expected no growth with perfect hint, got %d", stats2.NbGrowths)
+	}
+	if len(pb.Units) != 2 { // x4 is a duplicate unit
+		t.Errorf("expected 2 units, got %v", pb.Units)
+	}
+	if status := New(pb).Solve(); status != Sat {
+		t.Errorf("expected sat problem, got %v", status)
+	}
+}
+
+func TestParseCardConstrsHint(t *testing.T) {
+	constrs := []CardConstr{AtLeast1(1, 2, 3), AtMost1(1, 2, 3), AtLeast1(-1)}
+	pb, stats := ParseCardConstrsHint(constrs, SizeHint{NbUnits: 1, NbClauses: 2})
+	if stats.NbConstrs != 3 || stats.NbUnits != 1 || stats.NbClauses != 2 || stats.NbGrowths != 0 {
+		t.Errorf("invalid stats: %+v", stats)
+	}
+	if status := New(pb).Solve(); status != Sat {
+		t.Errorf("expected sat problem, got %v", status)
+	}
+}
+
+// bigConstrs returns a chain of n binary at-least-one constraints.
+func bigConstrs(n int) []PBConstr {
+	constrs := make([]PBConstr, n)
+	for i := range constrs {
+		constrs[i] = AtLeast([]int{i + 1, -(i + 2)}, 1)
+	}
+	return constrs
+}
+
+func BenchmarkParsePBConstrs(b *testing.B) {
+	constrs := bigConstrs(1 << 16)
+	for i := 0; i < b.N; i++ {
+		ParsePBConstrs(constrs)
+	}
+}
+
+func BenchmarkParsePBConstrsHint(b *testing.B) {
+	constrs := bigConstrs(1 << 16)
+	for i := 0; i < b.N; i++ {
+		ParsePBConstrsHint(constrs, SizeHint{NbClauses: len(constrs)})
+	}
+}
+
+func TestParseOPBRecover(t *testing.T) {
+	opb := "* two invalid constraints\n+1 x1 +1 x2 >= 1 ;\n+1 x1 +1 x2 >= 1\n+1 x1 +1 x3 => 1 ;\n+1 ~x1 >= 1 ;\n"
+	pb, err := ParseOPBOptions(strings.NewReader(opb), ParseOptions{Recover: true})
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 2 || errs[0].Line != 3 || errs[1].Line != 4 {
+		t.Fatalf("expected syntax errors on lines 3 and 4, got %v", err)
+	}
+	if status := New(pb).Solve(); status != Sat {
+		t.Errorf("expected sat problem, got %v", status)
+	}
+}
+
+func TestParseOPBLongLine(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("* a constraint with many terms\n")
+	for i := 1; i <= 20000; i++ {
+		fmt.Fprintf(&sb, "+1 x%d ", i)
+	}
+	sb.WriteString(">= 20000 ;\n")
+	opb := sb.String()
+	pb, err := ParseOPB(strings.NewReader(opb))
+	if err != nil {
+		t.Fatalf("could not parse long line: %v", err)
+	}
+	if pb.NbVars != 20000 {
+		t.Errorf("expected 20000 vars, got %d", pb.NbVars)
+	}
+	_, err = ParseOPBOptions(strings.NewReader(opb), ParseOptions{MaxLineSize: 1024})
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 2 || perr.Kind != LineTooLong || perr.Recoverable() || !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("expected an error on line 2 because of the line size, got %v", err)
+	}
+}
+
+func TestParseOPBInvalidTerms(t *testing.T) {
+	for _, opb := range []string{
+		"+1 x1 +0 x2 >= 1 ;\n",
+		"+1 x1 +2 ~x1 >= 1 ;\n",
+		"+1 x1 +2 x1 >= 1 ;\n",
+		"+1 x0 >= 1 ;\n",
+		"+1 x-2 >= 1 ;\n",
+		"+1 ~x >= 1 ;\n",
+		"+1 y1 >= 1 ;\n",
+		"+1 x1 +2 >= 1 ;\n",
+		"min: +1 x1 -1 x1 ;\n",
+		"min: x1 +0 x2 ;\n",
+	} {
+		if _, err := ParseOPB(strings.NewReader(opb)); err == nil {
+			t.Errorf("expected an error when parsing %q", opb)
+		}
+	}
+	pb, err := ParseOPB(strings.NewReader("min: x1 -2 ~x2 ;\n+1 x1 +1 x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse weightless lits: %v", err)
+	}
+	if pb.NbVars != 2 || len(pb.minLits) != 2 || pb.minWeights[0] != 1 || pb.minWeights[1] != -2 {
+		t.Errorf("invalid objective: %v %v", pb.minLits, pb.minWeights)
+	}
+}
+
+func TestParseOPBOperators(t *testing.T) {
+	tests := []struct {
+		constr   string
+		nbModels int
+	}{
+		{"+1 x1 +2 x2 +3 x3 >= 3 ;", 5},
+		{"+1 x1 +2 x2 +3 x3 > 3 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 <= 3 ;", 5},
+		{"+1 x1 +2 x2 +3 x3 < 3 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 = 3 ;", 2},
+		{"+1 x1 -2 x2 +3 ~x3 <= 0 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 < 0 ;", 0},
+	}
+	for _, test := range tests {
+		pb, err := ParseOPB(strings.NewReader("* #variable= 3 #constraint= 1\n" + test.constr + "\n"))
+		if err != nil {
+			t.Errorf("could not parse %q: %v", test.constr, err)
+			continue
+		}
+		if nb := New(pb).CountModels(); nb != test.nbModels {
+			t.Errorf("expected %d models for %q, got %d", test.nbModels, test.constr, nb)
+		}
+	}
+	if _, err := ParseOPB(strings.NewReader("+1 x1 +1 x2 => 1 ;\n")); err == nil {
+		t.Errorf("expected an error on invalid operator")
+	}
+}
+
+func TestParseOPBBigWeights(t *testing.T) {
+	factor := new(big.Int).Lsh(big.NewInt(1), 70)
+	tests := []struct {
+		constr   string
+		nbModels int
+	}{
+		{"+1 x1 +2 x2 +3 x3 >= 3 ;", 5},
+		{"+1 x1 +2 x2 +3 x3 > 3 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 <= 3 ;", 5},
+		{"+1 x1 +2 x2 +3 x3 < 3 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 = 3 ;", 2},
+		{"+1 x1 -2 x2 +3 ~x3 <= 0 ;", 3},
+		{"+1 x1 +2 x2 +3 x3 < 0 ;", 0},
+		{"+4611686018427387904 x1 +4611686018427387904 x2 +4611686018427387904 x3 >= 4611686018427387904 ;", 7},
+		{"+1 x1 +1 x2 +1180591620717411303424 x3 >= 2 ;", 5},
+	}
+	for _, test := range tests {
+		fields := strings.Fields(test.constr)
+		for i, field := range fields { // Weights and rhs are multiplied by factor, which does not change the models
+			if n, ok := new(big.Int).SetString(field, 10); ok {
+				fields[i] = fmt.Sprintf("%+d", n.Mul(n, factor))
+			}
+		}
+		constr := strings.Join(fields, " ")
+		pb, err := ParseOPB(strings.NewReader(constr + "\n"))
+		if err != nil {
+			t.Errorf("could not parse %q: %v", constr, err)
+			continue
+		}
+		if nb := New(pb).CountModels(); nb != test.nbModels {
+			t.Errorf("expected %d models for %q, got %d", test.nbModels, constr, nb)
+		}
+	}
+	for _, opb := range []string{
+		"min: +1180591620717411303424 x1 ;\n+1 x1 >= 1 ;\n",
+		"min: +4611686018427387904 x1 +4611686018427387904 x2 ;\n+1 x1 >= 1 ;\n",
+	} {
+		if _, err := ParseOPB(strings.NewReader(opb)); err == nil {
+			t.Errorf("expected an error when parsing %q", opb)
+		} else if perr, ok := err.(*ParseError); !ok || perr.Kind != OutOfRange {
+			t.Errorf("expected an out of range error when parsing %q, got %v", opb, err)
+		}
+	}
+}
+
+func TestBigObjective(t *testing.T) {
+	// Cost constraints have cardinalities bigger than maxCardinality
+	pb, err := ParseOPB(strings.NewReader("min: +3000000000 x1 +3000000000 x2 +4000000000 x3 ;\n+1 x1 +1 x2 +1 x3 >= 2 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse objective: %v", err)
+	}
+	if cost := New(pb).Minimize(); cost != 6000000000 {
+		t.Errorf("expected cost 6000000000, got %d", cost)
+	}
+	pb, err = ParseWBO(strings.NewReader("[3000000000] +1 x1 >= 1 ;\n[2000000000] +1 x2 >= 1 ;\n+1 ~x1 +1 ~x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse costs: %v", err)
+	}
+	if cost := New(pb).Minimize(); cost != 2000000000 {
+		t.Errorf("expected cost 2000000000, got %d", cost)
+	}
+}
+
+func TestBigPBConstrs(t *testing.T) {
+	w := func(n int64) *big.Int { return new(big.Int).Lsh(big.NewInt(n), 80) }
+	ge := BigGtEq([]int{1, 2, 3}, []*big.Int{w(1), w(-2), w(5)}, w(3))
+	if ge.BigAtLeast != nil || fmt.Sprint(ge.Lits, ge.Weights, ge.AtLeast) != "[1 -2 3] [1 2 5] 5" {
+		t.Errorf("invalid normalized constraint: got %v", ge)
+	}
+	eq := BigEq([]int{1, 2, 3}, []*big.Int{w(1), w(2), w(3)}, w(3))
+	if nb := New(ParsePBConstrs(eq)).CountModels(); nb != 2 {
+		t.Errorf("expected 2 models, got %d", nb)
+	}
+	odd := new(big.Int).Add(w(1), big.NewInt(1))
+	ge = BigGtEq([]int{1, 2, 3}, []*big.Int{odd, w(1), w(1)}, odd)
+	if ge.BigAtLeast == nil {
+		t.Errorf("expected arbitrary-precision weights for a constraint that cannot be normalized, got %v", ge)
+	}
+	if nb := New(ParsePBConstrs([]PBConstr{ge})).CountModels(); nb != 5 {
+		t.Errorf("expected 5 models, got %d", nb)
+	}
+	pb := ParsePBConstrs([]PBConstr{GtEq([]int{1, 2, 3}, []int{1, 1, math.MaxInt}, 2)})
+	if nb := New(pb).CountModels(); nb != 5 {
+		t.Errorf("expected 5 models, got %d", nb)
+	}
+}
+
+func TestBigPBClauses(t *testing.T) {
+	// Weights are coprime and too big to be summed as ints: the constraints keep their arbitrary-precision weights
+	const d = "1180591620717411303425" // 2^70 + 1
+	tests := []struct {
+		opb      string
+		nbModels int
+	}{
+		{"+" + d + " x1 +1180591620717411303424 x2 +1 x3 >= " + d + " ;", 5},
+		{"+" + d + " x1 +1180591620717411303424 x2 +1 x3 = " + d + " ;", 2},
+		{"+" + d + " x1 +1180591620717411303424 x2 +1 x3 < " + d + " ;", 3},
+		{"+" + d + " ~x1 +1180591620717411303424 x2 +1 x3 +1 x4 >= " + d + " ;\n+1 x1 >= 1 ;", 3},
+	}
+	for _, test := range tests {
+		pb, err := ParseOPB(strings.NewReader(test.opb + "\n"))
+		if err != nil {
+			t.Errorf("could not parse %q: %v", test.opb, err)
+			continue
+		}
+		if nb, err := pb.CountModelsTD(nil); hasCount && (err != nil || nb.Int64() != int64(test.nbModels)) {
+			t.Errorf("expected %d models for %q with tree decomposition, got %v and %v", test.nbModels, test.opb, nb, err)
+		}
+		written := pb.PBString()
+		if nb := New(pb).CountModels(); nb != test.nbModels {
+			t.Errorf("expected %d models for %q, got %d", test.nbModels, test.opb, nb)
+		}
+		pb2, err := ParseOPB(strings.NewReader(written))
+		if err != nil {
+			t.Errorf("could not parse written problem %q: %v", written, err)
+		} else if nb := New(pb2).CountModels(); nb != test.nbModels {
+			t.Errorf("expected %d models for written problem %q, got %d", test.nbModels, written, nb)
+		}
+	}
+	pb, err := ParseOPB(strings.NewReader(tests[0].opb + "\n" + tests[0].opb + "\n"))
+	if err != nil {
+		t.Fatalf("could not parse duplicate constraints: %v", err)
+	}
+	if nb := pb.RemoveDuplicates(); nb != 1 {
+		t.Errorf("expected 1 duplicate, got %d", nb)
+	}
+	s := New(pb.clone())
+	if s.Assume([]Lit{IntToLit(-1)}); s.Solve() != Sat {
+		t.Errorf("expected problem to be sat when x1 is false")
+	} else if model := s.Model(); !model[1] || !model[2] {
+		t.Errorf("expected x2 and x3 to be propagated when x1 is false, got %v", model)
+	}
+	if nb := New(pb).EnumerateAssignments(EnumerateOptions{Blocking: BlockImplicant}, nil, nil); nb == 0 || nb > tests[0].nbModels {
+		t.Errorf("expected between 1 and %d implicants, got %d", tests[0].nbModels, nb)
+	}
+	pb, err = ParseWBO(strings.NewReader("[3] +" + d + " x1 +1180591620717411303424 x2 +1 x3 >= " + d + " ;\n+1 ~x1 >= 1 ;\n+1 ~x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse soft constraint: %v", err)
+	}
+	if cost := New(pb).Minimize(); cost != 3 {
+		t.Errorf("expected cost 3, got %d", cost)
+	}
+}
+
+func TestPBOverflow(t *testing.T) {
+	if _, err := (PBConstr{Lits: []int{1, 2}, Weights: []int{math.MaxInt, 1}}).WeightSumErr(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow when summing weights, got %v", err)
+	}
+	if _, err := GtEqErr([]int{1, 2}, []int{-math.MaxInt, -math.MaxInt}, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow in GtEqErr, got %v", err)
+	}
+	if _, err := LtEqErr([]int{1, 2}, []int{math.MaxInt, 1}, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow in LtEqErr, got %v", err)
+	}
+	if _, err := EqErr([]int{1, 2}, []int{math.MinInt, 1}, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow in EqErr, got %v", err)
+	}
+	if constrs, err := EqErr([]int{1, 2}, []int{1, 2}, 2); err != nil || len(constrs) != 2 {
+		t.Errorf("expected 2 constraints and no error, got %v and %v", constrs, err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected GtEq to panic on overflow")
+			}
+		}()
+		GtEq([]int{1, 2}, []int{-math.MaxInt, -math.MaxInt}, 0)
+	}()
+	lits := []Lit{IntToLit(1), IntToLit(2)}
+	if _, err := NewPBClauseErr(lits, []int{math.MaxInt, math.MaxInt}, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow for too big weights, got %v", err)
+	}
+	if _, err := NewPBClauseErr(lits, []int{maxWeightSum / 2, maxWeightSum / 2}, maxWeightSum+1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow for a too big cardinality, got %v", err)
+	}
+	if c, err := NewPBClauseErr(lits, []int{maxCardinality, maxCardinality}, maxCardinality+1); err != nil || c.Cardinality() != maxCardinality+1 {
+		t.Errorf("expected a clause with cardinality %d, got %v and %v", maxCardinality+1, c, err)
+	}
+	constrs := []PBConstr{PropClause(1, 2), {Lits: []int{1, 2, 3}, Weights: []int{maxWeightSum, 2, 2}, AtLeast: maxWeightSum}}
+	if pb, err := ParsePBConstrsErr(constrs); err != nil {
+		t.Errorf("could not parse a constraint that cannot be normalized: %v", err)
+	} else if nb := New(pb).CountModels(); nb != 4 {
+		t.Errorf("expected 4 models, got %d", nb)
+	}
+	bigConstr := PBConstr{Lits: []int{1, 2}, BigWeights: []*big.Int{big.NewInt(1)}, BigAtLeast: big.NewInt(1)}
+	if _, err := ParsePBConstrsErr([]PBConstr{bigConstr}); !errors.Is(err, ErrBadWeight) {
+		t.Errorf("expected a weight error for %v, got %v", bigConstr, err)
+	}
+	for _, constr := range []PBConstr{{Lits: []int{1}, Weights: []int{1, 2}, AtLeast: 1}, {Lits: []int{1, 2}, Weights: []int{1}, AtLeast: 1}} {
+		if _, err := ParsePBConstrsErr([]PBConstr{PropClause(1), constr}); !errors.Is(err, ErrBadWeight) {
+			t.Errorf("expected a weight error for %v, got %v", constr, err)
+		} else if cerr, ok := err.(*ConstrError); !ok || cerr.Index != 1 {
+			t.Errorf("expected an error on constraint #1, got %v", err)
+		}
+	}
+	if _, err := GtEqErr([]int{1, 2}, []int{1}, 1); !errors.Is(err, ErrBadWeight) {
+		t.Errorf("expected a weight error in GtEqErr, got %v", err)
+	}
+	if _, err := LtEqErr([]int{1}, []int{1, 2}, 1); !errors.Is(err, ErrBadWeight) {
+		t.Errorf("expected a weight error in LtEqErr, got %v", err)
+	}
+	if _, err := ParseWBO(strings.NewReader("[4611686018427387903] +1 x1 >= 1 ;\n[1] +1 x2 >= 1 ;\n")); err == nil {
+		t.Errorf("expected an error for a too big sum of costs")
+	}
+	if _, err := ReadNogoods(strings.NewReader(`{"lits":[1,2],"weights":[2,1],"bound":4611686018427387904}`)); err == nil {
+		t.Errorf("expected an error for a too big nogood")
+	}
+}
+
+func TestParseOPBProducts(t *testing.T) {
+	pb, err := ParseOPB(strings.NewReader("* #variable= 3 #constraint= 1 #product= 1 sizeproduct= 2\n+2 x1 ~x2 +1 x3 >= 2 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse products: %v", err)
+	}
+	if pb.NbVars != 4 || !pb.Reserved(3) {
+		t.Errorf("expected 3 vars and a reserved product var, got %d vars", pb.NbVars)
+	}
+	s := New(pb)
+	if nb := s.CountModels(); nb != 2 {
+		t.Errorf("expected 2 models, got %d", nb)
+	}
+	pb, err = ParseOPB(strings.NewReader("min: +2 x1 x2 -4 x3 +1 x2 x1 x3 ;\n+1 x1 +1 x2 >= 1 ;\n+1 x2 x1 +1 ~x3 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse products: %v", err)
+	}
+	if pb.NbVars != 5 {
+		t.Errorf("expected 3 vars and 2 product vars, got %d vars", pb.NbVars)
+	}
+	s = New(pb)
+	if res := s.Optimal(nil, nil); res.Status != Sat || res.Weight != -1 || len(res.Model) != 3 {
+		t.Errorf("expected cost -1 with a model of 3 vars, got %v", res)
+	}
+	for _, opb := range []string{
+		"+1 x1 ~x1 >= 1 ;\n",
+		"+1 x1 x2 x1 >= 1 ;\n",
+		"+1 x1 x2 +2 x2 x1 >= 1 ;\n",
+		"+1 x1 x2 +2 x0 >= 1 ;\n",
+	} {
+		if _, err := ParseOPB(strings.NewReader(opb)); err == nil {
+			t.Errorf("expected an error when parsing %q", opb)
+		}
+	}
+	pb, err = ParseWBO(strings.NewReader("soft: ;\n[3] +1 x1 x2 >= 1 ;\n+1 ~x1 +1 ~x2 >= 1 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse WBO products: %v", err)
+	}
+	if res := New(pb).Optimal(nil, nil); res.Status != Sat || res.Weight != 3 {
+		t.Errorf("expected cost 3, got %v", res)
+	}
+}
+
+func TestParseOPBKeepComments(t *testing.T) {
+	opb := "* #variable= 2 #constraint= 1\n* from some benchmark\n+1 x1 +2 x2 >= 2 ;\n"
+	pb, err := ParseOPBOptions(strings.NewReader(opb), ParseOptions{KeepComments: true})
+	if err != nil {
+		t.Fatalf("could not parse OPB: %v", err)
+	}
+	if pb.Metadata.Header != "* #variable= 2 #constraint= 1" || len(pb.Metadata.Comments) != 1 || pb.Metadata.Comments[0] != "from some benchmark" {
+		t.Errorf("invalid metadata %+v", pb.Metadata)
+	}
+	if str := pb.PBString(); !strings.HasPrefix(str, "* from some benchmark\n") {
+		t.Errorf("comment was not written in %q", str)
+	}
+	opb = "* #variable= 3 #constraint= 2\n* objective\nmin: 1 x1 +1 x3 ;\n1 x1 +1 x2 >= 1 ;\n* middle\n1 ~x2 +1 x3 >= 1 ;\n* end\n"
+	if pb, err = ParseOPBOptions(strings.NewReader(opb), ParseOptions{KeepComments: true}); err != nil {
+		t.Fatalf("could not parse OPB: %v", err)
+	}
+	var sb strings.Builder
+	if err := pb.WriteOPB(&sb); err != nil {
+		t.Fatalf("could not write OPB: %v", err)
+	}
+	if str := sb.String(); str != opb {
+		t.Errorf("comments were not written at their position: expected %q, got %q", opb, str)
+	}
+}
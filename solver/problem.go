@@ -2,6 +2,7 @@ package solver
 
 import (
 	"fmt"
+	"strings"
 )
 
 // A Problem is a list of clauses & a nb of vars.
@@ -13,6 +14,74 @@ type Problem struct {
 	Model      []decLevel // For each var, its inferred binding. 0 means unbound, 1 means bound to true, -1 means bound to false.
 	minLits    []Lit      // For an optimisation problem, the list of lits whose sum must be minimized
 	minWeights []int      // For an optimisation problem, the weight of each lit.
+	nbReserved int        // How many vars, at the end of the problem, were reserved for selectors or assumptions
+	// If the problem was found Unsat while parsing because two input constraints imply contradictory units,
+	// the description of these constraints. It is nil in all other cases.
+	Conflict    *UnitConflict
+	unitSources []int     // While parsing, the input constraint each unit comes from
+	Metadata    *Metadata // Comments and header of the file the problem was parsed from, if they were kept
+	// Anomalies that were found and normalized while parsing, if the parse options asked to be warned about them.
+	Warnings []*ParseError
+	// Vars of the independent support, also called sampling set, declared by "c ind" lines in CNF files, if any.
+	// Model counters and samplers only consider the bindings of these vars.
+	ProjectionVars []Var
+	// XOR constraints, declared by "x" lines in CNF files, if any. Solvers do not use this field: each XOR was
+	// translated into clauses of Clauses, using fresh reserved vars. It describes the original constraints,
+	// so that they can be checked or written again.
+	Xors []Xor
+}
+
+// Metadata gives information found in the file a problem was parsed from, that is not part of the problem itself,
+// such as its provenance, so that it is not lost when the problem is written again.
+type Metadata struct {
+	Header   string   // Header line, such as "p cnf 3 2" or, for OPB files, "* #variable= 3 #constraint= 2"
+	Comments []string // Comment lines, in the order of the file, without their leading "c" or "*"
+	// For each comment, its position in the file: how many statements precede it. Statements are the header
+	// and the clauses of CNF files, and the cost function and the constraint lines of OPB files.
+	// If Positions is nil, or shorter than Comments, the comments it does not cover precede all statements.
+	Positions []int
+}
+
+// clone returns a deep copy of md, or nil if md is nil.
+func (md *Metadata) clone() *Metadata {
+	if md == nil {
+		return nil
+	}
+	md2 := &Metadata{Header: md.Header}
+	if md.Comments != nil {
+		md2.Comments = make([]string, len(md.Comments))
+		copy(md2.Comments, md.Comments)
+	}
+	if md.Positions != nil {
+		md2.Positions = make([]int, len(md.Positions))
+		copy(md2.Positions, md.Positions)
+	}
+	return md2
+}
+
+// addComment adds the given comment line, without its leading comment marker, to md.
+// pos is the number of statements that precede it.
+func (md *Metadata) addComment(comment string, pos int) {
+	md.Comments = append(md.Comments, strings.TrimPrefix(comment, " "))
+	md.Positions = append(md.Positions, pos)
+}
+
+// position returns the position of the ith comment of md.
+func (md *Metadata) position(i int) int {
+	if i >= len(md.Positions) {
+		return 0
+	}
+	return md.Positions[i]
+}
+
+// A UnitConflict describes two input constraints that imply contradictory units.
+// For problems parsed from slices, constraints are identified by their index in the slice;
+// for problems parsed from CNF, OPB or WBO files, they are identified by their line number, starting at 1.
+// For CNF files, only contradictory unit clauses are reported.
+type UnitConflict struct {
+	Lit    Lit // Lit implied by the first constraint; its negation is implied by the second one
+	First  int // Constraint implying Lit
+	Second int // Constraint implying Lit.Negation(), appearing after the first one
 }
 
 // Optim returns true iff pb is an optimisation problem, ie
@@ -22,33 +91,18 @@ func (pb *Problem) Optim() bool {
 	return pb.minLits != nil
 }
 
-// CNF returns a DIMACS CNF representation of the problem.
+// CNF returns a DIMACS CNF representation of the problem, whose constraints are written in problem order.
+// If the comments of the file the problem was parsed from were kept, they are written first.
+// If the problem contains cardinality constraints, it is written in the CNF+ dialect.
 func (pb *Problem) CNF() string {
-	res := fmt.Sprintf("p cnf %d %d\n", pb.NbVars, len(pb.Clauses)+len(pb.Units))
-	for _, unit := range pb.Units {
-		res += fmt.Sprintf("%d 0\n", unit.Int())
-	}
-	for _, clause := range pb.Clauses {
-		res += fmt.Sprintf("%s\n", clause.CNF())
-	}
-	return res
+	return pb.CNFOrdered(ProblemOrder)
 }
 
-// PBString returns a representation of the problem as a pseudo-boolean problem.
+// PBString returns a representation of the problem as a pseudo-boolean problem,
+// whose constraints are written in problem order.
+// If the comments of the file the problem was parsed from were kept, they are written first.
 func (pb *Problem) PBString() string {
-	res := pb.costFuncString()
-	for _, unit := range pb.Units {
-		sign := ""
-		if !unit.IsPositive() {
-			sign = "~"
-			unit = unit.Negation()
-		}
-		res += fmt.Sprintf("1 %sx%d = 1 ;\n", sign, unit.Int())
-	}
-	for _, clause := range pb.Clauses {
-		res += fmt.Sprintf("%s\n", clause.PBString())
-	}
-	return res
+	return pb.PBStringOrdered(ProblemOrder)
 }
 
 // SetCostFunc sets the function to minimize when optimizing the problem.
@@ -62,22 +116,40 @@ func (pb *Problem) SetCostFunc(lits []Lit, weights []int) {
 	pb.minWeights = weights
 }
 
-// costFuncString returns a string representation of the cost function of the problem, if any, followed by a \n.
-// If there is no cost function, the empty string will be returned.
-func (pb *Problem) costFuncString() string {
-	if pb.minLits == nil {
-		return ""
+// SetDistanceCostFunc sets the function to minimize to the Hamming distance to the reference model ref,
+// i.e the number of vars whose binding is not the one they have in ref.
+// ref is a model of the first len(ref) vars of the problem, such as one returned by Solver.Model;
+// the other vars are not part of the cost function.
+// Minimizing the problem then finds a model as close as possible to ref, such as a reconfiguration
+// that changes as few bindings as possible once the problem changed.
+// It panics if ref has more vars than the problem.
+func (pb *Problem) SetDistanceCostFunc(ref []bool) {
+	if len(ref) > pb.NbVars {
+		panic("reference model has more vars than the problem")
 	}
+	lits := make([]Lit, len(ref))
+	for i, binding := range ref {
+		lits[i] = Var(i).SignedLit(binding) // True iff the var is not bound as in ref
+	}
+	pb.SetCostFunc(lits, nil)
+}
+
+// costFuncString returns a string representation of the cost function made of the given lits and weights, followed by a \n.
+// If all weights are 1, weights can be nil.
+func costFuncString(lits []Lit, weights []int) string {
 	res := "min: "
-	for i, lit := range pb.minLits {
+	for i, lit := range lits {
 		w := 1
-		if pb.minWeights != nil {
-			w = pb.minWeights[i]
+		if weights != nil {
+			w = weights[i]
 		}
 		sign := ""
 		if w >= 0 && i != 0 { // No plus sign for the first term or for negative terms.
 			sign = "+"
 		}
+		if i != 0 {
+			res += " "
+		}
 		val := lit.Int()
 		neg := ""
 		if val < 0 {
@@ -216,6 +288,7 @@ func (pb *Problem) simplifyCard() {
 						clauseSat = true
 						break
 					}
+					j++
 				} else {
 					nbLits--
 					c.Set(j, c.Get(nbLits))
@@ -253,6 +326,27 @@ func (pb *Problem) simplifyPB() {
 		i := 0
 		for i < len(pb.Clauses) {
 			c := pb.Clauses[i]
+			if c.isBig() {
+				units, status := c.reduceBig(pb.litStatus)
+				for _, lit := range units {
+					if pb.addUnit(lit); pb.Status == Unsat {
+						return
+					}
+					modified = true
+				}
+				switch status {
+				case Sat:
+					pb.Clauses[i] = pb.Clauses[len(pb.Clauses)-1]
+					pb.Clauses = pb.Clauses[:len(pb.Clauses)-1]
+				case Unsat:
+					pb.Clauses = nil
+					pb.Status = Unsat
+					return
+				default:
+					i++
+				}
+				continue
+			}
 			j := 0
 			card := c.Cardinality()
 			wSum := c.WeightSum()
@@ -302,6 +396,16 @@ func (pb *Problem) simplifyPB() {
 	}
 }
 
+// litStatus returns the status of lit according to the model of pb.
+func (pb *Problem) litStatus(lit Lit) Status {
+	if val := pb.Model[lit.Var()]; val == 0 {
+		return Indet
+	} else if (val == 1) == lit.IsPositive() {
+		return Sat
+	}
+	return Unsat
+}
+
 func (pb *Problem) addUnit(lit Lit) {
 	if lit.IsPositive() {
 		if pb.Model[lit.Var()] == -1 {
@@ -319,9 +423,13 @@ func (pb *Problem) addUnit(lit Lit) {
 	pb.Units = append(pb.Units, lit)
 }
 
+// addUnits adds the first nbLits lits of c as units. Lits that are already true are ignored.
 func (pb *Problem) addUnits(c *Clause, nbLits int) {
 	for i := 0; i < nbLits; i++ {
 		lit := c.Get(i)
+		if b := pb.Model[lit.Var()]; b != 0 && (b == 1) == lit.IsPositive() {
+			continue
+		}
 		pb.addUnit(lit)
 	}
 }
@@ -0,0 +1,144 @@
+package solver
+
+// This file deals with the purging of clauses that are simplified by top-level units.
+// Once a lit is known to be true no matter the assumptions, clauses it satisfies are useless,
+// and its negation can be removed from all the clauses it appears in.
+// Only units are considered: other lits bound at the top level can depend on assumptions,
+// and are forgotten when the next assumptions are made.
+// Problem clauses are not removed from the problem, so that their indices stay meaningful; they are just not watched anymore.
+// Strengthened problem clauses are replaced by new clauses rather than modified, since they can be shared with the user.
+
+// purgeUnits removes the clauses that are satisfied by units from the watch lists, and strengthens
+// propositional clauses containing the negation of a unit, unless no unit was learned since the last call.
+// Clauses are not strengthened if s.TrackAntecedents is true, and problem clauses are not strengthened
+// if s.TrackUsage is true either, since explanations and usage statistics are about clauses as they were given.
+// Clauses are not strengthened either while clause groups exist, since units may be retracted once a group is removed.
+// It must only be called at the top level, once all top-level lits were propagated.
+// It returns how many clauses were removed.
+func (s *Solver) purgeUnits() int {
+	if len(s.units) == s.nbPurgedUnits {
+		return 0
+	}
+	s.nbPurgedUnits = len(s.units)
+	isUnit := make([]bool, 2*s.nbVars)
+	for _, unit := range s.units {
+		isUnit[unit] = true
+	}
+	changed := make(map[*Clause]bool) // Clauses that must not be watched anymore
+	var strengthened []*Clause        // Clauses that must be watched again
+	strengthen := !s.TrackAntecedents && s.groups.nbLive == 0
+	strengthenPb := strengthen && !s.TrackUsage
+	nbRemoved := 0
+	for i, c := range s.wl.pbClauses {
+		if !c.propositional() {
+			continue
+		}
+		switch sat, nbFalse := c.unitStatus(isUnit); {
+		case sat:
+			changed[c] = true
+			nbRemoved++
+		case nbFalse > 0 && strengthenPb && c.Len()-nbFalse >= 2:
+			c2 := NewClause(c.strengthen(isUnit, false))
+			changed[c] = true
+			s.wl.pbClauses[i] = c2
+			strengthened = append(strengthened, c2)
+		}
+	}
+	j := 0
+	for _, c := range s.wl.learned {
+		switch sat, nbFalse := c.unitStatus(isUnit); {
+		case sat:
+			changed[c] = true
+			nbRemoved++
+			s.Stats.NbDeleted++
+			delete(s.groups.learnedAt, c)
+			continue
+		case nbFalse > 0 && strengthen && c.Len()-nbFalse >= 2:
+			changed[c] = true
+			c.lits = c.strengthen(isUnit, true)
+			strengthened = append(strengthened, c)
+		}
+		s.wl.learned[j] = c
+		j++
+	}
+	for k := j; k < len(s.wl.learned); k++ {
+		s.wl.learned[k] = nil
+	}
+	s.wl.learned = s.wl.learned[:j]
+	if len(changed) == 0 {
+		return 0
+	}
+	s.unwatchAll(changed)
+	for _, c := range strengthened {
+		s.sortWatched(c)
+		s.watchClause(c)
+		if s.Certified {
+			s.certifyLemma(c)
+		}
+	}
+	s.Stats.NbPurged += nbRemoved
+	return nbRemoved
+}
+
+// unitStatus returns whether c is satisfied by a unit and, if not, how many of its lits are the negation of a unit.
+// isUnit indicates, for each lit, whether it is a unit.
+func (c *Clause) unitStatus(isUnit []bool) (sat bool, nbFalse int) {
+	for _, lit := range c.lits {
+		if isUnit[lit] {
+			return true, 0
+		}
+		if isUnit[lit.Negation()] {
+			nbFalse++
+		}
+	}
+	return false, nbFalse
+}
+
+// strengthen returns the lits of c that are not the negation of a unit.
+// If inPlace is true, the lits are moved inside c's own slice; otherwise, a new slice is returned.
+func (c *Clause) strengthen(isUnit []bool, inPlace bool) []Lit {
+	var res []Lit
+	if inPlace {
+		res = c.lits[:0]
+	} else {
+		res = make([]Lit, 0, len(c.lits))
+	}
+	for _, lit := range c.lits {
+		if !isUnit[lit.Negation()] {
+			res = append(res, lit)
+		}
+	}
+	return res
+}
+
+// sortWatched moves lits that are not false in front of c, so that watching its first two lits is valid.
+func (s *Solver) sortWatched(c *Clause) {
+	j := 0
+	for i, lit := range c.lits {
+		if s.litStatus(lit) != Unsat {
+			c.lits[i], c.lits[j] = c.lits[j], c.lits[i]
+			j++
+		}
+	}
+}
+
+// unwatchAll removes the given clauses from the propositional watch lists, in a single pass.
+// Watchers of deleted clauses are removed, too.
+func (s *Solver) unwatchAll(clauses map[*Clause]bool) {
+	for _, lists := range [][][]watcher{s.wl.wlistBin, s.wl.wlist} {
+		for lit, wl := range lists {
+			j := 0
+			for _, w := range wl {
+				if !clauses[w.clause] && !w.clause.isDeleted() {
+					wl[j] = w
+					j++
+				}
+			}
+			for k := j; k < len(wl); k++ {
+				wl[k] = watcher{} // Let the GC collect removed clauses
+			}
+			lists[lit] = wl[:j]
+		}
+	}
+	s.wl.nbDeleted = 0
+}
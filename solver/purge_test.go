@@ -0,0 +1,57 @@
+package solver
+
+import "testing"
+
+// watched returns true iff c is in one of the propositional watch lists of s.
+func watched(s *Solver, c *Clause) bool {
+	for _, lists := range [][][]watcher{s.wl.wlistBin, s.wl.wlist} {
+		for _, wl := range lists {
+			for _, w := range wl {
+				if w.clause == c {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestPurgeUnits(t *testing.T) {
+	clauses := [][]int{{1, 2, 3}, {-1, 4, 5}, {-1, -4, 5, 6}, {2, 3, 4}}
+	s := New(ParseSlice(clauses))
+	sat, strengthened := s.wl.pbClauses[0], s.wl.pbClauses[2]
+	s.AppendClause(NewClause(IntsToLits(1)))
+	if nb := s.purgeUnits(); nb != 1 {
+		t.Fatalf("expected 1 purged clause, got %d", nb)
+	}
+	if nb := s.purgeUnits(); nb != 0 {
+		t.Errorf("expected no purge when no unit was learned, got %d", nb)
+	}
+	if watched(s, sat) {
+		t.Errorf("clause %s satisfied by unit 1 is still watched", sat.CNF())
+	}
+	if watched(s, strengthened) {
+		t.Errorf("clause %s was strengthened, but its old version is still watched", strengthened.CNF())
+	}
+	if c := s.wl.pbClauses[2]; c.CNF() != "-4 5 6 0" || !watched(s, c) {
+		t.Errorf("expected strengthened clause -4 5 6 to be watched, got %s", c.CNF())
+	}
+	if strengthened.Len() != 4 {
+		t.Errorf("original clause should not be modified, got %s", strengthened.CNF())
+	}
+	clauses = append(clauses, []int{1})
+	if nb, expected := s.CountModels(), New(ParseSlice(clauses)).CountModels(); nb != expected {
+		t.Errorf("expected %d models after purge, got %d", expected, nb)
+	}
+}
+
+func TestPurgeUnitsTracked(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2, 3}, {-1, 4, 5}}))
+	s.TrackAntecedents = true
+	c := s.wl.pbClauses[1]
+	s.AppendClause(NewClause(IntsToLits(1)))
+	s.purgeUnits()
+	if s.wl.pbClauses[1] != c || !watched(s, c) {
+		t.Errorf("clauses should not be strengthened when antecedents are tracked")
+	}
+}
@@ -0,0 +1,17 @@
+package solver
+
+import "math/rand"
+
+// Randomize makes the search of s depend on the given seed: the initial order of vars is shuffled,
+// and the polarity of each decision is chosen at random instead of following the polarity of the last binding.
+// It is typically used before enumerating models, so that consumers only reading the first models
+// don't always get the same neighborhood of models. Two solvers randomized with the same seed
+// on the same problem behave the same way.
+// Randomize must be called before solving. Preferred values (see Prefer) still have precedence.
+func (s *Solver) Randomize(seed int64) {
+	s.rng = rand.New(rand.NewSource(seed))
+	for v := range s.activity {
+		s.activity[v] += s.rng.Float64() * s.varInc
+	}
+	s.rebuildOrderHeap()
+}
@@ -0,0 +1,35 @@
+package solver
+
+import (
+	"fmt"
+	"testing"
+)
+
+// firstModels returns the first nb models enumerated on a problem with 6 independent pairs of vars,
+// after the solver was randomized with the given seed.
+func firstModels(seed int64, nb int) string {
+	s := New(ParseSlice([][]int{{1, 2}, {3, 4}, {5, 6}, {7, 8}, {9, 10}, {11, 12}}))
+	s.Randomize(seed)
+	models := make(chan []bool)
+	go s.Enumerate(models, nil)
+	var res []string
+	for model := range models {
+		if len(res) < nb {
+			res = append(res, fmt.Sprint(model))
+		}
+	}
+	return fmt.Sprint(res)
+}
+
+func TestRandomize(t *testing.T) {
+	if first, again := firstModels(1, 3), firstModels(1, 3); first != again {
+		t.Errorf("same seed gave different models: %s and %s", first, again)
+	}
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 10; seed++ {
+		seen[firstModels(seed, 3)] = true
+	}
+	if len(seen) == 1 {
+		t.Errorf("10 different seeds always gave the same first models")
+	}
+}
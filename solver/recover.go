@@ -0,0 +1,64 @@
+package solver
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// This file deals with the recovery of internal panics, for programs, such as long-running services,
+// that would rather get an error for a faulty instance than crash.
+// Recovery is opt-in: it is enabled by the RecoverPanics field of Solver and of ParseOptions.
+
+// A PanicError is returned when the solver or a parser panicked, and RecoverPanics was set.
+// It is a bug in the solver, that should be reported along with the stack.
+type PanicError struct {
+	Value interface{} // Value the panic was called with
+	Stack []byte      // Stack of the goroutine when it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("internal error: %v\n%s", e.Value, e.Stack)
+}
+
+// protect calls parse and returns its results, unless it panicked: then, the panic is returned as a *PanicError.
+func protect(parse func() (*Problem, error)) (*Problem, error) {
+	var pb *Problem
+	err := protectFunc(func() (err error) {
+		pb, err = parse()
+		return err
+	})
+	return pb, err
+}
+
+// protectFunc calls fn and returns its error, unless it panicked: then, the panic is returned as a *PanicError.
+func protectFunc(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// recoverPanic is deferred by the solving methods when s.RecoverPanics is true.
+// If they panicked, the panic is recorded, so that it is returned by s.Err, and the solver's status becomes Indet.
+// r is the value returned by recover, and recoverPanic returns true iff it is not nil.
+func (s *Solver) recoverPanic(r interface{}) bool {
+	if r == nil {
+		return false
+	}
+	s.err = &PanicError{Value: r, Stack: debug.Stack()}
+	s.status = Indet
+	return true
+}
+
+// Err returns the error that happened during the solving methods called so far, if any.
+// It is either a *PanicError, recorded if RecoverPanics is true and the solver panicked,
+// or the error that prevented the certificate from being written to CertFile.
+// After a *PanicError, the solver is in an inconsistent state: solving methods return Indet without searching.
+func (s *Solver) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.certErr
+}
@@ -0,0 +1,79 @@
+package solver
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// panicReader is an io.Reader that panics when read.
+type panicReader struct{}
+
+func (panicReader) Read(p []byte) (int, error) {
+	panic("cannot read")
+}
+
+func TestRecoverPanics(t *testing.T) {
+	newSolver := func() *Solver {
+		s := New(ParseSliceNb(randomCNF(rand.New(rand.NewSource(1)), 150, 640, 3), 150))
+		s.Terminate = func() bool { panic("cannot terminate") }
+		s.RecoverPanics = true
+		return s
+	}
+	s := newSolver()
+	if status := s.Solve(); status != Indet {
+		t.Errorf("expected Indet, got %v", status)
+	}
+	err, ok := s.Err().(*PanicError)
+	if !ok || err.Value != "cannot terminate" || !strings.Contains(string(err.Stack), "budgetExhausted") {
+		t.Fatalf("expected a panic error with a stack, got %v", s.Err())
+	}
+	s.Terminate = nil
+	if status := s.Solve(); status != Indet || s.Err() != err {
+		t.Errorf("solver should not search after a panic, got %v and error %v", status, s.Err())
+	}
+	s = newSolver()
+	results := make(chan Result, 10)
+	if res := s.Optimal(results, nil); res.Status != Indet || s.Err() == nil {
+		t.Errorf("expected Indet and an error, got %v and error %v", res.Status, s.Err())
+	}
+	for range results { // Must have been closed
+	}
+	if s = newSolver(); s.Minimize() != -1 || s.Err() == nil {
+		t.Errorf("expected -1 and an error from Minimize, got error %v", s.Err())
+	}
+	if s = newSolver(); s.CountModels() != 0 || s.Err() == nil {
+		t.Errorf("expected no model and an error from CountModels, got error %v", s.Err())
+	}
+	models := make(chan []bool, 10)
+	if s = newSolver(); s.Enumerate(models, nil) != 0 || s.Err() == nil {
+		t.Errorf("expected no model and an error from Enumerate, got error %v", s.Err())
+	}
+	for range models { // Must have been closed
+	}
+	opts := ParseOptions{RecoverPanics: true}
+	if _, err := ParseCNFOptions(panicReader{}, opts); err == nil {
+		t.Errorf("expected a panic error while parsing CNF")
+	} else if _, ok := err.(*PanicError); !ok {
+		t.Errorf("expected a panic error while parsing CNF, got %v", err)
+	}
+	parsers := map[string]func(io.Reader, ParseOptions) (*Problem, error){
+		"OPB": ParseOPBOptions, "WCNF": ParseWCNFOptions, "WBO": ParseWBOOptions,
+	}
+	for name, parse := range parsers {
+		if _, err := parse(panicReader{}, opts); err == nil {
+			t.Errorf("expected a panic error while parsing %s", name)
+		}
+	}
+	if _, err := ParseICNFOptions(panicReader{}, opts); err == nil {
+		t.Errorf("expected a panic error while parsing iCNF")
+	}
+	err2 := ParseCNFStream(strings.NewReader("p cnf 1 1\n1 0\n"), opts, func(i int, pb *Problem) error { panic("cannot solve") })
+	if perr, ok := err2.(*PanicError); !ok || perr.Value != "cannot solve" {
+		t.Errorf("expected a panic error while parsing a stream, got %v", err2)
+	}
+	if pb, err := ParseCNFOptions(strings.NewReader("p cnf 1 1\n1 0\n"), opts); err != nil || pb.NbVars != 1 {
+		t.Errorf("unexpected result: %v, %v", pb, err)
+	}
+}
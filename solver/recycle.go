@@ -0,0 +1,121 @@
+package solver
+
+import "fmt"
+
+// This file deals with the recycling of vars in long-lived incremental sessions, where vars are typically introduced
+// for temporary constraints, such as the encoding of a query or the clauses of a group, and are not used anymore
+// once these constraints were removed. Since the arrays of a solver are indexed by vars, always allocating fresh vars
+// would make them grow without bound. Recycling is opt-in: CollectVars detects user-level vars that do not appear
+// in any problem clause, unit, assumption or cost function, forgets what was learned about them,
+// and NewVar hands them back before allocating fresh vars.
+// Learned clauses containing a collected var can be removed safely: since no problem clause contains the var,
+// they are implied by the problem clauses once the var is removed from them.
+
+// CollectVars makes the user-level vars that do not appear in the problem solved by s anymore free for reuse,
+// and returns them. A var is used if it appears in a problem clause, including the clauses of live groups,
+// in a unit, in the current assumptions or in the cost function. Learned clauses containing a free var are removed,
+// and its activity and polarity are reset. Reserved vars are never collected.
+// Free vars are unconstrained, so their binding in models is meaningless. They must not be used in clauses
+// or assumptions again until NewVar returns them, since NewVar may return them for another purpose.
+// The current assumptions, if any, are kept.
+// An error is returned if s is certified, since certificates cannot describe the reuse of a var.
+func (s *Solver) CollectVars() ([]Var, error) {
+	if s.Certified {
+		return nil, fmt.Errorf("cannot collect vars of a certified solver")
+	}
+	if s.wl.wlist == nil { // The problem s was made from was unsat: nothing can be reused
+		return nil, nil
+	}
+	used := make([]bool, s.nbVars)
+	for _, c := range s.wl.pbClauses {
+		for i := 0; i < c.Len(); i++ {
+			used[c.Get(i).Var()] = true
+		}
+	}
+	for _, lits := range [][]Lit{s.units, s.minLits, s.hypothesis} {
+		for _, lit := range lits {
+			used[lit.Var()] = true
+		}
+	}
+	for v, assumed := range s.assumptions {
+		if assumed {
+			used[v] = true
+		}
+	}
+	for _, v := range s.freeVars { // Already collected
+		used[v] = true
+	}
+	var collected []Var
+	for v := 0; v < s.nbUserVars; v++ {
+		if !used[v] {
+			collected = append(collected, Var(v))
+		}
+	}
+	if len(collected) == 0 {
+		return nil, nil
+	}
+	free := make([]bool, s.nbVars)
+	for _, v := range collected {
+		free[v] = true
+	}
+	j := 0
+	for _, c := range s.wl.learned {
+		if c.containsAny(free) {
+			c.markDeleted()
+			s.Stats.NbDeleted++
+			delete(s.antecedents.clauses, c)
+			delete(s.lemmaUses, c)
+			delete(s.groups.learnedAt, c)
+			continue
+		}
+		s.wl.learned[j] = c
+		j++
+	}
+	for k := j; k < len(s.wl.learned); k++ {
+		s.wl.learned[k] = nil
+	}
+	s.wl.learned = s.wl.learned[:j]
+	for _, v := range collected {
+		s.activity[v] = 0
+		s.polarity[v] = false
+		if int(v) < len(s.preferred) {
+			s.preferred[v] = Unassigned
+		}
+		if s.priority != nil {
+			s.priority[v] = false
+		}
+		delete(s.antecedents.units, v)
+	}
+	s.freeVars = append(s.freeVars, collected...)
+	s.Stats.NbRecycled += len(collected)
+	s.coreCache = coreCache{} // Cached assumptions may contain collected vars, that will stand for something else
+	s.nbPurgedUnits = 0
+	s.binReductionMark = 0
+	s.initQueues()
+	s.rewatch()
+	s.resetTopLevel()
+	return collected, nil
+}
+
+// NewVar returns a var that can be used in new clauses: a var freed by CollectVars, if any, or else a fresh var.
+// As for vars added by AppendClause, a fresh var is considered reserved if vars were reserved before.
+func (s *Solver) NewVar() Var {
+	if n := len(s.freeVars); n > 0 {
+		v := s.freeVars[n-1]
+		s.freeVars = s.freeVars[:n-1]
+		return v
+	}
+	v := Var(s.nbVars)
+	s.newVar(v)
+	return v
+}
+
+// containsAny returns true iff c contains a lit of one of the given vars.
+func (c *Clause) containsAny(vars []bool) bool {
+	for _, lit := range c.lits {
+		if vars[lit.Var()] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCollectVars(t *testing.T) {
+	s := New(ParseSliceNb([][]int{{1, 2}, {-1, 3}}, 4))
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	vars, err := s.CollectVars()
+	if err != nil {
+		t.Fatalf("could not collect vars: %v", err)
+	}
+	if !reflect.DeepEqual(vars, []Var{3}) {
+		t.Errorf("expected var 4 to be collected, got %v", vars)
+	}
+	if vars, _ := s.CollectVars(); vars != nil {
+		t.Errorf("expected no var to be collected twice, got %v", vars)
+	}
+	v := s.NewVar()
+	if v != 3 {
+		t.Fatalf("expected var 4 to be reused, got %d", v.Int())
+	}
+	id := s.AddClauseGroup([]*Clause{NewClause([]Lit{v.Lit(), IntToLit(-1)}), NewClause([]Lit{v.SignedLit(true), IntToLit(-2)})})
+	if s.Assume([]Lit{IntToLit(1)}); s.Solve() != Sat || !s.Model()[3] {
+		t.Fatalf("expected a model binding var 4 to true")
+	}
+	if vars, _ := s.CollectVars(); vars != nil {
+		t.Errorf("expected no var to be collected while the group exists, got %v", vars)
+	}
+	if err := s.RemoveClauseGroup(id); err != nil {
+		t.Fatalf("could not remove group: %v", err)
+	}
+	if vars, _ := s.CollectVars(); !reflect.DeepEqual(vars, []Var{3}) {
+		t.Errorf("expected var 4 to be collected again, got %v", vars)
+	}
+	if s.NewVar() != 3 || s.NewVar() != 4 || s.Stats.NbRecycled != 2 {
+		t.Errorf("expected var 4 to be reused, then var 5 to be allocated, and 2 vars to be recycled, got %d", s.Stats.NbRecycled)
+	}
+	s = New(ParseSlice([][]int{{1, 2}}))
+	s.Certified = true
+	if _, err := s.CollectVars(); err == nil {
+		t.Errorf("expected an error for a certified solver")
+	}
+}
+
+func TestCollectVarsIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	nbBase := 20
+	base := randomCNF(rng, nbBase, 60, 3)
+	s := New(ParseSliceNb(base, nbBase))
+	for step := 0; step < 100; step++ {
+		// Each query uses its own vars, encoding a random problem on them, linked to the base problem.
+		locals := make([]int, 10)
+		for i := range locals {
+			locals[i] = int(s.NewVar().Int())
+		}
+		var cnf [][]int
+		for _, c := range randomCNF(rng, len(locals), 30, 3) {
+			clause := make([]int, len(c), len(c)+1)
+			for j, val := range c {
+				if val > 0 {
+					clause[j] = locals[val-1]
+				} else {
+					clause[j] = -locals[-val-1]
+				}
+			}
+			cnf = append(cnf, append(clause, rng.Intn(nbBase)+1))
+		}
+		clauses := make([]*Clause, len(cnf))
+		for i, c := range cnf {
+			lits := make([]Lit, len(c))
+			for j, val := range c {
+				lits[j] = IntToLit(int32(val))
+			}
+			clauses[i] = NewClause(lits)
+		}
+		id := s.AddClauseGroup(clauses)
+		all := append(base[:len(base):len(base)], cnf...)
+		expected, _, err := solveCNF(all, s.nbVars)
+		if err != nil {
+			t.Fatalf("reference solver failed: %v", err)
+		}
+		if status := s.Solve(); status != expected {
+			t.Fatalf("step %d: expected %v, got %v", step, expected, status)
+		} else if status == Sat && !satisfies(all, s.Model()) {
+			t.Fatalf("step %d: model does not satisfy the clauses", step)
+		}
+		if err := s.RemoveClauseGroup(id); err != nil {
+			t.Fatalf("could not remove group: %v", err)
+		}
+		if _, err := s.CollectVars(); err != nil {
+			t.Fatalf("could not collect vars: %v", err)
+		}
+		for _, c := range s.wl.learned {
+			for j := 0; j < c.Len(); j++ {
+				if int(c.Get(j).Var()) >= nbBase {
+					t.Fatalf("step %d: learned clause %v contains a collected var", step, c)
+				}
+			}
+		}
+	}
+	if s.nbVars != nbBase+10 {
+		t.Errorf("expected vars to be reused, got %d vars", s.nbVars)
+	}
+}
@@ -0,0 +1,108 @@
+package solver
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// This file deals with structured benchmark reports, that external tools can read to draw plots,
+// such as cactus plots giving the number of instances each configuration solves within a given time.
+// Reports are written either as CSV, with a header line, or as JSON lines, one run per line, such as
+//
+//	{"config":"default","instance":"php6","status":"UNSAT","time":0.012,"conflicts":1432,...}
+
+// A RunRecord is a flattened run, along with the names of its configuration and of its instance.
+type RunRecord struct {
+	Config    string  `json:"config"`
+	Instance  string  `json:"instance"`
+	Status    string  `json:"status"`
+	Time      float64 `json:"time"` // Wall time, in seconds
+	Conflicts int     `json:"conflicts"`
+	Decisions int     `json:"decisions"`
+	Restarts  int     `json:"restarts"`
+	Learned   int     `json:"learned"`
+	Allocated uint64  `json:"allocated"`  // Bytes allocated during the run, not the peak memory usage
+	ProofSize int     `json:"proof_size"` // Size of the certificate in bytes, if any
+}
+
+// csvHeader is the first line of CSV reports, naming the columns in the order of the fields of RunRecord.
+var csvHeader = []string{"config", "instance", "status", "time", "conflicts", "decisions", "restarts", "learned", "allocated", "proof_size"}
+
+// NewRunRecord returns the record of run r, made with the configuration called config on the instance called instance.
+func NewRunRecord(config, instance string, r Run) RunRecord {
+	return RunRecord{
+		Config:    config,
+		Instance:  instance,
+		Status:    r.Status.String(),
+		Time:      r.Duration.Seconds(),
+		Conflicts: r.Stats.NbConflicts,
+		Decisions: r.Stats.NbDecisions,
+		Restarts:  r.Stats.NbRestarts,
+		Learned:   r.Stats.NbLearned,
+		Allocated: r.Allocated,
+		ProofSize: r.ProofSize,
+	}
+}
+
+// Benchmark runs cfg on each instance, one after the other, and returns the record of each run.
+func Benchmark(cfg Config, instances []Instance) []RunRecord {
+	records := make([]RunRecord, len(instances))
+	for i, inst := range instances {
+		records[i] = NewRunRecord(cfg.Name, inst.Name, RunConfig(cfg, inst.Problem))
+	}
+	return records
+}
+
+// Records returns the records of all the runs of cmp: the runs of A, then the runs of B.
+func (cmp *Comparison) Records() []RunRecord {
+	records := make([]RunRecord, 0, 2*len(cmp.Instances))
+	for i, name := range cmp.Instances {
+		records = append(records, NewRunRecord(cmp.A, name, cmp.RunsA[i]))
+	}
+	for i, name := range cmp.Instances {
+		records = append(records, NewRunRecord(cmp.B, name, cmp.RunsB[i]))
+	}
+	return records
+}
+
+// WriteRecordsCSV writes the given records on w as CSV, after a header line naming the columns.
+func WriteRecordsCSV(w io.Writer, records []RunRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.Config,
+			r.Instance,
+			r.Status,
+			strconv.FormatFloat(r.Time, 'f', -1, 64),
+			strconv.Itoa(r.Conflicts),
+			strconv.Itoa(r.Decisions),
+			strconv.Itoa(r.Restarts),
+			strconv.Itoa(r.Learned),
+			strconv.FormatUint(r.Allocated, 10),
+			strconv.Itoa(r.ProofSize),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteRecordsJSON writes the given records on w as JSON lines, one record per line.
+func WriteRecordsJSON(w io.Writer, records []RunRecord) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
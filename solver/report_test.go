@@ -0,0 +1,66 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBenchmarkReport(t *testing.T) {
+	instances := []Instance{
+		{Name: "pigeons5", Problem: ParseSlice(pigeons(5))},
+		{Name: "sat", Problem: ParseSlice([][]int{{1, 2}, {-1, 2}})},
+	}
+	certified := Config{Name: "certified", Setup: func(s *Solver) { s.Certified = true }}
+	records := Benchmark(certified, instances)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if r := records[0]; r.Config != "certified" || r.Instance != "pigeons5" || r.Status != "UNSAT" || r.Conflicts == 0 || r.ProofSize == 0 || r.Allocated == 0 {
+		t.Errorf("invalid record %+v", r)
+	}
+	if r := records[1]; r.Status != "SAT" {
+		t.Errorf("invalid record %+v", r)
+	}
+	if r := Benchmark(Config{Name: "default"}, instances[:1])[0]; r.ProofSize != 0 || r.Conflicts != records[0].Conflicts {
+		t.Errorf("expected no proof and the same conflicts, got %+v", r)
+	}
+	var buf bytes.Buffer
+	if err := WriteRecordsCSV(&buf, records); err != nil {
+		t.Fatalf("could not write CSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("could not read CSV: %v", err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[0], csvHeader) || rows[1][1] != "pigeons5" || rows[2][2] != "SAT" {
+		t.Errorf("invalid CSV %v", rows)
+	}
+	buf.Reset()
+	if err := WriteRecordsJSON(&buf, records); err != nil {
+		t.Fatalf("could not write JSON: %v", err)
+	}
+	var read []RunRecord
+	for sc := bufio.NewScanner(&buf); sc.Scan(); {
+		var r RunRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("could not read JSON line %q: %v", sc.Text(), err)
+		}
+		read = append(read, r)
+	}
+	if !reflect.DeepEqual(read, records) {
+		t.Errorf("expected %+v, got %+v", records, read)
+	}
+}
+
+func TestComparisonRecords(t *testing.T) {
+	instances := []Instance{{Name: "pigeons4", Problem: ParseSlice(pigeons(4))}}
+	cmp := Compare(Config{Name: "a"}, Config{Name: "b"}, instances)
+	records := cmp.Records()
+	if len(records) != 2 || records[0].Config != "a" || records[1].Config != "b" || records[1].Instance != "pigeons4" {
+		t.Errorf("invalid records %+v", records)
+	}
+}
@@ -0,0 +1,46 @@
+package solver
+
+// This file deals with vars reserved for internal purposes, typically selectors or assumption literals.
+// Reserved vars are always allocated after all user-level vars, so that user-level vars stay contiguous,
+// and they are never part of the models returned by a solver.
+// Preprocessing must not eliminate them, as they are meant to be used in assumptions.
+
+// ReserveVars adds n fresh vars to pb, after all existing ones, and returns them.
+// Reserved vars do not appear in the models returned by solvers working on pb.
+func (pb *Problem) ReserveVars(n int) []Var {
+	res := make([]Var, n)
+	for i := range res {
+		res[i] = Var(pb.NbVars + i)
+	}
+	pb.NbVars += n
+	pb.nbReserved += n
+	pb.Model = append(pb.Model, make([]decLevel, n)...)
+	return res
+}
+
+// Reserved returns true iff v was reserved.
+func (pb *Problem) Reserved(v Var) bool {
+	return int(v) >= pb.NbVars-pb.nbReserved
+}
+
+// ReserveVars adds n fresh vars to the problem solved by s, after all existing ones, and returns them.
+// Reserved vars do not appear in the models returned by s.
+// Vars added to the problem after the reservation, if any, are considered reserved as well,
+// so all user-level vars should be declared before reserving vars.
+func (s *Solver) ReserveVars(n int) []Var {
+	res := make([]Var, n)
+	for i := range res {
+		res[i] = Var(s.nbVars + i)
+	}
+	if n > 0 {
+		nbUserVars := s.nbUserVars
+		s.newVar(res[n-1])
+		s.nbUserVars = nbUserVars
+	}
+	return res
+}
+
+// Reserved returns true iff v was reserved, either directly or because it was added after a reservation.
+func (s *Solver) Reserved(v Var) bool {
+	return int(v) >= s.nbUserVars
+}
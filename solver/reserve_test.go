@@ -0,0 +1,35 @@
+package solver
+
+import "testing"
+
+func TestReserveVars(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {-1, 3}})
+	sels := pb.ReserveVars(2)
+	if len(sels) != 2 || sels[0] != 3 || sels[1] != 4 || pb.NbVars != 5 {
+		t.Fatalf("invalid reserved vars %v for problem with %d vars", sels, pb.NbVars)
+	}
+	if pb.Reserved(2) || !pb.Reserved(3) {
+		t.Errorf("invalid reservation status")
+	}
+	s := New(pb)
+	s.AppendClause(NewClause([]Lit{IntToLit(-2), sels[0].Lit()})) // sel0 -> ¬2
+	s.AppendClause(NewClause([]Lit{IntToLit(3), sels[1].Lit()}))  // sel1 -> 3
+	if s.Assume([]Lit{sels[0].Lit().Negation(), sels[1].Lit().Negation()}) == Unsat || s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	model := s.Model()
+	if len(model) != 3 {
+		t.Fatalf("reserved vars should not be part of model %v", model)
+	}
+	if !model[0] || model[1] || !model[2] {
+		t.Errorf("invalid model %v", model)
+	}
+	sel := s.ReserveVars(1)[0]
+	if sel != 5 || !s.Reserved(sel) || s.Reserved(0) {
+		t.Errorf("invalid var %d reserved by solver", sel)
+	}
+	s.AppendClause(NewClause([]Lit{IntToLit(1), sel.Lit()}))
+	if s.Assume([]Lit{sel.Lit().Negation()}) == Unsat || s.Solve() != Sat || !s.Model()[0] || len(s.Model()) != 3 {
+		t.Errorf("invalid model after reserving var from solver")
+	}
+}
@@ -0,0 +1,113 @@
+package solver
+
+import "sync"
+
+// This file deals with the cooperative scheduling of several solvers in the same goroutine.
+// Each solver is given, in turn, a quantum of conflicts, after which it stops searching and lets the next one run.
+// Since solvers keep what they learned between two calls to Solve, a problem is solved in several slices,
+// and a heavy problem cannot starve the other ones, as it would if problems were solved one after the other.
+
+// defaultQuantum is the number of conflicts each solver is given in turn, unless specified otherwise.
+const defaultQuantum = 1000
+
+// A Scheduler solves several problems in turn, by slices of a given number of conflicts.
+// Its zero value is ready to use. Tasks can be added from any goroutine, even while the scheduler is running.
+type Scheduler struct {
+	// Quantum is the number of conflicts each solver is given in turn.
+	// If it is not strictly positive, defaultQuantum is used.
+	Quantum int
+	mu      sync.Mutex
+	tasks   []*Task // Tasks that are not done yet, in the order they are run
+}
+
+// A Task is a problem being solved by a Scheduler.
+type Task struct {
+	s            *Solver
+	maxConflicts int           // Maximum number of conflicts the solver was given by its user, if any
+	status       Status        // Status of the problem, once done
+	err          error         // Error that stopped the solver, if any
+	done         chan struct{} // Closed once the problem was solved, the solver's own budget was exhausted or it failed
+}
+
+// Add adds a task solving the problem of s to the scheduler, and returns it.
+// The budget of s, such as its Deadline or MaxConflicts, is respected: once exhausted, the task is done,
+// and its status is Indet. If s has RecoverPanics and panics, the task is done as well, its status is Indet,
+// and Err returns the panic. s must not be used by anything else until the task is done.
+func (sc *Scheduler) Add(s *Solver) *Task {
+	t := &Task{s: s, maxConflicts: s.MaxConflicts, done: make(chan struct{})}
+	sc.mu.Lock()
+	sc.tasks = append(sc.tasks, t)
+	sc.mu.Unlock()
+	return t
+}
+
+// Step runs each task that is not done yet for one quantum, and returns the number of tasks that are still not done.
+func (sc *Scheduler) Step() int {
+	quantum := sc.Quantum
+	if quantum <= 0 {
+		quantum = defaultQuantum
+	}
+	sc.mu.Lock()
+	tasks := append([]*Task(nil), sc.tasks...)
+	sc.mu.Unlock()
+	finished := make(map[*Task]bool)
+	for _, t := range tasks {
+		if t.run(quantum) {
+			finished[t] = true
+		}
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	j := 0
+	for _, t := range sc.tasks { // Tasks might have been added in the meantime
+		if !finished[t] {
+			sc.tasks[j] = t
+			j++
+		}
+	}
+	sc.tasks = sc.tasks[:j]
+	return j
+}
+
+// Run runs the tasks in turn until they are all done, including the ones that are added while it is running.
+func (sc *Scheduler) Run() {
+	for sc.Step() != 0 {
+	}
+}
+
+// run solves the problem of t for at most quantum more conflicts, and returns true iff t is done.
+func (t *Task) run(quantum int) bool {
+	s := t.s
+	limit := s.Stats.NbConflicts + quantum
+	if t.maxConflicts > 0 && t.maxConflicts < limit {
+		limit = t.maxConflicts
+	}
+	s.MaxConflicts = limit
+	status := s.Solve()
+	s.MaxConflicts = t.maxConflicts
+	t.err = s.Err()
+	if status == Indet && t.err == nil && !s.budgetExhausted() { // Only the quantum was exhausted
+		return false
+	}
+	t.status = status
+	close(t.done)
+	return true
+}
+
+// Done returns a channel that is closed once t is done.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait waits until t is done, and returns the status of its problem.
+// The status is Indet if the solver's own budget was exhausted before the problem was solved.
+func (t *Task) Wait() Status {
+	<-t.done
+	return t.status
+}
+
+// Err returns the error that stopped the solver of t, once t is done, such as a recovered panic, or nil.
+func (t *Task) Err() error {
+	<-t.done
+	return t.err
+}
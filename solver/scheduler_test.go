@@ -0,0 +1,52 @@
+package solver
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestScheduler(t *testing.T) {
+	sc := Scheduler{Quantum: 100}
+	hard := sc.Add(New(parseFile(t, "testcnf/125.cnf", ParseCNF)))
+	easy := sc.Add(New(parseFile(t, "testcnf/25.cnf", ParseCNF)))
+	limited := New(parseFile(t, "testcnf/125.cnf", ParseCNF))
+	limited.MaxConflicts = 150
+	budget := sc.Add(limited)
+	if n := sc.Step(); n != 2 {
+		t.Errorf("expected 2 tasks left after the first step, got %d", n)
+	}
+	select {
+	case <-easy.Done():
+	default:
+		t.Fatalf("easy task should be done after the first step")
+	}
+	if status := easy.Wait(); status != Sat {
+		t.Errorf("expected Sat for the easy task, got %v", status)
+	}
+	if conflicts := hard.s.Stats.NbConflicts; conflicts > 200 {
+		t.Errorf("hard task should have been stopped after its quantum, got %d conflicts", conflicts)
+	}
+	late := sc.Add(New(parseFile(t, "testcnf/25.cnf", ParseCNF)))
+	sc.Run()
+	if status := hard.Wait(); status != Unsat {
+		t.Errorf("expected Unsat for the hard task, got %v", status)
+	}
+	if status := budget.Wait(); status != Indet || limited.MaxConflicts != 150 {
+		t.Errorf("expected Indet and an unchanged budget, got %v and %d", status, limited.MaxConflicts)
+	}
+	if status := late.Wait(); status != Sat {
+		t.Errorf("expected Sat for the task added later, got %v", status)
+	}
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	var sc Scheduler
+	s := New(ParseSliceNb(randomCNF(rand.New(rand.NewSource(1)), 150, 640, 3), 150))
+	s.Terminate = func() bool { panic("cannot terminate") }
+	s.RecoverPanics = true
+	task := sc.Add(s)
+	sc.Run()
+	if _, ok := task.Err().(*PanicError); !ok || task.Wait() != Indet {
+		t.Errorf("expected Indet and a panic error, got %v and %v", task.Wait(), task.Err())
+	}
+}
@@ -0,0 +1,148 @@
+//go:build !noserver
+// +build !noserver
+
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file deals with the line-based protocol used to drive an incremental solver from another process,
+// through its standard input and output. Each command is written on its own line, and is answered by the server:
+//
+//	add <lits> 0       adds the clause made of the given DIMACS lits to the problem; answered by "ok"
+//	assume <lits> 0    sets the assumptions of the next solve command; answered by "ok"
+//	solve              solves the problem under the current assumptions, then forgets them;
+//	                   answered by "s SATISFIABLE" followed by the model on a "v" line,
+//	                   or by "s UNSATISFIABLE" followed by the failed assumptions on a "u" line
+//	quit               stops the server
+//
+// Empty lines and lines starting with "c" are ignored. Invalid commands are answered by "e" followed by an error message,
+// and do not stop the server. So are lits about vars bigger than the limit of the session (see IncrementalSolver.MaxVar):
+// since the solver allocates memory for each var, huge vars could otherwise exhaust memory. As in iCNF files, learned clauses are kept from one solve command to the next.
+// Internal panics of the solver are recovered, so that a faulty problem cannot stop the server: the command that
+// panicked, and every solve command after it, are answered by an error.
+//
+// The server is an optional subsystem: it is left out of builds using the noserver build tag.
+
+// hasServer is true iff the server protocols are part of the build.
+const hasServer = true
+
+// Serve reads commands from r and writes the corresponding answers to w, until the quit command or the end of r.
+// The answer to each command is flushed before reading the next one, so r and w can be pipes.
+// Lits about vars bigger than DefaultMaxVar are rejected.
+func Serve(r io.Reader, w io.Writer) error {
+	return serve(NewIncrementalSolver(), r, w)
+}
+
+// Serve is like the Serve function, but commands are run on is, whose MaxVar field limits the vars of the session.
+func (is *IncrementalSolver) Serve(r io.Reader, w io.Writer) error {
+	return serve(is, r, w)
+}
+
+// serve is like Serve, with the given incremental solver.
+func serve(is *IncrementalSolver, r io.Reader, w io.Writer) error {
+	is.s.RecoverPanics = true
+	bw := bufio.NewWriter(w)
+	sc := NewScanner(r, 0)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || fields[0][0] == 'c' {
+			continue
+		}
+		if fields[0] == "quit" {
+			break
+		}
+		if err := protectFunc(func() error { return runCommand(is, bw, fields) }); err != nil {
+			if perr, ok := err.(*PanicError); ok { // Its stack would span several lines
+				err = fmt.Errorf("internal error: %v", perr.Value)
+			}
+			fmt.Fprintf(bw, "e %v\n", err)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// runCommand runs on is the text command made of the given fields, and writes its answer to w.
+func runCommand(is *IncrementalSolver, w io.Writer, fields []string) error {
+	switch fields[0] {
+	case "add":
+		lits, err := parseServerLits(fields)
+		if err != nil {
+			return err
+		}
+		if err := is.CheckLits(lits); err != nil {
+			return err
+		}
+		is.AddClause(lits)
+	case "assume":
+		lits, err := parseServerLits(fields)
+		if err != nil {
+			return err
+		}
+		if err := is.CheckLits(lits); err != nil {
+			return err
+		}
+		is.Assume(lits)
+	case "solve":
+		if len(fields) != 1 {
+			return fmt.Errorf("unexpected arguments to solve")
+		}
+		status := is.Solve()
+		if err := is.s.Err(); err != nil {
+			return err
+		}
+		writeAnswer(w, status, is.Model(), is.Core())
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", fields[0])
+	}
+	fmt.Fprintln(w, "ok")
+	return nil
+}
+
+// writeAnswer writes the answer to a text solve command, whose result is status, with the given model or core.
+func writeAnswer(w io.Writer, status Status, model, core []int) {
+	switch status {
+	case Sat:
+		fmt.Fprintln(w, "s SATISFIABLE")
+		fmt.Fprintf(w, "v %s0\n", intsString(model))
+	case Unsat:
+		fmt.Fprintln(w, "s UNSATISFIABLE")
+		fmt.Fprintf(w, "u %s0\n", intsString(core))
+	default:
+		fmt.Fprintln(w, "s UNKNOWN")
+	}
+}
+
+// intsString returns the given ints, each of them followed by a space.
+func intsString(vals []int) string {
+	var sb strings.Builder
+	for _, val := range vals {
+		fmt.Fprintf(&sb, "%d ", val)
+	}
+	return sb.String()
+}
+
+// parseServerLits parses the 0-terminated DIMACS lits following the command name in fields.
+func parseServerLits(fields []string) ([]int, error) {
+	if fields[len(fields)-1] != "0" {
+		return nil, fmt.Errorf("%s command is not terminated by 0", fields[0])
+	}
+	lits := make([]int, len(fields)-2)
+	for i, field := range fields[1 : len(fields)-1] {
+		val, err := strconv.Atoi(field)
+		if err != nil || val == 0 || val > maxVar || val < -maxVar {
+			return nil, fmt.Errorf("invalid literal %q", field)
+		}
+		lits[i] = val
+	}
+	return lits, nil
+}
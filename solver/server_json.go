@@ -0,0 +1,180 @@
+//go:build !noserver
+// +build !noserver
+
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// This file deals with the JSON-RPC variant of the protocol implemented in server.go.
+// Each line is a JSON-RPC 2.0 request, such as
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"clause": [1, -2]}}
+//
+// and is answered by a JSON-RPC 2.0 response, on its own line. The methods are:
+//
+//	add      params {"clause": [lits]}; adds the given clause to the problem; result true
+//	assume   params {"lits": [lits]}; sets the assumptions of the next solve call; result true
+//	solve    no params; solves the problem under the current assumptions, then forgets them;
+//	         result {"status": "SAT" or "UNSAT", "model": [lits], "core": [lits], "stats": {...}}
+//	quit     no params; stops the server; result true
+//
+// The model is only given when the problem is Sat, and the core, i.e the failed assumptions, when it is Unsat.
+// A missing core means the problem is unsat, no matter the assumptions. Requests without an id are notifications,
+// and are not answered. As with Serve, internal panics are recovered, and answered by an internal error,
+// and lits about vars bigger than the limit of the session are answered by an invalid params error.
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// An rpcRequest is a JSON-RPC 2.0 request.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// An rpcResponse is a JSON-RPC 2.0 response. Exactly one of Result and Error is set.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// An rpcError describes why a request failed.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// An rpcSolveResult is the result of the solve method.
+type rpcSolveResult struct {
+	Status string `json:"status"`
+	Model  []int  `json:"model,omitempty"`
+	Core   []int  `json:"core,omitempty"`
+	Stats  Stats  `json:"stats"`
+}
+
+// ServeJSON is like Serve, but requests are JSON-RPC 2.0 requests, one per line, and so are the responses.
+func ServeJSON(r io.Reader, w io.Writer) error {
+	return serveJSON(NewIncrementalSolver(), r, w)
+}
+
+// ServeJSON is like the ServeJSON function, but requests are run on is, whose MaxVar field limits the vars of the session.
+func (is *IncrementalSolver) ServeJSON(r io.Reader, w io.Writer) error {
+	return serveJSON(is, r, w)
+}
+
+// serveJSON is like ServeJSON, with the given incremental solver.
+func serveJSON(is *IncrementalSolver, r io.Reader, w io.Writer) error {
+	is.s.RecoverPanics = true
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	sc := NewScanner(r, 0)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp.Error = &rpcError{Code: rpcParseError, Message: err.Error()}
+		} else {
+			if req.ID != nil {
+				resp.ID = req.ID
+			}
+			err := protectFunc(func() error {
+				resp.Result, resp.Error = callRPC(is, req)
+				return nil
+			})
+			if err != nil {
+				resp.Result, resp.Error = nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+			if req.ID == nil && req.JSONRPC == "2.0" { // Notification
+				if req.Method == "quit" {
+					break
+				}
+				continue
+			}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if req.Method == "quit" && resp.Error == nil {
+			break
+		}
+	}
+	return sc.Err()
+}
+
+// callRPC runs the given request on is, and returns either its result or the error that prevented it.
+func callRPC(is *IncrementalSolver, req rpcRequest) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+	}
+	switch req.Method {
+	case "add":
+		var params struct {
+			Clause []int `json:"clause"`
+		}
+		if err := decodeParams(is, req.Params, &params, &params.Clause); err != nil {
+			return nil, err
+		}
+		is.AddClause(params.Clause)
+		return true, nil
+	case "assume":
+		var params struct {
+			Lits []int `json:"lits"`
+		}
+		if err := decodeParams(is, req.Params, &params, &params.Lits); err != nil {
+			return nil, err
+		}
+		is.Assume(params.Lits)
+		return true, nil
+	case "solve":
+		status := is.Solve()
+		if err := is.s.Err(); err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		res := rpcSolveResult{Status: status.String(), Model: is.Model(), Core: is.Core(), Stats: is.Solver().Stats}
+		return res, nil
+	case "quit":
+		return true, nil
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+// decodeParams decodes the given params into dest, and checks the lits it contains, once decoded, are valid for is.
+// lits must be a pointer to the slice of lits in dest.
+func decodeParams(is *IncrementalSolver, params json.RawMessage, dest interface{}, lits *[]int) *rpcError {
+	if err := json.Unmarshal(params, dest); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	for _, lit := range *lits {
+		if lit == 0 || lit > maxVar || lit < -maxVar {
+			return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid literal %d", lit)}
+		}
+	}
+	if err := is.CheckLits(*lits); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build !noserver
+// +build !noserver
+
+package solver
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestServeJSON(t *testing.T) {
+	requests := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"clause": [1, 2]}}`,
+		`{"jsonrpc": "2.0", "method": "add", "params": {"clause": [-2, 3]}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "assume", "params": {"lits": [-1, -3]}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "solve"}`,
+		`{"jsonrpc": "2.0", "id": "four", "method": "solve"}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "add", "params": {"clause": [1, 0]}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "foo"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "assume", "params": {"lits": [-2147483648]}}`,
+		`not json`,
+		`{"jsonrpc": "2.0", "id": 8, "method": "quit"}`,
+		`{"jsonrpc": "2.0", "id": 9, "method": "solve"}`,
+	}, "\n")
+	var sb strings.Builder
+	if err := ServeJSON(strings.NewReader(requests), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("expected 9 responses, got %q", sb.String())
+	}
+	type response struct {
+		ID     interface{}     `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	resps := make([]response, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &resps[i]); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+	}
+	var res rpcSolveResult
+	if err := json.Unmarshal(resps[2].Result, &res); err != nil || res.Status != "UNSAT" || len(res.Core) == 0 || res.Model != nil {
+		t.Errorf("expected unsat result with a core, got %s", resps[2].Result)
+	}
+	res = rpcSolveResult{}
+	if err := json.Unmarshal(resps[3].Result, &res); err != nil || res.Status != "SAT" || len(res.Model) != 3 || resps[3].ID != "four" {
+		t.Errorf("expected sat result with a model, got %s", resps[3].Result)
+	}
+	for i, code := range map[int]int{4: rpcInvalidParams, 5: rpcMethodNotFound, 6: rpcInvalidParams, 7: rpcParseError} {
+		if resps[i].Error == nil || resps[i].Error.Code != code {
+			t.Errorf("expected error %d in response #%d, got %q", code, i, lines[i])
+		}
+	}
+	if string(resps[8].Result) != "true" {
+		t.Errorf("expected quit to succeed, got %q", lines[8])
+	}
+}
+
+func TestServeJSONPanic(t *testing.T) {
+	is := &IncrementalSolver{s: New(ParseSliceNb(randomCNF(rand.New(rand.NewSource(1)), 150, 640, 3), 150))}
+	is.s.Terminate = func() bool { panic("cannot terminate") }
+	var sb strings.Builder
+	if err := serveJSON(is, strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "solve"}`), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	var resp struct {
+		Error *rpcError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(sb.String()), &resp); err != nil || resp.Error == nil || resp.Error.Code != rpcInternalError {
+		t.Errorf("expected an internal error, got %q", sb.String())
+	}
+}
+
+func TestServeJSONMaxVar(t *testing.T) {
+	is := NewIncrementalSolver()
+	is.MaxVar = 3
+	requests := `{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"clause": [1073741824]}}` + "\n" +
+		`{"jsonrpc": "2.0", "id": 2, "method": "assume", "params": {"lits": [-4]}}` + "\n" +
+		`{"jsonrpc": "2.0", "id": 3, "method": "add", "params": {"clause": [1, -3]}}`
+	var sb strings.Builder
+	if err := is.ServeJSON(strings.NewReader(requests), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 responses, got %q", sb.String())
+	}
+	for i, line := range lines[:2] {
+		var resp struct {
+			Error *rpcError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+			t.Errorf("expected an invalid params error in response #%d, got %q", i, line)
+		}
+	}
+	if !strings.Contains(lines[2], `"result":true`) {
+		t.Errorf("expected add to succeed, got %q", lines[2])
+	}
+}
@@ -0,0 +1,38 @@
+//go:build noserver
+// +build noserver
+
+package solver
+
+import (
+	"errors"
+	"io"
+)
+
+// This file replaces server.go and server_json.go in builds using the noserver build tag,
+// so that embedded users do not pay for the server protocols and their JSON dependencies.
+
+// hasServer is true iff the server protocols are part of the build.
+const hasServer = false
+
+// errNoServer is returned by Serve and ServeJSON when the server protocols are not part of the build.
+var errNoServer = errors.New("server protocols are disabled by the noserver build tag")
+
+// Serve always fails, as the server protocols are not part of this build.
+func Serve(r io.Reader, w io.Writer) error {
+	return errNoServer
+}
+
+// ServeJSON always fails, as the server protocols are not part of this build.
+func ServeJSON(r io.Reader, w io.Writer) error {
+	return errNoServer
+}
+
+// Serve always fails, as the server protocols are not part of this build.
+func (is *IncrementalSolver) Serve(r io.Reader, w io.Writer) error {
+	return errNoServer
+}
+
+// ServeJSON always fails, as the server protocols are not part of this build.
+func (is *IncrementalSolver) ServeJSON(r io.Reader, w io.Writer) error {
+	return errNoServer
+}
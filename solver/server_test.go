@@ -0,0 +1,53 @@
+//go:build !noserver
+// +build !noserver
+
+package solver
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	const commands = "c a simple session\nadd 1 2 0\nassume -1 0\nsolve\nadd -2 0\nsolve\nassume -1 3 0\n\nsolve\nfoo\nadd 1 x 0\nadd -2147483648 0\nassume 4294967297 0\nadd -1 0\nsolve\nsolve\nquit\nsolve\n"
+	const expected = "ok\nok\ns SATISFIABLE\nv -1 2 0\nok\ns SATISFIABLE\nv 1 -2 0\nok\ns UNSATISFIABLE\nu -1 0\n" +
+		"e unknown command \"foo\"\ne invalid literal \"x\"\n" +
+		"e invalid literal \"-2147483648\"\ne invalid literal \"4294967297\"\nok\ns UNSATISFIABLE\nu 0\ns UNSATISFIABLE\nu 0\n"
+	var sb strings.Builder
+	if err := Serve(strings.NewReader(commands), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	if sb.String() != expected {
+		t.Errorf("invalid answers: expected %q, got %q", expected, sb.String())
+	}
+}
+
+func TestServePanic(t *testing.T) {
+	is := &IncrementalSolver{s: New(ParseSliceNb(randomCNF(rand.New(rand.NewSource(1)), 150, 640, 3), 150))}
+	is.s.Terminate = func() bool { panic("cannot terminate") }
+	var sb strings.Builder
+	if err := serve(is, strings.NewReader("solve\nadd 1 0\nsolve\n"), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	const expected = "e internal error: cannot terminate\nok\ne internal error: cannot terminate\n"
+	if sb.String() != expected {
+		t.Errorf("invalid answers: expected %q, got %q", expected, sb.String())
+	}
+}
+
+func TestServeMaxVar(t *testing.T) {
+	is := NewIncrementalSolver()
+	is.MaxVar = 3
+	var sb strings.Builder
+	if err := is.Serve(strings.NewReader("add 1073741824 0\nassume -4 0\nadd 1 0\nadd -3 0\nsolve\n"), &sb); err != nil {
+		t.Fatalf("could not serve: %v", err)
+	}
+	const expected = "e invalid literal 1073741824: vars are limited to 3\ne invalid literal -4: vars are limited to 3\nok\nok\ns SATISFIABLE\nv 1 -2 -3 0\n"
+	if sb.String() != expected {
+		t.Errorf("invalid answers: expected %q, got %q", expected, sb.String())
+	}
+	if err := NewIncrementalSolver().CheckLits([]int{DefaultMaxVar, -DefaultMaxVar - 1}); err == nil {
+		t.Errorf("expected an error for vars bigger than DefaultMaxVar")
+	}
+}
@@ -1,9 +1,13 @@
 package solver
 
 import (
+	"bufio"
 	"fmt"
+	"math/rand"
+	"os"
 	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -24,6 +28,11 @@ type Stats struct {
 	NbBinaryLearned int // How many binary clauses were learned
 	NbLearned       int // How many clauses were learned
 	NbDeleted       int // How many clauses were deleted
+	NbCoreCacheHits int // How many times a cached core was reused
+	NbBinaryReduced int // How many redundant binary clauses were removed by transitive reduction
+	NbPurged        int // How many clauses satisfied by top-level units were removed
+	NbFiltered      int // How many imported clauses were dropped by the import filter
+	NbRecycled      int // How many vars were collected for reuse
 }
 
 // The level a decision was made.
@@ -51,34 +60,128 @@ func (m Model) String() string {
 	return fmt.Sprintf("%v", bound)
 }
 
+// ToInts returns the bound vars of m as DIMACS literals, i.e positive values for vars bound to true
+// and negative values for vars bound to false, in increasing order of vars.
+// Unbound vars are not part of the result.
+func (m Model) ToInts() []int {
+	res := make([]int, 0, len(m))
+	for i, lvl := range m {
+		if lvl > 0 {
+			res = append(res, i+1)
+		} else if lvl < 0 {
+			res = append(res, -i-1)
+		}
+	}
+	return res
+}
+
+// Project returns the bindings of the given vars only, as DIMACS literals, in the same order as vars.
+// It is typically used to hide the auxiliary vars introduced by encoders and relaxations.
+// Vars that are unbound, or that are not part of m, are not part of the result.
+func (m Model) Project(vars []Var) []int {
+	res := make([]int, 0, len(vars))
+	for _, v := range vars {
+		if int(v) >= len(m) {
+			continue
+		}
+		if lvl := m[v]; lvl > 0 {
+			res = append(res, int(v.Int()))
+		} else if lvl < 0 {
+			res = append(res, -int(v.Int()))
+		}
+	}
+	return res
+}
+
 // A Solver solves a given problem. It is the main data structure.
 type Solver struct {
-	Verbose     bool        // Indicates whether the solver should display information during solving or not. False by default
-	Certified   bool        // Indicates whether a certificate should be generated during solving or not, using the RUP notation. This is useful to prove UNSAT instances. False by default.
-	CertChan    chan string // Indicates where to write the certificate. If Certified is true but CertChan is nil, the certificate will be written on stdout.
-	nbVars      int
-	status      Status
-	wl          watcherList
-	trail       []Lit     // Current assignment stack
-	model       Model     // 0 means unbound, other value is a binding
-	lastModel   Model     // Placeholder for last model found, useful when looking for several models
-	activity    []float64 // How often each var is involved in conflicts
-	polarity    []bool    // Preferred sign for each var
-	assumptions []bool    // True iff the var's binding is assumed
+	Verbose   bool        // Indicates whether the solver should display information during solving or not. False by default
+	Certified bool        // Indicates whether a certificate should be generated during solving or not, using the RUP notation. This is useful to prove UNSAT instances. False by default.
+	CertChan  chan string // Indicates where to write the certificate. If Certified is true but CertChan is nil, the certificate will be written on stdout.
+	// If Certified is true and CertFile is not empty, the certificate is written to the file at that path instead,
+	// so that results can refer to it (see Result.Proof). The file is created or truncated by the first call to Solve,
+	// and the certificates of the next calls are appended to it. If it cannot be written, Err returns the error
+	// and results do not refer to it; if it cannot even be opened, Solve returns Indet.
+	CertFile string
+	// If CertComments is true, the certificate is annotated with comments giving the LBD of each lemma
+	// and how many times it was used during conflict analysis. False by default.
+	CertComments bool
+	CacheCores   bool // Indicates whether cores should be cached and reused when the same assumptions are made again. False by default.
+	// If ImportChan is not nil, the clauses sent on it are added to the problem before each restart.
+	// This lets other engines share the nogoods they learned with a running solver (see ImportNogoods).
+	ImportChan chan *Clause
+	// If ImportFilter is not nil, it selects which of the clauses imported from ImportChan or ImportNogoods are kept.
+	ImportFilter *ImportFilter
+	// If TrackAntecedents is true, the solver records which clauses each learned clause was derived from,
+	// so that top-level implications can be explained in terms of problem clauses. See ExplainLit. False by default.
+	TrackAntecedents bool
+	// If TrackUsage is true, the solver counts how many times each problem clause is involved in a conflict analysis.
+	// See HotClauses. False by default.
+	TrackUsage bool
+	// If Terminate is not nil, it is called regularly while searching, and the solver will stop searching and
+	// return Indet as soon as it returns true.
+	Terminate func() bool
+	// If Deadline is not zero, the solver will stop searching and return Indet once the deadline is passed.
+	Deadline time.Time
+	// If RecoverPanics is true, internal panics during Solve, Optimal, Minimize, Enumerate and CountModels
+	// are recovered: Solve and Optimal return Indet, Minimize returns -1, Enumerate and CountModels return
+	// the number of models found so far, and Err returns the panic, along with its stack. False by default.
+	RecoverPanics bool
+	// If MaxConflicts is strictly positive, the solver will stop searching and return Indet once
+	// the total number of conflicts reaches this value.
+	MaxConflicts int
+	nbVars       int
+	nbUserVars   int // Number of vars that are part of models, i.e vars that were not reserved
+	status       Status
+	wl           watcherList
+	trail        []Lit         // Current assignment stack
+	model        Model         // 0 means unbound, other value is a binding
+	lastModel    Model         // Placeholder for last model found, useful when looking for several models
+	lastResult   *Result       // Result of the last call to Optimal or Minimize, if it was the last solving method called
+	certOut      *bufio.Writer // Buffered certificate file, while Solve runs, if CertFile is set
+	certFile     *os.File      // Certificate file, while Solve runs, if CertFile is set
+	certPath     string        // Path of the certificate file that was last written to, if any
+	certErr      error         // Error that prevented the certificate file from being written, if any
+	activity     []float64     // How often each var is involved in conflicts
+	polarity     []bool        // Preferred sign for each var
+	preferred    Assignment    // Value to try first for each var, if any, no matter its polarity
+	assumptions  []bool        // True iff the var's binding is assumed
+	units        []Lit         // Lits that are true at top-level, no matter the assumptions
+	core         []Lit         // Assumptions responsible for the last Unsat status, if any
+	coreCache    coreCache     // Cores found so far, if CacheCores is true
+	err          *PanicError   // Panic recovered during solving, if RecoverPanics is true
+	antecedents  antecedents   // Clauses each learned clause was derived from, if TrackAntecedents is true
 	// For each var, clause considered when it was unified
 	// If the var is not bound yet, or if it was bound by a decision, value is nil.
 	reason          []*Clause
 	varQueue        queue
+	prioQueue       queue   // Queue for vars that must be branched on first, if any.
+	priority        []bool  // For each var, true iff it must be branched on first. nil if there is no such var.
 	varInc          float64 // On each var bump, how big the increment should be
 	clauseInc       float32 // On each var bump, how big the increment should be
 	lbdStats        lbdStats
-	Stats           Stats   // Statistics about the solving process.
-	minLits         []Lit   // Lits to minimize if the problem was an optimization problem.
-	minWeights      []int   // Weight of each lit to minimize if the problem was an optimization problem.
-	hypothesis      []Lit   // Literals that are, ideally, true. Useful when trying to minimize a function.
-	localNbRestarts int     // How many restarts since Solve() was called?
-	varDecay        float64 // On each var decay, how much the varInc should be decayed
-	trailBuf        []int   // A buffer while cleaning bindings
+	Stats           Stats           // Statistics about the solving process.
+	minLits         []Lit           // Lits to minimize if the problem was an optimization problem.
+	minWeights      []int           // Weight of each lit to minimize if the problem was an optimization problem. All weights are strictly positive.
+	costOffset      int             // Constant added to the cost function, once negative weights were removed from it.
+	hypothesis      []Lit           // Literals that are, ideally, true. Useful when trying to minimize a function.
+	localNbRestarts int             // How many restarts since Solve() was called?
+	varDecay        float64         // On each var decay, how much the varInc should be decayed
+	trailBuf        []int           // A buffer while cleaning bindings
+	bufLits         []Lit           // Buffer for lits in learnClause. Used to reduce allocations.
+	usage           map[*Clause]int // For each problem clause, how many times it was used during conflict analysis, if TrackUsage is true
+	lemmaUses       map[*Clause]int // For each learned clause, how many times it was used during conflict analysis, if CertComments is true
+	stop            chan struct{}   // If data is sent on it, the solver must stop
+	stopped         bool            // True iff data was received on stop
+	rng             *rand.Rand      // If not nil, source of random decisions
+	// 1 + Stats.NbBinaryLearned when binary clauses were last reduced, 0 if they never were.
+	binReductionMark int
+	nbPurgedUnits    int       // How many units were known when clauses were last purged
+	hotInc           float32   // Value of clauseInc when watchers were last sorted by recency
+	best             incumbent // Best model found so far while optimizing, that other goroutines can read
+	// Groups of clauses that can be removed, if any.
+	groups   clauseGroups
+	freeVars []Var // Vars collected by CollectVars, that NewVar can hand back
 }
 
 // New makes a solver, given a number of variables and a set of clauses.
@@ -97,6 +200,7 @@ func New(problem *Problem) *Solver {
 
 	s := &Solver{
 		nbVars:      nbVars,
+		nbUserVars:  nbVars - problem.nbReserved,
 		status:      problem.Status,
 		trail:       make([]Lit, len(problem.Units), trailCap),
 		model:       problem.Model,
@@ -106,15 +210,18 @@ func New(problem *Problem) *Solver {
 		reason:      make([]*Clause, nbVars),
 		varInc:      1.0,
 		clauseInc:   1.0,
-		minLits:     problem.minLits,
-		minWeights:  problem.minWeights,
 		varDecay:    defaultVarDecay,
 		trailBuf:    make([]int, nbVars),
+		units:       make([]Lit, len(problem.Units)),
+	}
+	copy(s.units, problem.Units)
+	if problem.minLits != nil {
+		s.minLits, s.minWeights, s.costOffset = normalizeCost(problem.minLits, problem.minWeights)
 	}
 	s.resetOptimPolarity()
 	s.initOptimActivity()
 	s.initWatcherList(problem.Clauses)
-	s.varQueue = newQueue(s.activity)
+	s.initQueues()
 	for i, lit := range problem.Units {
 		if lit.IsPositive() {
 			s.model[lit.Var()] = 1
@@ -137,14 +244,70 @@ func (s *Solver) newVar(v Var) {
 			s.activity = append(s.activity, 0.)
 			s.polarity = append(s.polarity, false)
 			s.reason = append(s.reason, nil)
+			s.assumptions = append(s.assumptions, false)
 			s.trailBuf = append(s.trailBuf, 0)
 		}
-		s.varQueue = newQueue(s.activity)
+		if s.priority != nil {
+			s.priority = append(s.priority, make([]bool, cnfVar-len(s.priority))...)
+		}
+		s.initQueues()
 		s.addVarWatcherList(v)
+		if s.nbUserVars == s.nbVars { // No var was reserved yet
+			s.nbUserVars = cnfVar
+		}
 		s.nbVars = cnfVar
 	}
 }
 
+// initQueues builds the queues of vars to branch on from scratch.
+// Vars that must be branched on first, if any, are put in their own queue.
+func (s *Solver) initQueues() {
+	if s.priority == nil {
+		s.varQueue = newQueue(s.activity)
+		return
+	}
+	s.varQueue = queue{activity: s.activity, indices: make([]int, s.nbVars)}
+	s.prioQueue = queue{activity: s.activity, indices: make([]int, s.nbVars)}
+	for v := range s.activity {
+		s.varQueue.indices[v] = -1
+		s.prioQueue.indices[v] = -1
+	}
+	for v := range s.activity {
+		s.queueFor(Var(v)).insert(v)
+	}
+}
+
+// queueFor returns the queue v belongs to.
+func (s *Solver) queueFor(v Var) *queue {
+	if s.priority != nil && s.priority[v] {
+		return &s.prioQueue
+	}
+	return &s.varQueue
+}
+
+// normalizeCost returns an equivalent cost function whose weights are all strictly positive, and a constant offset,
+// using the fact that w*lit = w + (-w)*not(lit). If weights is nil, all weights are 1.
+func normalizeCost(lits []Lit, weights []int) (lits2 []Lit, weights2 []int, offset int) {
+	lits2 = make([]Lit, 0, len(lits))
+	weights2 = make([]int, 0, len(lits))
+	for i, lit := range lits {
+		w := 1
+		if weights != nil {
+			w = weights[i]
+		}
+		if w < 0 {
+			offset += w
+			lit = lit.Negation()
+			w = -w
+		}
+		if w > 0 {
+			lits2 = append(lits2, lit)
+			weights2 = append(weights2, w)
+		}
+	}
+	return lits2, weights2, offset
+}
+
 // sets initial activity for optimization variables, if any.
 func (s *Solver) initOptimActivity() {
 	for i, lit := range s.minLits {
@@ -170,6 +333,17 @@ func (s *Solver) Optim() bool {
 	return s.minLits != nil
 }
 
+// SetCostFunc replaces the function s minimizes, so that s can optimize again once the weights changed,
+// without being rebuilt. lits must be lits of the problem s was made from, or of clauses appended to it.
+// If all weights are 1, weights can be nil. In all other cases, len(lits) must be the same as len(weights).
+// Bounds on the cost added by previous calls to Minimize are kept.
+func (s *Solver) SetCostFunc(lits []Lit, weights []int) {
+	if weights != nil && len(lits) != len(weights) {
+		panic("length of lits and of weights don't match")
+	}
+	s.minLits, s.minWeights, s.costOffset = normalizeCost(lits, weights)
+}
+
 // OutputModel outputs the model for the problem on stdout.
 func (s *Solver) OutputModel() {
 	if s.status == Sat || s.lastModel != nil {
@@ -178,7 +352,7 @@ func (s *Solver) OutputModel() {
 		if s.lastModel != nil {
 			model = s.lastModel
 		}
-		for i, val := range model {
+		for i, val := range model[:s.nbUserVars] {
 			if val < 0 {
 				fmt.Printf("%d ", -i-1)
 			} else {
@@ -218,8 +392,8 @@ func (s *Solver) varBumpActivity(v Var) {
 		}
 		s.varInc *= 1e-100
 	}
-	if s.varQueue.contains(int(v)) {
-		s.varQueue.decrease(int(v))
+	if q := s.queueFor(v); q.contains(int(v)) {
+		q.decrease(int(v))
 	}
 }
 
@@ -230,14 +404,24 @@ func (s *Solver) clauseDecayActivity() {
 
 // Bumps the given clause's activity.
 func (s *Solver) clauseBumpActivity(c *Clause) {
-	if c.Learned() {
-		c.activity += s.clauseInc
-		if c.activity > 1e30 { // Rescale to avoid overflow
-			for _, c2 := range s.wl.learned {
-				c2.activity *= 1e-30
-			}
-			s.clauseInc *= 1e-30
+	if s.TrackUsage && !c.Learned() {
+		if s.usage == nil {
+			s.usage = make(map[*Clause]int)
+		}
+		s.usage[c]++
+	}
+	// Problem clauses are bumped too: although their activity is never used to delete them,
+	// it tells hot clauses from cold ones (see sortWatchersByRecency).
+	c.activity += s.clauseInc
+	if c.activity > 1e30 { // Rescale to avoid overflow
+		for _, c2 := range s.wl.learned {
+			c2.activity *= 1e-30
 		}
+		for _, c2 := range s.wl.pbClauses {
+			c2.activity *= 1e-30
+		}
+		s.clauseInc *= 1e-30
+		s.hotInc *= 1e-30
 	}
 }
 
@@ -245,6 +429,11 @@ func (s *Solver) clauseBumpActivity(c *Clause) {
 // if all the variables are already bound.
 func (s *Solver) chooseLit() Lit {
 	v := Var(-1)
+	for v == -1 && !s.prioQueue.empty() {
+		if v2 := Var(s.prioQueue.removeMin()); s.model[v2] == 0 {
+			v = v2
+		}
+	}
 	for v == -1 && !s.varQueue.empty() {
 		if v2 := Var(s.varQueue.removeMin()); s.model[v2] == 0 { // Ignore already bound vars
 			v = v2
@@ -254,6 +443,12 @@ func (s *Solver) chooseLit() Lit {
 		return Lit(-1)
 	}
 	s.Stats.NbDecisions++
+	if int(v) < len(s.preferred) && s.preferred[v] != Unassigned {
+		return v.SignedLit(s.preferred[v] == False)
+	}
+	if s.rng != nil {
+		return v.SignedLit(s.rng.Intn(2) == 0)
+	}
 	return v.SignedLit(!s.polarity[v])
 }
 
@@ -298,14 +493,14 @@ func (s *Solver) cleanupBindings(lvl decLevel) {
 			s.reason[v] = nil
 		}
 		s.polarity[v] = lit2.IsPositive()
-		if !s.varQueue.contains(int(v)) {
+		if q := s.queueFor(v); !q.contains(int(v)) {
 			toInsert = append(toInsert, int(v))
-			s.varQueue.insert(int(v))
+			q.insert(int(v))
 		}
 	}
 	s.trail = s.trail[:i]
 	for i := len(toInsert) - 1; i >= 0; i-- {
-		s.varQueue.insert(toInsert[i])
+		s.queueFor(Var(toInsert[i])).insert(toInsert[i])
 	}
 	/*for i := len(s.trail) - 1; i >= 0; i-- {
 		lit := s.trail[i]
@@ -336,12 +531,20 @@ func backtrackData(c *Clause, model []decLevel) (btLevel decLevel, lit Lit) {
 
 func (s *Solver) rebuildOrderHeap() {
 	ints := make([]int, s.nbVars)
+	var prio []int
 	for v := 0; v < s.nbVars; v++ {
 		if s.model[v] == 0 {
-			ints = append(ints, int(v))
+			if s.priority != nil && s.priority[v] {
+				prio = append(prio, int(v))
+			} else {
+				ints = append(ints, int(v))
+			}
 		}
 	}
 	s.varQueue.build(ints)
+	if s.priority != nil {
+		s.prioQueue.build(prio)
+	}
 }
 
 // propagate binds the given lit, propagates it and searches for a solution,
@@ -350,6 +553,10 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 	for lit != -1 {
 		// log.Printf("picked %d at lvl %d", lit.Int(), lvl)
 		if conflict := s.unifyLiteral(lit, lvl); conflict == nil { // Pick new branch or restart
+			if s.budgetExhausted() {
+				s.cleanupBindings(1)
+				return Indet
+			}
 			if s.lbdStats.mustRestart() {
 				s.lbdStats.clear()
 				s.cleanupBindings(1)
@@ -370,15 +577,22 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 			s.lbdStats.addConflict(len(s.trail))
 			learnt, unit := s.learnClause(conflict, lvl)
 			if learnt == nil { // Unit clause was learned: this lit is known for sure
-				if unit == -1 || (abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat) { // Top-level conflict
+				if unit == -1 { // Top-level conflict
+					s.analyzeFinal(conflict, -1)
+					return s.setUnsat()
+				}
+				if abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat { // Top-level conflict
+					s.analyzeFinal(nil, unit)
 					return s.setUnsat()
 				}
 				s.Stats.NbUnitLearned++
 				s.lbdStats.addLbd(1)
 				s.cleanupBindings(1)
 				s.addLearnedUnit(unit)
+				s.units = append(s.units, unit)
 				s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
 				if conflict = s.unifyLiteral(unit, 1); conflict != nil { // top-level conflict
+					s.analyzeFinal(conflict, -1)
 					return s.setUnsat()
 				}
 				s.rebuildOrderHeap()
@@ -404,11 +618,7 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 // Sets the status to unsat and do cleanup tasks.
 func (s *Solver) setUnsat() Status {
 	if s.Certified {
-		if s.CertChan == nil {
-			fmt.Printf("0\n")
-		} else {
-			s.CertChan <- "0"
-		}
+		s.certifyEmpty()
 	}
 	s.status = Unsat
 	return Unsat
@@ -423,18 +633,49 @@ func (s *Solver) search() Status {
 }
 
 // Solve solves the problem associated with the solver and returns the appropriate status.
-func (s *Solver) Solve() Status {
+func (s *Solver) Solve() (status Status) {
+	if s.RecoverPanics {
+		if s.err != nil {
+			return Indet
+		}
+		defer func() {
+			if s.recoverPanic(recover()) {
+				status = Indet
+			}
+		}()
+	}
+	s.lastResult = nil
 	if s.status == Unsat {
 		return s.status
 	}
+	if s.Certified && s.CertFile != "" {
+		if err := s.openCert(); err != nil {
+			s.certErr = err
+			return Indet
+		}
+		defer s.closeCert()
+	}
 	s.status = Indet
 	//s.lbdStats.clear()
 	s.localNbRestarts = 0
 	var end chan struct{}
 	for s.status == Indet {
+		if s.importClauses(); s.status == Unsat {
+			break
+		}
 		s.search()
 		if s.status == Indet {
+			if s.budgetExhausted() {
+				break
+			}
 			s.Stats.NbRestarts++
+			s.purgeUnits()
+			if s.Stats.NbRestarts%hotColdInterval == 0 {
+				s.sortWatchersByRecency()
+			}
+			if s.Stats.NbRestarts%binReductionInterval == 0 {
+				s.reduceBinaries()
+			}
 			s.rebuildOrderHeap()
 		}
 	}
@@ -451,37 +692,125 @@ func (s *Solver) Solve() Status {
 
 // Assume adds unit literals to the solver.
 // This is useful when calling the solver several times, e.g to keep it "hot" while removing clauses.
+// Previous assumptions, if any, are forgotten.
+// If the problem is Unsat under the given assumptions, Core indicates which of them are responsible for it.
 func (s *Solver) Assume(lits []Lit) Status {
 	s.cleanupBindings(0)
 	s.trail = s.trail[:0]
 	s.assumptions = make([]bool, s.nbVars)
-
+	s.core = nil
+	if s.CacheCores {
+		if core := s.coreCache.lookup(lits); core != nil {
+			s.Stats.NbCoreCacheHits++
+			s.core = core
+			s.status = Unsat
+			return s.status
+		}
+	}
+	for _, unit := range s.units {
+		if s.litStatus(unit) == Indet {
+			s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
+			s.trail = append(s.trail, unit)
+		}
+	}
+	if confl := s.bindForced(); confl != nil {
+		s.analyzeFinal(confl, -1)
+		s.status = Unsat
+		return s.status
+	}
 	for _, lit := range lits {
-
-		s.addLearnedUnit(lit)
+		switch s.litStatus(lit) {
+		case Sat: // Already assumed or known
+			continue
+		case Unsat: // Contradicts a previous assumption or a top-level literal
+			s.core = []Lit{lit}
+			if s.assumptions[lit.Var()] {
+				s.core = append(s.core, lit.Negation())
+			}
+			s.cacheCore()
+			s.status = Unsat
+			return s.status
+		}
+		s.model[lit.Var()] = lvlToSignedLvl(lit, 1)
 		s.assumptions[lit.Var()] = true
 		s.trail = append(s.trail, lit)
 	}
 	s.status = Indet
 	if confl := s.propagate(0, 1); confl != nil {
 		// Conflict after unit propagation
+		s.analyzeFinal(confl, -1)
 		s.status = Unsat
 		return s.status
 	}
 	return s.status
 }
 
+// Core returns the subset of the assumptions that made the problem Unsat after the last call to Assume.
+// If the problem is Unsat no matter the assumptions, the returned core is empty.
+// If the problem is not Unsat, the returned core is nil.
+// The core is not guaranteed to be minimal.
+func (s *Solver) Core() []Lit {
+	if s.status != Unsat {
+		return nil
+	}
+	res := make([]Lit, len(s.core))
+	copy(res, s.core)
+	return res
+}
+
+// analyzeFinal computes the set of assumptions responsible for a top-level conflict and stores it as the core.
+// The conflict is described either by the clause confl or, if confl is nil, by the binding of lit's var.
+func (s *Solver) analyzeFinal(confl *Clause, lit Lit) {
+	s.core = []Lit{}
+	seen := make([]bool, s.nbVars)
+	if confl != nil {
+		for i := 0; i < confl.Len(); i++ {
+			if l := confl.Get(i); s.litStatus(l) == Unsat {
+				seen[l.Var()] = true
+			}
+		}
+	} else {
+		seen[lit.Var()] = true
+	}
+	for i := len(s.trail) - 1; i >= 0; i-- {
+		v := s.trail[i].Var()
+		if !seen[v] {
+			continue
+		}
+		if s.assumptions[v] {
+			s.core = append(s.core, s.trail[i])
+		} else if reason := s.reason[v]; reason != nil {
+			for j := 0; j < reason.Len(); j++ {
+				if l := reason.Get(j); l.Var() != v && s.litStatus(l) == Unsat {
+					seen[l.Var()] = true
+				}
+			}
+		}
+	}
+	s.cacheCore()
+}
+
+// cacheCore adds the current core to the cache, if needed.
+func (s *Solver) cacheCore() {
+	if s.CacheCores && len(s.core) != 0 {
+		s.coreCache.add(s.core)
+	}
+}
+
 // Enumerate returns the total number of models for the given problems.
 // if "models" is non-nil, it will write models on it as soon as it discovers them.
 // models will be closed at the end of the method.
-func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) int {
+func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) (nb int) {
 	if models != nil {
 		defer close(models)
 	}
+	if s.RecoverPanics {
+		if s.err != nil {
+			return 0
+		}
+		defer func() { s.recoverPanic(recover()) }() // Models found so far are still counted
+	}
 	s.lastModel = make(Model, len(s.model))
-	nb := 0
-	lit := s.chooseLit()
-	var lvl decLevel
 	for s.status != Unsat {
 		for s.status == Indet {
 			s.search()
@@ -504,14 +833,7 @@ func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) int {
 			case 1:
 				s.propagateUnits(lits)
 			default:
-				c := NewClause(lits)
-				s.appendClause(c)
-				lit = lits[len(lits)-1]
-				v := lit.Var()
-				lvl = abs(s.model[v]) - 1
-				s.cleanupBindings(lvl)
-				s.reason[v] = c // Must do it here because it won't be made by propagateAndSearch
-				s.propagateAndSearch(lit, lvl)
+				s.blockDecisions(lits)
 			}
 		}
 	}
@@ -519,11 +841,14 @@ func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) int {
 }
 
 // CountModels returns the total number of models for the given problem.
-func (s *Solver) CountModels() int {
+func (s *Solver) CountModels() (nb int) {
+	if s.RecoverPanics {
+		if s.err != nil {
+			return 0
+		}
+		defer func() { s.recoverPanic(recover()) }() // Models found so far are still counted
+	}
 	var end chan struct{}
-	nb := 0
-	lit := s.chooseLit()
-	var lvl decLevel
 	for s.status != Unsat {
 		for s.status == Indet {
 			s.search()
@@ -545,14 +870,7 @@ func (s *Solver) CountModels() int {
 			case 1:
 				s.propagateUnits(lits)
 			default:
-				c := NewClause(lits)
-				s.appendClause(c)
-				lit = lits[len(lits)-1]
-				v := lit.Var()
-				lvl = abs(s.model[v]) - 1
-				s.cleanupBindings(lvl)
-				s.reason[v] = c // Must do it here because it won't be made by propagateAndSearch
-				s.propagateAndSearch(lit, lvl)
+				s.blockDecisions(lits)
 			}
 		}
 	}
@@ -582,8 +900,29 @@ func (s *Solver) decisionLits() []Lit {
 	return lits
 }
 
+// blockDecisions adds a clause made of lits, the negation of the current decisions sorted by increasing level,
+// so that they are never made again, and resumes the search by flipping the last decision.
+// The flipped decision and the decision right before it are watched: since they are the last lits bound in the clause,
+// the clause is still watched properly once the search backtracks further.
+func (s *Solver) blockDecisions(lits []Lit) {
+	last := len(lits) - 1
+	lit := lits[last]
+	v := lit.Var()
+	lvl := abs(s.model[v]) - 1
+	lits[0], lits[last] = lits[last], lits[0]
+	if last > 1 {
+		lits[1], lits[last-1] = lits[last-1], lits[1]
+	}
+	c := NewClause(lits)
+	s.appendClause(c)
+	s.cleanupBindings(lvl)
+	s.reason[v] = c // Must do it here because it won't be made by propagateAndSearch
+	s.propagateAndSearch(lit, lvl)
+}
+
 func (s *Solver) propagateUnits(units []Lit) {
 	for _, unit := range units {
+		s.units = append(s.units, unit)
 		s.lbdStats.addLbd(1)
 		s.Stats.NbUnitLearned++
 		s.cleanupBindings(1)
@@ -637,7 +976,15 @@ func (s *Solver) PBString() string {
 // AppendClause appends a new clause to the set of clauses.
 // This is not a learned clause, but a clause that is part of the problem added afterwards (during model counting, for instance).
 func (s *Solver) AppendClause(clause *Clause) {
+	if s.groups.nbLive > 0 {
+		s.appendGuarded(clause)
+		return
+	}
 	s.cleanupBindings(1)
+	if clause.isBig() {
+		s.appendBig(clause)
+		return
+	}
 	card := clause.Cardinality()
 	minW := 0
 	maxW := 0
@@ -673,14 +1020,42 @@ func (s *Solver) AppendClause(clause *Clause) {
 	}
 }
 
+// appendBig is like AppendClause, for a PB clause with arbitrary-precision weights.
+func (s *Solver) appendBig(clause *Clause) {
+	for i := 0; i < clause.Len(); i++ {
+		s.newVar(clause.Get(i).Var())
+	}
+	units, status := clause.reduceBig(s.litStatus)
+	if status == Unsat {
+		s.status = Unsat
+		return
+	}
+	if status == Indet {
+		s.appendClause(clause)
+	}
+	s.propagateUnits(units)
+}
+
+// LastModel returns a copy of the last model found by s, including reserved vars, or nil if no model was found.
+// Vars whose binding does not matter may be unbound.
+func (s *Solver) LastModel() Model {
+	if s.lastModel == nil {
+		return nil
+	}
+	res := make(Model, len(s.lastModel))
+	copy(res, s.lastModel)
+	return res
+}
+
 // Model returns a slice that associates, to each variable, its binding.
+// Reserved vars (see ReserveVars) are not part of the returned slice.
 // If s's status is not Sat, the method will panic.
 func (s *Solver) Model() []bool {
 	if s.lastModel == nil {
 		panic("cannot call Model() from a non-Sat solver")
 	}
-	res := make([]bool, s.nbVars)
-	for i, lvl := range s.lastModel {
+	res := make([]bool, s.nbUserVars)
+	for i, lvl := range s.lastModel[:s.nbUserVars] {
 		res[i] = lvl > 0
 	}
 	return res
@@ -735,10 +1110,32 @@ func (s *Solver) countCurrentModels() int {
 // Optimal returns the optimal solution, if any.
 // If results is non-nil, all solutions will be written to it.
 // In any case, results will be closed at the end of the call.
+// If the solver's budget is exhausted or if data is sent on stop, the best solution found so far is
+// returned with the FeasibleOnly optimization status, along with the best proven lower bound on the cost.
+// The last result written on results is always the returned one.
 func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 	if results != nil {
 		defer close(results)
 	}
+	if s.RecoverPanics {
+		if s.err != nil {
+			return Result{Status: Indet}
+		}
+		defer func() {
+			if s.recoverPanic(recover()) {
+				res = Result{Status: Indet}
+			}
+		}()
+	}
+	s.stop = stop
+	s.resetBest()
+	defer func() {
+		s.stop = nil
+		res.Core = s.Core()
+		res.Stats = s.Stats
+		res.Proof = s.proof(res)
+		s.lastResult = &res
+	}()
 	status := s.Solve()
 	if status == Unsat { // Problem cannot be satisfied at all
 		res.Status = Unsat
@@ -747,6 +1144,13 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 		}
 		return res
 	}
+	if status == Indet { // Stopped before a model was found
+		res = Result{Status: Indet, Optim: BoundOnly, Bound: s.lowerBound() + s.costOffset}
+		if results != nil {
+			results <- res
+		}
+		return res
+	}
 	if s.minLits == nil { // No optimization clause: this is a decision problem, solution is optimal
 		s.lastModel = make(Model, len(s.model))
 		copy(s.lastModel, s.model)
@@ -754,6 +1158,7 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 			Status: Sat,
 			Model:  s.Model(),
 			Weight: 0,
+			Optim:  Optimum,
 		}
 		if results != nil {
 			results <- res
@@ -789,10 +1194,15 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 				}
 			}
 		}
+		s.setBest(cost + s.costOffset)
 		res = Result{
 			Status: Sat,
 			Model:  s.Model(),
-			Weight: cost,
+			Weight: cost + s.costOffset,
+			Optim:  FeasibleOnly,
+		}
+		if cost == 0 {
+			res.Optim = Optimum
 		}
 		if results != nil {
 			results <- res
@@ -809,15 +1219,101 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 		s.rebuildOrderHeap()
 		status = s.Solve()
 	}
+	if res.Optim == Optimum {
+		return res
+	}
+	switch status {
+	case Unsat: // No better model: the last one is optimal
+		res.Optim = Optimum
+		res.Bound = res.Weight
+	case Indet: // Stopped prematurely
+		if bound := s.lowerBound() + s.costOffset; bound < res.Weight {
+			res.Bound = bound
+		} else {
+			res.Bound = res.Weight
+		}
+	}
+	if results != nil { // Update the status of the last result
+		results <- res
+	}
+	return res
+}
+
+// Result returns the result of the last call to Solve, Optimal or Minimize, along with the statistics of the solver.
+// After Solve, the cost of the result is the cost of its model for optimization problems, and 0 otherwise;
+// its optimization status is Optimum for Sat decision problems, but only FeasibleOnly for optimization problems,
+// since models found by Solve are not proven optimal, and its bound is the lower bound known at the top level.
+// An Indet optimization problem is BoundOnly, and an Unsat problem is NotOptim.
+func (s *Solver) Result() Result {
+	if s.lastResult != nil {
+		res := *s.lastResult
+		res.Stats = s.Stats
+		return res
+	}
+	res := Result{Status: s.status, Core: s.Core(), Stats: s.Stats}
+	if s.minLits != nil {
+		res.Bound = s.lowerBound() + s.costOffset
+		if s.status == Indet {
+			res.Optim = BoundOnly
+		}
+	}
+	if s.status == Sat {
+		res.Model = s.Model()
+		if s.minLits == nil {
+			res.Optim = Optimum
+		} else {
+			res.Weight = s.cost(s.lastModel) + s.costOffset
+			res.Optim = FeasibleOnly
+		}
+	}
+	res.Proof = s.proof(res)
 	return res
 }
 
+// proof returns the path of the certificate file proving res, i.e proving it is Unsat or that its model is optimal,
+// or an empty string if there is no such file.
+func (s *Solver) proof(res Result) string {
+	if !s.Certified || s.CertFile == "" || s.err != nil || s.certErr != nil {
+		return ""
+	}
+	if res.Status == Unsat || (res.Optim == Optimum && s.minLits != nil) {
+		return s.CertFile
+	}
+	return ""
+}
+
+// recordOptimization records the result of Minimize, once the last model it found, whose cost is cost,
+// was saved in s.lastModel, and the search for a better model ended with the given status.
+func (s *Solver) recordOptimization(cost int, status Status) {
+	res := Result{Status: Sat, Model: s.Model(), Weight: cost, Optim: Optimum, Bound: cost, Stats: s.Stats}
+	if status == Indet { // Stopped prematurely
+		res.Optim = FeasibleOnly
+		if bound := s.lowerBound() + s.costOffset; bound < cost {
+			res.Bound = bound
+		}
+	}
+	res.Proof = s.proof(res)
+	s.lastResult = &res
+}
+
 // Minimize tries to find a model that minimizes the weight of the clause defined as the optimisation clause in the problem.
 // If no model can be found, it will return a cost of -1.
+// Note that, if the cost function has negative weights, -1 can also be a valid cost.
 // Otherwise, calling s.Model() afterwards will return the model that satisfy the formula, such that no other model with a smaller cost exists.
 // If this function is called on a non-optimization problem, it will either return -1, or a cost of 0 associated with a
 // satisfying model (ie any model is an optimal model).
-func (s *Solver) Minimize() int {
+func (s *Solver) Minimize() (cost int) {
+	if s.RecoverPanics {
+		if s.err != nil {
+			return -1
+		}
+		defer func() {
+			if s.recoverPanic(recover()) || s.err != nil { // Calls to Solve may have recovered from a panic, too
+				cost = -1
+			}
+		}()
+	}
+	s.resetBest()
 	status := s.Solve()
 	if status == Unsat { // Problem cannot be satisfied at all
 		return -1
@@ -840,8 +1336,10 @@ func (s *Solver) Minimize() int {
 	weights := make([]int, len(s.minWeights))
 	copy(weights, s.minWeights)
 	sort.Sort(wLits{lits: s.hypothesis, weights: weights})
+	if status != Sat { // Stopped before a model was found
+		return s.costOffset
+	}
 	s.lastModel = make(Model, len(s.model))
-	var cost int
 	for status == Sat {
 		copy(s.lastModel, s.model) // Save this model: it might be the last one
 		cost = 0
@@ -854,11 +1352,13 @@ func (s *Solver) Minimize() int {
 				}
 			}
 		}
+		s.setBest(cost + s.costOffset)
 		if cost == 0 {
-			return 0
+			s.recordOptimization(s.costOffset, status)
+			return s.costOffset
 		}
 		if s.Verbose {
-			fmt.Printf("o %d\n", cost)
+			fmt.Printf("o %d\n", cost+s.costOffset)
 		}
 		// Add a constraint incrementing current best cost
 		lits2 := make([]Lit, len(s.minLits))
@@ -869,7 +1369,10 @@ func (s *Solver) Minimize() int {
 		s.rebuildOrderHeap()
 		status = s.Solve()
 	}
-	return cost
+	if s.err == nil {
+		s.recordOptimization(cost+s.costOffset, status)
+	}
+	return cost + s.costOffset
 }
 
 // functions to sort hypothesis for pseudo-boolean minimization clause.
@@ -1,8 +1,11 @@
 package solver
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -128,6 +131,45 @@ func TestParseSliceTrivial(t *testing.T) {
 	if status := s.Solve(); status != Unsat {
 		t.Fatalf("expected unsat for problem %v, got %v", cnf, status)
 	}
+	if expected := (UnitConflict{Lit: IntToLit(1), First: 0, Second: 1}); pb.Conflict == nil || *pb.Conflict != expected {
+		t.Errorf("invalid conflict: expected %+v, got %+v", expected, pb.Conflict)
+	}
+}
+
+func TestParseCNFConflict(t *testing.T) {
+	pb, err := ParseCNF(strings.NewReader("p cnf 2 4\n1 0\n1 2 0\n2 0\n-1 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if expected := (UnitConflict{Lit: IntToLit(1), First: 2, Second: 5}); pb.Status != Unsat || pb.Conflict == nil || *pb.Conflict != expected {
+		t.Errorf("invalid conflict: expected %+v, got %+v", expected, pb.Conflict)
+	}
+	if pb, err = ParseCNFParallel([]byte("p cnf 2 4\n1 0\n1 2 0\n2 0\n-1 0\n"), ParseOptions{}, 2); err != nil || pb.Conflict == nil || pb.Conflict.Second != 5 {
+		t.Errorf("invalid conflict in parallel parsing: %+v, error %v", pb.Conflict, err)
+	}
+	if pb, err = ParseCNF(strings.NewReader("p cnf 2 3\n1 0\n-1 2 0\n-2 0\n")); err != nil || pb.Status != Unsat || pb.Conflict != nil {
+		t.Errorf("expected no conflict for units inferred by propagation, got %+v", pb.Conflict)
+	}
+}
+
+func TestParseSliceHint(t *testing.T) {
+	cnf := [][]int{{1, 2}, {-2}, {2, 3, -4}, {3, -1}}
+	hint := SizeHint{NbVars: 10, NbUnits: 1, NbClauses: 3}
+	pb, stats := ParseSliceHint(cnf, hint)
+	if stats.NbConstrs != 4 || stats.NbUnits != 1 || stats.NbClauses != 3 || stats.NbGrowths != 0 {
+		t.Errorf("invalid stats: %+v", stats)
+	}
+	if pb.NbVars != 10 {
+		t.Errorf("expected 10 vars, got %d", pb.NbVars)
+	}
+	s := New(pb)
+	s.AppendClause(NewClause(IntsToLits(-3, 10)))
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("expected sat for problem, got %v", status)
+	}
+	if model := s.Model(); len(model) != 10 || !model[0] || model[1] || !model[2] || !model[9] {
+		t.Errorf("invalid model %v", model)
+	}
 }
 
 func TestParseCardConstrs(t *testing.T) {
@@ -184,6 +226,64 @@ func TestParseCardConstrsTrivial(t *testing.T) {
 			t.Errorf("invalid model, expected all true bindings, got %v", model)
 		}
 	}
+	pb = ParseCardConstrs([]CardConstr{{Lits: []int{1, 2, 3}, AtLeast: 2}, AtLeast1(1), AtLeast1(-2), AtLeast1(-3)})
+	if pb.Status != Unsat {
+		t.Errorf("expected unsat after simplification, got %v", pb.Status)
+	}
+}
+
+func TestParseErr(t *testing.T) {
+	if pb, err := ParseSliceErr([][]int{{1, 2}, {-1}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if pb.NbVars != 2 || len(pb.Units) != 2 {
+		t.Errorf("expected 2 vars and 2 units, got %d vars and units %v", pb.NbVars, pb.Units)
+	}
+	_, err := ParseSliceErr([][]int{{1, 2}, {-1, 0, 3}})
+	if cerr, ok := err.(*ConstrError); !ok || cerr.Index != 1 || !errors.Is(err, ErrBadLiteral) {
+		t.Errorf("expected error on constraint #1, got %v", err)
+	}
+	if pb, err := ParseCardConstrsErr([]CardConstr{{Lits: []int{1, 2, 3}, AtLeast: 3}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(pb.Units) != 3 {
+		t.Errorf("expected 3 units, got %v", pb.Units)
+	}
+	_, err = ParseCardConstrsErr([]CardConstr{{Lits: []int{1, 2}, AtLeast: 3}, AtLeast1(0)})
+	if cerr, ok := err.(*ConstrError); !ok || cerr.Index != 1 {
+		t.Errorf("expected error on constraint #1, got %v", err)
+	}
+}
+
+func TestParseHugeVars(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		parse func(io.Reader) (*Problem, error)
+		input string
+	}{
+		{"CNF header", ParseCNF, "p cnf 2000000000 1\n1 0\n"},
+		{"CNF lit", ParseCNF, "p cnf 3 1\n1 -2000000000 0\n"},
+		{"CNF overflow", ParseCNF, "p cnf 3 1\n1 99999999999999999999 0\n"},
+		{"CNF lenient", func(r io.Reader) (*Problem, error) {
+			return ParseCNFOptions(r, ParseOptions{Lenient: true})
+		}, "1 2000000000 0\n"},
+		{"WCNF header", ParseWCNF, "p wcnf 2000000000 1 10\n10 1 0\n"},
+		{"WCNF lit", ParseWCNF, "p wcnf 3 1 10\n10 3333333333330 0\n"},
+		{"ICNF lit", func(r io.Reader) (*Problem, error) {
+			_, err := ParseICNF(r)
+			return nil, err
+		}, "p inccnf\n1 2000000000 0\n"},
+	} {
+		if _, err := test.parse(strings.NewReader(test.input)); err == nil {
+			t.Errorf("%s: expected an error on %q", test.name, test.input)
+		}
+	}
+	if _, err := ParseSliceErr([][]int{{1, 1 << 31}}); err == nil {
+		t.Errorf("expected an error on a huge lit")
+	}
+	if pb, err := ParseCNF(strings.NewReader("p cnf 2 2000000000\n1 2 0\n")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if cap(pb.Clauses) > maxPrealloc {
+		t.Errorf("%d clauses were preallocated", cap(pb.Clauses))
+	}
 }
 
 func TestPigeonCard(t *testing.T) {
@@ -388,3 +488,343 @@ func BenchmarkSolverManolPipe(b *testing.B) {
 func BenchmarkSolverZebra(b *testing.B) {
 	runBench("testcnf/zebra.cnf", b)
 }
+
+func TestModelProject(t *testing.T) {
+	m := Model{1, -2, 0, 3}
+	if ints := m.ToInts(); !reflect.DeepEqual(ints, []int{1, -2, 4}) {
+		t.Errorf("invalid DIMACS model: expected [1 -2 4], got %v", ints)
+	}
+	if ints := m.Project([]Var{3, 1, 2, 7}); !reflect.DeepEqual(ints, []int{4, -2}) {
+		t.Errorf("invalid projected model: expected [4 -2], got %v", ints)
+	}
+	pb := ParseSlice([][]int{{1, 2}, {-1}})
+	sel := pb.ReserveVars(1)[0]
+	s := New(pb)
+	if s.LastModel() != nil {
+		t.Errorf("no model should be available yet")
+	}
+	if s.Solve() != Sat {
+		t.Fatalf("problem should be sat")
+	}
+	if model := s.LastModel(); len(model) != 3 || !reflect.DeepEqual(model.Project([]Var{0, 1}), []int{-1, 2}) || sel != 2 {
+		t.Errorf("invalid last model %v", model)
+	}
+}
+
+func TestParseCNFMultiLine(t *testing.T) {
+	cnf := "c clauses can span several lines\np cnf 3 3\n1 2\n-3 0 -1 2 0\n\t3 -2 0"
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse CNF: %v", err)
+	}
+	if len(pb.Clauses) != 3 || pb.Clauses[0].Len() != 3 || pb.Clauses[1].Len() != 2 || pb.Clauses[2].Len() != 2 {
+		t.Errorf("invalid clauses %q", pb.CNF())
+	}
+}
+
+func TestParseCNFRecover(t *testing.T) {
+	cnf := "p cnf 3 4\n1 2 0\n1 x 0\n-1 4 0\n-2 3 0\n-3 -1\n"
+	if _, err := ParseCNF(strings.NewReader(cnf)); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3, got %v", err)
+	}
+	pb, err := ParseCNFOptions(strings.NewReader(cnf), ParseOptions{Recover: true})
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expected 3 syntax errors, got %v", err)
+	}
+	for i, line := range []int{3, 4, 6} {
+		if errs[i].Line != line {
+			t.Errorf("expected error #%d on line %d, got %v", i, line, errs[i])
+		}
+	}
+	if len(pb.Clauses) != 2 {
+		t.Errorf("expected 2 valid clauses, got %q", pb.CNF())
+	}
+}
+
+func TestParseCNFLenient(t *testing.T) {
+	for _, test := range []struct {
+		cnf   string
+		valid bool // Whether the CNF is valid in strict mode, too
+	}{
+		{"1 -2 0\n2 3 0\n-3 -1 0\n", false},            // No header
+		{"p cnf 2 1\n1 -2 0\n2 3 0\n-3 -1 0\n", false}, // Wrong header
+		{"c comment\np cnf 3 3\n1 -2 0\n2 3 0\n-3 -1 0\n", true},
+	} {
+		if _, err := ParseCNF(strings.NewReader(test.cnf)); (err == nil) != test.valid {
+			t.Errorf("invalid result in strict mode for %q: %v", test.cnf, err)
+		}
+		pb, err := ParseCNFOptions(strings.NewReader(test.cnf), ParseOptions{Lenient: true})
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.cnf, err)
+		}
+		if pb.NbVars != 3 || len(pb.Clauses) != 3 {
+			t.Errorf("expected 3 vars and 3 clauses for %q, got %d and %d", test.cnf, pb.NbVars, len(pb.Clauses))
+		}
+		if status := New(pb).Solve(); status != Sat {
+			t.Errorf("expected sat problem for %q, got %v", test.cnf, status)
+		}
+	}
+	pb, err := ParseCNFOptions(strings.NewReader("p cnf 5 1\n1 -2 0\n"), ParseOptions{Lenient: true})
+	if err != nil || pb.NbVars != 5 {
+		t.Errorf("expected the 5 vars announced in the header, got %v, %v", pb, err)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	parseICNF := func(r io.Reader) (*Problem, error) {
+		_, err := ParseICNF(r)
+		return nil, err
+	}
+	for _, test := range []struct {
+		name   string
+		parse  func(io.Reader) (*Problem, error)
+		input  string
+		line   int
+		column int
+		kind   ParseErrorKind
+		token  string
+		cause  error
+	}{
+		{"CNF", ParseCNF, "p cnf 3 2\n1 2 0\n-1 3 x 0\n", 3, 6, InvalidToken, "x", ErrBadLiteral},
+		{"CNF", ParseCNF, "p cnf 3 2\n1 2 0 -1 3 4 0\n", 2, 12, OutOfRange, "4", ErrBadLiteral},
+		{"CNF", ParseCNF, "c no header\n1 2 0\n", 2, 1, InvalidHeader, "1", ErrBadHeader},
+		{"CNF", ParseCNF, "p cnf 3 x\n", 1, 0, InvalidHeader, "", ErrBadHeader},
+		{"CNF", ParseCNF, "p cnf 3 1\n1 2\n", 2, 0, InvalidSyntax, "", ErrTruncated},
+		{"OPB", ParseOPB, "* comment\n+1 x1 >= 1 ;\n+1 x1 +2 >= 1 ;\n", 3, 0, InvalidSyntax, "", ErrBadConstraint},
+		{"OPB", ParseOPB, "+1 x1 >= 1 ;\n+1 x1 +2 x0 >= 1 ;\n", 2, 10, OutOfRange, "x0", ErrBadLiteral},
+		{"OPB", ParseOPB, "+1 x1 => 1 ;\n", 1, 7, InvalidToken, "=>", ErrBadOperator},
+		{"OPB", ParseOPB, "+a x1 >= 1 ;\n", 1, 1, InvalidToken, "+a", ErrBadWeight},
+		{"WBO", ParseWBO, "soft: 3 ;\n[2] +1 x1 >= 1 ;\n[a] +1 x2 >= 1 ;\n", 3, 2, InvalidToken, "a", ErrBadWeight},
+		{"WCNF", ParseWCNF, "p wcnf 2 2 10\n10 1 2 0\n3 -1 x 0\n", 3, 6, InvalidToken, "x", ErrBadLiteral},
+		{"WCNF", ParseWCNF, "p wcnf 2 x 10\n", 1, 10, InvalidHeader, "x", ErrBadHeader},
+		{"iCNF", parseICNF, "p inccnf\na 1 2\n", 2, 0, InvalidSyntax, "", ErrBadConstraint},
+		{"iCNF", parseICNF, "p inccnf\n1 2\n", 2, 0, InvalidSyntax, "", ErrTruncated},
+		{"iCNF", parseICNF, "p inccnf\n1  y 0\n", 2, 4, InvalidToken, "y", ErrBadLiteral},
+	} {
+		_, err := test.parse(strings.NewReader(test.input))
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%s: expected a ParseError for %q, got %v", test.name, test.input, err)
+			continue
+		}
+		if perr.Line != test.line || perr.Column != test.column {
+			t.Errorf("%s: expected error at line %d, byte %d, got %v", test.name, test.line, test.column, perr)
+		}
+		if perr.Kind != test.kind || perr.Token != test.token {
+			t.Errorf("%s: expected %v on token %q, got %v on token %q", test.name, test.kind, test.token, perr.Kind, perr.Token)
+		}
+		if !errors.Is(err, test.cause) || perr.Recoverable() != (test.cause != ErrTruncated) {
+			t.Errorf("%s: expected error caused by %v, got %v caused by %v", test.name, test.cause, perr, perr.Err)
+		}
+	}
+	if kind := ParseErrorKind(42).String(); kind != "ParseErrorKind(42)" {
+		t.Errorf("unexpected name %q for an unknown kind", kind)
+	}
+}
+
+func TestParseCNFKeepComments(t *testing.T) {
+	cnf := "c generated by some tool\nc seed 42\np cnf 3 2\n1 -2 0\nc middle\n2 3 0\nc end\n"
+	pb, err := ParseCNFOptions(strings.NewReader(cnf), ParseOptions{KeepComments: true})
+	if err != nil {
+		t.Fatalf("could not parse CNF: %v", err)
+	}
+	expected := &Metadata{Header: "p cnf 3 2", Comments: []string{"generated by some tool", "seed 42", "middle", "end"}, Positions: []int{0, 0, 2, 3}}
+	if !reflect.DeepEqual(pb.Metadata, expected) {
+		t.Fatalf("invalid metadata: expected %+v, got %+v", expected, pb.Metadata)
+	}
+	if str := pb.CNF(); str != cnf {
+		t.Errorf("comments were not written at their position: expected %q, got %q", cnf, str)
+	}
+	pb2, err := ParseCNFOptions(strings.NewReader(pb.CNF()), ParseOptions{KeepComments: true})
+	if err != nil {
+		t.Fatalf("could not parse written CNF: %v", err)
+	}
+	if !reflect.DeepEqual(pb2.Metadata.Comments, expected.Comments) {
+		t.Errorf("comments were not kept when writing the problem: got %q", pb2.Metadata.Comments)
+	}
+	if pb, _ := ParseCNF(strings.NewReader(cnf)); pb.Metadata != nil {
+		t.Errorf("metadata should not be kept by default")
+	}
+}
+
+func TestParseCNFTolerance(t *testing.T) {
+	const cnf = "p cnf 3 4\n1 2 1 0\n-1 2 1 0\n2 4 0\n"
+	var stats ParseStats
+	pb, err := ParseCNFOptions(strings.NewReader(cnf), ParseOptions{VarRange: Normalize, Stats: &stats})
+	if err != nil {
+		t.Fatalf("could not parse CNF with default tolerances: %v", err)
+	}
+	if pb.NbVars != 4 || len(pb.Clauses) != 2 || pb.Clauses[0].Len() != 2 || pb.Warnings != nil {
+		t.Errorf("expected a normalized problem, got %q, warnings %v", pb.CNF(), pb.Warnings)
+	}
+	if stats.NbConstrs != 3 || stats.NbTautologies != 1 || stats.NbDuplicates != 1 {
+		t.Errorf("invalid stats %+v", stats)
+	}
+	opts := ParseOptions{ClauseCount: Warn, VarRange: Warn, DuplicateLits: Warn, Tautologies: Warn}
+	pb, err = ParseCNFOptions(strings.NewReader(cnf), opts)
+	if err != nil {
+		t.Fatalf("could not parse CNF with warnings: %v", err)
+	}
+	kinds := []ParseErrorKind{Redundant, Redundant, OutOfRange, InvalidHeader}
+	if len(pb.Warnings) != len(kinds) {
+		t.Fatalf("expected %d warnings, got %v", len(kinds), pb.Warnings)
+	}
+	for i, kind := range kinds {
+		if pb.Warnings[i].Kind != kind {
+			t.Errorf("expected warning #%d to be of kind %v, got %v", i, kind, pb.Warnings[i])
+		}
+	}
+	for _, opts := range []ParseOptions{
+		{},
+		{VarRange: Normalize, ClauseCount: Reject},
+		{VarRange: Normalize, DuplicateLits: Reject},
+		{VarRange: Normalize, Tautologies: Reject},
+	} {
+		if _, err := ParseCNFOptions(strings.NewReader(cnf), opts); err == nil {
+			t.Errorf("expected an error with options %+v", opts)
+		}
+	}
+}
+
+func TestParseCNFValidateHeader(t *testing.T) {
+	const cnf = "p cnf 3 4\n1 2 0\n-1 5 0\nc comment\n2 4 -5 0\n"
+	_, err := ParseCNFOptions(strings.NewReader(cnf), ParseOptions{ValidateHeader: true, VarRange: Normalize})
+	report, ok := err.(*HeaderReport)
+	if !ok || !errors.Is(err, ErrBadHeader) {
+		t.Fatalf("expected a header report, got %v", err)
+	}
+	expected := HeaderReport{Line: 1, NbVars: 3, NbClauses: 4, MaxVar: 5, VarLines: []int{3, 5}, NbRead: 3}
+	if !reflect.DeepEqual(*report, expected) {
+		t.Errorf("expected report %+v, got %+v", expected, *report)
+	}
+	const msg = "line 1: inconsistent header: header announced 3 vars, but var 5 is used, on 2 lines starting at line 3; header announced 4 clauses, but 3 were found"
+	if report.Error() != msg {
+		t.Errorf("expected message %q, got %q", msg, report.Error())
+	}
+	pb, err := ParseCNFOptions(strings.NewReader("p cnf 3 2\n1 2 0\n-1 3 0\n"), ParseOptions{ValidateHeader: true})
+	if err != nil || pb.NbVars != 3 {
+		t.Errorf("expected a consistent header to be accepted, got error %v", err)
+	}
+}
+
+func TestParseOptionsValidate(t *testing.T) {
+	for _, opts := range []ParseOptions{{}, {Lenient: true, VarRange: Warn}, {Recover: true, Tautologies: Reject}} {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("unexpected error with options %+v: %v", opts, err)
+		}
+	}
+	for _, opts := range []ParseOptions{{MaxLineSize: -1}, {DuplicateLits: Normalize + 1}, {Lenient: true, VarRange: Reject}, {Lenient: true, ValidateHeader: true}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected an error with options %+v", opts)
+		}
+		if _, err := ParseCNFOptions(strings.NewReader("p cnf 1 1\n1 0\n"), opts); err == nil {
+			t.Errorf("expected parsing to fail with options %+v", opts)
+		}
+	}
+}
+
+func TestParseCNFPlus(t *testing.T) {
+	const cnf = "p cnf+ 4 5\n1 2 3 4 >= 2 0\n1 2 3 4 <= 2\n-1 0\n-2 -3 4 = 2 0\n2 0\n"
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse CNF+: %v", err)
+	}
+	s := New(pb)
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("expected sat, got %v", status)
+	}
+	model := s.Model()
+	nbTrue := 0
+	for _, b := range model {
+		if b {
+			nbTrue++
+		}
+	}
+	if nbTrue != 2 || !model[IntToVar(2)] || !model[IntToVar(4)] {
+		t.Errorf("invalid model %v", model)
+	}
+	const card = "p cnf+ 3 1\n1 2 3 >= 2 0\n"
+	if pb, err := ParseCNF(strings.NewReader(card)); err != nil {
+		t.Errorf("could not parse %q: %v", card, err)
+	} else if pb.CNF() != card {
+		t.Errorf("expected %q to be written unchanged, got %q", card, pb.CNF())
+	}
+	if _, err := ParseCNF(strings.NewReader("p cnf 3 1\n1 2 3 >= 2 0\n")); err == nil {
+		t.Errorf("expected an error for a cardinality constraint in a CNF file")
+	}
+	if pb, err := ParseCNFOptions(strings.NewReader("p cnf 3 1\n1 2 3 >= 2 0\n"), ParseOptions{Lenient: true}); err != nil || len(pb.Clauses) != 1 {
+		t.Errorf("expected a cardinality constraint in lenient mode, got %v", err)
+	}
+	for _, cnf := range []string{
+		"p cnf+ 3 1\n1 2 1 >= 2 0\n",
+		"p cnf+ 3 1\n1 2 3 >= -1 0\n",
+		"p cnf+ 3 1\n1 2 3 >=\n",
+	} {
+		if _, err := ParseCNF(strings.NewReader(cnf)); err == nil {
+			t.Errorf("expected an error for %q", cnf)
+		}
+	}
+	pb, err = ParseCNF(strings.NewReader("p cnf+ 3 1\n1 2 >= 3 0\n"))
+	if err != nil || pb.Status != Unsat {
+		t.Errorf("expected unsat problem, got %v, error %v", pb.Status, err)
+	}
+}
+
+func TestParseKNF(t *testing.T) {
+	const knf = "c at least 2 of 1, 2, 3, but not 1 and 2\np knf 3 3\nk 2 1 2 3 0\n-1 -2 0\nk 1 -3\n 1 0\n"
+	pb, err := ParseCNF(strings.NewReader(knf))
+	if err != nil {
+		t.Fatalf("could not parse KNF: %v", err)
+	}
+	s := New(pb)
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("expected sat, got %v", status)
+	}
+	if model := s.Model(); !model[IntToVar(1)] || model[IntToVar(2)] || !model[IntToVar(3)] {
+		t.Errorf("invalid model %v", model)
+	}
+	pb, err = ParseCNF(strings.NewReader("p knf 2 1\nk 3 1 2 0\n"))
+	if err != nil || pb.Status != Unsat {
+		t.Errorf("expected unsat problem, got %v, error %v", pb.Status, err)
+	}
+	for _, knf := range []string{
+		"p cnf 3 1\nk 2 1 2 3 0\n",
+		"p knf 3 1\n1 k 2 2 3 0\n",
+		"p knf 3 1\nk -1 1 2 3 0\n",
+		"p knf 3 1\nk\n",
+		"p knf 3 1\nk 2 1 2 3\n",
+	} {
+		if _, err := ParseCNF(strings.NewReader(knf)); err == nil {
+			t.Errorf("expected an error for %q", knf)
+		}
+	}
+}
+
+func TestParseCNFInd(t *testing.T) {
+	const cnf = "c ind 3 1 0\np cnf 4 2\nc ind 4\n1 2 0\n-3 4 0\n"
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse CNF: %v", err)
+	}
+	if !reflect.DeepEqual(pb.ProjectionVars, []Var{2, 0, 3}) {
+		t.Errorf("invalid projection vars %v", pb.ProjectionVars)
+	}
+	const expected = "c ind 1 3 4 0\np cnf 4 2\n1 2 0\n-3 4 0\n"
+	if written := pb.CNFOrdered(CanonicalOrder); written != expected {
+		t.Errorf("expected written problem %q, got %q", expected, written)
+	}
+	if pb, _ := ParseCNF(strings.NewReader("p cnf 1 1\nc indeed\n1 0\n")); pb.ProjectionVars != nil {
+		t.Errorf("expected no projection vars, got %v", pb.ProjectionVars)
+	}
+	for _, cnf := range []string{
+		"c ind 1 2 0\np cnf 1 1\n1 0\n",
+		"p cnf 2 1\nc ind 1 -2 0\n1 0\n",
+		"p cnf 2 1\nc ind 1 0 2\n1 0\n",
+		"p cnf 2 1\nc ind 1 x 0\n1 0\n",
+	} {
+		if _, err := ParseCNF(strings.NewReader(cnf)); err == nil {
+			t.Errorf("expected an error for %q", cnf)
+		}
+	}
+}
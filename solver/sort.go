@@ -21,3 +21,10 @@ func sortLiterals(lits []Lit, model []decLevel) {
 	cs := &clauseSorter{lits, model}
 	sort.Sort(cs)
 }
+
+// litSorter sorts lits by increasing value.
+type litSorter []Lit
+
+func (ls litSorter) Len() int           { return len(ls) }
+func (ls litSorter) Less(i, j int) bool { return ls[i] < ls[j] }
+func (ls litSorter) Swap(i, j int)      { ls[i], ls[j] = ls[j], ls[i] }
@@ -0,0 +1,103 @@
+package solver
+
+import "fmt"
+
+// This file deals with problems whose vars are identified by arbitrary ints, such as database keys or hashes,
+// rather than numbered from 1 without gaps. Since solvers allocate arrays indexed by vars, such ids are
+// mapped to dense vars, in the order they first appear, and the mapping is kept to decode models.
+
+// A SparseVars maps arbitrary, possibly huge, user ids to the dense vars of a problem.
+// Ids are strictly positive; a negative value stands for the negation of the var whose id is its opposite,
+// as in DIMACS. Its zero value is not ready to use: use NewSparseVars.
+type SparseVars struct {
+	ids  []int       // For each var, the id it stands for
+	vars map[int]Var // For each id, the var it is mapped to
+}
+
+// NewSparseVars returns an empty mapping.
+func NewSparseVars() *SparseVars {
+	return &SparseVars{vars: make(map[int]Var)}
+}
+
+// Len returns the number of ids mapped so far, i.e the number of vars they are mapped to.
+func (sv *SparseVars) Len() int {
+	return len(sv.ids)
+}
+
+// Var returns the var id is mapped to, mapping it to a fresh var if it was not mapped yet.
+// Will panic if id is not strictly positive.
+func (sv *SparseVars) Var(id int) Var {
+	if id <= 0 {
+		panic(fmt.Sprintf("invalid var id %d", id))
+	}
+	v, ok := sv.vars[id]
+	if !ok {
+		v = Var(len(sv.ids))
+		sv.vars[id] = v
+		sv.ids = append(sv.ids, id)
+	}
+	return v
+}
+
+// Lookup returns the var id is mapped to, and false if it was not mapped.
+func (sv *SparseVars) Lookup(id int) (Var, bool) {
+	v, ok := sv.vars[id]
+	return v, ok
+}
+
+// ID returns the id v stands for. Will panic if v is not mapped to any id.
+func (sv *SparseVars) ID(v Var) int {
+	return sv.ids[v]
+}
+
+// Lits returns the lits equivalent to the given signed ids, mapping new ids to fresh vars.
+// They can be used to add clauses to a solver, or as assumptions.
+// Will panic if a value is 0.
+func (sv *SparseVars) Lits(vals []int) []Lit {
+	lits := make([]Lit, len(vals))
+	for i, val := range vals {
+		lits[i] = sv.lit(val)
+	}
+	return lits
+}
+
+// lit returns the lit equivalent to the signed id val.
+func (sv *SparseVars) lit(val int) Lit {
+	if val < 0 {
+		return sv.Var(-val).SignedLit(true)
+	}
+	return sv.Var(val).Lit()
+}
+
+// Decode returns the binding of each id, according to model, whose vars are the ones ids are mapped to.
+// Ids whose var is not part of model are not part of the result.
+func (sv *SparseVars) Decode(model []bool) map[int]bool {
+	res := make(map[int]bool, len(sv.ids))
+	for v, id := range sv.ids {
+		if v < len(model) {
+			res[id] = model[v]
+		}
+	}
+	return res
+}
+
+// ParseSliceSparse is like ParseSlice, except vars are arbitrary ids, that are mapped to dense vars
+// in the order they first appear in cnf. It returns the problem and the mapping.
+// If a clause contains the lit 0 or a lit cannot be negated, a *ConstrError identifying that clause is returned.
+func ParseSliceSparse(cnf [][]int) (*Problem, *SparseVars, error) {
+	sv := NewSparseVars()
+	dense := make([][]int, len(cnf))
+	for i, clause := range cnf {
+		dense[i] = make([]int, len(clause))
+		for j, val := range clause {
+			if val == 0 || val == -val { // The smallest int is its own opposite
+				return nil, nil, &ConstrError{Index: i, Msg: fmt.Sprintf("invalid literal %d at position %d", val, j), Err: ErrBadLiteral}
+			}
+			dense[i][j] = int(sv.lit(val).Int())
+		}
+		if sv.Len() > maxVar {
+			return nil, nil, &ConstrError{Index: i, Msg: fmt.Sprintf("more than %d vars", maxVar), Err: ErrBadLiteral}
+		}
+	}
+	return ParseSliceNb(dense, sv.Len()), sv, nil
+}
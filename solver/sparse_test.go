@@ -0,0 +1,41 @@
+package solver
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSliceSparse(t *testing.T) {
+	const big = math.MaxInt
+	pb, sv, err := ParseSliceSparse([][]int{{big, -7}, {7, 3000}, {-big, -3000}, {-7}})
+	if err != nil {
+		t.Fatalf("could not parse sparse problem: %v", err)
+	}
+	if pb.NbVars != 3 || sv.Len() != 3 || sv.ID(0) != big || sv.ID(2) != 3000 {
+		t.Fatalf("invalid mapping: %d vars, ids %v", pb.NbVars, sv.ids)
+	}
+	s := New(pb)
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("problem should be sat, got %v", status)
+	}
+	expected := map[int]bool{big: false, 7: false, 3000: true}
+	if model := sv.Decode(s.Model()); !reflect.DeepEqual(model, expected) {
+		t.Errorf("expected model %v, got %v", expected, model)
+	}
+	s.AppendClause(NewClause(sv.Lits([]int{-3000, 123456789})))
+	if status := s.Solve(); status != Sat {
+		t.Fatalf("problem should be sat, got %v", status)
+	}
+	if model := sv.Decode(s.Model()); !model[123456789] || len(model) != 4 {
+		t.Errorf("clause with a new id was not enforced: got %v", model)
+	}
+	if _, ok := sv.Lookup(42); ok {
+		t.Errorf("unknown id should not be mapped")
+	}
+	if _, _, err := ParseSliceSparse([][]int{{1, 2}, {3, 0}}); err == nil {
+		t.Errorf("expected an error for the lit 0")
+	} else if cerr, ok := err.(*ConstrError); !ok || cerr.Index != 1 {
+		t.Errorf("expected an error on clause #1, got %v", err)
+	}
+}
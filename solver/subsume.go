@@ -0,0 +1,201 @@
+package solver
+
+import "sort"
+
+// This file deals with the detection of duplicate and subsumed clauses.
+// To scale to problems with millions of clauses, no pair of clauses is ever compared blindly.
+// Each clause is associated with a signature, a 64-bit bloom filter of its lits: if c subsumes c2,
+// all bits of c's signature are set in c2's, so most candidate pairs are rejected without looking at their lits.
+// Duplicates are found the same way: only clauses sharing the least frequent lit of a clause, with the same signature,
+// are compared to it. All passes work on the same occurrence lists (see occurs.go).
+
+// A signature is a bloom filter of the lits of a clause.
+type signature uint64
+
+// litSignature returns the bit associated with lit in signatures.
+func litSignature(lit Lit) signature {
+	return 1 << (uint64(lit) * 0x9E3779B97F4A7C15 >> 58)
+}
+
+// signature returns the signature of c.
+func (c *Clause) signature() signature {
+	var sig signature
+	for _, lit := range c.lits {
+		sig |= litSignature(lit)
+	}
+	return sig
+}
+
+// equal returns true iff c and c2 have the same lits, with the same weights, and the same cardinality.
+// weights is a buffer with one zeroed value per lit, and is zeroed again when the function returns.
+// Both clauses must be free of duplicate lits.
+func (c *Clause) equal(c2 *Clause, weights []int) bool {
+	if c.isBig() || c2.isBig() {
+		return c.equalBig(c2)
+	}
+	if c.Len() != c2.Len() || c.Cardinality() != c2.Cardinality() {
+		return false
+	}
+	for i, lit := range c.lits {
+		weights[lit] = c.Weight(i)
+	}
+	res := true
+	for i, lit := range c2.lits {
+		if weights[lit] != c2.Weight(i) {
+			res = false
+			break
+		}
+	}
+	for _, lit := range c.lits {
+		weights[lit] = 0
+	}
+	return res
+}
+
+// propositional returns true iff c is a plain propositional clause, i.e neither a cardinality nor a PB constraint.
+func (c *Clause) propositional() bool {
+	return !c.PseudoBoolean() && c.Cardinality() == 1
+}
+
+// RemoveDuplicates removes from pb all clauses, including cardinality and PB constraints,
+// that are equal to a previous clause of the problem, and returns how many clauses were removed.
+// Two clauses are equal if they have the same lits, in any order, with the same weights and the same cardinality.
+// It must be called before the problem is given to a solver.
+func (pb *Problem) RemoveDuplicates() int {
+	occ := pb.occurrences()
+	nb := occ.removeDuplicates()
+	pb.Clauses = occ.compact()
+	return nb
+}
+
+// removeDuplicates removes all clauses of occ that are equal to a previous clause, and returns how many were removed.
+func (occ *occurrences) removeDuplicates() int {
+	weights := make([]int, len(occ.lists))
+	nbRemoved := 0
+	firstEmpty := -1
+	for i, c := range occ.clauses {
+		if occ.removed[i] || !occ.indexed[i] {
+			continue
+		}
+		if c.Len() == 0 { // Empty clauses do not appear in any list
+			if firstEmpty == -1 {
+				firstEmpty = i
+			} else if c.equal(occ.clauses[firstEmpty], weights) {
+				occ.remove(i)
+				nbRemoved++
+			}
+			continue
+		}
+		// Only clauses containing c's least frequent lit can be equal to c
+		best := c.Get(0)
+		for j := 1; j < c.Len(); j++ {
+			if lit := c.Get(j); occ.nbOccurs(lit) < occ.nbOccurs(best) {
+				best = lit
+			}
+		}
+		for _, j := range occ.occurs(best) {
+			if j > i && !occ.removed[j] && occ.sigs[i] == occ.sigs[j] && c.equal(occ.clauses[j], weights) {
+				occ.remove(j)
+				nbRemoved++
+			}
+		}
+	}
+	return nbRemoved
+}
+
+// clausesByLen sorts the indices of clauses by increasing length.
+type clausesByLen struct {
+	idx     []int
+	clauses []*Clause
+}
+
+func (cl clausesByLen) Len() int { return len(cl.idx) }
+func (cl clausesByLen) Less(i, j int) bool {
+	return cl.clauses[cl.idx[i]].Len() < cl.clauses[cl.idx[j]].Len()
+}
+func (cl clausesByLen) Swap(i, j int) { cl.idx[i], cl.idx[j] = cl.idx[j], cl.idx[i] }
+
+// Subsume removes from pb all propositional clauses that are subsumed by another propositional clause,
+// i.e that contain all its lits, and returns how many clauses were removed.
+// When a clause appears several times, only its first occurrence is kept.
+// Cardinality and PB constraints are left untouched.
+// It must be called before the problem is given to a solver.
+func (pb *Problem) Subsume() int {
+	occ := pb.occurrences()
+	nb := occ.subsume()
+	pb.Clauses = occ.compact()
+	return nb
+}
+
+// Simplify removes from pb the clauses that are equal to a previous clause or subsumed by another clause,
+// as done by RemoveDuplicates, then Subsume, and returns how many clauses were removed.
+// Both passes share the same occurrence lists, that are built only once.
+// It must be called before the problem is given to a solver.
+func (pb *Problem) Simplify() int {
+	occ := pb.occurrences()
+	nb := occ.removeDuplicates()
+	nb += occ.subsume()
+	pb.Clauses = occ.compact()
+	return nb
+}
+
+// subsume removes all propositional clauses of occ that are subsumed by another propositional clause,
+// and returns how many were removed.
+func (occ *occurrences) subsume() int {
+	var idx []int
+	for i, c := range occ.clauses {
+		// Empty clauses do not appear in any list, so they cannot be found as candidates: they are left untouched,
+		// as they make the problem unsat anyway.
+		if occ.indexed[i] && !occ.removed[i] && c.propositional() && c.Len() > 0 {
+			idx = append(idx, i)
+		}
+	}
+	sort.Stable(clausesByLen{idx: idx, clauses: occ.clauses})
+	marks := make([]bool, len(occ.lists))
+	nbRemoved := 0
+	for _, i := range idx {
+		if occ.removed[i] {
+			continue
+		}
+		nbRemoved += occ.subsumeWith(i, marks)
+	}
+	return nbRemoved
+}
+
+// subsumeWith removes all indexed propositional clauses that are subsumed by the clause #i,
+// which must neither be removed nor empty, and returns how many were removed. Clauses equal to the clause #i are removed, too.
+// marks is a buffer with one false value per lit, and is reset when the function returns.
+func (occ *occurrences) subsumeWith(i int, marks []bool) int {
+	c := occ.clauses[i]
+	// Only clauses containing c's least frequent lit can be subsumed by c
+	best := c.lits[0]
+	for _, lit := range c.lits[1:] {
+		if occ.nbOccurs(lit) < occ.nbOccurs(best) {
+			best = lit
+		}
+	}
+	for _, lit := range c.lits {
+		marks[lit] = true
+	}
+	nbRemoved := 0
+	for _, j := range occ.occurs(best) {
+		c2 := occ.clauses[j]
+		if j == i || occ.removed[j] || c2.Len() < c.Len() || occ.sigs[i]&^occ.sigs[j] != 0 || !c2.propositional() {
+			continue
+		}
+		nbCommon := 0
+		for _, lit := range c2.lits {
+			if marks[lit] {
+				nbCommon++
+			}
+		}
+		if nbCommon == c.Len() {
+			occ.remove(j)
+			nbRemoved++
+		}
+	}
+	for _, lit := range c.lits {
+		marks[lit] = false
+	}
+	return nbRemoved
+}
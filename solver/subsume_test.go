@@ -0,0 +1,76 @@
+package solver
+
+import "testing"
+
+func TestSignature(t *testing.T) {
+	c := NewClause(IntsToLits(1, -2, 3))
+	c2 := NewClause(IntsToLits(4, 3, 1, -2))
+	if sig, sig2 := c.signature(), c2.signature(); sig&^sig2 != 0 {
+		t.Errorf("signature of %s is not included in signature of %s", c.CNF(), c2.CNF())
+	}
+	if NewClause(IntsToLits(2, 1)).signature() != NewClause(IntsToLits(1, 2)).signature() {
+		t.Errorf("signature depends on the order of lits")
+	}
+}
+
+func TestRemoveDuplicates(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {2, 1}, {1, 2, 3}, {-1, 3}, {3, -1}, {1, 2}})
+	pb.Clauses = append(pb.Clauses,
+		NewCardClause(IntsToLits(1, 2, 3), 2),
+		NewCardClause(IntsToLits(3, 2, 1), 2),
+		NewPBClause(IntsToLits(1, 2, 3), []int{1, 2, 3}, 3),
+		NewPBClause(IntsToLits(1, 2, 3), []int{3, 2, 1}, 3),
+		NewPBClause(IntsToLits(3, 2, 1), []int{1, 2, 3}, 3),
+	)
+	if nb := pb.RemoveDuplicates(); nb != 5 {
+		t.Errorf("expected 5 duplicates, got %d", nb)
+	}
+	if len(pb.Clauses) != 6 {
+		t.Errorf("expected 6 remaining clauses, got %d", len(pb.Clauses))
+	}
+	if nb := pb.RemoveDuplicates(); nb != 0 {
+		t.Errorf("expected no duplicate after removal, got %d", nb)
+	}
+}
+
+func TestSubsume(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2, 3}, {1, 2}, {-1, 3, 4}, {2, 1}, {3, 4, -1, 5}, {-3, -4}, {2, 4}})
+	pb.Clauses = append(pb.Clauses, NewCardClause(IntsToLits(1, 2, 3), 2))
+	if nb := pb.Subsume(); nb != 3 {
+		t.Errorf("expected 3 subsumed clauses, got %d", nb)
+	}
+	expected := []string{"1 2 0", "-1 3 4 0", "-3 -4 0", "2 4 0"}
+	if len(pb.Clauses) != len(expected)+1 {
+		t.Fatalf("expected %d remaining clauses, got %d", len(expected)+1, len(pb.Clauses))
+	}
+	for i, cnf := range expected {
+		if got := pb.Clauses[i].CNF(); got != cnf {
+			t.Errorf("expected clause #%d to be %q, got %q", i, cnf, got)
+		}
+	}
+	if pb.Clauses[len(expected)].Cardinality() != 2 {
+		t.Errorf("cardinality constraint should be kept")
+	}
+	pb = &Problem{NbVars: 2, Clauses: []*Clause{NewClause(nil), NewClause(IntsToLits(1))}}
+	if nb := pb.Subsume(); nb != 0 || len(pb.Clauses) != 2 {
+		t.Errorf("empty clause should be left untouched, got %d removed clauses and %v", nb, pb.Clauses)
+	}
+	pb = &Problem{NbVars: 2, Clauses: []*Clause{NewClause(nil), NewClause(IntsToLits(1)), NewClause(nil)}}
+	if nb := pb.Simplify(); nb != 1 || len(pb.Clauses) != 2 {
+		t.Errorf("expected only the duplicate empty clause to be removed, got %d removed clauses and %v", nb, pb.Clauses)
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2, 3}, {2, 1}, {1, 2}, {-1, 3}, {3, -1, 4}})
+	pb.Clauses = append(pb.Clauses, NewCardClause(IntsToLits(1, 2, 3), 2), NewCardClause(IntsToLits(3, 2, 1), 2))
+	if nb := pb.Simplify(); nb != 4 {
+		t.Errorf("expected 4 removed clauses, got %d", nb)
+	}
+	if len(pb.Clauses) != 3 || pb.Clauses[0].CNF() != "2 1 0" || pb.Clauses[1].CNF() != "-1 3 0" || pb.Clauses[2].Cardinality() != 2 {
+		t.Errorf("invalid remaining clauses %v", pb.Clauses)
+	}
+	if status := New(pb).Solve(); status != Sat {
+		t.Errorf("expected sat, got %v", status)
+	}
+}
@@ -0,0 +1,247 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file deals with tree decompositions of the interaction graph of a problem.
+// A tree decomposition can either be computed with the min-degree elimination heuristic,
+// or imported from a file in the PACE format.
+// It can then be used to guide the solver's decisions, or to count models by dynamic programming (see count_td.go),
+// which is efficient as long as the width of the decomposition is small.
+
+// MaxCountWidth is the maximal width of a tree decomposition that can be used to count models.
+const MaxCountWidth = 24
+
+// A TreeDecomposition is a tree whose nodes are bags of variables, such that
+// all variables appearing in a clause belong to a same bag, and
+// the bags containing any given variable form a subtree.
+type TreeDecomposition struct {
+	Bags   [][]Var // Variables in each bag
+	Parent []int   // Index of the parent of each bag, or -1 if the bag is a root
+}
+
+// Width returns the width of td, i.e the size of its largest bag minus one.
+func (td *TreeDecomposition) Width() int {
+	width := -1
+	for _, bag := range td.Bags {
+		if len(bag)-1 > width {
+			width = len(bag) - 1
+		}
+	}
+	return width
+}
+
+// postOrder returns the indices of the bags of td, each child being before its parent.
+func (td *TreeDecomposition) postOrder() []int {
+	children := make([][]int, len(td.Bags))
+	var roots []int
+	for i, p := range td.Parent {
+		if p == -1 {
+			roots = append(roots, i)
+		} else {
+			children[p] = append(children[p], i)
+		}
+	}
+	res := make([]int, 0, len(td.Bags))
+	for _, root := range roots {
+		stack := []int{root}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			res = append(res, i)
+			stack = append(stack, children[i]...)
+		}
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 { // Parents were added before their children
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+// depths returns the depth of each bag of td, roots having a depth of 0.
+func (td *TreeDecomposition) depths() []int {
+	order := td.postOrder()
+	res := make([]int, len(td.Bags))
+	for i := len(order) - 1; i >= 0; i-- {
+		if p := td.Parent[order[i]]; p != -1 {
+			res[order[i]] = res[p] + 1
+		}
+	}
+	return res
+}
+
+// TreeDecomposition computes a tree decomposition of the interaction graph of pb,
+// using the min-degree elimination heuristic.
+// Each variable of the problem, even one that appears in no clause, belongs to at least one bag.
+func (pb *Problem) TreeDecomposition() *TreeDecomposition {
+	neighbors := pb.neighbors()
+	for v := range neighbors {
+		if neighbors[v] == nil {
+			neighbors[v] = make(map[Var]int)
+		}
+	}
+	eliminated := make([]bool, pb.NbVars)
+	bagOf := make([]int, pb.NbVars) // Bag created when each var was eliminated
+	td := &TreeDecomposition{}
+	for n := 0; n < pb.NbVars; n++ {
+		v := Var(-1)
+		for v2 := range neighbors {
+			if !eliminated[v2] && (v == -1 || len(neighbors[v2]) < len(neighbors[v])) {
+				v = Var(v2)
+			}
+		}
+		bag := append([]Var{v}, sortedNeighbors(neighbors, v)...)
+		for _, v2 := range bag[1:] {
+			delete(neighbors[v2], v)
+			for _, v3 := range bag[1:] {
+				if v2 != v3 {
+					neighbors[v2][v3]++
+				}
+			}
+		}
+		eliminated[v] = true
+		bagOf[v] = len(td.Bags)
+		td.Bags = append(td.Bags, bag)
+		td.Parent = append(td.Parent, -1)
+	}
+	// The parent of a bag is the bag of the first eliminated var among its other vars.
+	for i, bag := range td.Bags {
+		for _, v := range bag[1:] {
+			if p := bagOf[v]; p > i && (td.Parent[i] == -1 || p < td.Parent[i]) {
+				td.Parent[i] = p
+			}
+		}
+	}
+	return td
+}
+
+// ParseTD parses a tree decomposition in the PACE format, i.e a line "s td nbBags maxBagSize nbVars",
+// a line "b idx v1 v2 ... vn" for each bag, with its 1-based index followed by its CNF variables, and
+// a line "i j" for each edge between two bags. Lines starting with 'c' are comments.
+// The first bag of each connected component is considered as the root of its tree.
+func ParseTD(r io.Reader) (*TreeDecomposition, error) {
+	scanner := NewScanner(r, 0)
+	var (
+		td    TreeDecomposition
+		edges [][2]int
+	)
+	header := false
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		vals := make([]int, 0, len(fields))
+		for _, field := range fields {
+			if field == "s" || field == "td" || field == "b" {
+				continue
+			}
+			val, err := strconv.Atoi(field)
+			if err != nil || val < 1 {
+				return nil, fmt.Errorf("invalid value %q in line %q", field, scanner.Text())
+			}
+			vals = append(vals, val)
+		}
+		switch {
+		case fields[0] == "s":
+			if header || len(fields) != 5 || fields[1] != "td" {
+				return nil, fmt.Errorf("invalid header %q", scanner.Text())
+			}
+			header = true
+			td.Bags = make([][]Var, vals[0])
+			td.Parent = make([]int, vals[0])
+		case !header:
+			return nil, fmt.Errorf("line %q found before header", scanner.Text())
+		case fields[0] == "b":
+			if len(vals) == 0 || vals[0] > len(td.Bags) {
+				return nil, fmt.Errorf("invalid bag %q", scanner.Text())
+			}
+			bag := make([]Var, len(vals)-1)
+			for i, val := range vals[1:] {
+				bag[i] = IntToVar(int32(val))
+			}
+			td.Bags[vals[0]-1] = bag
+		default:
+			if len(vals) != 2 || vals[0] > len(td.Bags) || vals[1] > len(td.Bags) {
+				return nil, fmt.Errorf("invalid edge %q", scanner.Text())
+			}
+			edges = append(edges, [2]int{vals[0] - 1, vals[1] - 1})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not parse tree decomposition: %v", err)
+	}
+	if !header {
+		return nil, fmt.Errorf("could not parse tree decomposition: no header found")
+	}
+	adj := make([][]int, len(td.Bags))
+	for _, e := range edges {
+		adj[e[0]] = append(adj[e[0]], e[1])
+		adj[e[1]] = append(adj[e[1]], e[0])
+	}
+	visited := make([]bool, len(td.Bags))
+	for root := range td.Bags {
+		if visited[root] {
+			continue
+		}
+		visited[root] = true
+		td.Parent[root] = -1
+		queue := []int{root}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, j := range adj[i] {
+				if visited[j] {
+					return nil, fmt.Errorf("decomposition is not a tree: bag %d is part of a cycle", j+1)
+				}
+				visited[j] = true
+				td.Parent[j] = i
+				queue = append(queue, j)
+				adj[j] = removeInt(adj[j], i)
+			}
+		}
+	}
+	return &td, nil
+}
+
+// removeInt removes the first occurrence of val from vals.
+func removeInt(vals []int, val int) []int {
+	for i := range vals {
+		if vals[i] == val {
+			return append(vals[:i], vals[i+1:]...)
+		}
+	}
+	return vals
+}
+
+// FollowDecomposition sets the initial activity of vars so that the solver first branches on the vars
+// that are closest to the root of td, i.e on the separators between the subtrees of the decomposition.
+// As usual, activities then evolve with conflicts.
+func (s *Solver) FollowDecomposition(td *TreeDecomposition) {
+	depths := td.depths()
+	maxDepth := 0
+	for _, d := range depths {
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	minDepth := make([]int, s.nbVars)
+	for v := range minDepth {
+		minDepth[v] = maxDepth + 1
+	}
+	for i, bag := range td.Bags {
+		for _, v := range bag {
+			if int(v) < s.nbVars && depths[i] < minDepth[v] {
+				minDepth[v] = depths[i]
+			}
+		}
+	}
+	for v, d := range minDepth {
+		s.activity[v] += float64(maxDepth+1-d) / float64(maxDepth+1)
+	}
+	s.initQueues()
+}
@@ -0,0 +1,127 @@
+package solver
+
+import (
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTreeDecomposition(t *testing.T) {
+	pb := twoChains(5)
+	td := pb.TreeDecomposition()
+	if len(td.Bags) != pb.NbVars {
+		t.Errorf("expected %d bags, got %d", pb.NbVars, len(td.Bags))
+	}
+	if w := td.Width(); w != 1 {
+		t.Errorf("a chain should have a width of 1, got %d", w)
+	}
+	if !hasCount {
+		return
+	}
+	if nb, err := pb.CountModelsTD(td); err != nil || nb.Int64() != 31 {
+		t.Errorf("expected 31 models, got %d (error: %v)", nb, err)
+	}
+}
+
+func TestCountModelsTD(t *testing.T) {
+	if !hasCount {
+		t.Skip("model counting with tree decompositions is not part of this build")
+	}
+	pb := ParseCardConstrs([]CardConstr{
+		AtLeast1(1, 2, 3),
+		AtLeast1(-1, -2, -3),
+		AtLeast1(2, 3, 4),
+		AtLeast1(2, 3, 5),
+		AtLeast1(3, 4, 5),
+		AtLeast1(2, 4, 5),
+	})
+	if nb, err := pb.CountModelsTD(nil); err != nil || nb.Int64() != 17 {
+		t.Errorf("expected 17 models, got %d (error: %v)", nb, err)
+	}
+	pb = ParsePBConstrs([]PBConstr{
+		AtMost([]int{1, 2, 3, 4}, 3),
+		AtLeast([]int{1, 2, 3, 4}, 2),
+		GtEq([]int{2, 3, 4}, []int{1, 1, 2}, 3),
+	})
+	if nb, err := pb.CountModelsTD(nil); err != nil || nb.Int64() != 5 {
+		t.Errorf("expected 5 models, got %d (error: %v)", nb, err)
+	}
+	pb = ParseSliceNb([][]int{{1}, {-1, 2, 3}}, 4)
+	if nb, err := pb.CountModelsTD(nil); err != nil || nb.Int64() != 6 {
+		t.Errorf("expected 6 models, got %d (error: %v)", nb, err)
+	}
+	var chain [][]int // No two consecutive vars are false: there are F(n+2) models, F being the Fibonacci sequence
+	for i := 1; i < 100; i++ {
+		chain = append(chain, []int{i, i + 1})
+	}
+	expected, _ := new(big.Int).SetString("927372692193078999176", 10)
+	if nb, err := ParseSlice(chain).CountModelsTD(nil); err != nil || nb.Cmp(expected) != 0 {
+		t.Errorf("expected %v models, got %v (error: %v)", expected, nb, err)
+	}
+	expected.Lsh(big.NewInt(1), 100)
+	if nb, err := ParseSliceNb(nil, 100).CountModelsTD(nil); err != nil || nb.Cmp(expected) != 0 {
+		t.Errorf("expected %v models, got %v (error: %v)", expected, nb, err)
+	}
+	pb = ParseSlice([][]int{{1, 2}, {2, 3}})
+	for _, td := range []*TreeDecomposition{
+		{Bags: [][]Var{{0, 1}, {1, 2}, {0}}, Parent: []int{-1, 0, 1}}, // Bags containing var 0 are not connected
+		{Bags: [][]Var{{0, 1}, {1, 2}}, Parent: []int{1, 0}},
+		{Bags: [][]Var{{0, 1}, {1, 2}}, Parent: []int{-1}},
+		{Bags: [][]Var{{0, 1}, {1, 2}}, Parent: []int{-1, 2}},
+	} {
+		if _, err := pb.CountModelsTD(td); err == nil {
+			t.Errorf("expected an error for invalid decomposition %v", td)
+		}
+	}
+}
+
+func TestParseTD(t *testing.T) {
+	const decomp = `c two bags
+s td 2 3 4
+b 1 1 2 3
+b 2 3 4
+1 2
+`
+	td, err := ParseTD(strings.NewReader(decomp))
+	if err != nil {
+		t.Fatalf("could not parse decomposition: %v", err)
+	}
+	if td.Width() != 2 || len(td.Bags) != 2 || td.Parent[0] != -1 || td.Parent[1] != 0 {
+		t.Errorf("invalid decomposition %v", td)
+	}
+	for _, invalid := range []string{"b 1 1 2\n", "s td 2 2 2\nb 3 1\n", "s td 2 2 2\n1 x\n", "s td 2 2 2\n1 2\n2 1\n"} {
+		if _, err := ParseTD(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+	if !hasCount {
+		return
+	}
+	pb := ParseSlice([][]int{{1, 2, 3}, {-3, 4}})
+	if nb, err := pb.CountModelsTD(td); err != nil || nb.Int64() != 10 {
+		t.Errorf("expected 10 models, got %d (error: %v)", nb, err)
+	}
+	pb = ParseSlice([][]int{{1, 4}})
+	if _, err := pb.CountModelsTD(td); err == nil {
+		t.Errorf("expected an error for clause not included in any bag")
+	}
+}
+
+func TestFollowDecomposition(t *testing.T) {
+	f, err := os.Open("testcnf/125.cnf")
+	if err != nil {
+		t.Fatalf("could not open file: %v", err)
+	}
+	defer f.Close()
+	pb, err := ParseCNF(f)
+	if err != nil {
+		t.Fatalf("could not parse file: %v", err)
+	}
+	td := pb.TreeDecomposition()
+	s := New(pb)
+	s.FollowDecomposition(td)
+	if status := s.Solve(); status != Unsat {
+		t.Errorf("expected unsat, got %v", status)
+	}
+}
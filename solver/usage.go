@@ -0,0 +1,40 @@
+package solver
+
+import "sort"
+
+// This file deals with statistics about how problem clauses are used during search.
+
+// A ClauseUsage associates a problem clause with the number of times it was used during conflict analysis,
+// either as a conflict clause or as the reason for a propagation.
+type ClauseUsage struct {
+	Clause  *Clause // The clause itself
+	Index   int     // Index of the clause in the problem's clauses, followed by clauses added with AppendClause
+	NbUsage int     // How many times the clause was used
+}
+
+type usageSorter []ClauseUsage
+
+func (us usageSorter) Len() int { return len(us) }
+func (us usageSorter) Less(i, j int) bool {
+	return us[i].NbUsage > us[j].NbUsage || (us[i].NbUsage == us[j].NbUsage && us[i].Index < us[j].Index)
+}
+func (us usageSorter) Swap(i, j int) { us[i], us[j] = us[j], us[i] }
+
+// HotClauses returns the n problem clauses that were the most used during conflict analysis,
+// from the most used to the least used. Clauses that were never used are not returned.
+// If n <= 0, all used clauses are returned.
+// This helps finding the constraints that dominate search effort.
+// Usage is only tracked when s.TrackUsage is true; otherwise, the result is empty.
+func (s *Solver) HotClauses(n int) []ClauseUsage {
+	res := make([]ClauseUsage, 0, len(s.usage))
+	for i, c := range s.wl.pbClauses {
+		if nb := s.usage[c]; nb > 0 {
+			res = append(res, ClauseUsage{Clause: c, Index: i, NbUsage: nb})
+		}
+	}
+	sort.Sort(usageSorter(res))
+	if n > 0 && n < len(res) {
+		res = res[:n]
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package solver
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHotClauses(t *testing.T) {
+	f, err := os.Open("testcnf/125.cnf")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() { _ = f.Close() }()
+	pb, err := ParseCNF(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s := New(pb)
+	s.TrackUsage = true
+	if status := s.Solve(); status != Unsat {
+		t.Fatalf("problem should be unsat")
+	}
+	hot := s.HotClauses(5)
+	if len(hot) != 5 {
+		t.Fatalf("expected 5 hot clauses, got %d", len(hot))
+	}
+	for i, usage := range hot {
+		if usage.Clause != pb.Clauses[usage.Index] {
+			t.Errorf("clause #%d does not match its index %d", i, usage.Index)
+		}
+		if usage.Clause.Learned() {
+			t.Errorf("learned clause returned as hot clause: %s", usage.Clause.CNF())
+		}
+		if i > 0 && usage.NbUsage > hot[i-1].NbUsage {
+			t.Errorf("hot clauses are not sorted: %d > %d", usage.NbUsage, hot[i-1].NbUsage)
+		}
+	}
+	for _, n := range []int{0, -1} {
+		if all := s.HotClauses(n); len(all) < len(hot) || all[0] != hot[0] {
+			t.Errorf("HotClauses(%d) should return all used clauses, got %d", n, len(all))
+		}
+	}
+	s = New(ParseSlice([][]int{{1, 2}, {-1, 2}}))
+	s.Solve()
+	if hot := s.HotClauses(5); len(hot) != 0 {
+		t.Errorf("expected no hot clauses when usage is not tracked, got %v", hot)
+	}
+}
@@ -1,9 +1,6 @@
 package solver
 
-import (
-	"fmt"
-	"sort"
-)
+import "sort"
 
 type watcher struct {
 	other  Lit // Another lit from the clause
@@ -19,6 +16,7 @@ type watcherList struct {
 	wlistPb   [][]*Clause // For each literal a list of PB or cardinality constraints.
 	pbClauses []*Clause   // All the problem clauses.
 	learned   []*Clause
+	nbDeleted int // # of deleted clauses whose watchers may still be in watch lists
 }
 
 // initWatcherList makes a new watcherList for the solver.
@@ -84,7 +82,13 @@ func (wl *watcherList) Less(i, j int) bool {
 
 // Watches the provided clause.
 func (s *Solver) watchClause(c *Clause) {
-	if c.Len() == 2 {
+	if c.isBig() { // All lits are watched, so that watchers never need to be updated
+		for i := 0; i < c.Len(); i++ {
+			neg := c.Get(i).Negation()
+			s.wl.wlistPb[neg] = append(s.wl.wlistPb[neg], c)
+			c.pbData.watched[i] = true
+		}
+	} else if c.Len() == 2 && c.propositional() { // Binary PB constraints such as 2 a + 1 b >= 2 are not binary clauses
 		first := c.First()
 		second := c.Second()
 		neg0 := first.Negation()
@@ -118,27 +122,31 @@ func (s *Solver) watchClause(c *Clause) {
 	}
 }
 
-// unwatch the given learned clause.
-// NOTE: since it is only called when c.lbd() > 2, we know for sure
-// that c is not a binary clause.
-// We also know for sure this is a propositional clause, since only those are learned.
-func (s *Solver) unwatchClause(c *Clause) {
-	for i := 0; i < 2; i++ {
-		neg := c.Get(i).Negation()
+// compactWatchers removes the watchers of deleted clauses from all watch lists.
+// NOTE: since only learned clauses with lbd() > 2 are deleted, we know for sure
+// that deleted clauses are neither binary clauses nor PB constraints.
+func (s *Solver) compactWatchers() {
+	for lit, wl := range s.wl.wlist {
 		j := 0
-		length := len(s.wl.wlist[neg])
-		// We're looking for the index of the clause.
-		// This will panic if c is not in wlist[neg], but this shouldn't happen.
-		for s.wl.wlist[neg][j].clause != c {
-			j++
+		for _, w := range wl {
+			if !w.clause.isDeleted() {
+				wl[j] = w
+				j++
+			}
+		}
+		for k := j; k < len(wl); k++ {
+			wl[k] = watcher{} // Let the GC collect deleted clauses
 		}
-		s.wl.wlist[neg][j] = s.wl.wlist[neg][length-1]
-		s.wl.wlist[neg] = s.wl.wlist[neg][:length-1]
+		s.wl.wlist[lit] = wl[:j]
 	}
+	s.wl.nbDeleted = 0
 }
 
 // reduceLearned removes a few learned clauses that are deemed useless.
 func (s *Solver) reduceLearned() {
+	if len(s.wl.learned) == 0 { // They could all have been removed along with a clause group
+		return
+	}
 	sort.Sort(&s.wl)
 	nbLearned := len(s.wl.learned)
 	length := nbLearned / 2
@@ -153,11 +161,19 @@ func (s *Solver) reduceLearned() {
 		}
 		nbRemoved++
 		s.Stats.NbDeleted++
+		if s.Certified && s.CertComments {
+			s.certifyLemmaStats(c, "deleted")
+		}
 		s.wl.learned[i] = s.wl.learned[nbLearned-nbRemoved]
-		s.unwatchClause(c)
+		c.markDeleted()
+		delete(s.groups.learnedAt, c)
 	}
 	nbLearned -= nbRemoved
 	s.wl.learned = s.wl.learned[:nbLearned]
+	// Watchers are removed lazily, but they must not pile up
+	if s.wl.nbDeleted += nbRemoved; s.wl.nbDeleted > nbLearned {
+		s.compactWatchers()
+	}
 }
 
 // Adds the given learned clause and updates watchers.
@@ -165,13 +181,12 @@ func (s *Solver) reduceLearned() {
 func (s *Solver) addLearned(c *Clause) {
 	s.wl.learned = append(s.wl.learned, c)
 	s.watchClause(c)
+	if s.groups.nbLive > 0 {
+		s.groups.learnedAt[c] = len(s.groups.groups)
+	}
 	s.clauseBumpActivity(c)
 	if s.Certified {
-		if s.CertChan == nil {
-			fmt.Printf("%s\n", c.CNF())
-		} else {
-			s.CertChan <- c.CNF()
-		}
+		s.certifyLemma(c)
 	}
 }
 
@@ -180,11 +195,7 @@ func (s *Solver) addLearnedUnit(unit Lit) {
 
 	s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
 	if s.Certified {
-		if s.CertChan == nil {
-			fmt.Printf("%d 0\n", unit.Int())
-		} else {
-			s.CertChan <- fmt.Sprintf("%d 0", unit.Int())
-		}
+		s.certifyUnit(unit)
 	}
 }
 
@@ -227,7 +238,11 @@ func (s *Solver) propagate(ptr int, lvl decLevel) *Clause {
 			return confl
 		}
 		for _, c := range s.wl.wlistPb[lit] {
-			if c.PseudoBoolean() {
+			if c.isBig() {
+				if !s.simplifyBigPB(c, lvl) {
+					return c
+				}
+			} else if c.PseudoBoolean() {
 				if !s.simplifyPseudoBool(c, lvl) {
 					return c
 				}
@@ -268,6 +283,9 @@ func (s *Solver) simplifyPropClauses(lit Lit, lvl decLevel) *Clause {
 			continue
 		}
 		c := w.clause
+		if c.isDeleted() { // Lazily remove the watcher
+			continue
+		}
 		// make sure c.Second() is lit
 		if c.First() == lit.Negation() {
 			c.swap(0, 1)
@@ -448,6 +466,24 @@ func (s *Solver) simplifyPseudoBool(clause *Clause, lvl decLevel) bool {
 	return true
 }
 
+// simplifyBigPB is like simplifyPseudoBool, for PB constraints with arbitrary-precision weights.
+func (s *Solver) simplifyBigPB(clause *Clause, lvl decLevel) bool {
+	slack, sat := clause.bigSlack(s.litStatus)
+	if sat {
+		return true
+	}
+	if slack.Sign() < 0 {
+		return false
+	}
+	// Propagating a lit does not change the slack, so a single pass is enough
+	for i, w := range clause.pbData.big.weights {
+		if lit := clause.Get(i); s.litStatus(lit) == Indet && w.Cmp(slack) > 0 { // lit can't be falsified
+			s.propagateUnit(clause, lvl, lit)
+		}
+	}
+	return true
+}
+
 func (s *Solver) updateWatchPB(clause *Clause) {
 	weightWatched := 0
 	i := 0
@@ -0,0 +1,32 @@
+package solver
+
+import "testing"
+
+func TestLazyDeletion(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2, 3}, {-1, 2, 3}, {-3, 4, 5}}))
+	c := NewLearnedClause(IntsToLits(1, 2, 4))
+	c.setLbd(3)
+	s.addLearned(c)
+	if a := s.Propagate(IntsToLits(-1, -2)); a[3] != True {
+		t.Fatalf("learned clause should propagate 4, got %v", a)
+	}
+	c.markDeleted()
+	if !c.isDeleted() || c.lbd() != 3 || !c.Learned() {
+		t.Errorf("deleted flag should not alter other flags and LBD")
+	}
+	if a := s.Propagate(IntsToLits(-1, -2)); a[3] != Unassigned {
+		t.Errorf("deleted clause should not propagate anymore, got %v", a)
+	}
+	s.wl.nbDeleted = 1
+	s.compactWatchers()
+	for lit, wl := range s.wl.wlist {
+		for _, w := range wl {
+			if w.clause == c {
+				t.Errorf("deleted clause still watched by %d after compaction", Lit(lit).Int())
+			}
+		}
+	}
+	if s.wl.nbDeleted != 0 {
+		t.Errorf("expected no pending deletion after compaction, got %d", s.wl.nbDeleted)
+	}
+}
@@ -0,0 +1,332 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file deals with the order in which problems are written, so that the same problem always gives the same file,
+// no matter how it was built or what happened to it, and generated files can be compared with a simple diff.
+
+// A WriteOrder is the order in which the constraints of a problem are written.
+type WriteOrder byte
+
+const (
+	// ProblemOrder writes the units of the problem first, in the order they were found, then its clauses,
+	// in the order of pb.Clauses. Terms of a clause are written in the order they are stored in the clause:
+	// since solvers reorder the lits of the clauses they watch, it can change once the problem was solved.
+	ProblemOrder WriteOrder = iota
+	// CanonicalOrder sorts the units of the problem, then the terms of each clause by var, then the clauses
+	// lexicographically, according to their lits, then their weights, then their cardinality.
+	// The terms of the cost function are sorted by var, too. The problem itself is not modified.
+	CanonicalOrder
+)
+
+// CNFOrdered is like CNF, but constraints are written in the given order.
+func (pb *Problem) CNFOrdered(order WriteOrder) string {
+	var sb strings.Builder
+	pb.writeCNF(&sb, order)
+	return sb.String()
+}
+
+// WriteCNF writes on w the DIMACS CNF representation of pb, as returned by CNF, without building it in memory first.
+// Units found while parsing and simplifying pb are written as unit clauses.
+// It returns an error if pb contains pseudo-boolean constraints, that cannot be written in DIMACS.
+func (pb *Problem) WriteCNF(w io.Writer) error {
+	for _, c := range pb.Clauses {
+		if c.pbData != nil {
+			return fmt.Errorf("cannot write PB constraint %s in DIMACS", c.PBString())
+		}
+	}
+	bw := bufio.NewWriter(w)
+	pb.writeCNF(bw, ProblemOrder)
+	return bw.Flush()
+}
+
+// WriteCNF writes on w the current clause database of s, as a DIMACS CNF problem: the units s knows of,
+// including the ones it learned, then the clauses of the problem that are not satisfied by these units yet.
+// Cardinality constraints are always written.
+// Other learned clauses are not written. The written problem is equivalent to the one s was built from:
+// current assumptions, and the lits they imply, are not part of it.
+// It returns an error if the problem contains pseudo-boolean constraints, that cannot be written in DIMACS.
+func (s *Solver) WriteCNF(w io.Writer) error {
+	pb := Problem{NbVars: s.nbVars}
+	if s.status == Unsat && len(s.core) == 0 { // Unsat, no matter the assumptions
+		pb.Clauses = []*Clause{NewClause(nil)}
+		return pb.WriteCNF(w)
+	}
+	isUnit := make([]bool, 2*s.nbVars)
+	for _, unit := range s.units {
+		if !isUnit[unit] {
+			isUnit[unit] = true
+			pb.Units = append(pb.Units, unit)
+		}
+	}
+	for _, c := range s.wl.pbClauses {
+		sat := false
+		for i := 0; i < len(c.lits) && c.pbData == nil && c.Cardinality() == 1; i++ {
+			if isUnit[c.lits[i]] {
+				sat = true
+				break
+			}
+		}
+		if !sat {
+			pb.Clauses = append(pb.Clauses, c)
+		}
+	}
+	return pb.WriteCNF(w)
+}
+
+// A textWriter is where problems are written, either a strings.Builder or a bufio.Writer.
+type textWriter interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// writeCNF writes the DIMACS CNF representation of pb on w, with constraints in the given order.
+func (pb *Problem) writeCNF(w textWriter, order WriteOrder) {
+	comments := commentWriter{md: pb.Metadata, marker: "c "}
+	comments.write(w, 0)
+	if pb.ProjectionVars != nil {
+		vars := pb.ProjectionVars
+		if order == CanonicalOrder {
+			vars = append([]Var(nil), vars...)
+			sort.Sort(varSorter(vars))
+		}
+		w.WriteString("c ind ")
+		for _, v := range vars {
+			fmt.Fprintf(w, "%d ", v.Int())
+		}
+		w.WriteString("0\n")
+	}
+	clauses := pb.orderedClauses(order)
+	format := "cnf"
+	for _, c := range clauses {
+		if c.weights == nil && c.card > 1 {
+			format = "cnf+"
+			break
+		}
+	}
+	fmt.Fprintf(w, "p %s %d %d\n", format, pb.NbVars, len(pb.Clauses)+len(pb.Units))
+	var buf []byte
+	stmt := 1 // Index of the next statement, once the header was written
+	for _, unit := range pb.orderedUnits(order) {
+		comments.write(w, stmt)
+		stmt++
+		buf = strconv.AppendInt(buf[:0], int64(unit.Int()), 10)
+		w.Write(append(buf, " 0\n"...))
+	}
+	for _, c := range clauses {
+		comments.write(w, stmt)
+		stmt++
+		buf = buf[:0]
+		for _, lit := range c.lits {
+			buf = strconv.AppendInt(buf, int64(lit.Int()), 10)
+			buf = append(buf, ' ')
+		}
+		if c.weights == nil && c.card > 1 { // CNF+ cardinality constraint
+			buf = append(buf, ">= "...)
+			buf = strconv.AppendInt(buf, int64(c.card), 10)
+			buf = append(buf, ' ')
+		}
+		w.Write(append(buf, "0\n"...))
+	}
+	comments.writeAll(w)
+}
+
+// PBStringOrdered is like PBString, but constraints are written in the given order.
+func (pb *Problem) PBStringOrdered(order WriteOrder) string {
+	var sb strings.Builder
+	pb.writeOPB(&sb, order)
+	return sb.String()
+}
+
+// WriteOPB writes on w the OPB representation of pb, as returned by PBString, without building it in memory first.
+// Unlike PBString, it starts with the "* #variable= ... #constraint= ..." line external PB solvers expect.
+// Clauses and cardinality constraints are written as PB constraints, and units as equalities.
+func (pb *Problem) WriteOPB(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "* #variable= %d #constraint= %d\n", pb.NbVars, len(pb.Units)+len(pb.Clauses))
+	pb.writeOPB(bw, ProblemOrder)
+	return bw.Flush()
+}
+
+// writeOPB writes the OPB representation of pb on w, with constraints in the given order.
+func (pb *Problem) writeOPB(w textWriter, order WriteOrder) {
+	comments := commentWriter{md: pb.Metadata, marker: "* "}
+	stmt := 0 // Index of the next statement
+	comments.write(w, stmt)
+	if pb.minLits != nil {
+		stmt++
+		lits, weights := pb.minLits, pb.minWeights
+		if order == CanonicalOrder {
+			lits = append([]Lit(nil), lits...)
+			if weights != nil {
+				weights = append([]int(nil), weights...)
+			}
+			sort.Sort(&termSorter{lits: lits, weights: weights})
+		}
+		w.WriteString(costFuncString(lits, weights))
+	}
+	for _, unit := range pb.orderedUnits(order) {
+		comments.write(w, stmt)
+		stmt++
+		sign := ""
+		if !unit.IsPositive() {
+			sign = "~"
+			unit = unit.Negation()
+		}
+		fmt.Fprintf(w, "1 %sx%d = 1 ;\n", sign, unit.Int())
+	}
+	for _, c := range pb.orderedClauses(order) {
+		comments.write(w, stmt)
+		stmt++
+		if c.big != nil {
+			w.WriteString(bigPBString(c.lits, c.big.weights, c.big.card))
+		} else {
+			w.WriteString(pbString(c.lits, c.weights, c.card))
+		}
+		w.WriteByte('\n')
+	}
+	comments.writeAll(w)
+}
+
+// A commentWriter writes the comments of a problem at their position, while its statements are written.
+type commentWriter struct {
+	md     *Metadata // Metadata of the problem, or nil if it has no comments
+	marker string    // Comment marker starting each comment line, followed by a space
+	next   int       // Index of the first comment that was not written yet
+}
+
+// write writes on w the comments that were not written yet, and that precede the statement whose index is stmt.
+func (cw *commentWriter) write(w textWriter, stmt int) {
+	for cw.md != nil && cw.next < len(cw.md.Comments) && cw.md.position(cw.next) <= stmt {
+		cw.writeNext(w)
+	}
+}
+
+// writeAll writes on w the comments that were not written yet, once all the statements were written.
+func (cw *commentWriter) writeAll(w textWriter) {
+	for cw.md != nil && cw.next < len(cw.md.Comments) {
+		cw.writeNext(w)
+	}
+}
+
+// writeNext writes the next comment on w.
+func (cw *commentWriter) writeNext(w textWriter) {
+	w.WriteString(cw.marker)
+	w.WriteString(cw.md.Comments[cw.next])
+	w.WriteByte('\n')
+	cw.next++
+}
+
+// orderedUnits returns the units of pb, in the given order.
+func (pb *Problem) orderedUnits(order WriteOrder) []Lit {
+	if order != CanonicalOrder {
+		return pb.Units
+	}
+	units := append([]Lit(nil), pb.Units...)
+	sort.Sort(litSorter(units))
+	return units
+}
+
+// writtenClause is the content of a clause, as it must be written.
+type writtenClause struct {
+	lits    []Lit
+	weights []int // Weight of each lit, or nil if all weights are 1
+	card    int
+	big     *bigPB // Weights and cardinality, if they do not fit in ints; weights is nil then
+}
+
+// orderedClauses returns the content of the clauses of pb, in the given order.
+// In canonical order, the content is a sorted copy of the clauses.
+func (pb *Problem) orderedClauses(order WriteOrder) []writtenClause {
+	res := make([]writtenClause, len(pb.Clauses))
+	for i, c := range pb.Clauses {
+		if c.isBig() {
+			res[i] = writtenClause{lits: c.lits, big: c.pbData.big}
+		} else {
+			res[i] = writtenClause{lits: c.lits, card: c.Cardinality()}
+			if c.pbData != nil {
+				res[i].weights = c.pbData.weights
+			}
+		}
+		if order == CanonicalOrder {
+			res[i].lits = append([]Lit(nil), res[i].lits...)
+			if res[i].weights != nil {
+				res[i].weights = append([]int(nil), res[i].weights...)
+			}
+			var bigWeights []*big.Int
+			if c := res[i]; c.big != nil {
+				bigWeights = append([]*big.Int(nil), c.big.weights...)
+				res[i].big = &bigPB{weights: bigWeights, card: c.big.card}
+			}
+			sort.Sort(&termSorter{lits: res[i].lits, weights: res[i].weights, bigWeights: bigWeights})
+		}
+	}
+	if order == CanonicalOrder {
+		sort.Sort(writtenClauses(res))
+	}
+	return res
+}
+
+// writtenClauses sorts clauses according to the canonical order.
+type writtenClauses []writtenClause
+
+func (wc writtenClauses) Len() int           { return len(wc) }
+func (wc writtenClauses) Less(i, j int) bool { return wc[i].less(wc[j]) }
+func (wc writtenClauses) Swap(i, j int)      { wc[i], wc[j] = wc[j], wc[i] }
+
+// less returns true iff c comes before c2 in the canonical order.
+func (c writtenClause) less(c2 writtenClause) bool {
+	for i := 0; i < len(c.lits) && i < len(c2.lits); i++ {
+		if c.lits[i] != c2.lits[i] {
+			return c.lits[i] < c2.lits[i]
+		}
+	}
+	if len(c.lits) != len(c2.lits) {
+		return len(c.lits) < len(c2.lits)
+	}
+	if c.big != nil || c2.big != nil {
+		return c.lessBig(c2)
+	}
+	for i := range c.lits {
+		if w, w2 := c.weight(i), c2.weight(i); w != w2 {
+			return w < w2
+		}
+	}
+	return c.card < c2.card
+}
+
+// weight returns the weight of the ith lit of c.
+func (c writtenClause) weight(i int) int {
+	if c.weights == nil {
+		return 1
+	}
+	return c.weights[i]
+}
+
+// termSorter sorts weighted lits by increasing lit value. weights and bigWeights can be nil.
+type termSorter struct {
+	lits       []Lit
+	weights    []int
+	bigWeights []*big.Int
+}
+
+func (ts *termSorter) Len() int           { return len(ts.lits) }
+func (ts *termSorter) Less(i, j int) bool { return ts.lits[i] < ts.lits[j] }
+func (ts *termSorter) Swap(i, j int) {
+	ts.lits[i], ts.lits[j] = ts.lits[j], ts.lits[i]
+	if ts.weights != nil {
+		ts.weights[i], ts.weights[j] = ts.weights[j], ts.weights[i]
+	}
+	if ts.bigWeights != nil {
+		ts.bigWeights[i], ts.bigWeights[j] = ts.bigWeights[j], ts.bigWeights[i]
+	}
+}
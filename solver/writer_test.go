@@ -0,0 +1,145 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCNFOrdered(t *testing.T) {
+	pb1 := ParseSlice([][]int{{3, -1}, {2, 1}, {4}, {-2, 3, 1}})
+	pb2 := ParseSlice([][]int{{1, -2, 3}, {1, 2}, {4}, {-1, 3}})
+	if pb1.CNF() == pb2.CNF() {
+		t.Errorf("problem order should keep the order of clauses and lits")
+	}
+	expected := "p cnf 4 4\n4 0\n1 2 0\n1 -2 3 0\n-1 3 0\n"
+	if str := pb1.CNFOrdered(CanonicalOrder); str != expected {
+		t.Errorf("invalid canonical CNF: expected %q, got %q", expected, str)
+	}
+	if str := pb2.CNFOrdered(CanonicalOrder); str != expected {
+		t.Errorf("invalid canonical CNF: expected %q, got %q", expected, str)
+	}
+	if str := pb1.CNF(); !strings.HasPrefix(str, "p cnf 4 4\n4 0\n3 -1 0\n") {
+		t.Errorf("canonical order should not modify the problem, got %q", str)
+	}
+	// Solving the problem reorders the lits of some clauses, but not the canonical output
+	if status := New(pb1).Solve(); status != Sat {
+		t.Fatalf("expected sat problem, got %v", status)
+	}
+	if str := pb1.CNFOrdered(CanonicalOrder); str != expected {
+		t.Errorf("invalid canonical CNF after solving: expected %q, got %q", expected, str)
+	}
+}
+
+func TestPBStringOrdered(t *testing.T) {
+	pb, err := ParseOPB(strings.NewReader("min: +3 x3 -1 x1 ;\n+1 x3 +2 x2 +1 ~x1 >= 2 ;\n+2 x2 +3 x1 +1 x4 >= 3 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	expected := "min: -1 x1 +3 x3 ;\n3 x1 +2 x2 +1 x4 >= 3 ;\n1 ~x1 +2 x2 +1 x3 >= 2 ;\n"
+	if str := pb.PBStringOrdered(CanonicalOrder); str != expected {
+		t.Errorf("invalid canonical OPB: expected %q, got %q", expected, str)
+	}
+	if _, err := ParseOPB(strings.NewReader(pb.PBString())); err != nil {
+		t.Errorf("could not parse written problem: %v", err)
+	}
+}
+
+func TestWriteCNF(t *testing.T) {
+	for _, path := range []string{"testcnf/25.cnf", "testcnf/zebra.cnf", "testcnf/125.cnf"} {
+		pb := parseFile(t, path, ParseCNF)
+		var sb strings.Builder
+		if err := pb.WriteCNF(&sb); err != nil {
+			t.Fatalf("could not write %q: %v", path, err)
+		}
+		if sb.String() != pb.CNF() {
+			t.Errorf("%q: WriteCNF and CNF disagree", path)
+		}
+		s := New(pb)
+		expected := s.Solve()
+		sb.Reset()
+		if err := s.WriteCNF(&sb); err != nil {
+			t.Fatalf("could not write solver state for %q: %v", path, err)
+		}
+		pb2, err := ParseCNF(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatalf("could not parse solver state for %q: %v", path, err)
+		}
+		if expected != Unsat && len(pb2.Units) < len(pb.Units) {
+			t.Errorf("%q: expected at least %d units, got %d", path, len(pb.Units), len(pb2.Units))
+		}
+		if status := New(pb2).Solve(); status != expected {
+			t.Errorf("%q: expected %v after a round-trip, got %v", path, expected, status)
+		}
+	}
+	pb := ParseCardConstrs([]CardConstr{{Lits: []int{1, 2, 3}, AtLeast: 2}, AtLeast1(-1, -2)})
+	var sb strings.Builder
+	if err := pb.WriteCNF(&sb); err != nil || sb.String() != "p cnf+ 3 2\n1 2 3 >= 2 0\n-1 -2 0\n" {
+		t.Errorf("invalid CNF+ output %q, error %v", sb.String(), err)
+	}
+	pb, err := ParseOPB(strings.NewReader("+1 x1 +2 x2 +1 x3 >= 2 ;\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if err := pb.WriteCNF(&sb); err == nil {
+		t.Errorf("expected an error when writing a PB problem")
+	}
+	sb.Reset()
+	s := New(ParseSlice([][]int{{1}, {-1, 2}, {-2, -1}}))
+	if err := s.WriteCNF(&sb); err != nil || sb.String() != "p cnf 0 1\n0\n" {
+		t.Errorf("invalid output for unsat problem %q, error %v", sb.String(), err)
+	}
+	sb.Reset()
+	s = New(ParseSlice([][]int{{1, 2, 3}, {-1, 2}, {-2, 4, 5}}))
+	s.Assume([]Lit{IntToLit(1)})
+	if err := s.WriteCNF(&sb); err != nil {
+		t.Fatalf("could not write solver state: %v", err)
+	}
+	if pb, err := ParseCNF(strings.NewReader(sb.String())); err != nil || len(pb.Units) != 0 || len(pb.Clauses) != 3 {
+		t.Errorf("assumptions were written as units: %q", sb.String())
+	}
+	sb.Reset()
+	s = New(ParseSlice([][]int{{1, 2}, {-1, 2}, {-2, 3}}))
+	s.Assume([]Lit{IntToLit(-3)})
+	if s.Solve() != Unsat {
+		t.Fatalf("expected unsat under assumptions")
+	}
+	if err := s.WriteCNF(&sb); err != nil {
+		t.Fatalf("could not write solver state: %v", err)
+	}
+	if pb, err := ParseCNF(strings.NewReader(sb.String())); err != nil || New(pb).Solve() != Sat {
+		t.Errorf("expected a sat problem once assumptions are dropped, got %q", sb.String())
+	}
+}
+
+func TestWriteOPB(t *testing.T) {
+	constrs := []PBConstr{GtEq([]int{1, -2, 3}, []int{2, 1, 3}, 3), AtMost([]int{1, 2}, 1), AtLeast([]int{2, 3, 4}, 2)}
+	pb := ParsePBConstrs(constrs)
+	pb.SetCostFunc([]Lit{IntToLit(1), IntToLit(-4)}, []int{2, 3})
+	var sb strings.Builder
+	if err := pb.WriteOPB(&sb); err != nil {
+		t.Fatalf("could not write problem: %v", err)
+	}
+	expected := "* #variable= 4 #constraint= 3\n" + pb.PBString()
+	if sb.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, sb.String())
+	}
+	pb2, err := ParseOPB(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("could not parse written problem: %v", err)
+	}
+	cost, cost2 := New(pb).Minimize(), New(pb2).Minimize()
+	if cost != cost2 {
+		t.Errorf("expected cost %d after a round-trip, got %d", cost, cost2)
+	}
+	pb = parseFile(t, "testcnf/ex1.opb", ParseOPB)
+	sb.Reset()
+	if err := pb.WriteOPB(&sb); err != nil {
+		t.Fatalf("could not write problem: %v", err)
+	}
+	if pb2, err = ParseOPB(strings.NewReader(sb.String())); err != nil {
+		t.Fatalf("could not parse written problem: %v", err)
+	}
+	if cost, cost2 := New(pb).Minimize(), New(pb2).Minimize(); cost != cost2 {
+		t.Errorf("ex1.opb: expected cost %d after a round-trip, got %d", cost, cost2)
+	}
+}
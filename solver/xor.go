@@ -0,0 +1,112 @@
+package solver
+
+import "sort"
+
+// This file deals with parity constraints, i.e XOR constraints, such as the "x1 -2 3 0" lines of the extended
+// DIMACS format introduced by CryptoMiniSat, stating that the exclusive or of lits 1, -2 and 3 is true.
+// They are common in cryptographic problems. The solver has no native support for them, such as Gaussian elimination:
+// they are translated into CNF when they are parsed, and the solver only sees the resulting clauses.
+// Encoding an XOR of n vars directly takes 2^(n-1) clauses, so long XORs are cut into chunks of at most maxXorChunk vars,
+// linked by fresh reserved vars: chunk i states that its vars, xored with the link to chunk i-1, equal the link
+// to chunk i+1. The encoding is thus linear.
+
+// maxXorChunk is the maximum number of vars, including links, of an XOR that is directly encoded as clauses.
+const maxXorChunk = 4
+
+// An Xor is a parity constraint, stating that the exclusive or of the values of its vars is Parity.
+type Xor struct {
+	Vars   []Var // Vars of the constraint, in increasing order, without duplicates
+	Parity bool
+}
+
+// NewXor returns the constraint stating that the exclusive or of the given DIMACS lits is true,
+// i.e that an odd number of them are true. Negative lits flip the parity of the constraint,
+// and a var appearing twice cancels itself out, so that the constraint can become trivially true or false.
+func NewXor(lits []int) Xor {
+	res := make([]Lit, len(lits))
+	for i, val := range lits {
+		res[i] = IntToLit(int32(val))
+	}
+	return newXor(res)
+}
+
+// newXor is like NewXor, for lits.
+func newXor(lits []Lit) Xor {
+	x := Xor{Parity: true}
+	odd := make(map[Var]bool, len(lits))
+	for _, lit := range lits {
+		if !lit.IsPositive() {
+			x.Parity = !x.Parity
+		}
+		odd[lit.Var()] = !odd[lit.Var()]
+	}
+	for v, isOdd := range odd {
+		if isOdd {
+			x.Vars = append(x.Vars, v)
+		}
+	}
+	sort.Slice(x.Vars, func(i, j int) bool { return x.Vars[i] < x.Vars[j] })
+	return x
+}
+
+// nbLinks returns how many fresh vars are needed to encode x.
+func (x Xor) nbLinks() int {
+	if len(x.Vars) <= maxXorChunk {
+		return 0
+	}
+	// The first and the last chunks contain one link and maxXorChunk-1 vars at most, the other ones contain
+	// two links and maxXorChunk-2 vars.
+	return (len(x.Vars) - 3) / (maxXorChunk - 2)
+}
+
+// clauses returns the clauses encoding x, using the given fresh vars as links between its chunks.
+func (x Xor) clauses(links []Var) []*Clause {
+	var res []*Clause
+	rest := x.Vars
+	var chunk []Var
+	for _, link := range links {
+		take := maxXorChunk - 1 - len(chunk)
+		chunk = append(chunk, rest[:take]...)
+		res = appendParityClauses(res, append(chunk, link), false)
+		rest = rest[take:]
+		chunk = []Var{link}
+	}
+	return appendParityClauses(res, append(chunk, rest...), x.Parity)
+}
+
+// appendParityClauses appends to clauses the 2^(n-1) clauses stating that the exclusive or of the n given vars is parity.
+// Each clause forbids one of the assignments of the vars whose parity is wrong.
+func appendParityClauses(clauses []*Clause, vars []Var, parity bool) []*Clause {
+	for mask := 0; mask < 1<<len(vars); mask++ {
+		odd := false
+		for i := range vars {
+			if mask&(1<<i) != 0 {
+				odd = !odd
+			}
+		}
+		if odd == parity {
+			continue
+		}
+		lits := make([]Lit, len(vars))
+		for i, v := range vars {
+			lits[i] = v.SignedLit(mask&(1<<i) != 0) // Falsified by the forbidden assignment
+		}
+		clauses = append(clauses, NewClause(lits))
+	}
+	return clauses
+}
+
+// encodeXors appends to the clauses of pb the encoding of its XOR constraints, reserving the vars linking their chunks.
+// It must be called before pb is simplified.
+func (pb *Problem) encodeXors() {
+	nbLinks := 0
+	for _, x := range pb.Xors {
+		nbLinks += x.nbLinks()
+	}
+	links := pb.ReserveVars(nbLinks)
+	for _, x := range pb.Xors {
+		n := x.nbLinks()
+		pb.Clauses = append(pb.Clauses, x.clauses(links[:n])...)
+		links = links[n:]
+	}
+}
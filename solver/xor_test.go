@@ -0,0 +1,128 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewXor(t *testing.T) {
+	for _, test := range []struct {
+		lits     []int
+		expected Xor
+	}{
+		{[]int{3, 1, 2}, Xor{Vars: []Var{0, 1, 2}, Parity: true}},
+		{[]int{-1, 2}, Xor{Vars: []Var{0, 1}, Parity: false}},
+		{[]int{1, -1}, Xor{Parity: false}},
+		{[]int{1, 1, 2}, Xor{Vars: []Var{1}, Parity: true}},
+		{nil, Xor{Parity: true}},
+	} {
+		if x := NewXor(test.lits); !reflect.DeepEqual(x, test.expected) {
+			t.Errorf("NewXor(%v): expected %v, got %v", test.lits, test.expected, x)
+		}
+	}
+}
+
+func TestParseXor(t *testing.T) {
+	const cnf = "p cnf 10 2\nx1 2 3 4 5 6 7 8 9 -10 0\nx 1\n-2 0\n"
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse XORs: %v", err)
+	}
+	if len(pb.Xors) != 2 || len(pb.Xors[0].Vars) != 10 || pb.Xors[0].Parity || !reflect.DeepEqual(pb.Xors[1], Xor{Vars: []Var{0, 1}, Parity: false}) {
+		t.Errorf("invalid XORs %v", pb.Xors)
+	}
+	// 4 chunks of 4 vars for the first XOR, 1 chunk of 2 vars for the second one
+	if pb.NbVars != 13 || len(pb.Clauses) != 4*8+2 {
+		t.Errorf("expected 13 vars and %d clauses, got %d and %d", 4*8+2, pb.NbVars, len(pb.Clauses))
+	}
+	s := New(pb)
+	if s.Solve() != Sat {
+		t.Fatalf("expected sat")
+	}
+	if model := s.Model(); len(model) != 10 || model[0] != model[1] {
+		t.Errorf("invalid model %v", model)
+	}
+	for _, cnf := range []string{"p cnf 1 1\nx1 1 0\n", "p cnf 0 1\nx0\n", "p cnf 2 2\nx1 2 0\nx-1 2 0\n"} {
+		if pb, err := ParseCNF(strings.NewReader(cnf)); err != nil {
+			t.Errorf("%q: could not parse: %v", cnf, err)
+		} else if status := New(pb).Solve(); status != Unsat {
+			t.Errorf("%q: expected unsat, got %v", cnf, status)
+		}
+	}
+	for _, cnf := range []string{"p cnf 2 1\n1 x 2 0\n", "p cnf 2 1\nx x1 2 0\n", "p cnf 2 1\nx1 2\n"} {
+		if _, err := ParseCNF(strings.NewReader(cnf)); err == nil {
+			t.Errorf("%q: expected an error", cnf)
+		}
+	}
+	if _, err := ParseCNF(strings.NewReader("p cnf 2 1\nx1 2\n")); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected a truncation error, got %v", err)
+	}
+}
+
+// directXorCNF returns the direct, exponential encoding of the XOR of the given lits.
+func directXorCNF(lits []int) [][]int {
+	var cnf [][]int
+	for mask := 0; mask < 1<<len(lits); mask++ {
+		odd := false
+		clause := make([]int, len(lits))
+		for i, lit := range lits {
+			if mask&(1<<i) != 0 { // lit is true in the forbidden assignment
+				odd = !odd
+				clause[i] = -lit
+			} else {
+				clause[i] = lit
+			}
+		}
+		if !odd {
+			cnf = append(cnf, clause)
+		}
+	}
+	return cnf
+}
+
+func TestXorRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	nbVars := 9
+	for step := 0; step < 50; step++ {
+		var sb strings.Builder
+		var cnf [][]int
+		nbXors := 1 + rng.Intn(3)
+		clauses := randomCNF(rng, nbVars, rng.Intn(15), 3)
+		fmt.Fprintf(&sb, "p cnf %d %d\n", nbVars, nbXors+len(clauses))
+		for i := 0; i < nbXors; i++ {
+			var lits []int
+			for _, v := range rng.Perm(nbVars)[:1+rng.Intn(nbVars)] {
+				lit := v + 1
+				if rng.Intn(2) == 0 {
+					lit = -lit
+				}
+				lits = append(lits, lit)
+			}
+			cnf = append(cnf, directXorCNF(lits)...)
+			sb.WriteString("x")
+			for _, lit := range lits {
+				fmt.Fprintf(&sb, "%d ", lit)
+			}
+			sb.WriteString("0\n")
+		}
+		for _, c := range clauses {
+			cnf = append(cnf, c)
+			for _, lit := range c {
+				fmt.Fprintf(&sb, "%d ", lit)
+			}
+			sb.WriteString("0\n")
+		}
+		pb, err := ParseCNF(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", sb.String(), err)
+		}
+		expected := New(ParseSliceNb(cnf, nbVars)).CountModels()
+		if got := New(pb).CountModels(); got != expected {
+			t.Fatalf("%q: expected %d models, got %d", sb.String(), expected, got)
+		}
+	}
+}